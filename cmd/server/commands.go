@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/mackerelio/go-osstat/cpu"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v3"
@@ -18,6 +23,8 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	"github.com/livekit/livekit-server/test/loadtest"
 )
 
 func generateKeys(_ *cli.Context) error {
@@ -230,3 +237,119 @@ func listNodes(c *cli.Context) error {
 
 	return nil
 }
+
+func loadTest(c *cli.Context) error {
+	conf, err := getConfig(c)
+	if err != nil {
+		return err
+	}
+
+	params := loadtest.Params{
+		URL:          c.String("url"),
+		APIKey:       c.String("api-key"),
+		APISecret:    c.String("api-secret"),
+		Room:         c.String("room"),
+		Publishers:   c.Int("publishers"),
+		Subscribers:  c.Int("subscribers"),
+		Duration:     c.Duration("duration"),
+		JoinInterval: c.Duration("join-interval"),
+	}
+	if c.Bool("video") {
+		params.VideoLayers = loadtest.DefaultVideoLayers
+	}
+	if c.Bool("audio") {
+		params.AudioBitrate = loadtest.DefaultAudioBitrate
+	}
+
+	currentNode, err := routing.NewLocalNode(conf)
+	if err != nil {
+		return err
+	}
+	// peer connections of the test clients update transport metrics
+	prometheus.Init(currentNode.Id, currentNode.Type, conf.Environment)
+
+	// CPU of the server nodes when they can be listed, otherwise CPU of this host
+	cpuSource := "host"
+	params.CPUSampler = hostCPUSampler()
+	if conf.Redis.IsConfigured() {
+		router, err := service.InitializeRouter(conf, currentNode)
+		if err != nil {
+			return err
+		}
+		cpuSource = "nodes"
+		params.CPUSampler = func() (float32, error) {
+			nodes, err := router.ListNodes()
+			if err != nil {
+				return 0, err
+			}
+			var load float32
+			for _, node := range nodes {
+				if node.Stats != nil && node.Stats.CpuLoad > load {
+					load = node.Stats.CpuLoad
+				}
+			}
+			return load, nil
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	report, err := loadtest.NewLoadTest(params).Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("json") {
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(encoded))
+		return nil
+	}
+
+	fmt.Printf("Room: %s, Publishers: %d, Subscribers: %d, Duration: %.1fs\n",
+		report.Room, report.Publishers, report.Subscribers, report.DurationSeconds)
+	fmt.Printf("Join failures: %d, Publish failures: %d, Tracks received: %d / %d\n",
+		report.JoinFailures, report.PublishFailures, report.TracksReceived, report.TracksExpected)
+	fmt.Printf("Packets received: %d, lost: %d (%.2f %%)\n",
+		report.PacketsReceived, report.PacketsLost, report.PacketLossPercentage)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Metric", "Count", "Min", "Mean", "P50", "P95", "Max"})
+	appendDistribution := func(name string, d loadtest.Distribution) {
+		table.Append([]string{
+			name, strconv.Itoa(d.Count),
+			fmt.Sprintf("%.1f", d.Min), fmt.Sprintf("%.1f", d.Mean),
+			fmt.Sprintf("%.1f", d.P50), fmt.Sprintf("%.1f", d.P95), fmt.Sprintf("%.1f", d.Max),
+		})
+	}
+	appendDistribution("Join latency (ms)", report.JoinLatencyMs)
+	appendDistribution("First frame (ms)", report.FirstFrameMs)
+	appendDistribution("Subscriber bitrate (kbps)", report.SubscriberBitrateKbps)
+	if report.CPUPercent != nil {
+		appendDistribution(fmt.Sprintf("CPU %s (%%)", cpuSource), *report.CPUPercent)
+	}
+	table.Render()
+
+	return nil
+}
+
+func hostCPUSampler() func() (float32, error) {
+	var lastTotal, lastIdle uint64
+	return func() (float32, error) {
+		stats, err := cpu.Get()
+		if err != nil {
+			return 0, err
+		}
+
+		var load float32
+		if lastTotal > 0 && lastTotal < stats.Total {
+			load = 1 - float32(stats.Idle-lastIdle)/float32(stats.Total-lastTotal)
+		}
+		lastTotal = stats.Total
+		lastIdle = stats.Idle
+		return load, nil
+	}
+}
@@ -158,6 +158,67 @@ func main() {
 				Usage:  "list all nodes",
 				Action: listNodes,
 			},
+			{
+				Name:   "load-test",
+				Usage:  "joins synthetic publishers and subscribers to a room and reports join latency, first frame time, bitrate, loss and CPU",
+				Action: loadTest,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "url",
+						Usage: "websocket URL of the server",
+						Value: "ws://localhost:7880",
+					},
+					&cli.StringFlag{
+						Name:    "api-key",
+						Usage:   "api key to create join tokens with",
+						EnvVars: []string{"LIVEKIT_API_KEY"},
+					},
+					&cli.StringFlag{
+						Name:    "api-secret",
+						Usage:   "api secret to create join tokens with",
+						EnvVars: []string{"LIVEKIT_API_SECRET"},
+					},
+					&cli.StringFlag{
+						Name:  "room",
+						Usage: "name of room to join",
+						Value: "load-test",
+					},
+					&cli.IntFlag{
+						Name:  "publishers",
+						Usage: "number of participants publishing video and audio",
+						Value: 1,
+					},
+					&cli.IntFlag{
+						Name:  "subscribers",
+						Usage: "number of participants subscribing to all tracks",
+						Value: 5,
+					},
+					&cli.BoolFlag{
+						Name:  "video",
+						Usage: "publish VP8 simulcast at 150 kbps, 500 kbps and 1.5 Mbps",
+						Value: true,
+					},
+					&cli.BoolFlag{
+						Name:  "audio",
+						Usage: "publish Opus at 32 kbps",
+						Value: true,
+					},
+					&cli.DurationFlag{
+						Name:  "duration",
+						Usage: "how long media flows once all participants have joined",
+						Value: 30 * time.Second,
+					},
+					&cli.DurationFlag{
+						Name:  "join-interval",
+						Usage: "delay between participants joining",
+						Value: 50 * time.Millisecond,
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "print the report as JSON",
+					},
+				},
+			},
 			{
 				Name:   "help-verbose",
 				Usage:  "prints app help, including all generated configuration flags",
@@ -21,6 +21,8 @@ import (
 
 type CongestionControlProbeMode string
 type StreamTrackerType string
+type SpeakerDetectorType string
+type ConnectionQualityModel string
 
 const (
 	generatedCLIFlagUsage = "generated"
@@ -28,8 +30,15 @@ const (
 	CongestionControlProbeModePadding CongestionControlProbeMode = "padding"
 	CongestionControlProbeModeMedia   CongestionControlProbeMode = "media"
 
-	StreamTrackerTypePacket StreamTrackerType = "packet"
-	StreamTrackerTypeFrame  StreamTrackerType = "frame"
+	StreamTrackerTypePacket  StreamTrackerType = "packet"
+	StreamTrackerTypeFrame   StreamTrackerType = "frame"
+	StreamTrackerTypeBitrate StreamTrackerType = "bitrate"
+
+	SpeakerDetectorTypePercentile SpeakerDetectorType = "percentile"
+	SpeakerDetectorTypeDominant   SpeakerDetectorType = "dominant_speaker"
+
+	ConnectionQualityModelDefault ConnectionQualityModel = "default"
+	ConnectionQualityModelEModel  ConnectionQualityModel = "emodel"
 
 	StatsUpdateInterval          = time.Second * 10
 	TelemetryStatsUpdateInterval = time.Second * 30
@@ -38,6 +47,12 @@ const (
 var (
 	ErrKeyFileIncorrectPermission = errors.New("key file must have 0600 permission")
 	ErrKeysNotSet                 = errors.New("one of key-file or keys must be provided")
+	ErrInvalidUpdateInterval      = errors.New("update_interval must be positive")
+	ErrInvalidQualityModel        = errors.New("model must be default or emodel")
+	ErrInvalidQualityThresholds   = errors.New("good_threshold must be lower than excellent_threshold")
+	ErrInvalidCanaryInterval      = errors.New("interval must be positive")
+	ErrInvalidCanaryTimeout       = errors.New("timeout must be positive")
+	ErrInvalidFailureThreshold    = errors.New("failure_threshold must be at least 1")
 )
 
 type Config struct {
@@ -53,11 +68,14 @@ type Config struct {
 	TURN           TURNConfig               `yaml:"turn,omitempty"`
 	Ingress        IngressConfig            `yaml:"ingress,omitempty"`
 	WebHook        WebHookConfig            `yaml:"webhook,omitempty"`
+	Admission      AdmissionConfig          `yaml:"admission,omitempty"`
+	HLS            HLSConfig                `yaml:"hls,omitempty"`
 	NodeSelector   NodeSelectorConfig       `yaml:"node_selector,omitempty"`
 	KeyFile        string                   `yaml:"key_file,omitempty"`
 	Keys           map[string]string        `yaml:"keys,omitempty"`
 	Region         string                   `yaml:"region,omitempty"`
 	SignalRelay    SignalRelayConfig        `yaml:"signal_relay,omitempty"`
+	Canary         CanaryConfig             `yaml:"canary,omitempty"`
 	// LogLevel is deprecated
 	LogLevel string        `yaml:"log_level,omitempty"`
 	Logging  LoggingConfig `yaml:"logging,omitempty"`
@@ -92,6 +110,14 @@ type RTCConfig struct {
 
 	// allow time stamp adjust to keep drift low, this is experimental
 	AllowTimestampAdjustment *bool `yaml:"allow_timestamp_adjustment,omitempty"`
+
+	// UDP ports to allocate for plain RTP ingest, one per room shared by its ingests, any free port when unset
+	RTPIngestPortRangeStart uint16 `yaml:"rtp_ingest_port_range_start,omitempty"`
+	RTPIngestPortRangeEnd   uint16 `yaml:"rtp_ingest_port_range_end,omitempty"`
+	// RTP ingests and forwards are stopped when nothing is heard from the peer for this long, 0 to disable
+	RTPInactivityTimeout time.Duration `yaml:"rtp_inactivity_timeout,omitempty"`
+
+	ConnectionQuality ConnectionQualityConfig `yaml:"connection_quality,omitempty"`
 }
 
 type TURNServer struct {
@@ -108,6 +134,42 @@ type PLIThrottleConfig struct {
 	HighQuality time.Duration `yaml:"high_quality,omitempty"`
 }
 
+type ConnectionQualityConfig struct {
+	// model scoring tracks, "default" or "emodel".
+	// emodel scores audio with the ITU-T G.107 E-model, video is scored with the default model.
+	Model ConnectionQualityModel `yaml:"model,omitempty"`
+	// multipliers of how much each factor lowers the score in the default model
+	LossWeight    float32 `yaml:"loss_weight,omitempty"`
+	DelayWeight   float32 `yaml:"delay_weight,omitempty"`
+	BitrateWeight float32 `yaml:"bitrate_weight,omitempty"`
+	LayerWeight   float32 `yaml:"layer_weight,omitempty"`
+	// scores, out of 100, above which quality is excellent and good
+	ExcellentThreshold float32 `yaml:"excellent_threshold,omitempty"`
+	GoodThreshold      float32 `yaml:"good_threshold,omitempty"`
+}
+
+func (c *ConnectionQualityConfig) Validate() error {
+	switch c.Model {
+	case ConnectionQualityModelDefault, ConnectionQualityModelEModel:
+	default:
+		return ErrInvalidQualityModel
+	}
+	if c.GoodThreshold >= c.ExcellentThreshold {
+		return ErrInvalidQualityThresholds
+	}
+	return nil
+}
+
+var DefaultConnectionQualityConfig = ConnectionQualityConfig{
+	Model:              ConnectionQualityModelDefault,
+	LossWeight:         1,
+	DelayWeight:        1,
+	BitrateWeight:      1,
+	LayerWeight:        1,
+	ExcellentThreshold: 80,
+	GoodThreshold:      40,
+}
+
 type CongestionControlConfig struct {
 	Enabled            bool                       `yaml:"enabled"`
 	AllowPause         bool                       `yaml:"allow_pause"`
@@ -129,6 +191,17 @@ type AudioConfig struct {
 	SmoothIntervals uint32 `yaml:"smooth_intervals,omitempty"`
 	// enable red encoding downtrack for opus only audio up track
 	ActiveREDEncoding bool `yaml:"active_red_encoding,omitempty"`
+	// algorithm used to pick active speakers, "percentile" or "dominant_speaker"
+	SpeakerDetector SpeakerDetectorType   `yaml:"speaker_detector,omitempty"`
+	DominantSpeaker DominantSpeakerConfig `yaml:"dominant_speaker,omitempty"`
+}
+
+type DominantSpeakerConfig struct {
+	// log-likelihood ratios a challenger needs to exceed over the current dominant speaker
+	// on immediate, medium and long time scales to become the dominant speaker
+	ImmediateThreshold float32 `yaml:"immediate_threshold,omitempty"`
+	MediumThreshold    float32 `yaml:"medium_threshold,omitempty"`
+	LongThreshold      float32 `yaml:"long_threshold,omitempty"`
 }
 
 type StreamTrackerPacketConfig struct {
@@ -141,11 +214,22 @@ type StreamTrackerFrameConfig struct {
 	MinFPS float64 `yaml:"min_fps"`
 }
 
+type StreamTrackerBitrateConfig struct {
+	// bitrate expected of the layer in bps, used when the publisher does not advertise the bitrate of the layer
+	ExpectedBitrate int64 `yaml:"expected_bitrate,omitempty"`
+	// the layer is active while the bitrate averaged over the window is at least this fraction of the expected bitrate
+	MinRatio      float64       `yaml:"min_ratio,omitempty"`
+	CycleDuration time.Duration `yaml:"cycle_duration,omitempty"`
+	// number of cycles bitrate is averaged over
+	WindowCycles uint32 `yaml:"window_cycles,omitempty"`
+}
+
 type StreamTrackerConfig struct {
-	StreamTrackerType     StreamTrackerType                   `yaml:"stream_tracker_type,omitempty"`
-	BitrateReportInterval map[int32]time.Duration             `yaml:"bitrate_report_interval,omitempty"`
-	PacketTracker         map[int32]StreamTrackerPacketConfig `yaml:"packet_tracker,omitempty"`
-	FrameTracker          map[int32]StreamTrackerFrameConfig  `yaml:"frame_tracker,omitempty"`
+	StreamTrackerType     StreamTrackerType                    `yaml:"stream_tracker_type,omitempty"`
+	BitrateReportInterval map[int32]time.Duration              `yaml:"bitrate_report_interval,omitempty"`
+	PacketTracker         map[int32]StreamTrackerPacketConfig  `yaml:"packet_tracker,omitempty"`
+	FrameTracker          map[int32]StreamTrackerFrameConfig   `yaml:"frame_tracker,omitempty"`
+	BitrateTracker        map[int32]StreamTrackerBitrateConfig `yaml:"bitrate_tracker,omitempty"`
 }
 
 type StreamTrackersConfig struct {
@@ -166,6 +250,35 @@ type RoomConfig struct {
 	EmptyTimeout       uint32      `yaml:"empty_timeout,omitempty"`
 	EnableRemoteUnmute bool        `yaml:"enable_remote_unmute,omitempty"`
 	MaxMetadataSize    uint32      `yaml:"max_metadata_size,omitempty"`
+	// hold new participants in a lobby until a room admin approves them, the default for rooms that are not
+	// set with UpdateRoomLobby
+	Lobby bool `yaml:"lobby,omitempty"`
+	// limit of bitrate published by a participant in bps, for all rooms of the server. It can be overridden
+	// by the participant's token
+	MaxPublishBitrate uint32 `yaml:"max_publish_bitrate,omitempty"`
+	// rules raising alerts when a participant's connection degrades
+	QualityAlerts []QualityAlertRule `yaml:"quality_alerts,omitempty"`
+}
+
+const (
+	QualityAlertMetricQuality        = "quality"
+	QualityAlertMetricPublisherLoss  = "publisher_loss"
+	QualityAlertMetricSubscriberLoss = "subscriber_loss"
+)
+
+type QualityAlertRule struct {
+	// identifies the rule in alerts
+	Name string `yaml:"name"`
+	// one of quality, publisher_loss or subscriber_loss
+	Metric string `yaml:"metric"`
+	// quality rules match at or below this connection quality, poor or good
+	Quality string `yaml:"quality,omitempty"`
+	// loss rules match at or above this percentage of packets lost
+	LossPercentage float32 `yaml:"loss_percentage,omitempty"`
+	// how long the rule has to match before an alert is raised
+	Duration time.Duration `yaml:"duration,omitempty"`
+	// also send alerts to room admins as data messages
+	NotifyAdmins bool `yaml:"notify_admins,omitempty"`
 }
 
 type CodecSpec struct {
@@ -196,6 +309,28 @@ type WebHookConfig struct {
 	APIKey string `yaml:"api_key"`
 }
 
+type AdmissionConfig struct {
+	// http(s)://host/path or unix:///path/to/socket
+	URL string `yaml:"url,omitempty"`
+	// key to sign requests with, defaults to the webhook key
+	APIKey string `yaml:"api_key,omitempty"`
+	// calls block signalling of the participant for up to this long
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// admit when the hook cannot be reached or fails
+	FailOpen bool `yaml:"fail_open,omitempty"`
+	// hooks to call
+	Join    bool `yaml:"join,omitempty"`
+	Publish bool `yaml:"publish,omitempty"`
+}
+
+type HLSConfig struct {
+	// target durations of partial and full segments, segments are cut on key frames
+	PartDuration    time.Duration `yaml:"part_duration,omitempty"`
+	SegmentDuration time.Duration `yaml:"segment_duration,omitempty"`
+	// number of segments listed in media playlists
+	PlaylistSize int `yaml:"playlist_size,omitempty"`
+}
+
 type NodeSelectorConfig struct {
 	Kind         string         `yaml:"kind"`
 	SortBy       string         `yaml:"sort_by,omitempty"`
@@ -212,6 +347,36 @@ type SignalRelayConfig struct {
 	StreamBufferSize int           `yaml:"stream_buffer_size,omitempty"`
 }
 
+type CanaryConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// prefix of the reserved room joined by the canary, the node ID is appended so that each node probes itself
+	Room string `yaml:"room,omitempty"`
+	// time between probes
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// how long media is published for in each probe
+	MediaDuration time.Duration `yaml:"media_duration,omitempty"`
+	// a probe fails when it does not join and receive media within this time
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// the node is not ready after this many consecutive failed probes
+	FailureThreshold int `yaml:"failure_threshold,omitempty"`
+}
+
+func (c *CanaryConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Interval <= 0 {
+		return ErrInvalidCanaryInterval
+	}
+	if c.Timeout <= 0 {
+		return ErrInvalidCanaryTimeout
+	}
+	if c.FailureThreshold < 1 {
+		return ErrInvalidFailureThreshold
+	}
+	return nil
+}
+
 // RegionConfig lists available regions and their latitude/longitude, so the selector would prefer
 // regions that are closer
 type RegionConfig struct {
@@ -225,6 +390,28 @@ type LimitConfig struct {
 	BytesPerSec            float32 `yaml:"bytes_per_sec,omitempty"`
 	SubscriptionLimitVideo int32   `yaml:"subscription_limit_video,omitempty"`
 	SubscriptionLimitAudio int32   `yaml:"subscription_limit_audio,omitempty"`
+
+	EgressGovernor EgressGovernorConfig `yaml:"egress_governor,omitempty"`
+}
+
+// EgressGovernorConfig keeps outgoing bitrate of the node under a budget by lowering channel capacity of subscribers
+type EgressGovernorConfig struct {
+	// outgoing budget of the node in bytes per second, governor is disabled when 0
+	BytesPerSec float32 `yaml:"bytes_per_sec,omitempty"`
+	// subscribers are not limited below this bitrate, in bits per second
+	MinSubscriberBitrate int64 `yaml:"min_subscriber_bitrate,omitempty"`
+	// how often outgoing bitrate is measured and limits adjusted
+	UpdateInterval time.Duration `yaml:"update_interval,omitempty"`
+	// when the node stays over budget with all subscribers at minimum bitrate for this long,
+	// the most recently joined subscriber is disconnected. 0 disables evictions.
+	EvictAfter time.Duration `yaml:"evict_after,omitempty"`
+}
+
+func (c *EgressGovernorConfig) Validate() error {
+	if c.BytesPerSec > 0 && c.UpdateInterval <= 0 {
+		return ErrInvalidUpdateInterval
+	}
+	return nil
 }
 
 type IngressConfig struct {
@@ -273,12 +460,20 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 				AllowPause: false,
 				ProbeMode:  CongestionControlProbeModePadding,
 			},
+			RTPInactivityTimeout: 30 * time.Second,
+			ConnectionQuality:    DefaultConnectionQualityConfig,
 		},
 		Audio: AudioConfig{
 			ActiveLevel:     35, // -35dBov
 			MinPercentile:   40,
 			UpdateInterval:  400,
 			SmoothIntervals: 2,
+			SpeakerDetector: SpeakerDetectorTypePercentile,
+			DominantSpeaker: DominantSpeakerConfig{
+				ImmediateThreshold: 3,
+				MediumThreshold:    2,
+				LongThreshold:      0,
+			},
 		},
 		Video: VideoConfig{
 			DynacastPauseDelay: 5 * time.Second,
@@ -318,6 +513,26 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 							MinFPS: 5.0,
 						},
 					},
+					BitrateTracker: map[int32]StreamTrackerBitrateConfig{
+						0: {
+							ExpectedBitrate: 150_000,
+							MinRatio:        0.05,
+							CycleDuration:   500 * time.Millisecond,
+							WindowCycles:    10,
+						},
+						1: {
+							ExpectedBitrate: 500_000,
+							MinRatio:        0.05,
+							CycleDuration:   500 * time.Millisecond,
+							WindowCycles:    10,
+						},
+						2: {
+							ExpectedBitrate: 1_500_000,
+							MinRatio:        0.05,
+							CycleDuration:   500 * time.Millisecond,
+							WindowCycles:    10,
+						},
+					},
 				},
 				Screenshare: StreamTrackerConfig{
 					StreamTrackerType: StreamTrackerTypePacket,
@@ -354,6 +569,26 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 							MinFPS: 0.5,
 						},
 					},
+					BitrateTracker: map[int32]StreamTrackerBitrateConfig{
+						0: {
+							ExpectedBitrate: 500_000,
+							MinRatio:        0.005,
+							CycleDuration:   time.Second,
+							WindowCycles:    20,
+						},
+						1: {
+							ExpectedBitrate: 1_000_000,
+							MinRatio:        0.005,
+							CycleDuration:   time.Second,
+							WindowCycles:    20,
+						},
+						2: {
+							ExpectedBitrate: 2_000_000,
+							MinRatio:        0.005,
+							CycleDuration:   time.Second,
+							WindowCycles:    20,
+						},
+					},
 				},
 			},
 		},
@@ -366,6 +601,7 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 				{Mime: webrtc.MimeTypeVP8},
 				{Mime: webrtc.MimeTypeH264},
 				// {Mime: webrtc.MimeTypeAV1},
+				// {Mime: webrtc.MimeTypeH265},
 				// {Mime: webrtc.MimeTypeVP9},
 			},
 			EmptyTimeout: 5 * 60,
@@ -376,6 +612,20 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 		TURN: TURNConfig{
 			Enabled: false,
 		},
+		Admission: AdmissionConfig{
+			Timeout: 2 * time.Second,
+		},
+		Limit: LimitConfig{
+			EgressGovernor: EgressGovernorConfig{
+				MinSubscriberBitrate: 150_000,
+				UpdateInterval:       2 * time.Second,
+			},
+		},
+		HLS: HLSConfig{
+			PartDuration:    250 * time.Millisecond,
+			SegmentDuration: 2 * time.Second,
+			PlaylistSize:    6,
+		},
 		NodeSelector: NodeSelectorConfig{
 			Kind:         "any",
 			SortBy:       "random",
@@ -389,6 +639,14 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 			MaxRetryInterval: 4 * time.Second,
 			StreamBufferSize: 1000,
 		},
+		Canary: CanaryConfig{
+			Enabled:          false,
+			Room:             "_livekit_canary",
+			Interval:         30 * time.Second,
+			MediaDuration:    2 * time.Second,
+			Timeout:          10 * time.Second,
+			FailureThreshold: 3,
+		},
 		Keys: map[string]string{},
 	}
 
@@ -403,6 +661,9 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 	if err := conf.RTC.Validate(conf.Development); err != nil {
 		return nil, fmt.Errorf("could not validate RTC config: %v", err)
 	}
+	if err := conf.RTC.ConnectionQuality.Validate(); err != nil {
+		return nil, fmt.Errorf("could not validate connection quality config: %w", err)
+	}
 
 	if c != nil {
 		if err := conf.updateFromCLI(c, baseFlags); err != nil {
@@ -410,6 +671,13 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 		}
 	}
 
+	if err := conf.Limit.EgressGovernor.Validate(); err != nil {
+		return nil, fmt.Errorf("could not validate egress governor config: %w", err)
+	}
+	if err := conf.Canary.Validate(); err != nil {
+		return nil, fmt.Errorf("could not validate canary config: %w", err)
+	}
+
 	// expand env vars in filenames
 	file, err := homedir.Expand(os.ExpandEnv(conf.KeyFile))
 	if err != nil {
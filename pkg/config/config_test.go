@@ -33,6 +33,55 @@ room:
 	require.Error(t, err)
 }
 
+func TestConfig_InvalidEgressGovernor(t *testing.T) {
+	const content = `limit:
+  egress_governor:
+    bytes_per_sec: 1000000
+    update_interval: 0s`
+	_, err := NewConfig(content, true, nil, nil)
+	require.ErrorIs(t, err, ErrInvalidUpdateInterval)
+}
+
+func TestConfig_InvalidCanary(t *testing.T) {
+	_, err := NewConfig(`canary:
+  enabled: true
+  interval: 0s`, true, nil, nil)
+	require.ErrorIs(t, err, ErrInvalidCanaryInterval)
+
+	_, err = NewConfig(`canary:
+  enabled: true
+  timeout: 0s`, true, nil, nil)
+	require.ErrorIs(t, err, ErrInvalidCanaryTimeout)
+
+	_, err = NewConfig(`canary:
+  enabled: true
+  failure_threshold: 0`, true, nil, nil)
+	require.ErrorIs(t, err, ErrInvalidFailureThreshold)
+
+	// not checked while disabled
+	_, err = NewConfig(`canary:
+  interval: 0s`, true, nil, nil)
+	require.NoError(t, err)
+}
+
+func TestConfig_InvalidConnectionQuality(t *testing.T) {
+	_, err := NewConfig(`rtc:
+  connection_quality:
+    model: mos`, true, nil, nil)
+	require.ErrorIs(t, err, ErrInvalidQualityModel)
+
+	_, err = NewConfig(`rtc:
+  connection_quality:
+    good_threshold: 80`, true, nil, nil)
+	require.ErrorIs(t, err, ErrInvalidQualityThresholds)
+
+	conf, err := NewConfig(`rtc:
+  connection_quality:
+    model: emodel`, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, ConnectionQualityModelEModel, conf.RTC.ConnectionQuality.Model)
+}
+
 func TestGeneratedFlags(t *testing.T) {
 	generatedFlags, err := GenerateCLIFlags(nil, false)
 	require.NoError(t, err)
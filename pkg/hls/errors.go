@@ -0,0 +1,14 @@
+package hls
+
+import (
+	"errors"
+)
+
+var (
+	ErrUnsupportedCodec = errors.New("only H.264 video and Opus audio can be packaged")
+	ErrTrackExists      = errors.New("a track of this kind is already packaged")
+	ErrPackagerClosed   = errors.New("packager is closed")
+
+	errBeyondLiveEdge = errors.New("segment is too far after the live edge")
+	errNotAvailable   = errors.New("segment is not available")
+)
@@ -0,0 +1,289 @@
+package hls
+
+import (
+	"encoding/binary"
+)
+
+// minimal ISO BMFF writer for CMAF init segments and fragments with a single track
+
+const (
+	fmp4TrackID = 1
+
+	sampleFlagsSync    = 0x02000000 // sample_depends_on = 2
+	sampleFlagsNonSync = 0x01010000 // sample_depends_on = 1, sample_is_non_sync_sample
+)
+
+var unityMatrix = []uint32{0x00010000, 0, 0, 0, 0x00010000, 0, 0, 0, 0x40000000}
+
+type boxWriter struct {
+	b []byte
+}
+
+func (w *boxWriter) u8(v uint8) {
+	w.b = append(w.b, v)
+}
+
+func (w *boxWriter) u16(v uint16) {
+	w.b = append(w.b, byte(v>>8), byte(v))
+}
+
+func (w *boxWriter) u32(v uint32) {
+	w.b = append(w.b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func (w *boxWriter) u64(v uint64) {
+	w.u32(uint32(v >> 32))
+	w.u32(uint32(v))
+}
+
+func (w *boxWriter) zeros(n int) {
+	w.b = append(w.b, make([]byte, n)...)
+}
+
+func (w *boxWriter) bytes(b []byte) {
+	w.b = append(w.b, b...)
+}
+
+func (w *boxWriter) box(typ string, f func()) {
+	start := len(w.b)
+	w.u32(0)
+	w.b = append(w.b, typ...)
+	f()
+	binary.BigEndian.PutUint32(w.b[start:], uint32(len(w.b)-start))
+}
+
+func (w *boxWriter) fullBox(typ string, version uint8, flags uint32, f func()) {
+	w.box(typ, func() {
+		w.u32(uint32(version)<<24 | flags&0xffffff)
+		f()
+	})
+}
+
+func (w *boxWriter) matrix() {
+	for _, v := range unityMatrix {
+		w.u32(v)
+	}
+}
+
+// -----------------------------------------------
+
+type trackDescription struct {
+	video     bool
+	timescale uint32
+
+	// video
+	width  uint16
+	height uint16
+	sps    []byte
+	pps    []byte
+
+	// audio
+	channels uint16
+}
+
+func writeInitSegment(td *trackDescription) []byte {
+	w := &boxWriter{}
+	w.box("ftyp", func() {
+		w.bytes([]byte("iso6"))
+		w.u32(0)
+		w.bytes([]byte("iso6cmfcmp41"))
+	})
+	w.box("moov", func() {
+		w.fullBox("mvhd", 0, 0, func() {
+			w.u32(0) // creation time
+			w.u32(0) // modification time
+			w.u32(1000)
+			w.u32(0) // duration
+			w.u32(0x00010000)
+			w.u16(0x0100)
+			w.zeros(10)
+			w.matrix()
+			w.zeros(24)
+			w.u32(fmp4TrackID + 1)
+		})
+		w.box("trak", func() {
+			w.fullBox("tkhd", 0, 3, func() {
+				w.u32(0)
+				w.u32(0)
+				w.u32(fmp4TrackID)
+				w.u32(0)
+				w.u32(0) // duration
+				w.zeros(8)
+				w.u16(0) // layer
+				w.u16(0) // alternate group
+				if td.video {
+					w.u16(0)
+				} else {
+					w.u16(0x0100)
+				}
+				w.u16(0)
+				w.matrix()
+				w.u32(uint32(td.width) << 16)
+				w.u32(uint32(td.height) << 16)
+			})
+			w.box("mdia", func() {
+				w.fullBox("mdhd", 0, 0, func() {
+					w.u32(0)
+					w.u32(0)
+					w.u32(td.timescale)
+					w.u32(0)
+					w.u16(0x55c4) // und
+					w.u16(0)
+				})
+				w.fullBox("hdlr", 0, 0, func() {
+					w.u32(0)
+					if td.video {
+						w.bytes([]byte("vide"))
+					} else {
+						w.bytes([]byte("soun"))
+					}
+					w.zeros(12)
+					w.bytes([]byte("LiveKit\x00"))
+				})
+				w.box("minf", func() {
+					if td.video {
+						w.fullBox("vmhd", 0, 1, func() {
+							w.zeros(8)
+						})
+					} else {
+						w.fullBox("smhd", 0, 0, func() {
+							w.zeros(4)
+						})
+					}
+					w.box("dinf", func() {
+						w.fullBox("dref", 0, 0, func() {
+							w.u32(1)
+							w.fullBox("url ", 0, 1, func() {})
+						})
+					})
+					w.box("stbl", func() {
+						w.fullBox("stsd", 0, 0, func() {
+							w.u32(1)
+							if td.video {
+								writeAVC1SampleEntry(w, td)
+							} else {
+								writeOpusSampleEntry(w, td)
+							}
+						})
+						w.fullBox("stts", 0, 0, func() { w.u32(0) })
+						w.fullBox("stsc", 0, 0, func() { w.u32(0) })
+						w.fullBox("stsz", 0, 0, func() { w.u32(0); w.u32(0) })
+						w.fullBox("stco", 0, 0, func() { w.u32(0) })
+					})
+				})
+			})
+		})
+		w.box("mvex", func() {
+			w.fullBox("trex", 0, 0, func() {
+				w.u32(fmp4TrackID)
+				w.u32(1)
+				w.u32(0)
+				w.u32(0)
+				w.u32(0)
+			})
+		})
+	})
+	return w.b
+}
+
+func writeAVC1SampleEntry(w *boxWriter, td *trackDescription) {
+	w.box("avc1", func() {
+		w.zeros(6)
+		w.u16(1) // data reference index
+		w.zeros(16)
+		w.u16(td.width)
+		w.u16(td.height)
+		w.u32(0x00480000)
+		w.u32(0x00480000)
+		w.u32(0)
+		w.u16(1) // frame count
+		w.zeros(32)
+		w.u16(0x0018)
+		w.u16(0xffff)
+		w.box("avcC", func() {
+			w.u8(1)
+			w.u8(td.sps[1]) // profile
+			w.u8(td.sps[2]) // constraints
+			w.u8(td.sps[3]) // level
+			w.u8(0xff)      // 4 byte NAL unit lengths
+			w.u8(0xe1)
+			w.u16(uint16(len(td.sps)))
+			w.bytes(td.sps)
+			w.u8(1)
+			w.u16(uint16(len(td.pps)))
+			w.bytes(td.pps)
+		})
+	})
+}
+
+func writeOpusSampleEntry(w *boxWriter, td *trackDescription) {
+	w.box("Opus", func() {
+		w.zeros(6)
+		w.u16(1) // data reference index
+		w.zeros(8)
+		w.u16(td.channels)
+		w.u16(16)
+		w.zeros(4)
+		w.u32(48000 << 16)
+		w.box("dOps", func() {
+			w.u8(0)
+			w.u8(uint8(td.channels))
+			w.u16(opusPreSkip)
+			w.u32(48000)
+			w.u16(0) // output gain
+			w.u8(0)  // channel mapping family
+		})
+	})
+}
+
+// -----------------------------------------------
+
+type sample struct {
+	dts      int64
+	duration uint32
+	keyFrame bool
+	data     []byte
+}
+
+// writeFragment writes a moof and mdat pair, samples must be consecutive
+func writeFragment(sequence uint32, samples []*sample) []byte {
+	w := &boxWriter{}
+	dataOffsetPos := 0
+	w.box("moof", func() {
+		w.fullBox("mfhd", 0, 0, func() {
+			w.u32(sequence)
+		})
+		w.box("traf", func() {
+			// default-base-is-moof
+			w.fullBox("tfhd", 0, 0x020000, func() {
+				w.u32(fmp4TrackID)
+			})
+			w.fullBox("tfdt", 1, 0, func() {
+				w.u64(uint64(samples[0].dts))
+			})
+			// data offset, sample duration, size and flags
+			w.fullBox("trun", 0, 0x000701, func() {
+				w.u32(uint32(len(samples)))
+				dataOffsetPos = len(w.b)
+				w.u32(0)
+				for _, s := range samples {
+					w.u32(s.duration)
+					w.u32(uint32(len(s.data)))
+					if s.keyFrame {
+						w.u32(sampleFlagsSync)
+					} else {
+						w.u32(sampleFlagsNonSync)
+					}
+				}
+			})
+		})
+	})
+	binary.BigEndian.PutUint32(w.b[dataOffsetPos:], uint32(len(w.b)+8))
+
+	w.box("mdat", func() {
+		for _, s := range samples {
+			w.bytes(s.data)
+		}
+	})
+	return w.b
+}
@@ -0,0 +1,308 @@
+package hls
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/pion/rtp/codecs"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	h264NALUTypeIDR = 5
+	h264NALUTypeSPS = 7
+	h264NALUTypePPS = 8
+	h264NALUTypeAUD = 9
+	h264NALUTypeFUA = 28
+)
+
+var errInvalidSPS = errors.New("invalid SPS")
+
+type accessUnit struct {
+	timestamp uint32
+	keyFrame  bool
+	// NAL units with 4 byte length prefixes
+	data []byte
+}
+
+// h264Depacketizer assembles access units from RTP packets of a single layer. Parameter sets are
+// kept out of the access units, they go into the init segment.
+// After a loss, access units are dropped until the next key frame.
+type h264Depacketizer struct {
+	packet      codecs.H264Packet
+	started     bool
+	lastSN      uint16
+	fragmenting bool
+
+	waitingForKeyFrame bool
+	current            *accessUnit
+
+	sps []byte
+	pps []byte
+}
+
+func newH264Depacketizer() *h264Depacketizer {
+	return &h264Depacketizer{
+		packet:             codecs.H264Packet{IsAVC: true},
+		waitingForKeyFrame: true,
+	}
+}
+
+// push returns access units completed by the packet, and whether a key frame is needed to recover from loss
+func (d *h264Depacketizer) push(pkt *buffer.ExtPacket) (aus []*accessUnit, needKeyFrame bool) {
+	sn := pkt.Packet.SequenceNumber
+	if d.started {
+		diff := sn - d.lastSN
+		if diff == 0 || diff > 0x8000 {
+			// duplicate or out of order
+			return nil, false
+		}
+		if diff != 1 {
+			d.reset()
+			needKeyFrame = !pkt.KeyFrame
+		}
+	}
+	d.started = true
+	d.lastSN = sn
+
+	if d.current != nil && d.current.timestamp != pkt.Packet.Timestamp {
+		// marker of the previous access unit was lost
+		if au := d.finish(); au != nil {
+			aus = append(aus, au)
+		}
+	}
+	if d.current == nil {
+		d.current = &accessUnit{timestamp: pkt.Packet.Timestamp}
+	}
+	if pkt.KeyFrame {
+		d.current.keyFrame = true
+	}
+
+	payload := pkt.Packet.Payload
+	if len(payload) > 1 && payload[0]&0x1f == h264NALUTypeFUA {
+		// fragments are only usable from the start of a NAL unit
+		switch {
+		case payload[1]&0x80 != 0:
+			d.fragmenting = true
+		case !d.fragmenting:
+			return aus, needKeyFrame
+		}
+		if payload[1]&0x40 != 0 {
+			d.fragmenting = false
+		}
+	}
+
+	nalus, err := d.packet.Unmarshal(payload)
+	if err != nil {
+		d.reset()
+		return aus, true
+	}
+	for len(nalus) >= 4 {
+		size := int(binary.BigEndian.Uint32(nalus))
+		if size == 0 || len(nalus) < 4+size {
+			break
+		}
+		nalu := nalus[4 : 4+size]
+		switch nalu[0] & 0x1f {
+		case h264NALUTypeSPS:
+			d.sps = append(d.sps[:0], nalu...)
+		case h264NALUTypePPS:
+			d.pps = append(d.pps[:0], nalu...)
+		case h264NALUTypeAUD:
+		case h264NALUTypeIDR:
+			d.current.keyFrame = true
+			fallthrough
+		default:
+			d.current.data = append(d.current.data, nalus[:4+size]...)
+		}
+		nalus = nalus[4+size:]
+	}
+
+	if pkt.Packet.Marker {
+		if au := d.finish(); au != nil {
+			aus = append(aus, au)
+		}
+	}
+	return aus, needKeyFrame
+}
+
+func (d *h264Depacketizer) finish() *accessUnit {
+	au := d.current
+	d.current = nil
+	if len(au.data) == 0 {
+		return nil
+	}
+	if d.waitingForKeyFrame {
+		if !au.keyFrame || d.sps == nil || d.pps == nil {
+			return nil
+		}
+		d.waitingForKeyFrame = false
+	}
+	return au
+}
+
+func (d *h264Depacketizer) reset() {
+	d.packet = codecs.H264Packet{IsAVC: true}
+	d.fragmenting = false
+	d.current = nil
+	d.waitingForKeyFrame = true
+}
+
+// h264Codec returns the RFC 6381 codec string of the stream described by the SPS
+func h264Codec(sps []byte) string {
+	return fmt.Sprintf("avc1.%02x%02x%02x", sps[1], sps[2], sps[3])
+}
+
+// -----------------------------------------------
+
+type bitReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *bitReader) bit() (uint32, error) {
+	if r.pos >= len(r.b)*8 {
+		return 0, errInvalidSPS
+	}
+	v := uint32(r.b[r.pos/8]>>(7-r.pos%8)) & 1
+	r.pos++
+	return v, nil
+}
+
+func (r *bitReader) bits(n int) (uint32, error) {
+	var v uint32
+	for i := 0; i < n; i++ {
+		b, err := r.bit()
+		if err != nil {
+			return 0, err
+		}
+		v = v<<1 | b
+	}
+	return v, nil
+}
+
+// ue reads an unsigned Exp-Golomb code
+func (r *bitReader) ue() (uint32, error) {
+	zeros := 0
+	for {
+		b, err := r.bit()
+		if err != nil {
+			return 0, err
+		}
+		if b == 1 {
+			break
+		}
+		zeros++
+		if zeros > 31 {
+			return 0, errInvalidSPS
+		}
+	}
+	v, err := r.bits(zeros)
+	return (1<<zeros - 1) + v, err
+}
+
+func (r *bitReader) se() (int32, error) {
+	v, err := r.ue()
+	if v&1 == 1 {
+		return int32((v + 1) / 2), err
+	}
+	return -int32(v / 2), err
+}
+
+// parseH264Resolution returns the cropped frame size described by an SPS
+func parseH264Resolution(sps []byte) (width int, height int, err error) {
+	if len(sps) < 4 {
+		return 0, 0, errInvalidSPS
+	}
+	r := &bitReader{b: bytes.ReplaceAll(sps[4:], []byte{0, 0, 3}, []byte{0, 0})}
+	profile := sps[1]
+
+	// errors are sticky at the end of the buffer, checking the last read is enough
+	_, _ = r.ue() // seq_parameter_set_id
+	chromaFormat := uint32(1)
+	switch profile {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		chromaFormat, _ = r.ue()
+		if chromaFormat == 3 {
+			_, _ = r.bit() // separate_colour_plane_flag
+		}
+		_, _ = r.ue()  // bit_depth_luma_minus8
+		_, _ = r.ue()  // bit_depth_chroma_minus8
+		_, _ = r.bit() // qpprime_y_zero_transform_bypass_flag
+		if present, _ := r.bit(); present == 1 {
+			lists := 8
+			if chromaFormat == 3 {
+				lists = 12
+			}
+			for i := 0; i < lists; i++ {
+				if listPresent, _ := r.bit(); listPresent == 1 {
+					size := 16
+					if i >= 6 {
+						size = 64
+					}
+					last, next := int32(8), int32(8)
+					for j := 0; j < size && next != 0; j++ {
+						delta, _ := r.se()
+						next = (last + delta + 256) % 256
+						if next != 0 {
+							last = next
+						}
+					}
+				}
+			}
+		}
+	}
+	_, _ = r.ue() // log2_max_frame_num_minus4
+	pocType, _ := r.ue()
+	switch pocType {
+	case 0:
+		_, _ = r.ue()
+	case 1:
+		_, _ = r.bit()
+		_, _ = r.se()
+		_, _ = r.se()
+		n, _ := r.ue()
+		for i := uint32(0); i < n && i < 256; i++ {
+			_, _ = r.se()
+		}
+	}
+	_, _ = r.ue()  // max_num_ref_frames
+	_, _ = r.bit() // gaps_in_frame_num_value_allowed_flag
+	widthInMbs, _ := r.ue()
+	heightInMapUnits, _ := r.ue()
+	frameMbsOnly, _ := r.bit()
+	if frameMbsOnly == 0 {
+		_, _ = r.bit() // mb_adaptive_frame_field_flag
+	}
+	_, _ = r.bit() // direct_8x8_inference_flag
+	var cropLeft, cropRight, cropTop, cropBottom uint32
+	cropping, err := r.bit()
+	if err != nil {
+		return 0, 0, err
+	}
+	if cropping == 1 {
+		cropLeft, _ = r.ue()
+		cropRight, _ = r.ue()
+		cropTop, _ = r.ue()
+		if cropBottom, err = r.ue(); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	cropUnitX, cropUnitY := uint32(1), 2-frameMbsOnly
+	if chromaFormat == 1 || chromaFormat == 2 {
+		cropUnitX = 2
+	}
+	if chromaFormat == 1 {
+		cropUnitY *= 2
+	}
+	width = int((widthInMbs+1)*16 - (cropLeft+cropRight)*cropUnitX)
+	height = int((2-frameMbsOnly)*(heightInMapUnits+1)*16 - (cropTop+cropBottom)*cropUnitY)
+	if width <= 0 || height <= 0 {
+		return 0, 0, errInvalidSPS
+	}
+	return width, height, nil
+}
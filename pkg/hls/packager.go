@@ -0,0 +1,500 @@
+package hls
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	opusPreSkip = 312
+	// used in the multivariant playlist until a segment has been measured
+	defaultOpusBitrate = 64000
+
+	playlistContentType = "application/vnd.apple.mpegurl"
+)
+
+type Config struct {
+	PartDuration    time.Duration
+	SegmentDuration time.Duration
+	PlaylistSize    int
+}
+
+type PackagerParams struct {
+	Config Config
+	Logger logger.Logger
+}
+
+// Packager packages one video track, with a rendition for every simulcast layer, and one audio track
+// into LL-HLS. Packets are taken as they come from the publisher, there is no transcoding.
+// Renditions share a timeline based on the arrival of their first packets.
+type Packager struct {
+	params    PackagerParams
+	startedAt time.Time
+
+	lock      sync.RWMutex
+	videoInfo *livekit.TrackInfo
+	video     map[int32]*videoRendition
+	audio     *audioRendition
+	closed    bool
+
+	onKeyFrameRequest func(layer int32)
+}
+
+type videoRendition struct {
+	stream       *Stream
+	depacketizer *h264Depacketizer
+	timeline     rtpTimeline
+}
+
+type audioRendition struct {
+	stream   *Stream
+	channels uint16
+	timeline rtpTimeline
+}
+
+func NewPackager(params PackagerParams) *Packager {
+	return &Packager{
+		params:    params,
+		startedAt: time.Now(),
+		video:     make(map[int32]*videoRendition),
+	}
+}
+
+func (p *Packager) OnKeyFrameRequest(f func(layer int32)) {
+	p.lock.Lock()
+	p.onKeyFrameRequest = f
+	p.lock.Unlock()
+}
+
+// AddVideoTrack sets the video track, renditions are created as its layers are received
+func (p *Packager) AddVideoTrack(ti *livekit.TrackInfo, codec webrtc.RTPCodecParameters) error {
+	if !strings.EqualFold(codec.MimeType, webrtc.MimeTypeH264) {
+		return ErrUnsupportedCodec
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return ErrPackagerClosed
+	}
+	if p.videoInfo != nil {
+		return ErrTrackExists
+	}
+	p.videoInfo = ti
+	return nil
+}
+
+func (p *Packager) AddAudioTrack(codec webrtc.RTPCodecParameters) error {
+	if !strings.EqualFold(codec.MimeType, webrtc.MimeTypeOpus) {
+		return ErrUnsupportedCodec
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return ErrPackagerClosed
+	}
+	if p.audio != nil {
+		return ErrTrackExists
+	}
+	channels := codec.Channels
+	if channels == 0 {
+		channels = 2
+	}
+	stream := newStream("audio", false, 48000, p.params.Config)
+	stream.setDescription(&trackDescription{timescale: 48000, channels: channels}, "opus")
+	p.audio = &audioRendition{stream: stream, channels: channels}
+	return nil
+}
+
+// WriteVideoPacket takes a packet of a spatial layer of the video track
+func (p *Packager) WriteVideoPacket(pkt *buffer.ExtPacket, layer int32) {
+	r := p.getOrCreateVideoRendition(layer)
+	if r == nil {
+		return
+	}
+
+	aus, needKeyFrame := r.depacketizer.push(pkt)
+	if needKeyFrame {
+		p.requestKeyFrame(layer)
+	}
+	for _, au := range aus {
+		if au.keyFrame {
+			d := r.depacketizer
+			width, height, err := parseH264Resolution(d.sps)
+			if err != nil {
+				p.params.Logger.Debugw("could not parse SPS", "error", err, "layer", layer)
+				continue
+			}
+			r.stream.setDescription(&trackDescription{
+				video:     true,
+				timescale: 90000,
+				width:     uint16(width),
+				height:    uint16(height),
+				sps:       append([]byte{}, d.sps...),
+				pps:       append([]byte{}, d.pps...),
+			}, h264Codec(d.sps))
+		}
+		r.stream.writeSample(&sample{
+			dts:      r.timeline.dts(au.timestamp, pkt.Arrival, p.startedAt, 90000),
+			keyFrame: au.keyFrame,
+			data:     au.data,
+		})
+	}
+}
+
+func (p *Packager) WriteAudioPacket(pkt *buffer.ExtPacket) {
+	p.lock.RLock()
+	r := p.audio
+	p.lock.RUnlock()
+	if r == nil || len(pkt.Packet.Payload) == 0 {
+		return
+	}
+
+	r.stream.writeSample(&sample{
+		dts:      r.timeline.dts(pkt.Packet.Timestamp, pkt.Arrival, p.startedAt, 48000),
+		keyFrame: true,
+		data:     append([]byte{}, pkt.Packet.Payload...),
+	})
+}
+
+func (p *Packager) getOrCreateVideoRendition(layer int32) *videoRendition {
+	p.lock.RLock()
+	r := p.video[layer]
+	ti := p.videoInfo
+	closed := p.closed
+	p.lock.RUnlock()
+	if r != nil || ti == nil || closed {
+		return r
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if r = p.video[layer]; r != nil {
+		return r
+	}
+	quality := buffer.SpatialLayerToVideoQuality(layer, ti)
+	name := "video_" + strings.ToLower(quality.String())
+	r = &videoRendition{
+		stream:       newStream(name, true, 90000, p.params.Config),
+		depacketizer: newH264Depacketizer(),
+	}
+	r.stream.OnKeyFrameNeeded(func() {
+		p.requestKeyFrame(layer)
+	})
+	p.video[layer] = r
+	return r
+}
+
+func (p *Packager) requestKeyFrame(layer int32) {
+	p.lock.RLock()
+	onKeyFrameRequest := p.onKeyFrameRequest
+	p.lock.RUnlock()
+
+	if onKeyFrameRequest != nil {
+		onKeyFrameRequest(layer)
+	}
+}
+
+// RemoveVideoTrack stops the video renditions, they are removed from the multivariant playlist
+func (p *Packager) RemoveVideoTrack() {
+	p.lock.Lock()
+	video := p.video
+	p.video = make(map[int32]*videoRendition)
+	p.videoInfo = nil
+	p.lock.Unlock()
+
+	for _, r := range video {
+		r.stream.close()
+	}
+}
+
+func (p *Packager) RemoveAudioTrack() {
+	p.lock.Lock()
+	audio := p.audio
+	p.audio = nil
+	p.lock.Unlock()
+
+	if audio != nil {
+		audio.stream.close()
+	}
+}
+
+func (p *Packager) IsEmpty() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.videoInfo == nil && p.audio == nil
+}
+
+func (p *Packager) Close() {
+	p.lock.Lock()
+	p.closed = true
+	p.lock.Unlock()
+
+	p.RemoveVideoTrack()
+	p.RemoveAudioTrack()
+}
+
+// -----------------------------------------------
+
+// streams returns the video renditions from the highest layer down, then audio
+func (p *Packager) streams() (video []*Stream, audio *Stream) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	layers := make([]int32, 0, len(p.video))
+	for layer := range p.video {
+		layers = append(layers, layer)
+	}
+	sort.Slice(layers, func(i, j int) bool { return layers[i] > layers[j] })
+	for _, layer := range layers {
+		video = append(video, p.video[layer].stream)
+	}
+	if p.audio != nil {
+		audio = p.audio.stream
+	}
+	return
+}
+
+func (p *Packager) getStream(name string) *Stream {
+	video, audio := p.streams()
+	if audio != nil && audio.Name() == name {
+		return audio
+	}
+	for _, s := range video {
+		if s.Name() == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// layerBitrate returns the target bitrate the publisher announced for a rendition
+func (p *Packager) layerBitrate(s *Stream) int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.videoInfo == nil {
+		return 0
+	}
+	for _, layer := range p.videoInfo.Layers {
+		if s.Name() == "video_"+strings.ToLower(layer.Quality.String()) {
+			return int(layer.Bitrate)
+		}
+	}
+	return 0
+}
+
+// MultivariantPlaylist lists renditions that have started, false when there are none
+func (p *Packager) MultivariantPlaylist() (string, bool) {
+	video, audio := p.streams()
+	audioCodec, audioBitrate := "", 0
+	if audio != nil && audio.hasSegments() {
+		audioCodec = audio.Codec()
+		audioBitrate = audio.PeakBitrate()
+		if audioBitrate == 0 {
+			audioBitrate = defaultOpusBitrate
+		}
+	}
+
+	b := &strings.Builder{}
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:6\n")
+	b.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+	if audioCodec != "" {
+		fmt.Fprintf(b, "#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"audio\",NAME=\"audio\",DEFAULT=YES,AUTOSELECT=YES,URI=\"%s/index.m3u8\"\n", audio.Name())
+	}
+
+	variants := 0
+	for _, s := range video {
+		if !s.hasSegments() {
+			continue
+		}
+		bitrate := s.PeakBitrate()
+		if bitrate == 0 {
+			bitrate = p.layerBitrate(s)
+		}
+		codecs := s.Codec()
+		width, height := s.Resolution()
+		fmt.Fprintf(b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d", bitrate+audioBitrate, width, height)
+		if audioCodec != "" {
+			fmt.Fprintf(b, ",CODECS=\"%s,%s\",AUDIO=\"audio\"\n", codecs, audioCodec)
+		} else {
+			fmt.Fprintf(b, ",CODECS=\"%s\"\n", codecs)
+		}
+		fmt.Fprintf(b, "%s/index.m3u8\n", s.Name())
+		variants++
+	}
+	if variants == 0 {
+		if audioCodec == "" {
+			return "", false
+		}
+		fmt.Fprintf(b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,CODECS=\"%s\"\n", audioBitrate, audioCodec)
+		fmt.Fprintf(b, "%s/index.m3u8\n", audio.Name())
+	}
+	return b.String(), true
+}
+
+// ServeHTTP serves paths relative to the packager: index.m3u8 for the multivariant playlist, and
+// <rendition>/index.m3u8, init<n>.mp4, seg<msn>.m4s and part<msn>.<part>.m4s.
+// Media playlists support blocking reloads with _HLS_msn and _HLS_part, a reload more than two segments after the
+// last one of the playlist is rejected with 400.
+func (p *Packager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path == "" || path == "index.m3u8" {
+		playlist, ok := p.MultivariantPlaylist()
+		if !ok {
+			http.Error(w, "stream has not started", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", playlistContentType)
+		w.Header().Set("Cache-Control", "no-cache")
+		_, _ = w.Write([]byte(playlist))
+		return
+	}
+
+	name, file, ok := strings.Cut(path, "/")
+	stream := p.getStream(name)
+	if !ok || stream == nil {
+		http.NotFound(w, r)
+		return
+	}
+	contentType := "video/mp4"
+	if !stream.video {
+		contentType = "audio/mp4"
+	}
+
+	switch {
+	case file == "index.m3u8":
+		p.serveMediaPlaylist(w, r, stream)
+
+	case strings.HasPrefix(file, "init") && strings.HasSuffix(file, ".mp4"):
+		id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(file, "init"), ".mp4"))
+		data := stream.initSegment(id)
+		if err != nil || data == nil {
+			http.NotFound(w, r)
+			return
+		}
+		serveMedia(w, contentType, data)
+
+	case strings.HasPrefix(file, "seg") && strings.HasSuffix(file, ".m4s"):
+		msn, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(file, "seg"), ".m4s"), 10, 64)
+		if err != nil || stream.waitFor(r.Context(), msn, -1) != nil {
+			http.NotFound(w, r)
+			return
+		}
+		data := stream.segmentData(msn)
+		if data == nil {
+			http.NotFound(w, r)
+			return
+		}
+		serveMedia(w, contentType, data)
+
+	case strings.HasPrefix(file, "part") && strings.HasSuffix(file, ".m4s"):
+		msnStr, indexStr, _ := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(file, "part"), ".m4s"), ".")
+		msn, err1 := strconv.ParseUint(msnStr, 10, 64)
+		index, err2 := strconv.Atoi(indexStr)
+		if err1 != nil || err2 != nil || index < 0 || stream.waitFor(r.Context(), msn, index) != nil {
+			http.NotFound(w, r)
+			return
+		}
+		data := stream.partData(msn, index)
+		if data == nil {
+			http.NotFound(w, r)
+			return
+		}
+		serveMedia(w, contentType, data)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (p *Packager) serveMediaPlaylist(w http.ResponseWriter, r *http.Request, stream *Stream) {
+	query := r.URL.Query()
+	if msnStr := query.Get("_HLS_msn"); msnStr != "" {
+		msn, err := strconv.ParseUint(msnStr, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid _HLS_msn", http.StatusBadRequest)
+			return
+		}
+		part := -1
+		if partStr := query.Get("_HLS_part"); partStr != "" {
+			if part, err = strconv.Atoi(partStr); err != nil || part < 0 {
+				http.Error(w, "invalid _HLS_part", http.StatusBadRequest)
+				return
+			}
+		}
+		switch err = stream.waitFor(r.Context(), msn, part); {
+		case errors.Is(err, errBeyondLiveEdge):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			http.Error(w, "playlist update not available", http.StatusServiceUnavailable)
+			return
+		}
+	} else if query.Get("_HLS_part") != "" {
+		http.Error(w, "_HLS_part requires _HLS_msn", http.StatusBadRequest)
+		return
+	}
+
+	playlist, ok := stream.playlist()
+	if !ok {
+		http.Error(w, "stream has not started", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", playlistContentType)
+	if query.Get("_HLS_msn") != "" {
+		// blocking requests have unique URLs, and can be cached by a CDN
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(p.params.Config.SegmentDuration.Seconds())*p.params.Config.PlaylistSize))
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	_, _ = w.Write([]byte(playlist))
+}
+
+func serveMedia(w http.ResponseWriter, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "max-age=3600")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	_, _ = w.Write(data)
+}
+
+// -----------------------------------------------
+
+// rtpTimeline maps RTP timestamps of a rendition onto the packager's timeline
+type rtpTimeline struct {
+	started bool
+	base    int64
+	lastTS  uint32
+	elapsed int64
+}
+
+func (t *rtpTimeline) dts(ts uint32, arrival time.Time, startedAt time.Time, clockRate uint32) int64 {
+	if !t.started {
+		t.started = true
+		t.lastTS = ts
+		t.base = int64(arrival.Sub(startedAt).Seconds() * float64(clockRate))
+		return t.base
+	}
+	t.elapsed += int64(int32(ts - t.lastTS))
+	t.lastTS = ts
+	return t.base + t.elapsed
+}
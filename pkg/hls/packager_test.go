@@ -0,0 +1,172 @@
+package hls
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// 1280x720, high profile
+var testSPS, _ = base64.StdEncoding.DecodeString("Z2QAH6zZQFAFuwEQAAADABAAAAMDwPGDGWA=")
+
+func TestParseH264Resolution(t *testing.T) {
+	width, height, err := parseH264Resolution(testSPS)
+	require.NoError(t, err)
+	require.Equal(t, 1280, width)
+	require.Equal(t, 720, height)
+	require.Equal(t, "avc1.64001f", h264Codec(testSPS))
+
+	_, _, err = parseH264Resolution(testSPS[:6])
+	require.ErrorIs(t, err, errInvalidSPS)
+}
+
+func TestPackager(t *testing.T) {
+	p := NewPackager(PackagerParams{
+		Config: Config{
+			PartDuration:    100 * time.Millisecond,
+			SegmentDuration: 500 * time.Millisecond,
+			PlaylistSize:    3,
+		},
+		Logger: logger.GetLogger(),
+	})
+	defer p.Close()
+
+	keyFrameRequests := atomic.NewInt32(0)
+	p.OnKeyFrameRequest(func(_ int32) {
+		keyFrameRequests.Inc()
+	})
+
+	ti := &livekit.TrackInfo{
+		Type:   livekit.TrackType_VIDEO,
+		Width:  1280,
+		Height: 720,
+		Layers: []*livekit.VideoLayer{{Quality: livekit.VideoQuality_HIGH, Width: 1280, Height: 720}},
+	}
+	require.ErrorIs(t, p.AddVideoTrack(ti, webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8},
+	}), ErrUnsupportedCodec)
+	require.NoError(t, p.AddVideoTrack(ti, webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000},
+	}))
+	require.NoError(t, p.AddAudioTrack(webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+	}))
+
+	_, ok := p.MultivariantPlaylist()
+	require.False(t, ok)
+
+	sn := uint16(0)
+	videoPacket := func(ts uint32, payload []byte, marker bool, keyFrame bool) *buffer.ExtPacket {
+		sn++
+		return &buffer.ExtPacket{
+			Arrival:  time.Now(),
+			KeyFrame: keyFrame,
+			Packet: &rtp.Packet{
+				Header:  rtp.Header{SequenceNumber: sn, Timestamp: ts, Marker: marker},
+				Payload: payload,
+			},
+		}
+	}
+	writeFrame := func(frame int) {
+		ts := uint32(frame * 3000)
+		if frame%15 == 0 {
+			pps := []byte{0x68, 0xeb, 0xe3, 0xcb, 0x22, 0xc0}
+			stap := []byte{0x78, 0, byte(len(testSPS))}
+			stap = append(stap, testSPS...)
+			stap = append(stap, 0, byte(len(pps)))
+			stap = append(stap, pps...)
+			p.WriteVideoPacket(videoPacket(ts, stap, false, true), 0)
+			p.WriteVideoPacket(videoPacket(ts, []byte{0x65, 0x88, 0x84, 0x00}, true, true), 0)
+		} else {
+			p.WriteVideoPacket(videoPacket(ts, []byte{0x41, 0x9a, 0x02, 0x00}, true, false), 0)
+		}
+		// two opus packets per frame interval of 33ms, roughly
+		for i := 0; i < 2; i++ {
+			n := frame*2 + i
+			p.WriteAudioPacket(&buffer.ExtPacket{
+				Arrival: time.Now(),
+				Packet: &rtp.Packet{
+					Header:  rtp.Header{SequenceNumber: uint16(n), Timestamp: uint32(n * 800)},
+					Payload: []byte{0xf8, 0xff, 0xfe},
+				},
+			})
+		}
+	}
+	for frame := 0; frame < 40; frame++ {
+		writeFrame(frame)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/index.m3u8")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `#EXT-X-STREAM-INF:BANDWIDTH=`)
+	require.Contains(t, w.Body.String(), `RESOLUTION=1280x720,CODECS="avc1.64001f,opus",AUDIO="audio"`)
+	require.Contains(t, w.Body.String(), "video_high/index.m3u8")
+	require.Contains(t, w.Body.String(), `URI="audio/index.m3u8"`)
+
+	w = get("/video_high/index.m3u8")
+	require.Equal(t, http.StatusOK, w.Code)
+	playlist := w.Body.String()
+	require.Contains(t, playlist, "#EXT-X-PART-INF:PART-TARGET=0.100")
+	require.Contains(t, playlist, `#EXT-X-MAP:URI="init0.mp4"`)
+	require.Contains(t, playlist, `#EXT-X-PART:DURATION=0.10000,URI="part0.0.m4s",INDEPENDENT=YES`)
+	require.Contains(t, playlist, "#EXTINF:0.50000,\nseg0.m4s")
+	require.Contains(t, playlist, `#EXT-X-PRELOAD-HINT:TYPE=PART,URI="part2.`)
+
+	w = get("/video_high/init0.mp4")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "ftyp", string(w.Body.Bytes()[4:8]))
+	require.True(t, strings.Contains(w.Body.String(), "avcC"))
+
+	w = get("/video_high/seg0.m4s")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "moof", string(w.Body.Bytes()[4:8]))
+	part := get("/video_high/part0.0.m4s")
+	require.Equal(t, http.StatusOK, part.Code)
+	require.True(t, strings.HasPrefix(w.Body.String(), part.Body.String()))
+
+	w = get("/audio/init0.mp4")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.True(t, strings.Contains(w.Body.String(), "dOps"))
+	require.Equal(t, http.StatusOK, get("/audio/index.m3u8").Code)
+
+	// blocking reload returns once the segment is complete
+	done := make(chan int, 1)
+	go func() {
+		done <- get("/video_high/index.m3u8?_HLS_msn=2").Code
+	}()
+	select {
+	case <-done:
+		t.Fatal("blocking reload returned early")
+	case <-time.After(50 * time.Millisecond):
+	}
+	for frame := 40; frame < 50; frame++ {
+		writeFrame(frame)
+	}
+	require.Equal(t, http.StatusOK, <-done)
+	require.Equal(t, http.StatusBadRequest, get("/video_high/index.m3u8?_HLS_msn=10").Code)
+	require.Equal(t, http.StatusBadRequest, get("/video_high/index.m3u8?_HLS_part=1").Code)
+
+	// a lost packet asks for a key frame
+	sn++
+	writeFrame(50)
+	require.Equal(t, int32(1), keyFrameRequests.Load())
+}
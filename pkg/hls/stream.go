@@ -0,0 +1,420 @@
+package hls
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+type part struct {
+	data        []byte
+	duration    float64
+	independent bool
+}
+
+type segment struct {
+	msn       uint64
+	initID    int
+	startedAt time.Time
+	parts     []*part
+	duration  float64
+	size      int
+	complete  bool
+}
+
+// Stream packages the samples of one rendition into CMAF segments made of partial segments,
+// and renders its LL-HLS media playlist
+type Stream struct {
+	name      string
+	video     bool
+	timescale uint32
+	config    Config
+
+	lock  sync.Mutex
+	codec string
+	// init segments by ID, a new one is created when the track description changes
+	inits      map[int][]byte
+	initID     int
+	descriptor *trackDescription
+	width      int
+	height     int
+
+	pending     *sample
+	lastDur     uint32
+	partSamples []*sample
+	partDur     float64
+	fragmentSeq uint32
+
+	segments    []*segment
+	current     *segment
+	nextMSN     uint64
+	maxSegDur   float64
+	peakBitrate int
+
+	// closed and replaced whenever a part is added
+	updated chan struct{}
+	closed  bool
+
+	onKeyFrameNeeded func()
+	keyFrameAsked    bool
+}
+
+func newStream(name string, video bool, timescale uint32, config Config) *Stream {
+	return &Stream{
+		name:      name,
+		video:     video,
+		timescale: timescale,
+		config:    config,
+		inits:     make(map[int][]byte),
+		initID:    -1,
+		updated:   make(chan struct{}),
+	}
+}
+
+func (s *Stream) Name() string {
+	return s.name
+}
+
+// Codec returns the RFC 6381 codec string, empty until the stream has started
+func (s *Stream) Codec() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.codec
+}
+
+func (s *Stream) Resolution() (int, int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.width, s.height
+}
+
+// hasSegments returns whether the playlist lists any media
+func (s *Stream) hasSegments() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.segments) != 0 || (s.current != nil && len(s.current.parts) != 0)
+}
+
+// PeakBitrate returns the highest bitrate of completed segments
+func (s *Stream) PeakBitrate() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.peakBitrate
+}
+
+func (s *Stream) OnKeyFrameNeeded(f func()) {
+	s.lock.Lock()
+	s.onKeyFrameNeeded = f
+	s.lock.Unlock()
+}
+
+// setDescription starts a new init segment when the description of the track changes
+func (s *Stream) setDescription(td *trackDescription, codec string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if d := s.descriptor; d != nil && d.width == td.width && d.height == td.height &&
+		string(d.sps) == string(td.sps) && string(d.pps) == string(td.pps) && d.channels == td.channels {
+		return
+	}
+	// the current segment uses the previous description, the next sample starts a new one
+	if s.pending != nil {
+		s.pending.duration = s.lastDur
+		s.addSampleLocked(s.pending)
+		s.pending = nil
+	}
+	s.flushPartLocked()
+	s.closeSegmentLocked()
+
+	s.descriptor = td
+	s.codec = codec
+	s.width, s.height = int(td.width), int(td.height)
+	s.initID++
+	s.inits[s.initID] = writeInitSegment(td)
+}
+
+// writeSample adds a sample, its duration is known once the next sample arrives
+func (s *Stream) writeSample(smp *sample) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed || s.initID < 0 {
+		return
+	}
+	if s.pending != nil {
+		if smp.dts <= s.pending.dts {
+			// out of order, or a timeline jump backwards
+			if !smp.keyFrame || !s.video {
+				return
+			}
+			smp.dts = s.pending.dts + int64(s.lastDur)
+		}
+		dur := smp.dts - s.pending.dts
+		if dur > int64(s.timescale) {
+			// a gap, do not stretch the last sample over it
+			dur = int64(s.lastDur)
+		}
+		s.pending.duration = uint32(dur)
+		s.lastDur = uint32(dur)
+		s.addSampleLocked(s.pending)
+	}
+	s.pending = smp
+}
+
+func (s *Stream) addSampleLocked(smp *sample) {
+	dur := float64(smp.duration) / float64(s.timescale)
+	segmentTarget := s.config.SegmentDuration.Seconds()
+	partTarget := s.config.PartDuration.Seconds()
+
+	if s.current != nil {
+		segDur := s.current.duration + s.partDur
+		if (smp.keyFrame || !s.video) && segDur+dur > segmentTarget {
+			s.flushPartLocked()
+			s.closeSegmentLocked()
+		} else if s.video && !s.keyFrameAsked && segDur >= segmentTarget && s.onKeyFrameNeeded != nil {
+			s.keyFrameAsked = true
+			go s.onKeyFrameNeeded()
+		}
+	}
+	if s.current == nil {
+		if s.video && !smp.keyFrame {
+			return
+		}
+		s.current = &segment{
+			msn:       s.nextMSN,
+			initID:    s.initID,
+			startedAt: time.Now(),
+		}
+		s.nextMSN++
+		s.keyFrameAsked = false
+	}
+
+	if s.partDur > 0 && s.partDur+dur > partTarget {
+		s.flushPartLocked()
+	}
+	s.partSamples = append(s.partSamples, smp)
+	s.partDur += dur
+}
+
+func (s *Stream) flushPartLocked() {
+	if len(s.partSamples) == 0 || s.current == nil {
+		s.partSamples = nil
+		s.partDur = 0
+		return
+	}
+
+	s.fragmentSeq++
+	p := &part{
+		data:        writeFragment(s.fragmentSeq, s.partSamples),
+		duration:    s.partDur,
+		independent: s.partSamples[0].keyFrame,
+	}
+	s.current.parts = append(s.current.parts, p)
+	s.current.duration += p.duration
+	s.current.size += len(p.data)
+	s.partSamples = nil
+	s.partDur = 0
+	s.notifyLocked()
+}
+
+func (s *Stream) closeSegmentLocked() {
+	seg := s.current
+	if seg == nil {
+		return
+	}
+	s.current = nil
+	if len(seg.parts) == 0 {
+		s.nextMSN--
+		return
+	}
+
+	seg.complete = true
+	s.segments = append(s.segments, seg)
+	if len(s.segments) > s.config.PlaylistSize {
+		s.segments = s.segments[len(s.segments)-s.config.PlaylistSize:]
+	}
+	if seg.duration > s.maxSegDur {
+		s.maxSegDur = seg.duration
+	}
+	if seg.duration > 0 {
+		if bitrate := int(float64(seg.size*8) / seg.duration); bitrate > s.peakBitrate {
+			s.peakBitrate = bitrate
+		}
+	}
+	s.notifyLocked()
+}
+
+func (s *Stream) notifyLocked() {
+	close(s.updated)
+	s.updated = make(chan struct{})
+}
+
+func (s *Stream) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		s.notifyLocked()
+	}
+}
+
+// -----------------------------------------------
+
+func (s *Stream) findSegmentLocked(msn uint64) *segment {
+	if s.current != nil && s.current.msn == msn {
+		return s.current
+	}
+	for _, seg := range s.segments {
+		if seg.msn == msn {
+			return seg
+		}
+	}
+	return nil
+}
+
+// hasLocked returns whether the segment, or one of its parts when part >= 0, is available
+func (s *Stream) hasLocked(msn uint64, part int) bool {
+	seg := s.findSegmentLocked(msn)
+	switch {
+	case seg == nil:
+		// segments before the window are gone, but they were available
+		return len(s.segments) > 0 && msn < s.segments[0].msn
+	case part < 0:
+		return seg.complete
+	default:
+		return seg.complete || part < len(seg.parts)
+	}
+}
+
+// waitFor blocks until the segment or part is available. It returns errBeyondLiveEdge when the segment is more
+// than two segments after the last one of the playlist, and errNotAvailable when it does not become available in time
+func (s *Stream) waitFor(ctx context.Context, msn uint64, part int) error {
+	timeout := time.NewTimer(3 * s.config.SegmentDuration)
+	defer timeout.Stop()
+
+	for {
+		s.lock.Lock()
+		if s.hasLocked(msn, part) {
+			s.lock.Unlock()
+			return nil
+		}
+		if s.closed {
+			s.lock.Unlock()
+			return errNotAvailable
+		}
+		// the last segment of the playlist is the one in progress, nextMSN - 1
+		if msn > s.nextMSN+1 {
+			s.lock.Unlock()
+			return errBeyondLiveEdge
+		}
+		updated := s.updated
+		s.lock.Unlock()
+
+		select {
+		case <-updated:
+		case <-timeout.C:
+			return errNotAvailable
+		case <-ctx.Done():
+			return errNotAvailable
+		}
+	}
+}
+
+func (s *Stream) initSegment(id int) []byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.inits[id]
+}
+
+func (s *Stream) segmentData(msn uint64) []byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	seg := s.findSegmentLocked(msn)
+	if seg == nil || !seg.complete {
+		return nil
+	}
+	data := make([]byte, 0, seg.size)
+	for _, p := range seg.parts {
+		data = append(data, p.data...)
+	}
+	return data
+}
+
+func (s *Stream) partData(msn uint64, index int) []byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	seg := s.findSegmentLocked(msn)
+	if seg == nil || index >= len(seg.parts) {
+		return nil
+	}
+	return seg.parts[index].data
+}
+
+// playlist renders the media playlist, parts are listed for the last few segments only
+func (s *Stream) playlist() (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.segments) == 0 && (s.current == nil || len(s.current.parts) == 0) {
+		return "", false
+	}
+
+	partTarget := s.config.PartDuration.Seconds()
+	targetDuration := int(math.Ceil(s.config.SegmentDuration.Seconds()))
+	if d := int(math.Round(s.maxSegDur)); d > targetDuration {
+		targetDuration = d
+	}
+
+	b := &strings.Builder{}
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:6\n")
+	fmt.Fprintf(b, "#EXT-X-TARGETDURATION:%d\n", targetDuration)
+	fmt.Fprintf(b, "#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=%.3f\n", 3*partTarget)
+	fmt.Fprintf(b, "#EXT-X-PART-INF:PART-TARGET=%.3f\n", partTarget)
+
+	segments := s.segments
+	if s.current != nil && len(s.current.parts) != 0 {
+		segments = append(segments[:len(segments):len(segments)], s.current)
+	}
+	fmt.Fprintf(b, "#EXT-X-MEDIA-SEQUENCE:%d\n", segments[0].msn)
+
+	initID := -1
+	for i, seg := range segments {
+		if seg.initID != initID {
+			initID = seg.initID
+			fmt.Fprintf(b, "#EXT-X-MAP:URI=\"init%d.mp4\"\n", initID)
+		}
+		fmt.Fprintf(b, "#EXT-X-PROGRAM-DATE-TIME:%s\n", seg.startedAt.UTC().Format("2006-01-02T15:04:05.000Z"))
+		if i >= len(segments)-3 {
+			for j, p := range seg.parts {
+				fmt.Fprintf(b, "#EXT-X-PART:DURATION=%.5f,URI=\"part%d.%d.m4s\"", p.duration, seg.msn, j)
+				if p.independent {
+					b.WriteString(",INDEPENDENT=YES")
+				}
+				b.WriteString("\n")
+			}
+		}
+		if seg.complete {
+			fmt.Fprintf(b, "#EXTINF:%.5f,\n", seg.duration)
+			fmt.Fprintf(b, "seg%d.m4s\n", seg.msn)
+		}
+	}
+
+	if s.current != nil {
+		fmt.Fprintf(b, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"part%d.%d.m4s\"\n", s.current.msn, len(s.current.parts))
+	} else {
+		fmt.Fprintf(b, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"part%d.0.m4s\"\n", s.nextMSN)
+	}
+	return b.String(), true
+}
@@ -45,6 +45,17 @@ type ParticipantInit struct {
 	AdaptiveStream       bool
 	ID                   livekit.ParticipantID
 	SubscriberAllowPause *bool
+	// bitrate limit of the participant's publications in bps, 0 for room's default
+	MaxPublishBitrate uint64
+	// joined by the canary of the node, set only after verifying the canary's token
+	Canary bool
+}
+
+// startSessionGrants carries limits that are not part of the grants in StartSession's grants
+type startSessionGrants struct {
+	*auth.ClaimGrants
+	MaxPublishBitrate uint64 `json:"maxPublishBitrate,omitempty"`
+	Canary            bool   `json:"canary,omitempty"`
 }
 
 type NewParticipantCallback func(
@@ -113,7 +124,11 @@ func CreateRouter(config *config.Config, rc redis.UniversalClient, node LocalNod
 }
 
 func (pi *ParticipantInit) ToStartSession(roomName livekit.RoomName, connectionID livekit.ConnectionID) (*livekit.StartSession, error) {
-	claims, err := json.Marshal(pi.Grants)
+	claims, err := json.Marshal(startSessionGrants{
+		ClaimGrants:       pi.Grants,
+		MaxPublishBitrate: pi.MaxPublishBitrate,
+		Canary:            pi.Canary,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -141,22 +156,24 @@ func (pi *ParticipantInit) ToStartSession(roomName livekit.RoomName, connectionI
 }
 
 func ParticipantInitFromStartSession(ss *livekit.StartSession, region string) (*ParticipantInit, error) {
-	claims := &auth.ClaimGrants{}
-	if err := json.Unmarshal([]byte(ss.GrantsJson), claims); err != nil {
+	claims := startSessionGrants{ClaimGrants: &auth.ClaimGrants{}}
+	if err := json.Unmarshal([]byte(ss.GrantsJson), &claims); err != nil {
 		return nil, err
 	}
 
 	pi := &ParticipantInit{
-		Identity:        livekit.ParticipantIdentity(ss.Identity),
-		Name:            livekit.ParticipantName(ss.Name),
-		Reconnect:       ss.Reconnect,
-		ReconnectReason: ss.ReconnectReason,
-		Client:          ss.Client,
-		AutoSubscribe:   ss.AutoSubscribe,
-		Grants:          claims,
-		Region:          region,
-		AdaptiveStream:  ss.AdaptiveStream,
-		ID:              livekit.ParticipantID(ss.ParticipantId),
+		Identity:          livekit.ParticipantIdentity(ss.Identity),
+		Name:              livekit.ParticipantName(ss.Name),
+		Reconnect:         ss.Reconnect,
+		ReconnectReason:   ss.ReconnectReason,
+		Client:            ss.Client,
+		AutoSubscribe:     ss.AutoSubscribe,
+		Grants:            claims.ClaimGrants,
+		Region:            region,
+		AdaptiveStream:    ss.AdaptiveStream,
+		ID:                livekit.ParticipantID(ss.ParticipantId),
+		MaxPublishBitrate: claims.MaxPublishBitrate,
+		Canary:            claims.Canary,
 	}
 	if ss.SubscriberAllowPause != nil {
 		subscriberAllowPause := *ss.SubscriberAllowPause
@@ -0,0 +1,32 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestParticipantInitStartSession(t *testing.T) {
+	pi := ParticipantInit{
+		Identity:          "guest",
+		Name:              "Guest",
+		AutoSubscribe:     true,
+		Client:            &livekit.ClientInfo{Sdk: livekit.ClientInfo_JS},
+		Grants:            &auth.ClaimGrants{Name: "Guest", Video: &auth.VideoGrant{Room: "room", RoomJoin: true}},
+		MaxPublishBitrate: 1_500_000,
+		Canary:            true,
+	}
+
+	ss, err := pi.ToStartSession("room", "connection")
+	require.NoError(t, err)
+
+	decoded, err := ParticipantInitFromStartSession(ss, "region")
+	require.NoError(t, err)
+	require.Equal(t, pi.Grants, decoded.Grants)
+	require.Equal(t, pi.MaxPublishBitrate, decoded.MaxPublishBitrate)
+	require.True(t, decoded.Canary)
+	require.Equal(t, "region", decoded.Region)
+}
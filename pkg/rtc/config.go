@@ -1,6 +1,8 @@
 package rtc
 
 import (
+	"time"
+
 	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v3"
 
@@ -21,10 +23,13 @@ type WebRTCConfig struct {
 	Receiver      ReceiverConfig
 	Publisher     DirectionConfig
 	Subscriber    DirectionConfig
+	// RTP ingests and forwards are stopped when nothing is heard from the peer for this long, 0 to disable
+	RTPInactivityTimeout time.Duration
 }
 
 type ReceiverConfig struct {
-	PacketBufferSize int
+	PacketBufferSize  int
+	ConnectionQuality config.ConnectionQualityConfig
 }
 
 type RTPHeaderExtensionConfig struct {
@@ -81,6 +86,9 @@ func NewWebRTCConfig(conf *config.Config) (*WebRTCConfig, error) {
 				{Type: webrtc.TypeRTCPFBCCM, Parameter: "fir"},
 				{Type: webrtc.TypeRTCPFBNACK},
 				{Type: webrtc.TypeRTCPFBNACK, Parameter: "pli"},
+				// bitrate feedback to publishers, see UplinkFeedback
+				{Type: webrtc.TypeRTCPFBGoogREMB},
+				{Type: webrtc.TypeRTCPFBCCM, Parameter: "tmmbr"},
 			},
 		},
 	}
@@ -110,10 +118,12 @@ func NewWebRTCConfig(conf *config.Config) (*WebRTCConfig, error) {
 	return &WebRTCConfig{
 		WebRTCConfig: *webRTCConfig,
 		Receiver: ReceiverConfig{
-			PacketBufferSize: rtcConf.PacketBufferSize,
+			PacketBufferSize:  rtcConf.PacketBufferSize,
+			ConnectionQuality: rtcConf.ConnectionQuality,
 		},
-		Publisher:  publisherConfig,
-		Subscriber: subscriberConfig,
+		Publisher:            publisherConfig,
+		Subscriber:           subscriberConfig,
+		RTPInactivityTimeout: rtcConf.RTPInactivityTimeout,
 	}, nil
 }
 
@@ -0,0 +1,59 @@
+package rtc
+
+import (
+	"encoding/json"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// DTMFTopic is the data packet topic telephone events received on a participant's audio tracks are published on.
+// The topic is reserved, data packets sent by clients on it are dropped.
+const DTMFTopic = "lk.dtmf"
+
+type DTMFMessage struct {
+	TrackSid string `json:"trackSid"`
+	Code     uint8  `json:"code"`
+	Digit    string `json:"digit"`
+	Duration uint32 `json:"durationMs"`
+	Volume   uint8  `json:"volume"`
+}
+
+func (p *ParticipantImpl) onReceivedDTMF(trackID livekit.TrackID, event buffer.DTMFEvent) {
+	if p.IsDisconnected() {
+		return
+	}
+
+	payload, err := json.Marshal(&DTMFMessage{
+		TrackSid: string(trackID),
+		Code:     event.Code,
+		Digit:    event.Digit(),
+		Duration: event.DurationMs,
+		Volume:   event.Volume,
+	})
+	if err != nil {
+		p.params.Logger.Errorw("failed to marshal dtmf event", err)
+		return
+	}
+	p.params.Logger.Debugw("received dtmf", "trackID", trackID, "code", event.Code)
+
+	p.lock.RLock()
+	onDataPacket := p.onDataPacket
+	p.lock.RUnlock()
+	if onDataPacket == nil {
+		return
+	}
+
+	topic := DTMFTopic
+	onDataPacket(p, &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				ParticipantSid: string(p.params.SID),
+				Payload:        payload,
+				Topic:          &topic,
+			},
+		},
+	})
+}
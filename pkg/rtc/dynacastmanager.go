@@ -25,6 +25,8 @@ type DynacastManager struct {
 	dynacastQuality               map[string]*DynacastQuality // mime type => DynacastQuality
 	maxSubscribedQuality          map[string]livekit.VideoQuality
 	committedMaxSubscribedQuality map[string]livekit.VideoQuality
+	// highest quality the publisher is allowed to send, regardless of subscriptions
+	maxPublishedQuality livekit.VideoQuality
 
 	maxSubscribedQualityDebounce func(func())
 
@@ -44,6 +46,7 @@ func NewDynacastManager(params DynacastManagerParams) *DynacastManager {
 		dynacastQuality:               make(map[string]*DynacastQuality),
 		maxSubscribedQuality:          make(map[string]livekit.VideoQuality),
 		committedMaxSubscribedQuality: make(map[string]livekit.VideoQuality),
+		maxPublishedQuality:           livekit.VideoQuality_HIGH,
 		maxSubscribedQualityDebounce:  debounce.New(params.DynacastPauseDelay),
 		qualityNotifyOpQueue:          utils.NewOpsQueue(params.Logger, "quality-notify", 100),
 	}
@@ -111,6 +114,22 @@ func (d *DynacastManager) ForceQuality(quality livekit.VideoQuality) {
 	d.enqueueSubscribedQualityChange()
 }
 
+// SetMaxPublishedQuality caps the qualities enabled on the publisher, used to keep
+// a publisher under its bitrate limit even when higher qualities are subscribed.
+// Lowering the cap takes effect immediately.
+func (d *DynacastManager) SetMaxPublishedQuality(quality livekit.VideoQuality) {
+	d.lock.Lock()
+	if d.maxPublishedQuality == quality {
+		d.lock.Unlock()
+		return
+	}
+	d.params.Logger.Infow("setting max published quality", "old", d.maxPublishedQuality, "new", quality)
+	d.maxPublishedQuality = quality
+	d.lock.Unlock()
+
+	d.update(true)
+}
+
 func (d *DynacastManager) NotifySubscriberMaxQuality(subscriberID livekit.ParticipantID, mime string, quality livekit.VideoQuality) {
 	dq := d.getOrCreateDynacastQuality(mime)
 	if dq != nil {
@@ -185,11 +204,13 @@ func (d *DynacastManager) update(force bool) {
 		return
 	}
 
+	maxSubscribedQuality := d.getCappedMaxSubscribedQualityLocked()
+
 	// add or remove of a mime triggers an update
-	changed := len(d.maxSubscribedQuality) != len(d.committedMaxSubscribedQuality)
+	changed := len(maxSubscribedQuality) != len(d.committedMaxSubscribedQuality)
 	downgradesOnly := !changed
 	if !changed {
-		for mime, quality := range d.maxSubscribedQuality {
+		for mime, quality := range maxSubscribedQuality {
 			if cq, ok := d.committedMaxSubscribedQuality[mime]; ok {
 				if cq != quality {
 					changed = true
@@ -231,15 +252,23 @@ func (d *DynacastManager) update(force bool) {
 	)
 
 	// commit change
-	d.committedMaxSubscribedQuality = make(map[string]livekit.VideoQuality, len(d.maxSubscribedQuality))
-	for mime, quality := range d.maxSubscribedQuality {
-		d.committedMaxSubscribedQuality[mime] = quality
-	}
+	d.committedMaxSubscribedQuality = maxSubscribedQuality
 
 	d.enqueueSubscribedQualityChange()
 	d.lock.Unlock()
 }
 
+func (d *DynacastManager) getCappedMaxSubscribedQualityLocked() map[string]livekit.VideoQuality {
+	maxSubscribedQuality := make(map[string]livekit.VideoQuality, len(d.maxSubscribedQuality))
+	for mime, quality := range d.maxSubscribedQuality {
+		if quality != livekit.VideoQuality_OFF && quality > d.maxPublishedQuality {
+			quality = d.maxPublishedQuality
+		}
+		maxSubscribedQuality[mime] = quality
+	}
+	return maxSubscribedQuality
+}
+
 func (d *DynacastManager) enqueueSubscribedQualityChange() {
 	if d.isClosed || d.onSubscribedMaxQualityChange == nil {
 		return
@@ -20,4 +20,30 @@ var (
 	ErrTrackNotAttached          = errors.New("track is not yet attached")
 	ErrTrackNotBound             = errors.New("track not bound")
 	ErrSubscriptionLimitExceeded = errors.New("participant has exceeded its subscription limit")
+
+	// Lobby related
+	ErrParticipantNotInLobby = errors.New("participant is not waiting in the lobby")
+	ErrInvalidLobbyAction    = errors.New("invalid lobby action")
+
+	// Move related
+	ErrParticipantNotInRoom = errors.New("participant is not in the room")
+
+	// RTP forward related
+	ErrRTPForwarderNotFound = errors.New("rtp forwarder cannot be found")
+	ErrUnsupportedSRTPSuite = errors.New("unsupported srtp crypto suite")
+	ErrInvalidSRTPKey       = errors.New("srtp key must be base64 encoded master key and salt")
+
+	// RTP ingest related
+	ErrInvalidSDP       = errors.New("invalid session description")
+	ErrNoSupportedMedia = errors.New("session description has no supported media, accepted codecs are opus, VP8 and H.264")
+	ErrNoPortAvailable  = errors.New("no port available in the configured range")
+
+	// In-process participant related
+	ErrParticipantNotReady        = errors.New("participant has not joined a room")
+	ErrNoPublishPermission        = errors.New("participant is not allowed to publish")
+	ErrUnsupportedPayloadType     = errors.New("no payloader for codec")
+	ErrTrackCodecAlreadyPublished = errors.New("codec is already published on track")
+	ErrBufferUnavailable          = errors.New("could not create buffer")
+	ErrTrackClosed                = errors.New("track is closed")
+	ErrNotSupportedInProcess      = errors.New("not supported by in-process participants")
 )
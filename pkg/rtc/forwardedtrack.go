@@ -0,0 +1,155 @@
+package rtc
+
+import (
+	"github.com/pion/webrtc/v3"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+type ForwardedTrackParams struct {
+	Origin              types.MediaTrack
+	ParticipantID       livekit.ParticipantID
+	ParticipantIdentity livekit.ParticipantIdentity
+	ParticipantVersion  uint32
+	ReceiverConfig      ReceiverConfig
+	SubscriberConfig    DirectionConfig
+	AudioConfig         config.AudioConfig
+	Telemetry           telemetry.TelemetryService
+	Logger              logger.Logger
+}
+
+// ForwardedTrack mirrors a track published in another room. It shares the receivers of the origin track,
+// so down tracks of its subscribers are fed by the origin's receivers without the media being published again.
+type ForwardedTrack struct {
+	params ForwardedTrackParams
+	*MediaTrackReceiver
+}
+
+var _ types.LocalMediaTrack = (*ForwardedTrack)(nil)
+
+func NewForwardedTrack(params ForwardedTrackParams) *ForwardedTrack {
+	ti := proto.Clone(params.Origin.ToProto()).(*livekit.TrackInfo)
+	ti.Sid = utils.NewGuid(utils.TrackPrefix)
+
+	t := &ForwardedTrack{
+		params: params,
+	}
+	t.MediaTrackReceiver = NewMediaTrackReceiver(MediaTrackReceiverParams{
+		TrackInfo:           ti,
+		MediaTrack:          t,
+		IsRelayed:           true,
+		ParticipantID:       params.ParticipantID,
+		ParticipantIdentity: params.ParticipantIdentity,
+		ParticipantVersion:  params.ParticipantVersion,
+		ReceiverConfig:      params.ReceiverConfig,
+		SubscriberConfig:    params.SubscriberConfig,
+		AudioConfig:         params.AudioConfig,
+		Telemetry:           params.Telemetry,
+		Logger:              params.Logger,
+	})
+	t.SetSimulcast(params.Origin.IsSimulcast())
+	// down tracks of subscribers are fed by the origin's receivers, the origin's dynacast is told of their layers
+	if params.Origin.Kind() == livekit.TrackType_VIDEO {
+		t.MediaTrackReceiver.OnSubscriberMaxQualityChange(
+			func(subscriberID livekit.ParticipantID, codec webrtc.RTPCodecCapability, layer int32) {
+				t.NotifySubscriberMaxQuality(subscriberID, codec.MimeType, buffer.SpatialLayerToVideoQuality(layer, params.Origin.ToProto()))
+			},
+		)
+	}
+	for i, receiver := range params.Origin.Receivers() {
+		t.MediaTrackReceiver.SetupReceiver(receiver, i, "")
+	}
+	return t
+}
+
+// Origin returns the track being forwarded
+func (t *ForwardedTrack) Origin() types.MediaTrack {
+	return t.params.Origin
+}
+
+func (t *ForwardedTrack) ToProto() *livekit.TrackInfo {
+	info := t.MediaTrackReceiver.TrackInfo(true)
+	info.Muted = t.IsMuted()
+	info.Simulcast = t.IsSimulcast()
+	return info
+}
+
+// SyncMuted mirrors the muted state of the origin track, returns true when it changed
+func (t *ForwardedTrack) SyncMuted() bool {
+	muted := t.params.Origin.IsMuted()
+	if muted == t.IsMuted() {
+		return false
+	}
+	t.SetMuted(muted)
+	return true
+}
+
+// Close detaches subscribers from the origin's receivers, the origin track is not affected
+func (t *ForwardedTrack) Close(willBeResumed bool) {
+	t.MediaTrackReceiver.SetClosing()
+	t.MediaTrackReceiver.ClearAllReceivers(willBeResumed)
+	t.MediaTrackReceiver.Close()
+}
+
+func (t *ForwardedTrack) SignalCid() string {
+	return string(t.ID())
+}
+
+func (t *ForwardedTrack) HasSdpCid(_ string) bool {
+	return false
+}
+
+func (t *ForwardedTrack) GetConnectionScoreAndQuality() (float32, livekit.ConnectionQuality) {
+	receiver := t.PrimaryReceiver()
+	if rtcReceiver, ok := receiver.(*sfu.WebRTCReceiver); ok {
+		return rtcReceiver.GetConnectionScoreAndQuality()
+	}
+
+	return connectionquality.MaxMOS, livekit.ConnectionQuality_EXCELLENT
+}
+
+func (t *ForwardedTrack) GetConnectionScoreBreakdown() connectionquality.ScoreBreakdown {
+	receiver := t.PrimaryReceiver()
+	if rtcReceiver, ok := receiver.(*sfu.WebRTCReceiver); ok {
+		return rtcReceiver.GetConnectionScoreBreakdown()
+	}
+
+	return connectionquality.ScoreBreakdown{}
+}
+
+func (t *ForwardedTrack) GetTrackStats() *livekit.RTPStats {
+	receiver := t.PrimaryReceiver()
+	if rtcReceiver, ok := receiver.(*sfu.WebRTCReceiver); ok {
+		return rtcReceiver.GetTrackStats()
+	}
+
+	return nil
+}
+
+func (t *ForwardedTrack) NotifySubscriberMaxQuality(subscriberID livekit.ParticipantID, mime string, quality livekit.VideoQuality) {
+	if n, ok := t.params.Origin.(subscriberMaxQualityNotifier); ok {
+		n.NotifySubscriberMaxQuality(subscriberID, mime, quality)
+	}
+}
+
+func (t *ForwardedTrack) NotifySubscriberNodeMaxQuality(nodeID livekit.NodeID, qualities []types.SubscribedCodecQuality) {
+	if lt, ok := t.params.Origin.(types.LocalMediaTrack); ok {
+		lt.NotifySubscriberNodeMaxQuality(nodeID, qualities)
+	}
+}
+
+func (t *ForwardedTrack) NotifySubscriberNodeMediaLoss(nodeID livekit.NodeID, fractionalLoss uint8) {
+	if lt, ok := t.params.Origin.(types.LocalMediaTrack); ok {
+		lt.NotifySubscriberNodeMediaLoss(nodeID, fractionalLoss)
+	}
+}
@@ -0,0 +1,1542 @@
+package rtc
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frostbyte73/core"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
+	"github.com/livekit/livekit-server/pkg/sfu/impairment"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+const (
+	inProcessMTU = 1200
+)
+
+type InProcessParticipantParams struct {
+	Identity livekit.ParticipantIdentity
+	Name     livekit.ParticipantName
+	// optional, generated when empty
+	SID               livekit.ParticipantID
+	Grants            *auth.ClaimGrants
+	AutoSubscribe     bool
+	VideoConfig       config.VideoConfig
+	PLIThrottleConfig config.PLIThrottleConfig
+	Telemetry         telemetry.TelemetryService
+	Logger            logger.Logger
+	VersionGenerator  utils.TimedVersionGenerator
+	// optional, receiver and subscriber settings of forwarded tracks
+	Config *WebRTCConfig
+
+	// OnTrackSubscribed is called when a track of another participant is subscribed.
+	// The receiver can be used to request key frames or to inspect the published layers.
+	OnTrackSubscribed func(p *InProcessParticipant, track types.MediaTrack, receiver sfu.TrackReceiver)
+	// OnTrackPacket is called for every packet of every layer received on a subscribed track.
+	// The packet is only valid for the duration of the call.
+	OnTrackPacket       func(p *InProcessParticipant, trackID livekit.TrackID, pkt *buffer.ExtPacket, layer int32)
+	OnTrackUnsubscribed func(p *InProcessParticipant, trackID livekit.TrackID)
+	OnDataPacket        func(p *InProcessParticipant, dp *livekit.DataPacket)
+}
+
+// InProcessParticipant is a LocalParticipant that lives inside the server process, without a PeerConnection.
+// Media is published by writing RTP packets or samples and subscribed media is delivered through callbacks,
+// to other participants it looks like any other participant in the room.
+type InProcessParticipant struct {
+	params InProcessParticipantParams
+
+	isClosed    atomic.Bool
+	state       atomic.Value // livekit.ParticipantInfo_State
+	isPublisher atomic.Bool
+	connectedAt time.Time
+
+	room          *Room
+	bufferFactory *buffer.Factory
+	rtcpCh        chan []rtcp.Packet
+	rtcpDone      core.Fuse
+
+	*UpTrackManager
+
+	lock             sync.RWMutex
+	once             sync.Once
+	grants           *auth.ClaimGrants
+	publishedTracks  map[livekit.TrackID]*InProcessTrack
+	subscriptions    map[livekit.TrackID]*inProcessSubscription
+	pendingTrackIDs  map[livekit.TrackID]bool
+	requireBroadcast bool
+	migrateState     atomic.Value // types.MigrateState
+
+	dirty        atomic.Bool
+	version      atomic.Uint32
+	timedVersion utils.TimedVersion
+
+	onTrackPublished         func(types.LocalParticipant, types.MediaTrack)
+	onTrackUpdated           func(types.LocalParticipant, types.MediaTrack)
+	onTrackUnpublished       func(types.LocalParticipant, types.MediaTrack)
+	onStateChange            func(p types.LocalParticipant, oldState livekit.ParticipantInfo_State)
+	onParticipantUpdate      func(types.LocalParticipant)
+	onDataPacket             func(types.LocalParticipant, *livekit.DataPacket)
+	onSubscribeStatusChanged func(publisherID livekit.ParticipantID, subscribed bool)
+	onClose                  func(types.LocalParticipant)
+	onClaimsChanged          func(types.LocalParticipant)
+}
+
+var _ types.LocalParticipant = (*InProcessParticipant)(nil)
+
+func NewInProcessParticipant(params InProcessParticipantParams) (*InProcessParticipant, error) {
+	if params.Identity == "" {
+		return nil, ErrEmptyIdentity
+	}
+	if params.Grants == nil || params.Grants.Video == nil {
+		return nil, ErrMissingGrants
+	}
+	if params.SID == "" {
+		params.SID = livekit.ParticipantID(utils.NewGuid(utils.ParticipantPrefix))
+	}
+	if params.Logger == nil {
+		params.Logger = logger.GetLogger()
+	}
+	params.Logger = LoggerWithParticipant(params.Logger, params.Identity, params.SID, false)
+	if params.VersionGenerator == nil {
+		params.VersionGenerator = utils.NewDefaultTimedVersionGenerator()
+	}
+
+	grants := params.Grants.Clone()
+	if params.Name != "" {
+		grants.Name = string(params.Name)
+	}
+
+	p := &InProcessParticipant{
+		params:          params,
+		connectedAt:     time.Now(),
+		rtcpCh:          make(chan []rtcp.Packet, 100),
+		rtcpDone:        core.NewFuse(),
+		grants:          grants,
+		publishedTracks: make(map[livekit.TrackID]*InProcessTrack),
+		subscriptions:   make(map[livekit.TrackID]*inProcessSubscription),
+		pendingTrackIDs: make(map[livekit.TrackID]bool),
+	}
+	p.timedVersion.Update(params.VersionGenerator.New())
+	p.migrateState.Store(types.MigrateStateComplete)
+	p.state.Store(livekit.ParticipantInfo_JOINING)
+
+	p.UpTrackManager = NewUpTrackManager(UpTrackManagerParams{
+		SID:              params.SID,
+		Logger:           params.Logger,
+		VersionGenerator: params.VersionGenerator,
+	})
+	p.UpTrackManager.OnPublishedTrackUpdated(func(track types.MediaTrack) {
+		p.dirty.Store(true)
+		if onTrackUpdated := p.getOnTrackUpdated(); onTrackUpdated != nil {
+			onTrackUpdated(p, track)
+		}
+	})
+	p.UpTrackManager.OnUpTrackManagerClose(func() {
+		p.rtcpDone.Break()
+	})
+
+	go p.rtcpWorker()
+
+	return p, nil
+}
+
+// Join adds the participant to the room and makes it active
+func (p *InProcessParticipant) Join(room *Room) error {
+	p.lock.Lock()
+	p.room = room
+	p.bufferFactory = room.GetBufferFactory()
+	p.lock.Unlock()
+
+	if err := room.Join(p, nil, &ParticipantOptions{AutoSubscribe: p.params.AutoSubscribe}, nil); err != nil {
+		return err
+	}
+
+	if p.params.Telemetry != nil {
+		p.params.Telemetry.ParticipantJoined(context.Background(), room.ToProto(), p.ToProto(), nil, nil, true)
+	}
+
+	p.updateState(livekit.ParticipantInfo_ACTIVE)
+	return nil
+}
+
+// MoveTo moves the participant from the room it joined to room, keeping its published tracks
+func (p *InProcessParticipant) MoveTo(room *Room) error {
+	p.lock.RLock()
+	current := p.room
+	p.lock.RUnlock()
+	if current == nil {
+		return ErrParticipantNotReady
+	}
+
+	requestSource, opts, err := current.DetachParticipant(p.Identity())
+	if err != nil {
+		return err
+	}
+	if p.params.Telemetry != nil {
+		p.params.Telemetry.ParticipantLeft(context.Background(), current.ToProto(), p.ToProto(), true, nil)
+	}
+
+	p.MoveToRoom(room.Name())
+	p.lock.Lock()
+	p.room = room
+	p.lock.Unlock()
+
+	if err = room.AttachParticipant(p, requestSource, opts); err != nil {
+		_ = p.Close(false, types.ParticipantCloseReasonJoinFailed)
+		return err
+	}
+	if p.params.Telemetry != nil {
+		p.params.Telemetry.ParticipantJoined(context.Background(), room.ToProto(), p.ToProto(), nil, nil, true)
+	}
+	return nil
+}
+
+func (p *InProcessParticipant) getRoom() *Room {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.room
+}
+
+// Leave removes the participant from the room it joined
+func (p *InProcessParticipant) Leave() {
+	p.lock.RLock()
+	room := p.room
+	p.lock.RUnlock()
+
+	if room != nil {
+		room.RemoveParticipant(p.Identity(), p.ID(), types.ParticipantCloseReasonClientRequestLeave)
+	} else {
+		_ = p.Close(false, types.ParticipantCloseReasonClientRequestLeave)
+	}
+}
+
+func (p *InProcessParticipant) GetLogger() logger.Logger {
+	return p.params.Logger
+}
+
+func (p *InProcessParticipant) GetAdaptiveStream() bool {
+	return false
+}
+
+func (p *InProcessParticipant) GetAllowTimestampAdjustment() bool {
+	return false
+}
+
+func (p *InProcessParticipant) ID() livekit.ParticipantID {
+	return p.params.SID
+}
+
+func (p *InProcessParticipant) Identity() livekit.ParticipantIdentity {
+	return p.params.Identity
+}
+
+func (p *InProcessParticipant) State() livekit.ParticipantInfo_State {
+	return p.state.Load().(livekit.ParticipantInfo_State)
+}
+
+func (p *InProcessParticipant) ProtocolVersion() types.ProtocolVersion {
+	return types.CurrentProtocol
+}
+
+func (p *InProcessParticipant) ConnectedAt() time.Time {
+	return p.connectedAt
+}
+
+func (p *InProcessParticipant) IsClosed() bool {
+	return p.isClosed.Load()
+}
+
+func (p *InProcessParticipant) IsReady() bool {
+	state := p.State()
+	return state == livekit.ParticipantInfo_JOINED || state == livekit.ParticipantInfo_ACTIVE
+}
+
+func (p *InProcessParticipant) IsDisconnected() bool {
+	return p.State() == livekit.ParticipantInfo_DISCONNECTED
+}
+
+func (p *InProcessParticipant) IsIdle() bool {
+	return false
+}
+
+func (p *InProcessParticipant) SubscriberAsPrimary() bool {
+	return false
+}
+
+func (p *InProcessParticipant) GetClientConfiguration() *livekit.ClientConfiguration {
+	return nil
+}
+
+func (p *InProcessParticipant) GetICEConnectionType() types.ICEConnectionType {
+	return types.ICEConnectionTypeUnknown
+}
+
+func (p *InProcessParticipant) GetBufferFactory() *buffer.Factory {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.bufferFactory
+}
+
+func (p *InProcessParticipant) SetName(name string) {
+	p.lock.Lock()
+	if p.grants.Name == name {
+		p.lock.Unlock()
+		return
+	}
+
+	p.grants.Name = name
+	p.dirty.Store(true)
+
+	onParticipantUpdate := p.onParticipantUpdate
+	onClaimsChanged := p.onClaimsChanged
+	p.lock.Unlock()
+
+	if onParticipantUpdate != nil {
+		onParticipantUpdate(p)
+	}
+	if onClaimsChanged != nil {
+		onClaimsChanged(p)
+	}
+}
+
+func (p *InProcessParticipant) SetMetadata(metadata string) {
+	p.lock.Lock()
+	if p.grants.Metadata == metadata {
+		p.lock.Unlock()
+		return
+	}
+
+	p.grants.Metadata = metadata
+	p.requireBroadcast = p.requireBroadcast || metadata != ""
+	p.dirty.Store(true)
+
+	onParticipantUpdate := p.onParticipantUpdate
+	onClaimsChanged := p.onClaimsChanged
+	p.lock.Unlock()
+
+	if onParticipantUpdate != nil {
+		onParticipantUpdate(p)
+	}
+	if onClaimsChanged != nil {
+		onClaimsChanged(p)
+	}
+}
+
+func (p *InProcessParticipant) ClaimGrants() *auth.ClaimGrants {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.grants.Clone()
+}
+
+func (p *InProcessParticipant) SetPermission(permission *livekit.ParticipantPermission) bool {
+	if permission == nil {
+		return false
+	}
+	p.lock.Lock()
+	video := p.grants.Video
+	if video.MatchesPermission(permission) {
+		p.lock.Unlock()
+		return false
+	}
+
+	video.UpdateFromPermission(permission)
+	p.dirty.Store(true)
+	canSubscribe := video.GetCanSubscribe()
+	onParticipantUpdate := p.onParticipantUpdate
+	onClaimsChanged := p.onClaimsChanged
+	p.lock.Unlock()
+
+	// publish permission has been revoked then remove offending tracks
+	for _, track := range p.GetPublishedTracks() {
+		if !video.GetCanPublishSource(track.Source()) {
+			p.RemovePublishedTrack(track, false, true)
+		}
+	}
+
+	if !canSubscribe {
+		for _, trackID := range p.getSubscribedTrackIDs() {
+			p.UnsubscribeFromTrack(trackID)
+		}
+	}
+
+	if onParticipantUpdate != nil {
+		onParticipantUpdate(p)
+	}
+	if onClaimsChanged != nil {
+		onClaimsChanged(p)
+	}
+	return true
+}
+
+// MoveToRoom rebinds the participant's grants to roomName and drops subscriptions to tracks of the room it's leaving
+func (p *InProcessParticipant) MoveToRoom(roomName livekit.RoomName) {
+	p.lock.Lock()
+	p.grants.Video.Room = string(roomName)
+	p.dirty.Store(true)
+	onClaimsChanged := p.onClaimsChanged
+	p.lock.Unlock()
+
+	for _, trackID := range p.getSubscribedTrackIDs() {
+		p.UnsubscribeFromTrack(trackID)
+	}
+
+	if onClaimsChanged != nil {
+		onClaimsChanged(p)
+	}
+}
+
+func (p *InProcessParticipant) CanSkipBroadcast() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return !p.requireBroadcast
+}
+
+func (p *InProcessParticipant) ToProtoWithVersion() (*livekit.ParticipantInfo, utils.TimedVersion) {
+	v := p.version.Load()
+	piv := p.timedVersion.Load()
+	if p.dirty.Swap(false) {
+		v = p.version.Inc()
+		piv = p.params.VersionGenerator.Next()
+		p.timedVersion.Update(&piv)
+	}
+
+	p.lock.RLock()
+	pi := &livekit.ParticipantInfo{
+		Sid:         string(p.params.SID),
+		Identity:    string(p.params.Identity),
+		Name:        p.grants.Name,
+		State:       p.State(),
+		JoinedAt:    p.ConnectedAt().Unix(),
+		Version:     v,
+		Permission:  p.grants.Video.ToPermission(),
+		Metadata:    p.grants.Metadata,
+		IsPublisher: p.IsPublisher(),
+	}
+	p.lock.RUnlock()
+	pi.Tracks = p.UpTrackManager.ToProto()
+
+	return pi, piv
+}
+
+func (p *InProcessParticipant) ToProto() *livekit.ParticipantInfo {
+	pi, _ := p.ToProtoWithVersion()
+	return pi
+}
+
+func (p *InProcessParticipant) IsPublisher() bool {
+	return p.isPublisher.Load()
+}
+
+func (p *InProcessParticipant) CanPublishSource(source livekit.TrackSource) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.grants.Video.GetCanPublishSource(source)
+}
+
+func (p *InProcessParticipant) CanSubscribe() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.grants.Video.GetCanSubscribe()
+}
+
+func (p *InProcessParticipant) CanPublishData() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.grants.Video.GetCanPublishData()
+}
+
+func (p *InProcessParticipant) Hidden() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.grants.Video.Hidden
+}
+
+func (p *InProcessParticipant) IsRecorder() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.grants.Video.Recorder
+}
+
+func (p *InProcessParticipant) Start() {
+	p.once.Do(func() {
+		p.UpTrackManager.Start()
+	})
+}
+
+func (p *InProcessParticipant) Close(sendLeave bool, reason types.ParticipantCloseReason) error {
+	if p.isClosed.Swap(true) {
+		return nil
+	}
+
+	p.params.Logger.Infow("in-process participant closing", "reason", reason.String())
+
+	for _, trackID := range p.getSubscribedTrackIDs() {
+		p.UnsubscribeFromTrack(trackID)
+	}
+
+	p.lock.Lock()
+	room := p.room
+	tracks := make([]*InProcessTrack, 0, len(p.publishedTracks))
+	for _, t := range p.publishedTracks {
+		tracks = append(tracks, t)
+	}
+	p.publishedTracks = make(map[livekit.TrackID]*InProcessTrack)
+	p.pendingTrackIDs = make(map[livekit.TrackID]bool)
+	p.lock.Unlock()
+
+	// forwarded tracks hold on to their origin, let go of it
+	for _, t := range p.GetPublishedTracks() {
+		if ft, ok := t.(*ForwardedTrack); ok {
+			ft.Close(false)
+		}
+	}
+	p.UpTrackManager.Close(!sendLeave)
+	for _, t := range tracks {
+		t.closeBuffer()
+	}
+
+	p.updateState(livekit.ParticipantInfo_DISCONNECTED)
+
+	p.lock.RLock()
+	onClose := p.onClose
+	p.lock.RUnlock()
+	if onClose != nil {
+		onClose(p)
+	}
+
+	if p.params.Telemetry != nil && room != nil {
+		p.params.Telemetry.ParticipantLeft(context.Background(), room.ToProto(), p.ToProto(), true, nil)
+	}
+	return nil
+}
+
+func (p *InProcessParticipant) RemovePublishedTrack(track types.MediaTrack, willBeResumed bool, shouldClose bool) {
+	p.lock.Lock()
+	t := p.publishedTracks[track.ID()]
+	delete(p.publishedTracks, track.ID())
+	p.lock.Unlock()
+
+	p.UpTrackManager.RemovePublishedTrack(track, willBeResumed, shouldClose)
+	if t != nil {
+		t.closeBuffer()
+	}
+}
+
+func (p *InProcessParticipant) UpdateSubscriptionPermission(
+	subscriptionPermission *livekit.SubscriptionPermission,
+	timedVersion utils.TimedVersion,
+	resolverByIdentity func(participantIdentity livekit.ParticipantIdentity) types.LocalParticipant,
+	resolverBySid func(participantID livekit.ParticipantID) types.LocalParticipant,
+) error {
+	return p.UpTrackManager.UpdateSubscriptionPermission(subscriptionPermission, timedVersion, resolverByIdentity, resolverBySid)
+}
+
+func (p *InProcessParticipant) DebugInfo() map[string]interface{} {
+	info := map[string]interface{}{
+		"ID":        p.params.SID,
+		"State":     p.State().String(),
+		"InProcess": true,
+	}
+	info["UpTrackManager"] = p.UpTrackManager.DebugInfo()
+	return info
+}
+
+// -------------------------------------------------------
+// publishing
+
+type InProcessTrackParams struct {
+	Name   string
+	Source livekit.TrackSource
+	Codec  webrtc.RTPCodecParameters
+	// video dimensions, advertised to subscribers
+	Width  uint32
+	Height uint32
+	// audio
+	DisableDTX bool
+	Stereo     bool
+	// optional, used to map samples into RTP, defaults to a payloader matching the codec
+	Payloader rtp.Payloader
+}
+
+// InProcessTrack is a track published by an InProcessParticipant
+type InProcessTrack struct {
+	*MediaTrack
+
+	ssrc        uint32
+	payloadType uint8
+	clockRate   uint32
+	buff        *buffer.Buffer
+	packetizer  rtp.Packetizer
+
+	lock              sync.Mutex
+	closed            bool
+	onKeyFrameRequest func()
+}
+
+// PublishTrack publishes a track whose media is written through the returned InProcessTrack
+func (p *InProcessParticipant) PublishTrack(params InProcessTrackParams) (*InProcessTrack, error) {
+	if p.IsClosed() || !p.IsReady() {
+		return nil, ErrParticipantNotReady
+	}
+	if !p.CanPublishSource(params.Source) {
+		return nil, ErrNoPublishPermission
+	}
+
+	mime := strings.ToLower(params.Codec.MimeType)
+	trackType := livekit.TrackType_AUDIO
+	if strings.HasPrefix(mime, "video/") {
+		trackType = livekit.TrackType_VIDEO
+	}
+
+	payloader := params.Payloader
+	if payloader == nil {
+		payloader = payloaderForCodec(mime)
+		if payloader == nil {
+			return nil, ErrUnsupportedPayloadType
+		}
+	}
+
+	ti := &livekit.TrackInfo{
+		Sid:        utils.NewGuid(utils.TrackPrefix),
+		Type:       trackType,
+		Name:       params.Name,
+		Source:     params.Source,
+		MimeType:   params.Codec.MimeType,
+		Width:      params.Width,
+		Height:     params.Height,
+		DisableDtx: params.DisableDTX,
+		Stereo:     params.Stereo,
+	}
+	if trackType == livekit.TrackType_VIDEO {
+		ti.Layers = []*livekit.VideoLayer{
+			{Quality: livekit.VideoQuality_HIGH, Width: params.Width, Height: params.Height},
+		}
+	}
+	trackID := livekit.TrackID(ti.Sid)
+
+	if p.params.Telemetry != nil {
+		p.params.Telemetry.TrackPublishRequested(context.Background(), p.ID(), p.Identity(), ti)
+	}
+
+	mt := NewMediaTrack(MediaTrackParams{
+		TrackInfo:           ti,
+		SignalCid:           ti.Sid,
+		SdpCid:              ti.Sid,
+		ParticipantID:       p.params.SID,
+		ParticipantIdentity: p.params.Identity,
+		ParticipantVersion:  p.version.Load(),
+		RTCPChan:            p.rtcpCh,
+		BufferFactory:       p.GetBufferFactory(),
+		AudioConfig:         p.getRoomAudioConfig(),
+		VideoConfig:         p.params.VideoConfig,
+		Telemetry:           p.params.Telemetry,
+		Logger:              LoggerWithTrack(p.params.Logger, trackID, false),
+		PLIThrottleConfig:   p.params.PLIThrottleConfig,
+	})
+
+	ssrc := rand.Uint32()
+	buff, err := mt.AddInProcessReceiver(params.Codec, nil, ssrc)
+	if err != nil {
+		mt.Close(false)
+		return nil, err
+	}
+
+	t := &InProcessTrack{
+		MediaTrack:  mt,
+		ssrc:        ssrc,
+		payloadType: uint8(params.Codec.PayloadType),
+		clockRate:   params.Codec.ClockRate,
+		buff:        buff,
+		packetizer: rtp.NewPacketizer(
+			inProcessMTU,
+			uint8(params.Codec.PayloadType),
+			ssrc,
+			payloader,
+			rtp.NewRandomSequencer(),
+			params.Codec.ClockRate,
+		),
+	}
+
+	mt.AddOnClose(func() {
+		p.lock.Lock()
+		delete(p.publishedTracks, trackID)
+		p.lock.Unlock()
+
+		t.closeBuffer()
+
+		if p.params.Telemetry != nil {
+			p.params.Telemetry.TrackUnpublished(context.Background(), p.ID(), p.Identity(), mt.ToProto(), !p.IsClosed())
+		}
+
+		p.dirty.Store(true)
+		if !p.IsClosed() {
+			p.params.Logger.Infow("unpublished track", "trackID", trackID)
+			p.lock.RLock()
+			onTrackUnpublished := p.onTrackUnpublished
+			p.lock.RUnlock()
+			if onTrackUnpublished != nil {
+				onTrackUnpublished(p, mt)
+			}
+		}
+	})
+
+	p.lock.Lock()
+	p.publishedTracks[trackID] = t
+	p.lock.Unlock()
+	p.UpTrackManager.AddPublishedTrack(mt)
+
+	if !p.isPublisher.Swap(true) {
+		p.lock.Lock()
+		p.requireBroadcast = true
+		p.lock.Unlock()
+	}
+	p.dirty.Store(true)
+
+	p.params.Logger.Infow("in-process track published", "trackID", trackID, "mime", params.Codec.MimeType)
+
+	p.lock.RLock()
+	onTrackPublished := p.onTrackPublished
+	p.lock.RUnlock()
+	if onTrackPublished != nil {
+		onTrackPublished(p, mt)
+	}
+
+	if p.params.Telemetry != nil {
+		p.params.Telemetry.TrackPublished(context.Background(), p.ID(), p.Identity(), mt.ToProto())
+	}
+
+	return t, nil
+}
+
+// UnpublishTrack removes a published track from the room
+func (p *InProcessParticipant) UnpublishTrack(trackID livekit.TrackID) {
+	if track := p.GetPublishedTrack(trackID); track != nil {
+		p.RemovePublishedTrack(track, false, true)
+	}
+}
+
+// ForwardTrack publishes a track of another room hosted on this node. Subscribers receive media from the
+// receivers of the origin track, which is not published again. The forwarded track is closed when the origin
+// track is unpublished.
+func (p *InProcessParticipant) ForwardTrack(origin *Room, trackID livekit.TrackID) (*ForwardedTrack, error) {
+	if p.IsClosed() || !p.IsReady() {
+		return nil, ErrParticipantNotReady
+	}
+
+	res := origin.ResolveMediaTrackForSubscriber(p.Identity(), trackID)
+	if res.Track == nil {
+		return nil, ErrTrackNotFound
+	}
+	if !res.HasPermission {
+		return nil, ErrNoTrackPermission
+	}
+	if !p.CanPublishSource(res.Track.Source()) {
+		return nil, ErrNoPublishPermission
+	}
+
+	var receiverConfig ReceiverConfig
+	var subscriberConfig DirectionConfig
+	if p.params.Config != nil {
+		receiverConfig = p.params.Config.Receiver
+		subscriberConfig = p.params.Config.Subscriber
+	}
+	ft := NewForwardedTrack(ForwardedTrackParams{
+		Origin:              res.Track,
+		ParticipantID:       p.params.SID,
+		ParticipantIdentity: p.params.Identity,
+		ParticipantVersion:  p.version.Load(),
+		ReceiverConfig:      receiverConfig,
+		SubscriberConfig:    subscriberConfig,
+		AudioConfig:         p.getRoomAudioConfig(),
+		Telemetry:           p.params.Telemetry,
+		Logger:              LoggerWithTrack(p.params.Logger, trackID, true),
+	})
+	forwardedID := ft.ID()
+
+	if res.TrackRemovedNotifier != nil {
+		res.TrackRemovedNotifier.AddObserver(string(forwardedID), func() {
+			go p.UnpublishTrack(forwardedID)
+		})
+	}
+	if res.TrackChangedNotifier != nil {
+		res.TrackChangedNotifier.AddObserver(string(forwardedID), func() {
+			if ft.SyncMuted() {
+				p.dirty.Store(true)
+				if onTrackUpdated := p.getOnTrackUpdated(); onTrackUpdated != nil {
+					onTrackUpdated(p, ft)
+				}
+			}
+		})
+	}
+	ft.SyncMuted()
+
+	ft.AddOnClose(func() {
+		if res.TrackRemovedNotifier != nil {
+			res.TrackRemovedNotifier.RemoveObserver(string(forwardedID))
+		}
+		if res.TrackChangedNotifier != nil {
+			res.TrackChangedNotifier.RemoveObserver(string(forwardedID))
+		}
+		if p.params.Telemetry != nil {
+			p.params.Telemetry.TrackUnpublished(context.Background(), p.ID(), p.Identity(), ft.ToProto(), !p.IsClosed())
+		}
+
+		p.dirty.Store(true)
+		if !p.IsClosed() {
+			p.params.Logger.Infow("stopped forwarding track", "trackID", forwardedID, "originTrackID", trackID)
+			p.lock.RLock()
+			onTrackUnpublished := p.onTrackUnpublished
+			p.lock.RUnlock()
+			if onTrackUnpublished != nil {
+				onTrackUnpublished(p, ft)
+			}
+		}
+	})
+
+	p.UpTrackManager.AddPublishedTrack(ft)
+	if !p.isPublisher.Swap(true) {
+		p.lock.Lock()
+		p.requireBroadcast = true
+		p.lock.Unlock()
+	}
+	p.dirty.Store(true)
+
+	p.params.Logger.Infow("forwarding track", "trackID", forwardedID, "originTrackID", trackID, "originRoom", origin.Name())
+
+	p.lock.RLock()
+	onTrackPublished := p.onTrackPublished
+	p.lock.RUnlock()
+	if onTrackPublished != nil {
+		onTrackPublished(p, ft)
+	}
+
+	if p.params.Telemetry != nil {
+		p.params.Telemetry.TrackPublished(context.Background(), p.ID(), p.Identity(), ft.ToProto())
+	}
+
+	return ft, nil
+}
+
+// WriteRTP writes a packet of the track, SSRC and payload type are replaced by those of the track
+func (t *InProcessTrack) WriteRTP(pkt *rtp.Packet) error {
+	t.lock.Lock()
+	closed := t.closed
+	t.lock.Unlock()
+	if closed {
+		return ErrTrackClosed
+	}
+
+	hdr := pkt.Header
+	hdr.SSRC = t.ssrc
+	hdr.PayloadType = t.payloadType
+	out := &rtp.Packet{Header: hdr, Payload: pkt.Payload}
+	data, err := out.Marshal()
+	if err != nil {
+		return err
+	}
+
+	_, err = t.buff.Write(data)
+	return err
+}
+
+// WriteSample packetizes a media sample and writes the resulting packets
+func (t *InProcessTrack) WriteSample(sample media.Sample) error {
+	t.lock.Lock()
+	if t.closed {
+		t.lock.Unlock()
+		return ErrTrackClosed
+	}
+	samples := uint32(sample.Duration.Seconds() * float64(t.clockRate))
+	if sample.PrevDroppedPackets > 0 {
+		t.packetizer.SkipSamples(samples * uint32(sample.PrevDroppedPackets))
+	}
+	pkts := t.packetizer.Packetize(sample.Data, samples)
+	t.lock.Unlock()
+
+	for _, pkt := range pkts {
+		data, err := pkt.Marshal()
+		if err != nil {
+			return err
+		}
+		if _, err = t.buff.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnKeyFrameRequest is called when subscribers need a key frame, video sources should send one as soon as possible
+func (t *InProcessTrack) OnKeyFrameRequest(f func()) {
+	t.lock.Lock()
+	t.onKeyFrameRequest = f
+	t.lock.Unlock()
+}
+
+func (t *InProcessTrack) handleKeyFrameRequest() {
+	t.lock.Lock()
+	onKeyFrameRequest := t.onKeyFrameRequest
+	t.lock.Unlock()
+
+	if onKeyFrameRequest != nil {
+		onKeyFrameRequest()
+	}
+}
+
+func (t *InProcessTrack) closeBuffer() {
+	t.lock.Lock()
+	if t.closed {
+		t.lock.Unlock()
+		return
+	}
+	t.closed = true
+	t.lock.Unlock()
+
+	// closing the buffer stops forwarding and closes the receiver
+	_ = t.buff.Close()
+}
+
+func (p *InProcessParticipant) getRoomAudioConfig() config.AudioConfig {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.room == nil || p.room.audioConfig == nil {
+		return config.AudioConfig{}
+	}
+	return *p.room.audioConfig
+}
+
+func (p *InProcessParticipant) rtcpWorker() {
+	done := p.rtcpDone.Watch()
+	for {
+		var pkts []rtcp.Packet
+		select {
+		case <-done:
+			return
+		case pkts = <-p.rtcpCh:
+		}
+
+		for _, pkt := range pkts {
+			var mediaSSRC uint32
+			switch pkt := pkt.(type) {
+			case *rtcp.PictureLossIndication:
+				mediaSSRC = pkt.MediaSSRC
+			case *rtcp.FullIntraRequest:
+				mediaSSRC = pkt.MediaSSRC
+			default:
+				continue
+			}
+
+			p.lock.RLock()
+			var track *InProcessTrack
+			for _, t := range p.publishedTracks {
+				if t.ssrc == mediaSSRC {
+					track = t
+					break
+				}
+			}
+			p.lock.RUnlock()
+
+			if track != nil {
+				track.handleKeyFrameRequest()
+			}
+		}
+	}
+}
+
+func payloaderForCodec(mime string) rtp.Payloader {
+	switch mime {
+	case strings.ToLower(webrtc.MimeTypeOpus):
+		return &codecs.OpusPayloader{}
+	case strings.ToLower(webrtc.MimeTypeVP8):
+		return &codecs.VP8Payloader{EnablePictureID: true}
+	case strings.ToLower(webrtc.MimeTypeVP9):
+		return &codecs.VP9Payloader{}
+	case strings.ToLower(webrtc.MimeTypeH264):
+		return &codecs.H264Payloader{}
+	case strings.ToLower(webrtc.MimeTypeAV1):
+		return &codecs.AV1Payloader{}
+	case strings.ToLower(webrtc.MimeTypePCMU), strings.ToLower(webrtc.MimeTypePCMA):
+		return &codecs.G711Payloader{}
+	case strings.ToLower(webrtc.MimeTypeG722):
+		return &codecs.G722Payloader{}
+	}
+	return nil
+}
+
+// -------------------------------------------------------
+// subscribing
+
+type inProcessSubscription struct {
+	track    types.MediaTrack
+	receiver sfu.TrackReceiver
+	sender   *inProcessTrackSender
+}
+
+func (p *InProcessParticipant) clearPendingTrack(trackID livekit.TrackID) {
+	p.lock.Lock()
+	delete(p.pendingTrackIDs, trackID)
+	p.lock.Unlock()
+}
+
+// SubscribeToTrack subscribes to a track of another participant, if the track is not published yet,
+// subscription happens once it is
+func (p *InProcessParticipant) SubscribeToTrack(trackID livekit.TrackID) {
+	if p.IsClosed() || !p.CanSubscribe() {
+		return
+	}
+
+	p.lock.Lock()
+	room := p.room
+	if room == nil || p.subscriptions[trackID] != nil {
+		p.lock.Unlock()
+		return
+	}
+	p.pendingTrackIDs[trackID] = true
+	p.lock.Unlock()
+
+	res := room.ResolveMediaTrackForSubscriber(p.Identity(), trackID)
+	if res.Track == nil {
+		if res.TrackChangedNotifier != nil {
+			res.TrackChangedNotifier.AddObserver(string(p.ID()), func() {
+				go p.SubscribeToTrack(trackID)
+			})
+		}
+		return
+	}
+	if res.TrackChangedNotifier != nil {
+		res.TrackChangedNotifier.RemoveObserver(string(p.ID()))
+	}
+	if !res.HasPermission {
+		p.params.Logger.Debugw("no permission to subscribe to track", "trackID", trackID)
+		p.clearPendingTrack(trackID)
+		return
+	}
+
+	receivers := res.Track.Receivers()
+	if len(receivers) == 0 {
+		p.params.Logger.Debugw("track has no receivers", "trackID", trackID)
+		p.clearPendingTrack(trackID)
+		return
+	}
+	receiver := receivers[0]
+
+	sender := &inProcessTrackSender{
+		trackID:      trackID,
+		subscriberID: p.ID(),
+		onPacket: func(pkt *buffer.ExtPacket, layer int32) {
+			if p.params.OnTrackPacket != nil {
+				p.params.OnTrackPacket(p, trackID, pkt, layer)
+			}
+		},
+		onClose: func() {
+			go p.UnsubscribeFromTrack(trackID)
+		},
+	}
+
+	p.lock.Lock()
+	if !p.pendingTrackIDs[trackID] || p.subscriptions[trackID] != nil {
+		// unsubscribed or subscribed while resolving
+		delete(p.pendingTrackIDs, trackID)
+		p.lock.Unlock()
+		return
+	}
+	delete(p.pendingTrackIDs, trackID)
+	firstFromPublisher := p.numSubscriptionsForPublisherLocked(res.PublisherID) == 0
+	p.subscriptions[trackID] = &inProcessSubscription{
+		track:    res.Track,
+		receiver: receiver,
+		sender:   sender,
+	}
+	onSubscribeStatusChanged := p.onSubscribeStatusChanged
+	p.lock.Unlock()
+
+	if err := receiver.AddDownTrack(sender); err != nil {
+		p.params.Logger.Warnw("could not subscribe to track", err, "trackID", trackID)
+		p.lock.Lock()
+		delete(p.subscriptions, trackID)
+		p.lock.Unlock()
+		return
+	}
+
+	if res.TrackRemovedNotifier != nil {
+		res.TrackRemovedNotifier.AddObserver(string(p.ID()), func() {
+			go p.UnsubscribeFromTrack(trackID)
+		})
+	}
+
+	// all layers are received, dynacast is told as there is no down track to report the subscribed layer
+	if n, ok := res.Track.(subscriberMaxQualityNotifier); ok && res.Track.Kind() == livekit.TrackType_VIDEO {
+		n.NotifySubscriberMaxQuality(p.ID(), receiver.Codec().MimeType, livekit.VideoQuality_HIGH)
+	}
+	receiver.SendPLI(0, true)
+
+	p.params.Logger.Debugw("subscribed to track", "trackID", trackID, "publisherID", res.PublisherID)
+	if p.params.OnTrackSubscribed != nil {
+		p.params.OnTrackSubscribed(p, res.Track, receiver)
+	}
+	if firstFromPublisher && onSubscribeStatusChanged != nil {
+		onSubscribeStatusChanged(res.PublisherID, true)
+	}
+}
+
+func (p *InProcessParticipant) UnsubscribeFromTrack(trackID livekit.TrackID) {
+	p.lock.Lock()
+	delete(p.pendingTrackIDs, trackID)
+	sub := p.subscriptions[trackID]
+	if sub == nil {
+		p.lock.Unlock()
+		return
+	}
+	delete(p.subscriptions, trackID)
+	publisherID := sub.track.PublisherID()
+	lastFromPublisher := p.numSubscriptionsForPublisherLocked(publisherID) == 0
+	onSubscribeStatusChanged := p.onSubscribeStatusChanged
+	p.lock.Unlock()
+
+	sub.sender.markClosed()
+	sub.receiver.DeleteDownTrack(p.ID())
+	if n, ok := sub.track.(subscriberMaxQualityNotifier); ok && sub.track.Kind() == livekit.TrackType_VIDEO {
+		n.NotifySubscriberMaxQuality(p.ID(), sub.receiver.Codec().MimeType, livekit.VideoQuality_OFF)
+	}
+
+	p.params.Logger.Debugw("unsubscribed from track", "trackID", trackID)
+	if p.params.OnTrackUnsubscribed != nil {
+		p.params.OnTrackUnsubscribed(p, trackID)
+	}
+	if lastFromPublisher && onSubscribeStatusChanged != nil {
+		onSubscribeStatusChanged(publisherID, false)
+	}
+}
+
+func (p *InProcessParticipant) UpdateSubscribedTrackSettings(_ livekit.TrackID, _ *livekit.UpdateTrackSettings) {
+}
+
+// GetSubscribedTracks returns nothing as in-process subscriptions do not use down tracks
+func (p *InProcessParticipant) GetSubscribedTracks() []types.SubscribedTrack {
+	return nil
+}
+
+func (p *InProcessParticipant) VerifySubscribeParticipantInfo(_ livekit.ParticipantID, _ uint32) {
+}
+
+func (p *InProcessParticipant) WaitUntilSubscribed(_ time.Duration) error {
+	return nil
+}
+
+func (p *InProcessParticipant) GetSubscribedParticipants() []livekit.ParticipantID {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	seen := make(map[livekit.ParticipantID]bool)
+	var participantIDs []livekit.ParticipantID
+	for _, sub := range p.subscriptions {
+		pID := sub.track.PublisherID()
+		if !seen[pID] {
+			seen[pID] = true
+			participantIDs = append(participantIDs, pID)
+		}
+	}
+	return participantIDs
+}
+
+func (p *InProcessParticipant) IsSubscribedTo(participantID livekit.ParticipantID) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.numSubscriptionsForPublisherLocked(participantID) != 0
+}
+
+func (p *InProcessParticipant) getSubscribedTrackIDs() []livekit.TrackID {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	trackIDs := make([]livekit.TrackID, 0, len(p.subscriptions)+len(p.pendingTrackIDs))
+	for trackID := range p.subscriptions {
+		trackIDs = append(trackIDs, trackID)
+	}
+	for trackID := range p.pendingTrackIDs {
+		trackIDs = append(trackIDs, trackID)
+	}
+	return trackIDs
+}
+
+func (p *InProcessParticipant) numSubscriptionsForPublisherLocked(participantID livekit.ParticipantID) int {
+	num := 0
+	for _, sub := range p.subscriptions {
+		if sub.track.PublisherID() == participantID {
+			num++
+		}
+	}
+	return num
+}
+
+// inProcessTrackSender receives packets of all layers from a publisher's receiver
+type inProcessTrackSender struct {
+	trackID      livekit.TrackID
+	subscriberID livekit.ParticipantID
+	onPacket     func(pkt *buffer.ExtPacket, layer int32)
+	onClose      func()
+	closed       atomic.Bool
+}
+
+func (s *inProcessTrackSender) UpTrackLayersChange()                           {}
+func (s *inProcessTrackSender) UpTrackBitrateAvailabilityChange()              {}
+func (s *inProcessTrackSender) UpTrackMaxPublishedLayerChange(_ int32)         {}
+func (s *inProcessTrackSender) UpTrackMaxTemporalLayerSeenChange(_ int32)      {}
+func (s *inProcessTrackSender) UpTrackBitrateReport(_ []int32, _ sfu.Bitrates) {}
+func (s *inProcessTrackSender) TrackInfoAvailable()                            {}
+
+func (s *inProcessTrackSender) WriteRTP(pkt *buffer.ExtPacket, layer int32) error {
+	if s.closed.Load() {
+		return nil
+	}
+	s.onPacket(pkt, layer)
+	return nil
+}
+
+// Close is called when the publisher's receiver goes away
+func (s *inProcessTrackSender) Close() {
+	if !s.closed.Swap(true) {
+		s.onClose()
+	}
+}
+
+func (s *inProcessTrackSender) markClosed() {
+	s.closed.Store(true)
+}
+
+func (s *inProcessTrackSender) IsClosed() bool {
+	return s.closed.Load()
+}
+
+func (s *inProcessTrackSender) ID() string {
+	return string(s.trackID)
+}
+
+func (s *inProcessTrackSender) SubscriberID() livekit.ParticipantID {
+	return s.subscriberID
+}
+
+func (s *inProcessTrackSender) HandleRTCPSenderReportData(_ webrtc.PayloadType, _ int32, _ *buffer.RTCPSenderReportData) error {
+	return nil
+}
+
+// -------------------------------------------------------
+// data
+
+// PublishData sends a user packet to the room, to all participants when destinationSids is empty
+func (p *InProcessParticipant) PublishData(payload []byte, kind livekit.DataPacket_Kind, destinationSids []livekit.ParticipantID) error {
+	if p.State() != livekit.ParticipantInfo_ACTIVE {
+		return ErrDataChannelUnavailable
+	}
+	if !p.CanPublishData() {
+		return ErrNoPublishPermission
+	}
+
+	destinations := make([]string, 0, len(destinationSids))
+	for _, sid := range destinationSids {
+		destinations = append(destinations, string(sid))
+	}
+	dp := &livekit.DataPacket{
+		Kind: kind,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				ParticipantSid:  string(p.params.SID),
+				Payload:         payload,
+				DestinationSids: destinations,
+			},
+		},
+	}
+
+	p.lock.RLock()
+	onDataPacket := p.onDataPacket
+	p.lock.RUnlock()
+	if onDataPacket != nil {
+		onDataPacket(p, dp)
+	}
+	return nil
+}
+
+func (p *InProcessParticipant) SendDataPacket(dp *livekit.DataPacket, _ []byte) error {
+	if p.State() != livekit.ParticipantInfo_ACTIVE {
+		return ErrDataChannelUnavailable
+	}
+
+	if p.params.OnDataPacket != nil {
+		p.params.OnDataPacket(p, dp)
+	}
+	return nil
+}
+
+// -------------------------------------------------------
+// signalling, there is no client to signal to
+
+func (p *InProcessParticipant) SetResponseSink(_ routing.MessageSink) {}
+func (p *InProcessParticipant) CloseSignalConnection()                {}
+func (p *InProcessParticipant) UpdateLastSeenSignal()                 {}
+func (p *InProcessParticipant) SetSignalSourceValid(_ bool)           {}
+
+// SendJoinResponse is a no-op, Join makes the participant active right after joining the room
+func (p *InProcessParticipant) SendJoinResponse(_ *livekit.JoinResponse) error {
+	return nil
+}
+
+func (p *InProcessParticipant) SendParticipantUpdate(_ []*livekit.ParticipantInfo) error {
+	return nil
+}
+
+func (p *InProcessParticipant) SendSpeakerUpdate(_ []*livekit.SpeakerInfo, _ bool) error {
+	return nil
+}
+
+func (p *InProcessParticipant) SendRoomUpdate(_ *livekit.Room) error {
+	return nil
+}
+
+func (p *InProcessParticipant) SendConnectionQualityUpdate(_ *livekit.ConnectionQualityUpdate) error {
+	return nil
+}
+
+func (p *InProcessParticipant) SubscriptionPermissionUpdate(_ livekit.ParticipantID, _ livekit.TrackID, _ bool) {
+}
+
+func (p *InProcessParticipant) SendRefreshToken(_ string) error {
+	return nil
+}
+
+func (p *InProcessParticipant) HandleReconnectAndSendResponse(_ livekit.ReconnectReason, _ *livekit.ReconnectResponse) error {
+	return ErrNotSupportedInProcess
+}
+
+func (p *InProcessParticipant) IssueFullReconnect(_ types.ParticipantCloseReason) {
+	p.Leave()
+}
+
+// -------------------------------------------------------
+// PeerConnection, not applicable
+
+func (p *InProcessParticipant) AddICECandidate(_ webrtc.ICECandidateInit, _ livekit.SignalTarget) {}
+func (p *InProcessParticipant) HandleOffer(_ webrtc.SessionDescription)                           {}
+func (p *InProcessParticipant) HandleAnswer(_ webrtc.SessionDescription)                          {}
+func (p *InProcessParticipant) AddTrack(_ *livekit.AddTrackRequest)                               {}
+func (p *InProcessParticipant) RejectTrack(_ string, _ string)                                    {}
+func (p *InProcessParticipant) Negotiate(_ bool)                                                  {}
+func (p *InProcessParticipant) ICERestart(_ *livekit.ICEConfig)                                   {}
+
+func (p *InProcessParticipant) SetTrackMuted(trackID livekit.TrackID, muted bool, _ bool) {
+	p.UpTrackManager.SetPublishedTrackMuted(trackID, muted)
+}
+
+func (p *InProcessParticipant) AddTrackToSubscriber(_ webrtc.TrackLocal, _ types.AddTrackParams) (*webrtc.RTPSender, *webrtc.RTPTransceiver, error) {
+	return nil, nil, ErrNotSupportedInProcess
+}
+
+func (p *InProcessParticipant) AddTransceiverFromTrackToSubscriber(_ webrtc.TrackLocal, _ types.AddTrackParams) (*webrtc.RTPSender, *webrtc.RTPTransceiver, error) {
+	return nil, nil, ErrNotSupportedInProcess
+}
+
+func (p *InProcessParticipant) RemoveTrackFromSubscriber(_ *webrtc.RTPSender) error {
+	return ErrNotSupportedInProcess
+}
+
+func (p *InProcessParticipant) GetAudioLevel() (level float64, active bool) {
+	for _, pt := range p.GetPublishedTracks() {
+		mediaTrack := pt.(types.LocalMediaTrack)
+		if mediaTrack.Source() == livekit.TrackSource_MICROPHONE {
+			tl, ta := mediaTrack.GetAudioLevel()
+			if ta {
+				active = true
+				if tl > level {
+					level = tl
+				}
+			}
+		}
+	}
+	return
+}
+
+func (p *InProcessParticipant) GetConnectionQuality() *livekit.ConnectionQualityInfo {
+	return &livekit.ConnectionQualityInfo{
+		ParticipantSid: string(p.ID()),
+		Quality:        livekit.ConnectionQuality_EXCELLENT,
+		Score:          connectionquality.MaxMOS,
+	}
+}
+
+func (p *InProcessParticipant) GetConnectionQualityDetails() *types.ConnectionQualityDetails {
+	return &types.ConnectionQualityDetails{
+		ParticipantSid: string(p.ID()),
+		Identity:       string(p.Identity()),
+		Quality:        strings.ToLower(livekit.ConnectionQuality_EXCELLENT.String()),
+		Score:          connectionquality.MaxMOS,
+	}
+}
+
+// -------------------------------------------------------
+// callbacks
+
+func (p *InProcessParticipant) OnStateChange(callback func(p types.LocalParticipant, oldState livekit.ParticipantInfo_State)) {
+	p.lock.Lock()
+	p.onStateChange = callback
+	p.lock.Unlock()
+}
+
+func (p *InProcessParticipant) OnMigrateStateChange(_ func(p types.LocalParticipant, migrateState types.MigrateState)) {
+}
+
+func (p *InProcessParticipant) OnTrackPublished(callback func(types.LocalParticipant, types.MediaTrack)) {
+	p.lock.Lock()
+	p.onTrackPublished = callback
+	p.lock.Unlock()
+}
+
+func (p *InProcessParticipant) OnTrackUpdated(callback func(types.LocalParticipant, types.MediaTrack)) {
+	p.lock.Lock()
+	p.onTrackUpdated = callback
+	p.lock.Unlock()
+}
+
+func (p *InProcessParticipant) getOnTrackUpdated() func(types.LocalParticipant, types.MediaTrack) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.onTrackUpdated
+}
+
+func (p *InProcessParticipant) OnTrackUnpublished(callback func(types.LocalParticipant, types.MediaTrack)) {
+	p.lock.Lock()
+	p.onTrackUnpublished = callback
+	p.lock.Unlock()
+}
+
+func (p *InProcessParticipant) OnParticipantUpdate(callback func(types.LocalParticipant)) {
+	p.lock.Lock()
+	p.onParticipantUpdate = callback
+	p.lock.Unlock()
+}
+
+func (p *InProcessParticipant) OnDataPacket(callback func(types.LocalParticipant, *livekit.DataPacket)) {
+	p.lock.Lock()
+	p.onDataPacket = callback
+	p.lock.Unlock()
+}
+
+func (p *InProcessParticipant) OnSubscribeStatusChanged(fn func(publisherID livekit.ParticipantID, subscribed bool)) {
+	p.lock.Lock()
+	p.onSubscribeStatusChanged = fn
+	p.lock.Unlock()
+}
+
+func (p *InProcessParticipant) OnClose(callback func(types.LocalParticipant)) {
+	p.lock.Lock()
+	p.onClose = callback
+	p.lock.Unlock()
+}
+
+func (p *InProcessParticipant) OnClaimsChanged(callback func(types.LocalParticipant)) {
+	p.lock.Lock()
+	p.onClaimsChanged = callback
+	p.lock.Unlock()
+}
+
+func (p *InProcessParticipant) OnReceiverReport(_ *sfu.DownTrack, _ *rtcp.ReceiverReport) {}
+
+func (p *InProcessParticipant) OnICEConfigChanged(_ func(participant types.LocalParticipant, iceConfig *livekit.ICEConfig)) {
+}
+
+// -------------------------------------------------------
+// session migration and transport tuning, not applicable
+
+func (p *InProcessParticipant) MaybeStartMigration(_ bool, _ func()) bool {
+	return false
+}
+
+func (p *InProcessParticipant) SetMigrateState(s types.MigrateState) {
+	p.migrateState.Store(s)
+}
+
+func (p *InProcessParticipant) MigrateState() types.MigrateState {
+	return p.migrateState.Load().(types.MigrateState)
+}
+
+func (p *InProcessParticipant) SetMigrateInfo(
+	_, _ *webrtc.SessionDescription,
+	_ []*livekit.TrackPublishedResponse,
+	_ []*livekit.DataChannelInfo,
+) {
+}
+
+func (p *InProcessParticipant) UpdateMediaRTT(_ uint32)     {}
+func (p *InProcessParticipant) UpdateSignalingRTT(_ uint32) {}
+
+func (p *InProcessParticipant) CacheDownTrack(_ livekit.TrackID, _ *webrtc.RTPTransceiver, _ sfu.DownTrackState) {
+}
+
+func (p *InProcessParticipant) UncacheDownTrack(_ *webrtc.RTPTransceiver) {}
+
+func (p *InProcessParticipant) GetCachedDownTrack(_ livekit.TrackID) (*webrtc.RTPTransceiver, sfu.DownTrackState) {
+	return nil, sfu.DownTrackState{}
+}
+
+func (p *InProcessParticipant) SetICEConfig(_ *livekit.ICEConfig) {}
+
+func (p *InProcessParticipant) UpdateSubscribedQuality(_ livekit.NodeID, _ livekit.TrackID, _ []types.SubscribedCodecQuality) error {
+	return nil
+}
+
+func (p *InProcessParticipant) UpdateMediaLoss(_ livekit.NodeID, _ livekit.TrackID, _ uint32) error {
+	return nil
+}
+
+func (p *InProcessParticipant) SetSubscriberAllowPause(_ bool)              {}
+func (p *InProcessParticipant) SetSubscriberChannelCapacity(_ int64)        {}
+func (p *InProcessParticipant) SetSubscriberChannelCapacityCeiling(_ int64) {}
+func (p *InProcessParticipant) SetDownTrackImpairment(_ *impairment.Config) {}
+
+// -------------------------------------------------------
+
+func (p *InProcessParticipant) updateState(state livekit.ParticipantInfo_State) {
+	oldState := p.State()
+	if state == oldState {
+		return
+	}
+
+	p.params.Logger.Debugw("updating participant state", "state", state.String())
+	p.state.Store(state)
+	p.dirty.Store(true)
+
+	p.lock.RLock()
+	onStateChange := p.onStateChange
+	p.lock.RUnlock()
+	if onStateChange != nil {
+		go onStateChange(p, oldState)
+	}
+}
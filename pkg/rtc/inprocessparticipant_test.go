@@ -0,0 +1,193 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
+)
+
+func TestInProcessParticipant(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	defer rm.Close()
+
+	grants := &auth.ClaimGrants{Video: &auth.VideoGrant{Room: "room", RoomJoin: true}}
+
+	publisher, err := NewInProcessParticipant(InProcessParticipantParams{
+		Identity:  "soundboard",
+		Grants:    grants,
+		Telemetry: &telemetryfakes.FakeTelemetryService{},
+	})
+	require.NoError(t, err)
+
+	packets := atomic.NewInt32(0)
+	subscribed := make(chan livekit.TrackID, 1)
+	data := make(chan *livekit.DataPacket, 1)
+	subscriber, err := NewInProcessParticipant(InProcessParticipantParams{
+		Identity:      "moderator",
+		Grants:        grants,
+		AutoSubscribe: true,
+		Telemetry:     &telemetryfakes.FakeTelemetryService{},
+		OnTrackSubscribed: func(_ *InProcessParticipant, track types.MediaTrack, _ sfu.TrackReceiver) {
+			subscribed <- track.ID()
+		},
+		OnTrackPacket: func(_ *InProcessParticipant, _ livekit.TrackID, _ *buffer.ExtPacket, _ int32) {
+			packets.Inc()
+		},
+		OnDataPacket: func(_ *InProcessParticipant, dp *livekit.DataPacket) {
+			data <- dp
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, publisher.Join(rm))
+	require.NoError(t, subscriber.Join(rm))
+	require.Eventually(t, func() bool {
+		return publisher.State() == livekit.ParticipantInfo_ACTIVE && subscriber.State() == livekit.ParticipantInfo_ACTIVE
+	}, time.Second, 10*time.Millisecond)
+	require.Len(t, rm.GetParticipants(), 2)
+
+	t.Run("media is forwarded to subscribers", func(t *testing.T) {
+		track, err := publisher.PublishTrack(InProcessTrackParams{
+			Name:   "sounds",
+			Source: livekit.TrackSource_MICROPHONE,
+			Codec: webrtc.RTPCodecParameters{
+				RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+				PayloadType:        111,
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, publisher.IsPublisher())
+		require.Len(t, publisher.ToProto().Tracks, 1)
+
+		select {
+		case trackID := <-subscribed:
+			require.Equal(t, track.ID(), trackID)
+		case <-time.After(time.Second):
+			require.Fail(t, "track not subscribed")
+		}
+		require.True(t, subscriber.IsSubscribedTo(publisher.ID()))
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, track.WriteSample(media.Sample{Data: []byte{0xf8, 0xff, 0xfe}, Duration: 20 * time.Millisecond}))
+		}
+		require.Eventually(t, func() bool {
+			return packets.Load() == 10
+		}, time.Second, 10*time.Millisecond)
+
+		publisher.UnpublishTrack(track.ID())
+		require.Eventually(t, func() bool {
+			return !subscriber.IsSubscribedTo(publisher.ID())
+		}, time.Second, 10*time.Millisecond)
+		require.ErrorIs(t, track.WriteSample(media.Sample{Data: []byte{0xf8}, Duration: 20 * time.Millisecond}), ErrTrackClosed)
+	})
+
+	t.Run("data packets", func(t *testing.T) {
+		require.NoError(t, publisher.PublishData([]byte("hello"), livekit.DataPacket_RELIABLE, nil))
+		select {
+		case dp := <-data:
+			require.Equal(t, []byte("hello"), dp.GetUser().Payload)
+			require.Equal(t, string(publisher.ID()), dp.GetUser().ParticipantSid)
+		case <-time.After(time.Second):
+			require.Fail(t, "data packet not received")
+		}
+	})
+
+	t.Run("leave", func(t *testing.T) {
+		publisher.Leave()
+		require.True(t, publisher.IsClosed())
+		require.Nil(t, rm.GetParticipant(publisher.Identity()))
+	})
+}
+
+func TestForwardTrack(t *testing.T) {
+	origin := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	defer origin.Close()
+	overflow := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	defer overflow.Close()
+
+	grants := &auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}}
+	newParticipant := func(identity livekit.ParticipantIdentity, params InProcessParticipantParams) *InProcessParticipant {
+		params.Identity = identity
+		params.Grants = grants
+		params.Telemetry = &telemetryfakes.FakeTelemetryService{}
+		p, err := NewInProcessParticipant(params)
+		require.NoError(t, err)
+		return p
+	}
+
+	publisher := newParticipant("speaker", InProcessParticipantParams{})
+	require.NoError(t, publisher.Join(origin))
+	forwarder := newParticipant("overflow", InProcessParticipantParams{})
+	require.NoError(t, forwarder.Join(overflow))
+
+	packets := atomic.NewInt32(0)
+	subscribed := make(chan types.MediaTrack, 1)
+	listener := newParticipant("listener", InProcessParticipantParams{
+		AutoSubscribe: true,
+		OnTrackSubscribed: func(_ *InProcessParticipant, track types.MediaTrack, _ sfu.TrackReceiver) {
+			subscribed <- track
+		},
+		OnTrackPacket: func(_ *InProcessParticipant, _ livekit.TrackID, _ *buffer.ExtPacket, _ int32) {
+			packets.Inc()
+		},
+	})
+	require.NoError(t, listener.Join(overflow))
+
+	_, err := forwarder.ForwardTrack(origin, "TR_unknown")
+	require.ErrorIs(t, err, ErrTrackNotFound)
+
+	track, err := publisher.PublishTrack(InProcessTrackParams{
+		Name:   "keynote",
+		Source: livekit.TrackSource_MICROPHONE,
+		Codec: webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+			PayloadType:        111,
+		},
+	})
+	require.NoError(t, err)
+
+	ft, err := forwarder.ForwardTrack(origin, track.ID())
+	require.NoError(t, err)
+	require.NotEqual(t, track.ID(), ft.ID())
+	require.Equal(t, forwarder.ID(), ft.PublisherID())
+	require.Equal(t, "keynote", ft.Name())
+	require.Len(t, forwarder.ToProto().Tracks, 1)
+
+	select {
+	case subTrack := <-subscribed:
+		require.Equal(t, ft.ID(), subTrack.ID())
+	case <-time.After(time.Second):
+		require.Fail(t, "forwarded track not subscribed")
+	}
+	require.True(t, listener.IsSubscribedTo(forwarder.ID()))
+
+	// media flows from the origin's receiver
+	for i := 0; i < 10; i++ {
+		require.NoError(t, track.WriteSample(media.Sample{Data: []byte{0xf8, 0xff, 0xfe}, Duration: 20 * time.Millisecond}))
+	}
+	require.Eventually(t, func() bool {
+		return packets.Load() == 10
+	}, time.Second, 10*time.Millisecond)
+
+	// mute state follows the origin
+	publisher.SetTrackMuted(track.ID(), true, false)
+	require.Eventually(t, ft.IsMuted, time.Second, 10*time.Millisecond)
+
+	// unpublishing the origin stops forwarding
+	publisher.UnpublishTrack(track.ID())
+	require.Eventually(t, func() bool {
+		return len(forwarder.GetPublishedTracks()) == 0 && !listener.IsSubscribedTo(forwarder.ID())
+	}, time.Second, 10*time.Millisecond)
+}
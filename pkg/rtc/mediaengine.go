@@ -6,11 +6,19 @@ import (
 	"github.com/pion/webrtc/v3"
 
 	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/protocol/livekit"
 )
 
 var opusCodecCapability = webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2, SDPFmtpLine: "minptime=10;useinbandfec=1"}
 var redCodecCapability = webrtc.RTPCodecCapability{MimeType: sfu.MimeTypeAudioRed, ClockRate: 48000, Channels: 2, SDPFmtpLine: "111/111"}
+var multiOpusCodecCapabilities = []webrtc.RTPCodecCapability{
+	// 5.1
+	{MimeType: sfu.MimeTypeMultiOpus, ClockRate: 48000, Channels: 6, SDPFmtpLine: "channel_mapping=0,4,1,2,3,5;coupled_streams=2;minptime=10;num_streams=4;useinbandfec=1"},
+	// 7.1
+	{MimeType: sfu.MimeTypeMultiOpus, ClockRate: 48000, Channels: 8, SDPFmtpLine: "channel_mapping=0,6,1,2,3,4,5,7;coupled_streams=3;minptime=10;num_streams=5;useinbandfec=1"},
+}
+var telephoneEventCodecCapability = webrtc.RTPCodecCapability{MimeType: buffer.MimeTypeTelephoneEvent, SDPFmtpLine: "0-16"}
 
 func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedback RTCPFeedbackConfig) error {
 	opusCodec := opusCodecCapability
@@ -35,6 +43,53 @@ func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedbac
 		}
 	}
 
+	for i, multiOpusCodec := range multiOpusCodecCapabilities {
+		if IsCodecEnabled(codecs, multiOpusCodec) {
+			multiOpusCodec.RTCPFeedback = rtcpFeedback.Audio
+			if err := me.RegisterCodec(webrtc.RTPCodecParameters{
+				RTPCodecCapability: multiOpusCodec,
+				PayloadType:        webrtc.PayloadType(112 + i),
+			}, webrtc.RTPCodecTypeAudio); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, codec := range []webrtc.RTPCodecParameters{
+		{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypePCMU, ClockRate: 8000, RTCPFeedback: rtcpFeedback.Audio},
+			PayloadType:        0,
+		},
+		{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypePCMA, ClockRate: 8000, RTCPFeedback: rtcpFeedback.Audio},
+			PayloadType:        8,
+		},
+	} {
+		if IsCodecEnabled(codecs, codec.RTPCodecCapability) {
+			if err := me.RegisterCodec(codec, webrtc.RTPCodecTypeAudio); err != nil {
+				return err
+			}
+		}
+	}
+
+	// telephone events are registered at the clock rate of every audio codec they can accompany
+	if IsCodecEnabled(codecs, telephoneEventCodecCapability) {
+		for _, codec := range []webrtc.RTPCodecParameters{
+			{
+				RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: telephoneEventCodecCapability.MimeType, ClockRate: 48000, SDPFmtpLine: telephoneEventCodecCapability.SDPFmtpLine},
+				PayloadType:        110,
+			},
+			{
+				RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: telephoneEventCodecCapability.MimeType, ClockRate: 8000, SDPFmtpLine: telephoneEventCodecCapability.SDPFmtpLine},
+				PayloadType:        101,
+			},
+		} {
+			if err := me.RegisterCodec(codec, webrtc.RTPCodecTypeAudio); err != nil {
+				return err
+			}
+		}
+	}
+
 	for _, codec := range []webrtc.RTPCodecParameters{
 		{
 			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000, RTCPFeedback: rtcpFeedback.Video},
@@ -64,6 +119,11 @@ func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedbac
 			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeAV1, ClockRate: 90000, RTCPFeedback: rtcpFeedback.Video},
 			PayloadType:        35,
 		},
+		{
+			// no fmtp, so any profile, tier and level offered is accepted
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH265, ClockRate: 90000, RTCPFeedback: rtcpFeedback.Video},
+			PayloadType:        116,
+		},
 	} {
 		if IsCodecEnabled(codecs, codec.RTPCodecCapability) {
 			if err := me.RegisterCodec(codec, webrtc.RTPCodecTypeVideo); err != nil {
@@ -2,10 +2,12 @@ package rtc
 
 import (
 	"context"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/pion/rtcp"
+	"github.com/pion/transport/v2/packetio"
 	"github.com/pion/webrtc/v3"
 	"go.uber.org/atomic"
 	"google.golang.org/protobuf/proto"
@@ -33,8 +35,15 @@ type MediaTrack struct {
 	*MediaLossProxy
 
 	dynacastManager *DynacastManager
+	// share of the publisher's bitrate limit in bps, 0 for no limit
+	maxPublishBitrate atomic.Uint64
+	// channel count of a multichannel audio track, 0 otherwise
+	multiOpusChannels atomic.Uint32
 
 	lock sync.RWMutex
+
+	onDTMF  func(trackID livekit.TrackID, event buffer.DTMFEvent)
+	onTMMBN func(tmmbn *sfu.TMMBN)
 }
 
 type MediaTrackParams struct {
@@ -55,12 +64,15 @@ type MediaTrackParams struct {
 	Telemetry         telemetry.TelemetryService
 	Logger            logger.Logger
 	SimTracks         map[uint32]SimulcastTrackInfo
+	// share of the publisher's bitrate limit for this track in bps, 0 for no limit
+	MaxPublishBitrate uint64
 }
 
 func NewMediaTrack(params MediaTrackParams) *MediaTrack {
 	t := &MediaTrack{
 		params: params,
 	}
+	t.maxPublishBitrate.Store(params.MaxPublishBitrate)
 
 	t.MediaTrackReceiver = NewMediaTrackReceiver(MediaTrackReceiverParams{
 		TrackInfo:           params.TrackInfo,
@@ -84,6 +96,8 @@ func NewMediaTrack(params MediaTrackParams) *MediaTrack {
 				Simulcast: t.IsSimulcast(),
 				Layers:    layers,
 			})
+
+		t.updateMaxPublishedQuality(layers)
 	})
 
 	if params.TrackInfo.Type == livekit.TrackType_AUDIO {
@@ -116,11 +130,32 @@ func NewMediaTrack(params MediaTrackParams) *MediaTrack {
 				)
 			},
 		)
+		t.updateMaxPublishedQuality(params.TrackInfo.Layers)
 	}
 
 	return t
 }
 
+// updateMaxPublishedQuality keeps simulcast layers enabled on the publisher within its bitrate limit.
+// Tracks without simulcast are limited only by the receiver estimate sent to the publisher.
+func (t *MediaTrack) updateMaxPublishedQuality(layers []*livekit.VideoLayer) {
+	maxPublishBitrate := t.maxPublishBitrate.Load()
+	if t.dynacastManager == nil || maxPublishBitrate == 0 || !t.params.TrackInfo.Simulcast {
+		return
+	}
+
+	t.dynacastManager.SetMaxPublishedQuality(maxQualityForBitrate(layers, maxPublishBitrate))
+}
+
+// SetMaxPublishBitrate updates the share of the publisher's bitrate limit for this track,
+// as tracks of the publisher come and go
+func (t *MediaTrack) SetMaxPublishBitrate(bitrate uint64) {
+	if t.maxPublishBitrate.Swap(bitrate) == bitrate {
+		return
+	}
+	t.updateMaxPublishedQuality(t.GetVideoLayers())
+}
+
 func (t *MediaTrack) OnSubscribedMaxQualityChange(
 	f func(
 		trackID livekit.TrackID,
@@ -148,6 +183,63 @@ func (t *MediaTrack) OnSubscribedMaxQualityChange(
 	t.dynacastManager.OnSubscribedMaxQualityChange(handler)
 }
 
+// OnDTMF sets the callback for telephone events sent along with the track
+func (t *MediaTrack) OnDTMF(f func(trackID livekit.TrackID, event buffer.DTMFEvent)) {
+	t.lock.Lock()
+	t.onDTMF = f
+	t.lock.Unlock()
+}
+
+// OnTMMBN sets the callback for bitrate bounds acknowledged by the publisher
+func (t *MediaTrack) OnTMMBN(f func(tmmbn *sfu.TMMBN)) {
+	t.lock.Lock()
+	t.onTMMBN = f
+	t.lock.Unlock()
+}
+
+// subscriberMaxQualityNotifier is implemented by tracks with dynacast, for subscribers that do not receive
+// the track through one of its down tracks, e. g. in-process subscribers. Quality is cleared with VideoQuality_OFF.
+type subscriberMaxQualityNotifier interface {
+	NotifySubscriberMaxQuality(subscriberID livekit.ParticipantID, mime string, quality livekit.VideoQuality)
+}
+
+// GetConsumableBitrates returns the bitrate current subscribers can consume of each published stream, by SSRC.
+// Returns false if subscribers could use more than what is being published.
+func (t *MediaTrack) GetConsumableBitrates() (map[uint32]int64, bool) {
+	if t.Kind() != livekit.TrackType_VIDEO {
+		return nil, false
+	}
+
+	consumable := make(map[uint32]int64)
+	for _, receiver := range t.Receivers() {
+		wr, ok := receiver.(*sfu.WebRTCReceiver)
+		if !ok {
+			return nil, false
+		}
+
+		bitrates, ok := wr.GetConsumableBitrates()
+		if !ok {
+			return nil, false
+		}
+
+		for layer, bitrate := range bitrates {
+			if ssrc := wr.SSRC(layer); ssrc != 0 {
+				consumable[ssrc] = bitrate
+			}
+		}
+	}
+
+	return consumable, len(consumable) != 0
+}
+
+// NotifySubscriberMaxQuality accounts for a subscriber that does not receive the track through one of its
+// down tracks, see subscriberMaxQualityNotifier
+func (t *MediaTrack) NotifySubscriberMaxQuality(subscriberID livekit.ParticipantID, mime string, quality livekit.VideoQuality) {
+	if t.dynacastManager != nil {
+		t.dynacastManager.NotifySubscriberMaxQuality(subscriberID, mime, quality)
+	}
+}
+
 func (t *MediaTrack) NotifySubscriberNodeMaxQuality(nodeID livekit.NodeID, qualities []types.SubscribedCodecQuality) {
 	if t.dynacastManager != nil {
 		t.dynacastManager.NotifySubscriberNodeMaxQuality(nodeID, qualities)
@@ -176,9 +268,18 @@ func (t *MediaTrack) ToProto() *livekit.TrackInfo {
 	info := t.MediaTrackReceiver.TrackInfo(true)
 	info.Muted = t.IsMuted()
 	info.Simulcast = t.IsSimulcast()
+	if channels := t.multiOpusChannels.Load(); channels != 0 && strings.EqualFold(info.MimeType, sfu.MimeTypeMultiOpus) {
+		info.MimeType = sfu.MultiOpusMimeType(int(channels))
+	}
 	return info
 }
 
+func (t *MediaTrack) updateMultiOpusChannels(codec webrtc.RTPCodecCapability) {
+	if strings.EqualFold(codec.MimeType, sfu.MimeTypeMultiOpus) {
+		t.multiOpusChannels.Store(uint32(sfu.ParseMultiOpusLayout(codec.SDPFmtpLine).Channels))
+	}
+}
+
 func (t *MediaTrack) SetPendingCodecSid(codecs []*livekit.SimulcastCodec) {
 	ti := proto.Clone(t.params.TrackInfo).(*livekit.TrackInfo)
 	for _, c := range codecs {
@@ -215,10 +316,16 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 			// do nothing for now
 			case *rtcp.SenderReport:
 				buff.SetSenderReportData(pkt.RTPTime, pkt.NTPTime)
+			case *rtcp.RawPacket:
+				if sfu.IsTMMBN(pkt) {
+					t.handleTMMBN(*pkt)
+				}
 			}
 		}
 	})
 
+	t.updateMultiOpusChannels(track.Codec().RTPCodecCapability)
+
 	t.lock.Lock()
 	mime := strings.ToLower(track.Codec().MimeType)
 	layer := buffer.RidToSpatialLayer(track.RID(), t.trackInfo)
@@ -239,26 +346,9 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 			LoggerWithCodecMime(t.params.Logger, mime),
 			twcc,
 			t.params.VideoConfig.StreamTracker,
-			sfu.WithPliThrottleConfig(t.params.PLIThrottleConfig),
-			sfu.WithAudioConfig(t.params.AudioConfig),
-			sfu.WithLoadBalanceThreshold(20),
-			sfu.WithStreamTrackers(),
+			t.receiverOpts()...,
 		)
-		newWR.SetRTCPCh(t.params.RTCPChan)
-		newWR.OnCloseHandler(func() {
-			t.MediaTrackReceiver.SetClosing()
-			t.MediaTrackReceiver.ClearReceiver(mime, false)
-			if t.MediaTrackReceiver.TryClose() {
-				if t.dynacastManager != nil {
-					t.dynacastManager.Close()
-				}
-			}
-		})
-		newWR.OnStatsUpdate(func(_ *sfu.WebRTCReceiver, stat *livekit.AnalyticsStat) {
-			// LK-TODO: this needs to be receiver/mime aware
-			key := telemetry.StatsKeyForTrack(livekit.StreamType_UPSTREAM, t.PublisherID(), t.ID(), t.params.TrackInfo.Source, t.params.TrackInfo.Type)
-			t.params.Telemetry.TrackStats(key, stat)
-		})
+		t.handleReceiverEvents(newWR, mime)
 		if t.PrimaryReceiver() == nil {
 			// primary codec published, set potential codecs
 			potentialCodecs := make([]webrtc.RTPCodecParameters, 0, len(t.params.TrackInfo.Codecs))
@@ -306,7 +396,118 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 		t.MediaTrackReceiver.SetLayerSsrc(mime, track.RID(), uint32(track.SSRC()))
 	}
 
-	buff.Bind(receiver.GetParameters(), track.Codec().RTPCodecCapability)
+	t.bindBuffer(buff, receiver.GetParameters(), track.Codec().RTPCodecCapability, mime, layer)
+	return newCodec
+}
+
+func (t *MediaTrack) handleTMMBN(pkt rtcp.RawPacket) {
+	tmmbn := &sfu.TMMBN{}
+	if err := tmmbn.Unmarshal(pkt); err != nil {
+		t.params.Logger.Debugw("could not unmarshal TMMBN", "error", err)
+		return
+	}
+
+	t.lock.RLock()
+	onTMMBN := t.onTMMBN
+	t.lock.RUnlock()
+
+	if onTMMBN != nil {
+		onTMMBN(tmmbn)
+	}
+}
+
+// AddInProcessReceiver adds a single layer receiver for a codec whose packets are written into the returned buffer
+// directly instead of arriving over a PeerConnection. Closing the buffer closes the receiver.
+func (t *MediaTrack) AddInProcessReceiver(
+	codec webrtc.RTPCodecParameters,
+	headerExtensions []webrtc.RTPHeaderExtensionParameter,
+	ssrc uint32,
+) (*buffer.Buffer, error) {
+	mime := strings.ToLower(codec.MimeType)
+
+	t.lock.Lock()
+	if t.MediaTrackReceiver.Receiver(mime) != nil {
+		t.lock.Unlock()
+		return nil, ErrTrackCodecAlreadyPublished
+	}
+
+	buff, ok := t.params.BufferFactory.GetOrNew(packetio.RTPBufferPacket, ssrc).(*buffer.Buffer)
+	if !ok || buff == nil {
+		t.lock.Unlock()
+		return nil, ErrBufferUnavailable
+	}
+
+	newWR := sfu.NewInProcessReceiver(
+		t.ID(),
+		string(t.PublisherID()),
+		codec,
+		headerExtensions,
+		t.params.TrackInfo,
+		LoggerWithCodecMime(t.params.Logger, mime),
+		t.params.VideoConfig.StreamTracker,
+		t.receiverOpts()...,
+	)
+	t.handleReceiverEvents(newWR, mime)
+	newWR.OnMaxLayerChange(t.onMaxLayerChange)
+
+	t.buffer = buff
+
+	var priority int
+	for idx, c := range t.params.TrackInfo.Codecs {
+		if strings.HasSuffix(mime, c.MimeType) {
+			priority = idx
+			break
+		}
+	}
+	t.MediaTrackReceiver.SetupReceiver(newWR, priority, "")
+	t.lock.Unlock()
+
+	newWR.AddUpTrackBuffer(0, ssrc, buff)
+	t.bindBuffer(buff, webrtc.RTPParameters{
+		HeaderExtensions: headerExtensions,
+		Codecs:           []webrtc.RTPCodecParameters{codec},
+	}, codec.RTPCodecCapability, mime, 0)
+	return buff, nil
+}
+
+func (t *MediaTrack) receiverOpts() []sfu.ReceiverOpts {
+	return []sfu.ReceiverOpts{
+		sfu.WithPliThrottleConfig(t.params.PLIThrottleConfig),
+		sfu.WithAudioConfig(t.params.AudioConfig),
+		sfu.WithConnectionQualityConfig(t.params.ReceiverConfig.ConnectionQuality),
+		sfu.WithLoadBalanceThreshold(20),
+		sfu.WithStreamTrackers(),
+	}
+}
+
+func (t *MediaTrack) handleReceiverEvents(wr *sfu.WebRTCReceiver, mime string) {
+	wr.SetRTCPCh(t.params.RTCPChan)
+	wr.OnCloseHandler(func() {
+		t.MediaTrackReceiver.SetClosing()
+		t.MediaTrackReceiver.ClearReceiver(mime, false)
+		if t.MediaTrackReceiver.TryClose() {
+			if t.dynacastManager != nil {
+				t.dynacastManager.Close()
+			}
+		}
+	})
+	wr.OnStatsUpdate(func(_ *sfu.WebRTCReceiver, stat *livekit.AnalyticsStat) {
+		// LK-TODO: this needs to be receiver/mime aware
+		key := telemetry.StatsKeyForTrack(livekit.StreamType_UPSTREAM, t.PublisherID(), t.ID(), t.params.TrackInfo.Source, t.params.TrackInfo.Type)
+		t.params.Telemetry.TrackStats(key, stat)
+	})
+}
+
+func (t *MediaTrack) bindBuffer(buff *buffer.Buffer, params webrtc.RTPParameters, codec webrtc.RTPCodecCapability, mime string, layer int32) {
+	buff.OnDTMF(func(event buffer.DTMFEvent) {
+		t.lock.RLock()
+		onDTMF := t.onDTMF
+		t.lock.RUnlock()
+		if onDTMF != nil {
+			onDTMF(t.ID(), event)
+		}
+	})
+	buff.Bind(params, codec)
 
 	// if subscriber request fps before fps calculated, update them after fps updated.
 	buff.OnFpsChanged(func() {
@@ -323,7 +524,6 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 			stats.ToProto(),
 		)
 	})
-	return newCodec
 }
 
 func (t *MediaTrack) GetConnectionScoreAndQuality() (float32, livekit.ConnectionQuality) {
@@ -335,6 +535,25 @@ func (t *MediaTrack) GetConnectionScoreAndQuality() (float32, livekit.Connection
 	return connectionquality.MaxMOS, livekit.ConnectionQuality_EXCELLENT
 }
 
+func (t *MediaTrack) GetConnectionScoreBreakdown() connectionquality.ScoreBreakdown {
+	receiver := t.PrimaryReceiver()
+	if rtcReceiver, ok := receiver.(*sfu.WebRTCReceiver); ok {
+		return rtcReceiver.GetConnectionScoreBreakdown()
+	}
+
+	return connectionquality.ScoreBreakdown{}
+}
+
+// GetTrackStats returns RTP stats aggregated over all received streams of the primary codec
+func (t *MediaTrack) GetTrackStats() *livekit.RTPStats {
+	receiver := t.PrimaryReceiver()
+	if rtcReceiver, ok := receiver.(*sfu.WebRTCReceiver); ok {
+		return rtcReceiver.GetTrackStats()
+	}
+
+	return nil
+}
+
 func (t *MediaTrack) SetRTT(rtt uint32) {
 	t.MediaTrackReceiver.SetRTT(rtt)
 }
@@ -384,3 +603,33 @@ func (t *MediaTrack) SetMuted(muted bool) {
 
 	t.MediaTrackReceiver.SetMuted(muted)
 }
+
+// maxQualityForBitrate returns the highest quality that fits in the bitrate.
+// Simulcast layers are sent together, so bitrates of all enabled layers add up.
+// The lowest quality is always allowed and layers without a bitrate are not limited.
+func maxQualityForBitrate(layers []*livekit.VideoLayer, bitrate uint64) livekit.VideoQuality {
+	if bitrate == 0 || len(layers) == 0 {
+		return livekit.VideoQuality_HIGH
+	}
+
+	sorted := make([]*livekit.VideoLayer, len(layers))
+	copy(sorted, layers)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Quality < sorted[j].Quality
+	})
+
+	maxQuality := livekit.VideoQuality_LOW
+	total := uint64(0)
+	for _, layer := range sorted {
+		if layer.Bitrate == 0 {
+			return livekit.VideoQuality_HIGH
+		}
+
+		total += uint64(layer.Bitrate)
+		if total > bitrate {
+			break
+		}
+		maxQuality = layer.Quality
+	}
+	return maxQuality
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
 )
 
 func TestTrackInfo(t *testing.T) {
@@ -41,6 +43,30 @@ func TestTrackInfo(t *testing.T) {
 	require.True(t, mt.ToProto().Simulcast)
 }
 
+func TestTrackInfoMultiOpus(t *testing.T) {
+	ti := livekit.TrackInfo{
+		Sid:      "testsid",
+		Type:     livekit.TrackType_AUDIO,
+		MimeType: sfu.MimeTypeMultiOpus,
+		Stereo:   true,
+	}
+
+	mt := NewMediaTrack(MediaTrackParams{
+		TrackInfo: &ti,
+	})
+	// 5.1 and 7.1 should be told apart by their channel count
+	mt.updateMultiOpusChannels(multiOpusCodecCapabilities[0])
+	require.Equal(t, "audio/multiopus;channels=6", mt.ToProto().MimeType)
+
+	mt.updateMultiOpusChannels(multiOpusCodecCapabilities[1])
+	outInfo := mt.ToProto()
+	require.Equal(t, "audio/multiopus;channels=8", outInfo.MimeType)
+	require.True(t, outInfo.Stereo)
+
+	// stored track info keeps the codec mime type
+	require.Equal(t, sfu.MimeTypeMultiOpus, mt.TrackInfo(false).MimeType)
+}
+
 func TestGetQualityForDimension(t *testing.T) {
 	t.Run("landscape source", func(t *testing.T) {
 		mt := NewMediaTrack(MediaTrackParams{TrackInfo: &livekit.TrackInfo{
@@ -99,3 +125,26 @@ func TestGetQualityForDimension(t *testing.T) {
 		require.Equal(t, livekit.VideoQuality_HIGH, mt.GetQualityForDimension(1000, 700))
 	})
 }
+
+func TestMaxQualityForBitrate(t *testing.T) {
+	layers := []*livekit.VideoLayer{
+		{Quality: livekit.VideoQuality_HIGH, Bitrate: 2_500_000},
+		{Quality: livekit.VideoQuality_LOW, Bitrate: 150_000},
+		{Quality: livekit.VideoQuality_MEDIUM, Bitrate: 500_000},
+	}
+
+	require.Equal(t, livekit.VideoQuality_HIGH, maxQualityForBitrate(layers, 0))
+	require.Equal(t, livekit.VideoQuality_HIGH, maxQualityForBitrate(layers, 4_000_000))
+	// layers are sent together, HIGH needs 3.15 Mbps
+	require.Equal(t, livekit.VideoQuality_MEDIUM, maxQualityForBitrate(layers, 1_500_000))
+	require.Equal(t, livekit.VideoQuality_LOW, maxQualityForBitrate(layers, 600_000))
+	// lowest quality is always allowed
+	require.Equal(t, livekit.VideoQuality_LOW, maxQualityForBitrate(layers, 100_000))
+
+	// bitrates unknown
+	require.Equal(t, livekit.VideoQuality_HIGH, maxQualityForBitrate([]*livekit.VideoLayer{
+		{Quality: livekit.VideoQuality_LOW},
+		{Quality: livekit.VideoQuality_HIGH},
+	}, 100_000))
+	require.Equal(t, livekit.VideoQuality_HIGH, maxQualityForBitrate(nil, 100_000))
+}
@@ -2,6 +2,7 @@ package rtc
 
 import (
 	"errors"
+	"strings"
 	"sync"
 
 	"github.com/pion/rtcp"
@@ -105,6 +106,7 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 		subscriberID,
 		t.params.ReceiverConfig.PacketBufferSize,
 		sub.GetAllowTimestampAdjustment(),
+		t.params.ReceiverConfig.ConnectionQuality,
 		LoggerWithTrack(sub.GetLogger(), trackID, t.params.IsRelayed),
 	)
 	if err != nil {
@@ -209,6 +211,10 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 		if addTrackParams.Red && (len(codecs) == 1 && codecs[0].MimeType == webrtc.MimeTypeOpus) {
 			addTrackParams.Red = false
 		}
+		if len(codecs) != 0 && strings.EqualFold(codecs[0].MimeType, sfu.MimeTypeMultiOpus) {
+			addTrackParams.Red = false
+			addTrackParams.Channels = codecs[0].Channels
+		}
 
 		sub.VerifySubscribeParticipantInfo(subTrack.PublisherID(), subTrack.PublisherVersion())
 		if sub.ProtocolVersion().SupportsTransceiverReuse() {
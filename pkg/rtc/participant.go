@@ -24,6 +24,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
+	"github.com/livekit/livekit-server/pkg/sfu/impairment"
 	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
 	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
@@ -42,6 +43,15 @@ const (
 	migrationWaitDuration     = 3 * time.Second
 )
 
+// TrackRejectedTopic is the data packet topic participants receive a JSON encoded TrackRejection on
+// when publishing a track is refused
+const TrackRejectedTopic = "lk.track_rejected"
+
+type TrackRejection struct {
+	Cid    string `json:"cid"`
+	Reason string `json:"reason,omitempty"`
+}
+
 type pendingTrackInfo struct {
 	trackInfos []*livekit.TrackInfo
 	migrated   bool
@@ -94,6 +104,11 @@ type ParticipantParams struct {
 	SubscriptionLimitAudio       int32
 	SubscriptionLimitVideo       int32
 	AllowTimestampAdjustment     bool
+	// MaxPublishBitrate limits the bitrate of all tracks published by the participant, in bps
+	MaxPublishBitrate uint64
+	// AdmitTrack is consulted before a track is published, it may deny or modify the request.
+	// It is responsible for calling RejectTrack of the participant when it denies the request.
+	AdmitTrack func(p types.LocalParticipant, req *livekit.AddTrackRequest) error
 }
 
 type ParticipantImpl struct {
@@ -128,6 +143,8 @@ type ParticipantImpl struct {
 	*UpTrackManager
 	*SubscriptionManager
 
+	uplinkFeedback *UplinkFeedback
+
 	// keeps track of unpublished tracks in order to reuse trackID
 	unpublishedTracks []*livekit.TrackInfo
 
@@ -169,9 +186,16 @@ type ParticipantImpl struct {
 
 	cachedDownTracks map[livekit.TrackID]*downTrackState
 
+	// simulated network conditions of down tracks, including tracks subscribed later, guarded by lock
+	downTrackImpairment *impairment.Config
+	// offsets the seed of each down track so that tracks do not lose the same packets
+	downTrackImpairmentSeed int64
+
 	supervisor *supervisor.ParticipantSupervisor
 
 	tracksQuality map[livekit.TrackID]livekit.ConnectionQuality
+	// details of the connection quality last calculated
+	connectionQualityDetails *types.ConnectionQualityDetails
 }
 
 func NewParticipant(params ParticipantParams) (*ParticipantImpl, error) {
@@ -221,6 +245,7 @@ func NewParticipant(params ParticipantParams) (*ParticipantImpl, error) {
 
 	p.setupUpTrackManager()
 	p.setupSubscriptionManager()
+	p.setupUplinkFeedback()
 
 	return p, nil
 }
@@ -412,6 +437,21 @@ func (p *ParticipantImpl) SetPermission(permission *livekit.ParticipantPermissio
 	return true
 }
 
+// MoveToRoom rebinds the participant's grants to roomName and drops subscriptions to tracks of the room it's leaving
+func (p *ParticipantImpl) MoveToRoom(roomName livekit.RoomName) {
+	p.lock.Lock()
+	p.grants.Video.Room = string(roomName)
+	p.dirty.Store(true)
+	onClaimsChanged := p.onClaimsChanged
+	p.lock.Unlock()
+
+	p.SubscriptionManager.UnsubscribeFromAll()
+
+	if onClaimsChanged != nil {
+		onClaimsChanged(p)
+	}
+}
+
 func (p *ParticipantImpl) CanSkipBroadcast() bool {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
@@ -612,8 +652,17 @@ func (p *ParticipantImpl) removeMutedTrackNotFired(mt *MediaTrack) {
 }
 
 // AddTrack is called when client intends to publish track.
-// records track details and lets client know it's ok to proceed
+// records track details and lets client know it's ok to proceed.
+// AdmitTrack runs synchronously on the signal goroutine of the participant, it calls RejectTrack when the track is refused.
 func (p *ParticipantImpl) AddTrack(req *livekit.AddTrackRequest) {
+	// only ask about tracks the participant is allowed to publish
+	if p.params.AdmitTrack != nil && p.CanPublishSource(req.Source) {
+		if err := p.params.AdmitTrack(p, req); err != nil {
+			p.params.Logger.Warnw("track not admitted", err, "cid", req.Cid)
+			return
+		}
+	}
+
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
@@ -630,6 +679,20 @@ func (p *ParticipantImpl) AddTrack(req *livekit.AddTrackRequest) {
 	p.sendTrackPublished(req.Cid, ti)
 }
 
+// RejectTrack ends a pending publication that was refused. Clients are sent a TrackUnpublished with the cid of the track
+// in place of its sid, and the reason as a JSON encoded TrackRejection on TrackRejectedTopic.
+func (p *ParticipantImpl) RejectTrack(cid string, reason string) {
+	p.params.Logger.Infow("rejecting track", "cid", cid, "reason", reason)
+	p.sendTrackUnpublished(livekit.TrackID(cid))
+
+	dp, dpData, err := newJSONDataPacket(TrackRejectedTopic, &TrackRejection{Cid: cid, Reason: reason})
+	if err != nil {
+		p.params.Logger.Errorw("failed to marshal track rejection", err)
+		return
+	}
+	_ = p.SendDataPacket(dp, dpData)
+}
+
 func (p *ParticipantImpl) SetMigrateInfo(
 	previousOffer, previousAnswer *webrtc.SessionDescription,
 	mediaTracks []*livekit.TrackPublishedResponse,
@@ -677,6 +740,7 @@ func (p *ParticipantImpl) Close(sendLeave bool, reason types.ParticipantCloseRea
 	}
 
 	p.supervisor.Stop()
+	p.uplinkFeedback.Stop()
 
 	p.pendingTracksLock.Lock()
 	p.pendingTracks = make(map[string]*pendingTrackInfo)
@@ -861,22 +925,29 @@ func (p *ParticipantImpl) GetConnectionQuality() *livekit.ConnectionQualityInfo
 	numTracks := 0
 	minQuality := livekit.ConnectionQuality_EXCELLENT
 	minScore := float32(0.0)
+	minLimit := connectionquality.QualityFactor("")
 	numUpDrops := 0
 	numDownDrops := 0
 
 	availableTracks := make(map[livekit.TrackID]bool)
+	var tracks []*types.TrackConnectionQuality
 
 	for _, pt := range p.GetPublishedTracks() {
 		numTracks++
 
-		score, quality := pt.(types.LocalMediaTrack).GetConnectionScoreAndQuality()
+		lmt := pt.(types.LocalMediaTrack)
+		score, quality := lmt.GetConnectionScoreAndQuality()
+		breakdown := lmt.GetConnectionScoreBreakdown()
 		if quality < minQuality {
 			// WARNING NOTE: comparing protobuf enums directly
 			minQuality = quality
 			minScore = score
+			minLimit = breakdown.Limit()
 		} else if quality == minQuality && score < minScore {
 			minScore = score
+			minLimit = breakdown.Limit()
 		}
+		tracks = append(tracks, toTrackConnectionQuality(pt.ID(), livekit.StreamType_UPSTREAM, score, quality, breakdown))
 
 		p.lock.Lock()
 		trackID := pt.ID()
@@ -897,13 +968,17 @@ func (p *ParticipantImpl) GetConnectionQuality() *livekit.ConnectionQualityInfo
 		numTracks++
 
 		score, quality := subTrack.DownTrack().GetConnectionScoreAndQuality()
+		breakdown := subTrack.DownTrack().GetConnectionScoreBreakdown()
 		if quality < minQuality {
 			// WARNING NOTE: comparing protobuf enums directly
 			minQuality = quality
 			minScore = score
+			minLimit = breakdown.Limit()
 		} else if quality == minQuality && score < minScore {
 			minScore = score
+			minLimit = breakdown.Limit()
 		}
+		tracks = append(tracks, toTrackConnectionQuality(subTrack.ID(), livekit.StreamType_DOWNSTREAM, score, quality, breakdown))
 
 		p.lock.Lock()
 		trackID := subTrack.ID()
@@ -933,6 +1008,14 @@ func (p *ParticipantImpl) GetConnectionQuality() *livekit.ConnectionQualityInfo
 			delete(p.tracksQuality, trackID)
 		}
 	}
+	p.connectionQualityDetails = &types.ConnectionQualityDetails{
+		ParticipantSid: string(p.ID()),
+		Identity:       string(p.Identity()),
+		Quality:        strings.ToLower(minQuality.String()),
+		Score:          minScore,
+		Limit:          minLimit,
+		Tracks:         tracks,
+	}
 	p.lock.Unlock()
 
 	return &livekit.ConnectionQualityInfo{
@@ -942,6 +1025,30 @@ func (p *ParticipantImpl) GetConnectionQuality() *livekit.ConnectionQualityInfo
 	}
 }
 
+func (p *ParticipantImpl) GetConnectionQualityDetails() *types.ConnectionQualityDetails {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.connectionQualityDetails
+}
+
+func toTrackConnectionQuality(
+	trackID livekit.TrackID,
+	direction livekit.StreamType,
+	score float32,
+	quality livekit.ConnectionQuality,
+	breakdown connectionquality.ScoreBreakdown,
+) *types.TrackConnectionQuality {
+	return &types.TrackConnectionQuality{
+		TrackSid:    string(trackID),
+		Direction:   strings.ToLower(direction.String()),
+		Quality:     strings.ToLower(quality.String()),
+		Score:       score,
+		Limit:       breakdown.Limit(),
+		Impairments: breakdown.Impairments,
+	}
+}
+
 func (p *ParticipantImpl) IsPublisher() bool {
 	return p.isPublisher.Load()
 }
@@ -1001,6 +1108,9 @@ func (p *ParticipantImpl) onTrackSubscribed(subTrack types.SubscribedTrack) {
 	if p.params.ClientInfo.FireTrackByRTPPacket() {
 		subTrack.DownTrack().SetActivePaddingOnMuteUpTrack()
 	}
+	if imp := p.newDownTrackImpairment(); imp != nil {
+		subTrack.DownTrack().SetImpairment(imp)
+	}
 
 	subTrack.AddOnBind(func() {
 		if p.TransportManager.HasSubscriberEverConnected() {
@@ -1010,6 +1120,34 @@ func (p *ParticipantImpl) onTrackSubscribed(subTrack types.SubscribedTrack) {
 	})
 }
 
+// SetDownTrackImpairment simulates network conditions on media sent to the participant,
+// applies to current and future subscriptions, nil removes the impairment
+func (p *ParticipantImpl) SetDownTrackImpairment(config *impairment.Config) {
+	p.lock.Lock()
+	p.downTrackImpairment = config
+	p.downTrackImpairmentSeed = 0
+	p.lock.Unlock()
+
+	for _, subTrack := range p.SubscriptionManager.GetSubscribedTracks() {
+		if dt := subTrack.DownTrack(); dt != nil {
+			dt.SetImpairment(p.newDownTrackImpairment())
+		}
+	}
+}
+
+func (p *ParticipantImpl) newDownTrackImpairment() *impairment.Impairment {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.downTrackImpairment == nil {
+		return nil
+	}
+	config := *p.downTrackImpairment
+	config.Seed += p.downTrackImpairmentSeed
+	p.downTrackImpairmentSeed++
+	return impairment.New(config)
+}
+
 // onTrackUnsubscribed handles post-processing after a track is unsubscribed
 func (p *ParticipantImpl) onTrackUnsubscribed(subTrack types.SubscribedTrack) {
 	p.TransportManager.RemoveSubscribedTrack(subTrack)
@@ -1110,6 +1248,7 @@ func (p *ParticipantImpl) setupTransportManager() error {
 
 	tm.OnPrimaryTransportInitialConnected(p.onPrimaryTransportInitialConnected)
 	tm.OnPrimaryTransportFullyEstablished(p.onPrimaryTransportFullyEstablished)
+	tm.OnPrimaryTransportICEConnected(p.onPrimaryTransportICEConnected)
 	tm.OnAnyTransportFailed(p.onAnyTransportFailed)
 	tm.OnAnyTransportNegotiationFailed(p.onAnyTransportNegotiationFailed)
 
@@ -1141,6 +1280,58 @@ func (p *ParticipantImpl) setupUpTrackManager() {
 	p.UpTrackManager.OnUpTrackManagerClose(p.onUpTrackManagerClose)
 }
 
+func (p *ParticipantImpl) setupUplinkFeedback() {
+	p.uplinkFeedback = NewUplinkFeedback(UplinkFeedbackParams{
+		MaxPublishBitrate: p.params.MaxPublishBitrate,
+		GetTracks:         p.getUplinkFeedbackTracks,
+		GetAudioBitrate:   p.getPublishedAudioBitrate,
+		WriteRTCP:         p.postRtcp,
+		Logger:            p.params.Logger,
+	})
+}
+
+func (p *ParticipantImpl) getUplinkFeedbackTracks() []UplinkFeedbackTrack {
+	var tracks []UplinkFeedbackTrack
+	for _, track := range p.UpTrackManager.GetPublishedTracks() {
+		if mt, ok := track.(*MediaTrack); ok && mt.Kind() == livekit.TrackType_VIDEO {
+			tracks = append(tracks, mt)
+		}
+	}
+	return tracks
+}
+
+// updateMaxPublishBitrates splits the participant's bitrate limit evenly between its published video tracks
+func (p *ParticipantImpl) updateMaxPublishBitrates() {
+	if p.params.MaxPublishBitrate == 0 {
+		return
+	}
+
+	var tracks []*MediaTrack
+	for _, track := range p.UpTrackManager.GetPublishedTracks() {
+		if mt, ok := track.(*MediaTrack); ok && mt.Kind() == livekit.TrackType_VIDEO {
+			tracks = append(tracks, mt)
+		}
+	}
+	for _, mt := range tracks {
+		mt.SetMaxPublishBitrate(p.params.MaxPublishBitrate / uint64(len(tracks)))
+	}
+}
+
+func (p *ParticipantImpl) getPublishedAudioBitrate() int64 {
+	bitrate := int64(0)
+	for _, track := range p.UpTrackManager.GetPublishedTracks() {
+		if track.Kind() != livekit.TrackType_AUDIO || track.IsMuted() {
+			continue
+		}
+		if mt, ok := track.(*MediaTrack); ok {
+			if stats := mt.GetTrackStats(); stats != nil {
+				bitrate += int64(stats.Bitrate)
+			}
+		}
+	}
+	return bitrate
+}
+
 func (p *ParticipantImpl) setupSubscriptionManager() {
 	p.SubscriptionManager = NewSubscriptionManager(SubscriptionManagerParams{
 		Participant:            p,
@@ -1280,6 +1471,10 @@ func (p *ParticipantImpl) onDataMessage(kind livekit.DataPacket_Kind, data []byt
 	// only forward on user payloads
 	switch payload := dp.Value.(type) {
 	case *livekit.DataPacket_User:
+		if topic := payload.User.GetTopic(); p.isReservedTopic(topic) {
+			p.params.Logger.Debugw("dropping data packet on reserved topic", "topic", topic)
+			break
+		}
 		p.lock.RLock()
 		onDataPacket := p.onDataPacket
 		p.lock.RUnlock()
@@ -1296,6 +1491,18 @@ func (p *ParticipantImpl) onDataMessage(kind livekit.DataPacket_Kind, data []byt
 	}
 }
 
+// reserved topics are published on by the server, except for lobby requests of room admins
+func (p *ParticipantImpl) isReservedTopic(topic string) bool {
+	switch topic {
+	case DTMFTopic, QualityAlertTopic, ConnectionQualityTopic, TrackRejectedTopic:
+		return true
+	case LobbyTopic:
+		return !isRoomAdmin(p)
+	default:
+		return false
+	}
+}
+
 func (p *ParticipantImpl) onICECandidate(c *webrtc.ICECandidate, target livekit.SignalTarget) error {
 	if c == nil || p.IsDisconnected() {
 		return nil
@@ -1311,6 +1518,7 @@ func (p *ParticipantImpl) onICECandidate(c *webrtc.ICECandidate, target livekit.
 func (p *ParticipantImpl) onPublisherInitialConnected() {
 	p.supervisor.SetPublisherPeerConnectionConnected(true)
 	go p.publisherRTCPWorker()
+	p.uplinkFeedback.Start()
 }
 
 func (p *ParticipantImpl) onSubscriberInitialConnected() {
@@ -1329,6 +1537,14 @@ func (p *ParticipantImpl) onPrimaryTransportFullyEstablished() {
 	p.updateState(livekit.ParticipantInfo_ACTIVE)
 }
 
+func (p *ParticipantImpl) onPrimaryTransportICEConnected() {
+	// connection type of the initial connection is reported when the participant becomes active
+	if p.State() != livekit.ParticipantInfo_ACTIVE {
+		return
+	}
+	p.params.Telemetry.ParticipantICEReconnected(context.Background(), p.ID(), string(p.GetICEConnectionType()))
+}
+
 func (p *ParticipantImpl) clearDisconnectTimer() {
 	p.lock.Lock()
 	if p.disconnectTimer != nil {
@@ -1660,6 +1876,11 @@ func (p *ParticipantImpl) mediaTrackReceived(track *webrtc.TrackRemote, rtpRecei
 		}
 
 		ti.MimeType = track.Codec().MimeType
+		if strings.EqualFold(ti.MimeType, sfu.MimeTypeMultiOpus) {
+			// multichannel is flagged as stereo for clients unaware of multiopus,
+			// the channel count is reported in the mime type of MediaTrack.ToProto
+			ti.Stereo = true
+		}
 		mt = p.addMediaTrack(signalCid, track.ID(), ti)
 		newTrack = true
 		p.dirty.Store(true)
@@ -1737,13 +1958,20 @@ func (p *ParticipantImpl) addMediaTrack(signalCid string, sdpCid string, ti *liv
 		SubscriberConfig:    p.params.Config.Subscriber,
 		PLIThrottleConfig:   p.params.PLIThrottleConfig,
 		SimTracks:           p.params.SimTracks,
+		MaxPublishBitrate:   p.params.MaxPublishBitrate,
 	})
 
 	mt.OnSubscribedMaxQualityChange(p.onSubscribedMaxQualityChange)
+	if ti.Type == livekit.TrackType_AUDIO {
+		mt.OnDTMF(p.onReceivedDTMF)
+	} else {
+		mt.OnTMMBN(p.uplinkFeedback.HandleTMMBN)
+	}
 
 	// add to published and clean up pending
 	p.supervisor.SetPublishedTrack(livekit.TrackID(ti.Sid), mt)
 	p.UpTrackManager.AddPublishedTrack(mt)
+	p.updateMaxPublishBitrates()
 
 	pti := p.pendingTracks[signalCid]
 	if pti != nil {
@@ -1764,6 +1992,7 @@ func (p *ParticipantImpl) addMediaTrack(signalCid string, sdpCid string, ti *liv
 	trackID := livekit.TrackID(ti.Sid)
 	mt.AddOnClose(func() {
 		p.supervisor.ClearPublishedTrack(trackID, mt)
+		p.updateMaxPublishBitrates()
 
 		// not logged when closing
 		p.params.Telemetry.TrackUnpublished(
@@ -1,6 +1,8 @@
 package rtc
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -22,6 +24,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/testutils"
 )
 
@@ -98,6 +101,24 @@ func TestTrackPublishing(t *testing.T) {
 		require.Equal(t, uint32(768), published.Track.Height)
 	})
 
+	t.Run("sends back trackUnpublished when the track is not admitted", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+		p.params.AdmitTrack = func(lp types.LocalParticipant, req *livekit.AddTrackRequest) error {
+			lp.RejectTrack(req.Cid, "not allowed")
+			return errors.New("not allowed")
+		}
+		p.AddTrack(&livekit.AddTrackRequest{
+			Cid:  "cid",
+			Name: "webcam",
+			Type: livekit.TrackType_VIDEO,
+		})
+		require.Equal(t, 1, sink.WriteMessageCallCount())
+		res := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse)
+		require.Equal(t, "cid", res.GetTrackUnpublished().GetTrackSid())
+		require.Empty(t, p.pendingTracks)
+	})
+
 	t.Run("should not allow adding of duplicate tracks", func(t *testing.T) {
 		p := newParticipantForTest("test")
 		sink := p.params.Sink.(*routingfakes.FakeMessageSink)
@@ -204,6 +225,32 @@ func TestTrackPublishing(t *testing.T) {
 	})
 }
 
+func TestMaxPublishBitrateSplit(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.params.MaxPublishBitrate = 3_000_000
+
+	publish := func(cid string, trackType livekit.TrackType) *MediaTrack {
+		p.AddTrack(&livekit.AddTrackRequest{Cid: cid, Type: trackType})
+		p.pendingTracksLock.Lock()
+		defer p.pendingTracksLock.Unlock()
+		return p.addMediaTrack(cid, cid, p.pendingTracks[cid].trackInfos[0])
+	}
+
+	camera := publish("camera", livekit.TrackType_VIDEO)
+	require.Equal(t, uint64(3_000_000), camera.maxPublishBitrate.Load())
+
+	// the limit is split between video tracks only
+	mic := publish("mic", livekit.TrackType_AUDIO)
+	screen := publish("screen", livekit.TrackType_VIDEO)
+	require.Equal(t, uint64(1_500_000), camera.maxPublishBitrate.Load())
+	require.Equal(t, uint64(1_500_000), screen.maxPublishBitrate.Load())
+	require.Equal(t, uint64(3_000_000), mic.maxPublishBitrate.Load())
+
+	// and given back when a track is unpublished
+	screen.Close(false)
+	require.Equal(t, uint64(3_000_000), camera.maxPublishBitrate.Load())
+}
+
 func TestOutOfOrderUpdates(t *testing.T) {
 	p := newParticipantForTest("test")
 	p.updateState(livekit.ParticipantInfo_JOINED)
@@ -252,6 +299,64 @@ func TestCorrectJoinedAt(t *testing.T) {
 	require.True(t, time.Now().Unix()-info.JoinedAt <= 1)
 }
 
+func TestDTMFDataPacket(t *testing.T) {
+	p := newParticipantForTestWithOpts("test", &participantOpts{
+		permissions: &livekit.ParticipantPermission{CanPublish: true, CanPublishData: true},
+	})
+	var packets []*livekit.DataPacket
+	p.OnDataPacket(func(_ types.LocalParticipant, dp *livekit.DataPacket) {
+		packets = append(packets, dp)
+	})
+
+	p.onReceivedDTMF("TR_audio", buffer.DTMFEvent{Code: 11, Volume: 10, DurationMs: 120})
+	require.Len(t, packets, 1)
+	user := packets[0].GetUser()
+	require.Equal(t, DTMFTopic, user.GetTopic())
+	require.Equal(t, string(p.ID()), user.ParticipantSid)
+	msg := DTMFMessage{}
+	require.NoError(t, json.Unmarshal(user.Payload, &msg))
+	require.Equal(t, DTMFMessage{TrackSid: "TR_audio", Code: 11, Digit: "#", Duration: 120, Volume: 10}, msg)
+
+	// clients cannot publish on the reserved topic
+	topic := DTMFTopic
+	data, err := proto.Marshal(&livekit.DataPacket{
+		Value: &livekit.DataPacket_User{User: &livekit.UserPacket{Payload: []byte("1"), Topic: &topic}},
+	})
+	require.NoError(t, err)
+	p.onDataMessage(livekit.DataPacket_RELIABLE, data)
+	require.Len(t, packets, 1)
+}
+
+func TestReservedTopicDataPacket(t *testing.T) {
+	p := newParticipantForTestWithOpts("test", &participantOpts{
+		permissions: &livekit.ParticipantPermission{CanPublish: true, CanPublishData: true},
+	})
+	var topics []string
+	p.OnDataPacket(func(_ types.LocalParticipant, dp *livekit.DataPacket) {
+		topics = append(topics, dp.GetUser().GetTopic())
+	})
+	sendOnTopic := func(topic string) {
+		data, err := proto.Marshal(&livekit.DataPacket{
+			Value: &livekit.DataPacket_User{User: &livekit.UserPacket{Payload: []byte("{}"), Topic: &topic}},
+		})
+		require.NoError(t, err)
+		p.onDataMessage(livekit.DataPacket_RELIABLE, data)
+	}
+
+	sendOnTopic(QualityAlertTopic)
+	sendOnTopic(ConnectionQualityTopic)
+	sendOnTopic(TrackRejectedTopic)
+	sendOnTopic(LobbyTopic)
+	sendOnTopic("chat")
+	require.Equal(t, []string{"chat"}, topics)
+
+	// room admins send lobby requests
+	p.grants.Video.RoomAdmin = true
+	sendOnTopic(LobbyTopic)
+	sendOnTopic(QualityAlertTopic)
+	require.Equal(t, []string{"chat", LobbyTopic}, topics)
+}
+
 func TestMuteSetting(t *testing.T) {
 	t.Run("can set mute when track is pending", func(t *testing.T) {
 		p := newParticipantForTest("test")
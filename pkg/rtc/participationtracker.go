@@ -0,0 +1,164 @@
+package rtc
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	// pauses shorter than this do not end a speaking turn
+	participationTurnEndGrace = time.Second
+)
+
+type ParticipantParticipation struct {
+	ParticipantID livekit.ParticipantID       `json:"sid"`
+	Identity      livekit.ParticipantIdentity `json:"identity"`
+	// total time the participant was an active speaker
+	SpeakingTimeMs int64 `json:"speakingTimeMs"`
+	// time the participant spent speaking while at least one other participant was also speaking
+	OverlapTimeMs int64 `json:"overlapTimeMs"`
+	// number of speaking turns taken
+	Turns uint32 `json:"turns"`
+	// number of turns started while another participant was in the middle of a turn
+	Interruptions uint32 `json:"interruptions"`
+	// number of times another participant started a turn while this participant was in the middle of a turn
+	Interrupted uint32 `json:"interrupted"`
+}
+
+type RoomParticipation struct {
+	// time at least one participant was speaking
+	SpeakingTimeMs int64 `json:"speakingTimeMs"`
+	// time more than one participant was speaking
+	OverlapTimeMs int64                       `json:"overlapTimeMs"`
+	Participants  []*ParticipantParticipation `json:"participants"`
+}
+
+type participationState struct {
+	stats        ParticipantParticipation
+	inTurn       bool
+	lastActiveAt time.Time
+}
+
+// ParticipationTracker accumulates talk time, turns, interruptions and overlap of participants in a room
+// from the active speakers computed on every audio update
+type ParticipationTracker struct {
+	lock           sync.Mutex
+	participants   map[livekit.ParticipantID]*participationState
+	speakingTimeMs int64
+	overlapTimeMs  int64
+	lastObservedAt time.Time
+}
+
+func NewParticipationTracker() *ParticipationTracker {
+	return &ParticipationTracker{
+		participants: make(map[livekit.ParticipantID]*participationState),
+	}
+}
+
+func (t *ParticipationTracker) AddParticipant(participantID livekit.ParticipantID, identity livekit.ParticipantIdentity) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.participants[participantID]; ok {
+		return
+	}
+
+	t.participants[participantID] = &participationState{
+		stats: ParticipantParticipation{
+			ParticipantID: participantID,
+			Identity:      identity,
+		},
+	}
+}
+
+// Observe accounts the time elapsed since the previous observation to the given active speakers
+func (t *ParticipationTracker) Observe(activeSpeakers []*livekit.SpeakerInfo, at time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	elapsedMs := int64(0)
+	if !t.lastObservedAt.IsZero() {
+		elapsedMs = at.Sub(t.lastObservedAt).Milliseconds()
+	}
+	t.lastObservedAt = at
+
+	speaking := make([]*participationState, 0, len(activeSpeakers))
+	for _, speaker := range activeSpeakers {
+		if s := t.participants[livekit.ParticipantID(speaker.Sid)]; s != nil {
+			speaking = append(speaking, s)
+		}
+	}
+
+	// close turns of participants that have been quiet for long enough
+	ongoing := make([]*participationState, 0, len(t.participants))
+	for _, s := range t.participants {
+		if s.inTurn && at.Sub(s.lastActiveAt) > participationTurnEndGrace {
+			s.inTurn = false
+		}
+		if s.inTurn {
+			ongoing = append(ongoing, s)
+		}
+	}
+
+	if len(speaking) > 0 {
+		t.speakingTimeMs += elapsedMs
+	}
+	if len(speaking) > 1 {
+		t.overlapTimeMs += elapsedMs
+	}
+
+	for _, s := range speaking {
+		s.stats.SpeakingTimeMs += elapsedMs
+		if len(speaking) > 1 {
+			s.stats.OverlapTimeMs += elapsedMs
+		}
+		s.lastActiveAt = at
+
+		if s.inTurn {
+			continue
+		}
+
+		s.inTurn = true
+		s.stats.Turns++
+		for _, other := range ongoing {
+			s.stats.Interruptions++
+			other.stats.Interrupted++
+		}
+	}
+}
+
+func (t *ParticipationTracker) GetParticipantParticipation(participantID livekit.ParticipantID) *ParticipantParticipation {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s := t.participants[participantID]
+	if s == nil {
+		return nil
+	}
+
+	stats := s.stats
+	return &stats
+}
+
+func (t *ParticipationTracker) GetRoomParticipation() *RoomParticipation {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	rp := &RoomParticipation{
+		SpeakingTimeMs: t.speakingTimeMs,
+		OverlapTimeMs:  t.overlapTimeMs,
+		Participants:   make([]*ParticipantParticipation, 0, len(t.participants)),
+	}
+	for _, s := range t.participants {
+		stats := s.stats
+		rp.Participants = append(rp.Participants, &stats)
+	}
+
+	sort.Slice(rp.Participants, func(i, j int) bool {
+		return rp.Participants[i].SpeakingTimeMs > rp.Participants[j].SpeakingTimeMs
+	})
+	return rp
+}
@@ -0,0 +1,78 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestParticipationTracker(t *testing.T) {
+	speakers := func(sids ...string) []*livekit.SpeakerInfo {
+		infos := make([]*livekit.SpeakerInfo, 0, len(sids))
+		for _, sid := range sids {
+			infos = append(infos, &livekit.SpeakerInfo{Sid: sid, Active: true})
+		}
+		return infos
+	}
+
+	t.Run("accumulates speaking time and turns", func(t *testing.T) {
+		tracker := NewParticipationTracker()
+		tracker.AddParticipant("PA_a", "a")
+		tracker.AddParticipant("PA_b", "b")
+
+		now := time.Now()
+		tracker.Observe(nil, now)
+		for i := 1; i <= 5; i++ {
+			tracker.Observe(speakers("PA_a"), now.Add(time.Duration(i)*100*time.Millisecond))
+		}
+
+		// short pause does not start a new turn
+		now = now.Add(500 * time.Millisecond)
+		tracker.Observe(nil, now.Add(500*time.Millisecond))
+		tracker.Observe(speakers("PA_a"), now.Add(time.Second))
+
+		a := tracker.GetParticipantParticipation("PA_a")
+		require.Equal(t, livekit.ParticipantIdentity("a"), a.Identity)
+		require.Equal(t, int64(1000), a.SpeakingTimeMs)
+		require.Equal(t, uint32(1), a.Turns)
+		require.Zero(t, a.OverlapTimeMs)
+
+		b := tracker.GetParticipantParticipation("PA_b")
+		require.Zero(t, b.SpeakingTimeMs)
+		require.Zero(t, b.Turns)
+	})
+
+	t.Run("counts interruptions and overlap", func(t *testing.T) {
+		tracker := NewParticipationTracker()
+		tracker.AddParticipant("PA_a", "a")
+		tracker.AddParticipant("PA_b", "b")
+
+		now := time.Now()
+		tracker.Observe(speakers("PA_a"), now)
+		tracker.Observe(speakers("PA_a"), now.Add(400*time.Millisecond))
+		tracker.Observe(speakers("PA_a", "PA_b"), now.Add(800*time.Millisecond))
+		tracker.Observe(speakers("PA_a", "PA_b"), now.Add(1200*time.Millisecond))
+		tracker.Observe(speakers("PA_b"), now.Add(1600*time.Millisecond))
+
+		a := tracker.GetParticipantParticipation("PA_a")
+		require.Equal(t, uint32(1), a.Turns)
+		require.Zero(t, a.Interruptions)
+		require.Equal(t, uint32(1), a.Interrupted)
+		require.Equal(t, int64(800), a.OverlapTimeMs)
+
+		b := tracker.GetParticipantParticipation("PA_b")
+		require.Equal(t, uint32(1), b.Turns)
+		require.Equal(t, uint32(1), b.Interruptions)
+		require.Zero(t, b.Interrupted)
+		require.Equal(t, int64(1200), b.SpeakingTimeMs)
+
+		rp := tracker.GetRoomParticipation()
+		require.Equal(t, int64(1600), rp.SpeakingTimeMs)
+		require.Equal(t, int64(800), rp.OverlapTimeMs)
+		require.Len(t, rp.Participants, 2)
+		require.Equal(t, int64(1200), rp.Participants[0].SpeakingTimeMs)
+	})
+}
@@ -0,0 +1,216 @@
+package rtc
+
+import (
+	"strings"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+// QualityAlertTopic is the data packet topic room admins receive quality alerts on, as JSON encoded telemetry.QualityAlert
+const QualityAlertTopic = "lk.quality_alert"
+
+// ConnectionQualityTopic is the data packet topic participants receive the breakdown of their own connection quality on,
+// as JSON encoded types.ConnectionQualityDetails, when their quality changes
+const ConnectionQualityTopic = "lk.connection_quality"
+
+type qualityAlertRule struct {
+	config.QualityAlertRule
+	quality livekit.ConnectionQuality
+}
+
+type qualityAlertCounters struct {
+	packets     uint32
+	packetsLost uint32
+}
+
+type qualityAlertParticipantState struct {
+	// when each rule started matching, zero if it does not match
+	matchingSince []time.Time
+	raised        []bool
+
+	upTracks   map[livekit.TrackID]qualityAlertCounters
+	downTracks map[livekit.TrackID]qualityAlertCounters
+}
+
+type QualityAlerterParams struct {
+	Rules   []config.QualityAlertRule
+	Logger  logger.Logger
+	OnAlert func(p types.LocalParticipant, alert *telemetry.QualityAlert, notifyAdmins bool)
+}
+
+// QualityAlerter raises an alert when a participant's connection matches a rule for the rule's duration.
+// An alert is raised once, till the rule stops matching.
+type QualityAlerter struct {
+	params       QualityAlerterParams
+	rules        []qualityAlertRule
+	participants map[livekit.ParticipantID]*qualityAlertParticipantState
+}
+
+func NewQualityAlerter(params QualityAlerterParams) *QualityAlerter {
+	q := &QualityAlerter{
+		params:       params,
+		participants: make(map[livekit.ParticipantID]*qualityAlertParticipantState),
+	}
+
+	for _, rule := range params.Rules {
+		r := qualityAlertRule{QualityAlertRule: rule}
+		switch rule.Metric {
+		case config.QualityAlertMetricQuality:
+			quality, ok := livekit.ConnectionQuality_value[strings.ToUpper(rule.Quality)]
+			if !ok {
+				params.Logger.Warnw("ignoring quality alert rule with invalid quality", nil, "rule", rule.Name, "quality", rule.Quality)
+				continue
+			}
+			r.quality = livekit.ConnectionQuality(quality)
+
+		case config.QualityAlertMetricPublisherLoss, config.QualityAlertMetricSubscriberLoss:
+
+		default:
+			params.Logger.Warnw("ignoring quality alert rule with invalid metric", nil, "rule", rule.Name, "metric", rule.Metric)
+			continue
+		}
+		q.rules = append(q.rules, r)
+	}
+
+	return q
+}
+
+// Update evaluates rules against the current connection quality of participants
+func (q *QualityAlerter) Update(participants []types.LocalParticipant, qualities map[livekit.ParticipantID]*livekit.ConnectionQualityInfo, at time.Time) {
+	if len(q.rules) == 0 {
+		return
+	}
+
+	present := make(map[livekit.ParticipantID]bool, len(participants))
+	for _, p := range participants {
+		info, ok := qualities[p.ID()]
+		if !ok {
+			continue
+		}
+		present[p.ID()] = true
+
+		state := q.participants[p.ID()]
+		if state == nil {
+			state = &qualityAlertParticipantState{
+				matchingSince: make([]time.Time, len(q.rules)),
+				raised:        make([]bool, len(q.rules)),
+			}
+			q.participants[p.ID()] = state
+		}
+
+		upLoss, upLossOk := state.updateUpLoss(p)
+		downLoss, downLossOk := state.updateDownLoss(p)
+
+		for i, rule := range q.rules {
+			alert := &telemetry.QualityAlert{
+				Rule:           rule.Name,
+				Metric:         rule.Metric,
+				ParticipantSid: string(p.ID()),
+				Identity:       string(p.Identity()),
+			}
+
+			var matches bool
+			switch rule.Metric {
+			case config.QualityAlertMetricQuality:
+				// WARNING NOTE: comparing protobuf enums directly
+				matches = info.Quality <= rule.quality
+				alert.Quality = strings.ToLower(info.Quality.String())
+			case config.QualityAlertMetricPublisherLoss:
+				matches = upLossOk && upLoss >= rule.LossPercentage
+				alert.LossPercentage = upLoss
+			case config.QualityAlertMetricSubscriberLoss:
+				matches = downLossOk && downLoss >= rule.LossPercentage
+				alert.LossPercentage = downLoss
+			}
+
+			if !matches {
+				state.matchingSince[i] = time.Time{}
+				state.raised[i] = false
+				continue
+			}
+
+			if state.matchingSince[i].IsZero() {
+				state.matchingSince[i] = at
+			}
+			matchingFor := at.Sub(state.matchingSince[i])
+			if state.raised[i] || matchingFor < rule.Duration {
+				continue
+			}
+
+			state.raised[i] = true
+			alert.DurationMs = matchingFor.Milliseconds()
+			q.params.Logger.Infow("quality alert", "participant", p.Identity(), "pID", p.ID(), "alert", alert)
+			if q.params.OnAlert != nil {
+				q.params.OnAlert(p, alert, rule.NotifyAdmins)
+			}
+		}
+	}
+
+	for pID := range q.participants {
+		if !present[pID] {
+			delete(q.participants, pID)
+		}
+	}
+}
+
+func (s *qualityAlertParticipantState) updateUpLoss(p types.LocalParticipant) (float32, bool) {
+	stats := make(map[livekit.TrackID]*livekit.RTPStats)
+	for _, track := range p.GetPublishedTracks() {
+		if lt, ok := track.(types.LocalMediaTrack); ok {
+			stats[track.ID()] = lt.GetTrackStats()
+		}
+	}
+
+	var lossPercentage float32
+	var ok bool
+	s.upTracks, lossPercentage, ok = getLossPercentage(s.upTracks, stats)
+	return lossPercentage, ok
+}
+
+func (s *qualityAlertParticipantState) updateDownLoss(p types.LocalParticipant) (float32, bool) {
+	stats := make(map[livekit.TrackID]*livekit.RTPStats)
+	for _, track := range p.GetSubscribedTracks() {
+		if dt := track.DownTrack(); dt != nil {
+			stats[track.ID()] = dt.GetTrackStats()
+		}
+	}
+
+	var lossPercentage float32
+	var ok bool
+	s.downTracks, lossPercentage, ok = getLossPercentage(s.downTracks, stats)
+	return lossPercentage, ok
+}
+
+// getLossPercentage returns the loss since the previous counters of tracks, and the counters to use next
+func getLossPercentage(
+	prev map[livekit.TrackID]qualityAlertCounters,
+	stats map[livekit.TrackID]*livekit.RTPStats,
+) (map[livekit.TrackID]qualityAlertCounters, float32, bool) {
+	next := make(map[livekit.TrackID]qualityAlertCounters, len(stats))
+	packets := uint32(0)
+	packetsLost := uint32(0)
+	for trackID, stat := range stats {
+		if stat == nil {
+			continue
+		}
+
+		counters := qualityAlertCounters{packets: stat.Packets, packetsLost: stat.PacketsLost}
+		// counters restart when a track is republished or resubscribed
+		if p, ok := prev[trackID]; ok && counters.packets >= p.packets && counters.packetsLost >= p.packetsLost {
+			packets += counters.packets - p.packets
+			packetsLost += counters.packetsLost - p.packetsLost
+		}
+		next[trackID] = counters
+	}
+
+	if packets+packetsLost == 0 {
+		return next, 0, false
+	}
+	return next, float32(packetsLost) * 100 / float32(packets+packetsLost), true
+}
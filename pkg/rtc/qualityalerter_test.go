@@ -0,0 +1,100 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestQualityAlerter(t *testing.T) {
+	var alerts []*telemetry.QualityAlert
+	var notifyAdmins []bool
+	q := NewQualityAlerter(QualityAlerterParams{
+		Rules: []config.QualityAlertRule{
+			{
+				Name:         "poor_quality",
+				Metric:       config.QualityAlertMetricQuality,
+				Quality:      "poor",
+				Duration:     20 * time.Second,
+				NotifyAdmins: true,
+			},
+			{
+				Name:           "publisher_loss",
+				Metric:         config.QualityAlertMetricPublisherLoss,
+				LossPercentage: 10,
+				Duration:       10 * time.Second,
+			},
+			{
+				Name:   "invalid",
+				Metric: "bitrate",
+			},
+		},
+		Logger: logger.GetLogger(),
+		OnAlert: func(_ types.LocalParticipant, alert *telemetry.QualityAlert, notify bool) {
+			alerts = append(alerts, alert)
+			notifyAdmins = append(notifyAdmins, notify)
+		},
+	})
+	require.Len(t, q.rules, 2)
+
+	track := &typesfakes.FakeLocalMediaTrack{}
+	track.IDReturns("TR_1")
+	p := &typesfakes.FakeLocalParticipant{}
+	p.IDReturns("PA_1")
+	p.IdentityReturns("p1")
+	p.GetPublishedTracksReturns([]types.MediaTrack{track})
+	participants := []types.LocalParticipant{p}
+
+	packets := uint32(0)
+	packetsLost := uint32(0)
+	now := time.Now()
+	update := func(quality livekit.ConnectionQuality, received uint32, lost uint32) {
+		packets += received
+		packetsLost += lost
+		track.GetTrackStatsReturns(&livekit.RTPStats{Packets: packets, PacketsLost: packetsLost})
+		q.Update(participants, map[livekit.ParticipantID]*livekit.ConnectionQualityInfo{
+			"PA_1": {ParticipantSid: "PA_1", Quality: quality},
+		}, now)
+		now = now.Add(5 * time.Second)
+	}
+
+	// poor for less than the duration
+	for i := 0; i < 4; i++ {
+		update(livekit.ConnectionQuality_POOR, 100, 0)
+	}
+	require.Empty(t, alerts)
+	update(livekit.ConnectionQuality_GOOD, 100, 0)
+
+	// poor for long enough, raised once
+	for i := 0; i < 8; i++ {
+		update(livekit.ConnectionQuality_POOR, 100, 0)
+	}
+	require.Len(t, alerts, 1)
+	require.Equal(t, "poor_quality", alerts[0].Rule)
+	require.Equal(t, "poor", alerts[0].Quality)
+	require.Equal(t, "PA_1", alerts[0].ParticipantSid)
+	require.Equal(t, int64(20_000), alerts[0].DurationMs)
+	require.True(t, notifyAdmins[0])
+
+	// lossy for long enough
+	for i := 0; i < 4; i++ {
+		update(livekit.ConnectionQuality_GOOD, 80, 20)
+	}
+	require.Len(t, alerts, 2)
+	require.Equal(t, "publisher_loss", alerts[1].Rule)
+	require.Equal(t, float32(20), alerts[1].LossPercentage)
+	require.False(t, notifyAdmins[1])
+
+	// participant gone, state is cleaned up
+	q.Update(nil, nil, now)
+	require.Empty(t, q.participants)
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"io"
 	"math"
-	"sort"
 	"sync"
 	"time"
 
@@ -20,7 +19,10 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/audio"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/impairment"
 	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
@@ -53,12 +55,17 @@ type Room struct {
 	protoProxy *utils.ProtoProxy[*livekit.Room]
 	Logger     logger.Logger
 
-	config         WebRTCConfig
-	audioConfig    *config.AudioConfig
-	serverInfo     *livekit.ServerInfo
-	telemetry      telemetry.TelemetryService
-	egressLauncher EgressLauncher
-	trackManager   *RoomTrackManager
+	config          WebRTCConfig
+	audioConfig     *config.AudioConfig
+	serverInfo      *livekit.ServerInfo
+	telemetry       telemetry.TelemetryService
+	egressLauncher  EgressLauncher
+	trackManager    *RoomTrackManager
+	speakerDetector audio.SpeakerDetector
+	// set when speakers are decided by the detector's transitions rather than by changes of level
+	speakerTransitions bool
+	speakersChanged    atomic.Bool
+	participation      *ParticipationTracker
 
 	// map of identity -> Participant
 	participants              map[livekit.ParticipantIdentity]types.LocalParticipant
@@ -66,6 +73,18 @@ type Room struct {
 	participantRequestSources map[livekit.ParticipantIdentity]routing.MessageSource
 	bufferFactory             *buffer.FactoryOfBufferFactory
 
+	// participants waiting to be approved, by identity
+	lobbyEnabled bool
+	lobby        map[livekit.ParticipantIdentity]*lobbyEntry
+
+	// plain RTP forwarders, by ID
+	rtpForwarders map[string]*RTPForwarder
+	// socket shared by plain RTP ingests, and the number of ingests using it
+	rtpIngestMux     *rtpIngestMux
+	rtpIngestMuxRefs int
+
+	qualityAlerter *QualityAlerter
+
 	// batch update participant info for non-publishers
 	batchedUpdates   map[livekit.ParticipantIdentity]*livekit.ParticipantInfo
 	batchedUpdatesMu sync.Mutex
@@ -104,15 +123,25 @@ func NewRoom(
 		telemetry:                 telemetry,
 		egressLauncher:            egressLauncher,
 		trackManager:              NewRoomTrackManager(),
+		speakerDetector:           newSpeakerDetector(audioConfig),
+		participation:             NewParticipationTracker(),
 		serverInfo:                serverInfo,
 		participants:              make(map[livekit.ParticipantIdentity]types.LocalParticipant),
 		participantOpts:           make(map[livekit.ParticipantIdentity]*ParticipantOptions),
 		participantRequestSources: make(map[livekit.ParticipantIdentity]routing.MessageSource),
 		bufferFactory:             buffer.NewFactoryOfBufferFactory(config.Receiver.PacketBufferSize),
 		batchedUpdates:            make(map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
+		lobby:                     make(map[livekit.ParticipantIdentity]*lobbyEntry),
+		rtpForwarders:             make(map[string]*RTPForwarder),
 		closed:                    make(chan struct{}),
 	}
 	r.protoProxy = utils.NewProtoProxy[*livekit.Room](roomUpdateInterval, r.updateProto)
+	if notifier, ok := r.speakerDetector.(audio.SpeakerTransitionNotifier); ok {
+		r.speakerTransitions = true
+		notifier.OnSpeakersChanged(func() {
+			r.speakersChanged.Store(true)
+		})
+	}
 	if r.protoRoom.EmptyTimeout == 0 {
 		r.protoRoom.EmptyTimeout = DefaultEmptyTimeout
 	}
@@ -168,37 +197,53 @@ func (r *Room) GetParticipants() []types.LocalParticipant {
 	return participants
 }
 
+// GetLocalParticipants returns participants that have been admitted to the room, i. e. not waiting in the lobby
 func (r *Room) GetLocalParticipants() []types.LocalParticipant {
-	return r.GetParticipants()
+	return r.getAdmittedParticipants()
 }
 
 func (r *Room) GetActiveSpeakers() []*livekit.SpeakerInfo {
+	return r.getActiveSpeakers(r.getAudioLevels())
+}
+
+func (r *Room) getAudioLevels() []audio.SpeakerLevel {
 	participants := r.GetParticipants()
-	speakers := make([]*livekit.SpeakerInfo, 0, len(participants))
+	levels := make([]audio.SpeakerLevel, 0, len(participants))
 	for _, p := range participants {
 		level, active := p.GetAudioLevel()
-		if !active {
-			continue
-		}
-		speakers = append(speakers, &livekit.SpeakerInfo{
-			Sid:    string(p.ID()),
-			Level:  float32(level),
-			Active: active,
+		levels = append(levels, audio.SpeakerLevel{
+			ParticipantID: p.ID(),
+			Level:         level,
+			Active:        active,
 		})
 	}
+	return levels
+}
 
-	sort.Slice(speakers, func(i, j int) bool {
-		return speakers[i].Level > speakers[j].Level
-	})
-
-	// quantize to smooth out small changes
-	for _, speaker := range speakers {
-		speaker.Level = float32(math.Ceil(float64(speaker.Level*AudioLevelQuantization)) * invAudioLevelQuantization)
+func (r *Room) getActiveSpeakers(levels []audio.SpeakerLevel) []*livekit.SpeakerInfo {
+	activeSpeakers := r.speakerDetector.GetActiveSpeakers(levels)
+	speakers := make([]*livekit.SpeakerInfo, 0, len(activeSpeakers))
+	for _, speaker := range activeSpeakers {
+		speakers = append(speakers, &livekit.SpeakerInfo{
+			Sid: string(speaker.ParticipantID),
+			// quantize to smooth out small changes
+			Level:  float32(math.Ceil(speaker.Level*AudioLevelQuantization) * invAudioLevelQuantization),
+			Active: speaker.Active,
+		})
 	}
 
 	return speakers
 }
 
+// GetParticipation returns talk time statistics of all participants that have been in the room
+func (r *Room) GetParticipation() *RoomParticipation {
+	return r.participation.GetRoomParticipation()
+}
+
+func (r *Room) GetParticipantParticipation(participantID livekit.ParticipantID) *ParticipantParticipation {
+	return r.participation.GetParticipantParticipation(participantID)
+}
+
 func (r *Room) GetBufferFactory() *buffer.Factory {
 	return r.bufferFactory.CreateBufferFactory()
 }
@@ -235,6 +280,75 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
+	if err := r.canJoinLocked(participant); err != nil {
+		return err
+	}
+
+	// restrict permissions before any callbacks are registered, nobody needs to hear about it
+	var lobbyPermission *livekit.ParticipantPermission
+	if r.shouldWaitInLobbyLocked(participant) {
+		lobbyPermission = participant.ClaimGrants().Video.ToPermission()
+		participant.SetPermission(&livekit.ParticipantPermission{Hidden: true})
+	}
+
+	r.addParticipantLocked(participant, requestSource, opts)
+
+	r.Logger.Infow("new participant joined",
+		"pID", participant.ID(),
+		"participant", participant.Identity(),
+		"protocol", participant.ProtocolVersion(),
+		"options", opts)
+
+	if lobbyPermission != nil {
+		r.lobby[participant.Identity()] = &lobbyEntry{
+			participant: participant,
+			permission:  lobbyPermission,
+			joinedAt:    time.Now(),
+		}
+	}
+
+	if r.onParticipantChanged != nil {
+		r.onParticipantChanged(participant)
+	}
+
+	time.AfterFunc(time.Minute, func() {
+		state := participant.State()
+		if state == livekit.ParticipantInfo_JOINING || state == livekit.ParticipantInfo_JOINED {
+			r.RemoveParticipant(participant.Identity(), participant.ID(), types.ParticipantCloseReasonJoinTimeout)
+		}
+	})
+
+	joinResponse := r.createJoinResponseLocked(participant, iceServers)
+	if err := participant.SendJoinResponse(joinResponse); err != nil {
+		prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "error", "send_response").Add(1)
+		return err
+	}
+
+	participant.SetMigrateState(types.MigrateStateComplete)
+
+	if lobbyPermission != nil {
+		go r.onLobbyJoined(participant)
+	}
+
+	if participant.SubscriberAsPrimary() {
+		// initiates sub connection as primary
+		if participant.ProtocolVersion().SupportFastStart() {
+			go func() {
+				r.subscribeToExistingTracks(participant)
+				participant.Negotiate(true)
+			}()
+		} else {
+			participant.Negotiate(true)
+		}
+	}
+
+	prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "success", "").Add(1)
+
+	return nil
+}
+
+// checks whether participant is allowed in the room, assumes lock is already acquired
+func (r *Room) canJoinLocked(participant types.LocalParticipant) error {
 	if r.IsClosed() {
 		return ErrRoomClosed
 	}
@@ -254,7 +368,11 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 			return ErrMaxParticipantsExceeded
 		}
 	}
+	return nil
+}
 
+// registers callbacks and adds participant to the room, assumes lock is already acquired
+func (r *Room) addParticipantLocked(participant types.LocalParticipant, requestSource routing.MessageSource, opts *ParticipantOptions) {
 	if r.FirstJoinedAt() == 0 {
 		r.joinedAt.Store(time.Now().Unix())
 	}
@@ -280,6 +398,11 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 			// start the workers once connectivity is established
 			p.Start()
 
+			// admins learn about participants already waiting
+			if isRoomAdmin(p) && len(r.GetLobbyParticipants()) != 0 {
+				r.sendLobbyUpdate()
+			}
+
 			r.telemetry.ParticipantActive(context.Background(), r.ToProto(), p.ToProto(), &livekit.AnalyticsClientMeta{
 				ClientConnectTime: uint32(time.Since(p.ConnectedAt()).Milliseconds()),
 				ConnectionType:    string(p.GetICEConnectionType()),
@@ -328,11 +451,6 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 		}
 
 	})
-	r.Logger.Infow("new participant joined",
-		"pID", participant.ID(),
-		"participant", participant.Identity(),
-		"protocol", participant.ProtocolVersion(),
-		"options", opts)
 
 	if participant.IsRecorder() && !r.protoRoom.ActiveRecording {
 		r.protoRoom.ActiveRecording = true
@@ -344,41 +462,7 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 	r.participants[participant.Identity()] = participant
 	r.participantOpts[participant.Identity()] = opts
 	r.participantRequestSources[participant.Identity()] = requestSource
-
-	if r.onParticipantChanged != nil {
-		r.onParticipantChanged(participant)
-	}
-
-	time.AfterFunc(time.Minute, func() {
-		state := participant.State()
-		if state == livekit.ParticipantInfo_JOINING || state == livekit.ParticipantInfo_JOINED {
-			r.RemoveParticipant(participant.Identity(), participant.ID(), types.ParticipantCloseReasonJoinTimeout)
-		}
-	})
-
-	joinResponse := r.createJoinResponseLocked(participant, iceServers)
-	if err := participant.SendJoinResponse(joinResponse); err != nil {
-		prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "error", "send_response").Add(1)
-		return err
-	}
-
-	participant.SetMigrateState(types.MigrateStateComplete)
-
-	if participant.SubscriberAsPrimary() {
-		// initiates sub connection as primary
-		if participant.ProtocolVersion().SupportFastStart() {
-			go func() {
-				r.subscribeToExistingTracks(participant)
-				participant.Negotiate(true)
-			}()
-		} else {
-			participant.Negotiate(true)
-		}
-	}
-
-	prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "success", "").Add(1)
-
-	return nil
+	r.participation.AddParticipant(participant.ID(), participant.Identity())
 }
 
 func (r *Room) ReplaceParticipantRequestSource(identity livekit.ParticipantIdentity, reqSource routing.MessageSource) {
@@ -413,7 +497,12 @@ func (r *Room) ResumeParticipant(p types.LocalParticipant, requestSource routing
 	}
 
 	// include the local participant's info as well, since metadata could have been changed
-	updates := r.getOtherParticipantInfo("")
+	var updates []*livekit.ParticipantInfo
+	if r.IsInLobby(p.Identity()) {
+		updates = []*livekit.ParticipantInfo{p.ToProto()}
+	} else {
+		updates = r.getOtherParticipantInfo("")
+	}
 	if err := p.SendParticipantUpdate(updates); err != nil {
 		return err
 	}
@@ -424,6 +513,7 @@ func (r *Room) ResumeParticipant(p types.LocalParticipant, requestSource routing
 }
 
 func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livekit.ParticipantID, reason types.ParticipantCloseReason) {
+	wasInLobby := false
 	r.lock.Lock()
 	p, ok := r.participants[identity]
 	if ok {
@@ -436,6 +526,8 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 		delete(r.participants, identity)
 		delete(r.participantOpts, identity)
 		delete(r.participantRequestSources, identity)
+		_, wasInLobby = r.lobby[identity]
+		delete(r.lobby, identity)
 		if !p.Hidden() {
 			r.protoRoom.NumParticipants--
 		}
@@ -464,6 +556,8 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 		return
 	}
 
+	r.speakerDetector.Remove(p.ID())
+
 	// send broadcast only if it's not already closed
 	sendUpdates := !p.IsDisconnected()
 
@@ -492,6 +586,10 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 		}
 		r.broadcastParticipantState(p, broadcastOptions{skipSource: true})
 	}
+
+	if wasInLobby {
+		r.sendLobbyUpdate()
+	}
 }
 
 func (r *Room) UpdateSubscriptions(
@@ -616,6 +714,7 @@ func (r *Room) Close() {
 	close(r.closed)
 	r.lock.Unlock()
 	r.Logger.Infow("closing room")
+	r.closeRTPForwarders()
 	for _, p := range r.GetParticipants() {
 		_ = p.Close(true, types.ParticipantCloseReasonRoomClose)
 	}
@@ -731,6 +830,36 @@ func (r *Room) SimulateScenario(participant types.LocalParticipant, simulateScen
 	return nil
 }
 
+// SimulateNetworkImpairment applies network conditions to media published (upstream) or received (downstream) by the participant,
+// a nil config removes them. Upstream covers all layers of the published tracks, including layers added later,
+// downstream covers current and later subscriptions.
+// It is not a SimulateScenario as the signal protocol does not have a scenario for it.
+func (r *Room) SimulateNetworkImpairment(participant types.LocalParticipant, direction livekit.StreamType, config *impairment.Config) error {
+	if config != nil {
+		if err := config.Validate(); err != nil {
+			return err
+		}
+		r.Logger.Infow("simulating network impairment start", "participant", participant.Identity(), "direction", direction, "config", *config)
+	} else {
+		r.Logger.Infow("simulating network impairment end", "participant", participant.Identity(), "direction", direction)
+	}
+
+	switch direction {
+	case livekit.StreamType_UPSTREAM:
+		for _, track := range participant.GetPublishedTracks() {
+			for _, receiver := range track.Receivers() {
+				if wr, ok := receiver.(*sfu.WebRTCReceiver); ok {
+					wr.SetNetworkImpairment(config)
+				}
+			}
+		}
+
+	case livekit.StreamType_DOWNSTREAM:
+		participant.SetDownTrackImpairment(config)
+	}
+	return nil
+}
+
 func (r *Room) getOtherParticipantInfo(identity livekit.ParticipantIdentity) []*livekit.ParticipantInfo {
 	participants := r.GetParticipants()
 	pi := make([]*livekit.ParticipantInfo, 0, len(participants))
@@ -755,10 +884,13 @@ func (r *Room) autoSubscribe(participant types.LocalParticipant) bool {
 
 func (r *Room) createJoinResponseLocked(participant types.LocalParticipant, iceServers []*livekit.ICEServer) *livekit.JoinResponse {
 	// gather other participants and send join response
+	// participants waiting in the lobby learn about others once approved
 	otherParticipants := make([]*livekit.ParticipantInfo, 0, len(r.participants))
-	for _, p := range r.participants {
-		if p.ID() != participant.ID() && !p.Hidden() {
-			otherParticipants = append(otherParticipants, p.ToProto())
+	if _, ok := r.lobby[participant.Identity()]; !ok {
+		for _, p := range r.participants {
+			if p.ID() != participant.ID() && !p.Hidden() {
+				otherParticipants = append(otherParticipants, p.ToProto())
+			}
 		}
 	}
 
@@ -832,9 +964,13 @@ func (r *Room) onTrackPublished(participant types.LocalParticipant, track types.
 	}
 }
 
-func (r *Room) onTrackUpdated(p types.LocalParticipant, _ types.MediaTrack) {
+func (r *Room) onTrackUpdated(p types.LocalParticipant, track types.MediaTrack) {
 	// send track updates to everyone, especially if track was updated by admin
 	r.broadcastParticipantState(p, broadcastOptions{})
+	// let forwarded copies of the track catch up
+	if track != nil {
+		r.trackManager.NotifyTrackChanged(track.ID())
+	}
 	if r.onParticipantChanged != nil {
 		r.onParticipantChanged(p)
 	}
@@ -859,6 +995,10 @@ func (r *Room) onParticipantUpdate(p types.LocalParticipant) {
 }
 
 func (r *Room) onDataPacket(source types.LocalParticipant, dp *livekit.DataPacket) {
+	if source != nil && dp.GetUser().GetTopic() == LobbyTopic {
+		r.handleLobbyDataPacket(source, dp.GetUser())
+		return
+	}
 	BroadcastDataPacketForRoom(r, source, dp, r.Logger)
 }
 
@@ -913,7 +1053,7 @@ func (r *Room) sendParticipantUpdates(updates []*livekit.ParticipantInfo) {
 		return
 	}
 
-	for _, op := range r.GetParticipants() {
+	for _, op := range r.getAdmittedParticipants() {
 		err := op.SendParticipantUpdate(updates)
 		if err != nil {
 			r.Logger.Errorw("could not send update to participant", err,
@@ -934,7 +1074,7 @@ func (r *Room) sendActiveSpeakers(speakers []*livekit.SpeakerInfo) {
 	}
 
 	var dpData []byte
-	for _, p := range r.GetParticipants() {
+	for _, p := range r.getAdmittedParticipants() {
 		if p.ProtocolVersion().HandlesDataPackets() && !p.ProtocolVersion().SupportsSpeakerChanged() {
 			if dpData == nil {
 				var err error
@@ -951,7 +1091,7 @@ func (r *Room) sendActiveSpeakers(speakers []*livekit.SpeakerInfo) {
 
 // for protocol 3, send only changed updates
 func (r *Room) sendSpeakerChanges(speakers []*livekit.SpeakerInfo) {
-	for _, p := range r.GetParticipants() {
+	for _, p := range r.getAdmittedParticipants() {
 		if p.ProtocolVersion().SupportsSpeakerChanged() {
 			_ = p.SendSpeakerUpdate(speakers, false)
 		}
@@ -1013,7 +1153,8 @@ func (r *Room) updateProto() *livekit.Room {
 
 	room.NumPublishers = 0
 	room.NumParticipants = 0
-	for _, p := range r.GetParticipants() {
+	// participants waiting in the lobby have not joined yet
+	for _, p := range r.getAdmittedParticipants() {
 		if !p.IsRecorder() {
 			room.NumParticipants++
 		}
@@ -1064,7 +1205,18 @@ func (r *Room) audioUpdateWorker() {
 			return
 		}
 
-		activeSpeakers := r.GetActiveSpeakers()
+		levels := r.getAudioLevels()
+		r.speakerDetector.Observe(levels)
+
+		activeSpeakers := r.getActiveSpeakers(levels)
+		r.participation.Observe(activeSpeakers, time.Now())
+
+		// with a transition based detector, speakers are only updated when the detector reports a stable change
+		if r.speakerTransitions && !r.speakersChanged.Swap(false) {
+			time.Sleep(time.Duration(r.audioConfig.UpdateInterval) * time.Millisecond)
+			continue
+		}
+
 		changedSpeakers := make([]*livekit.SpeakerInfo, 0, len(activeSpeakers))
 		nextActiveMap := make(map[livekit.ParticipantID]*livekit.SpeakerInfo, len(activeSpeakers))
 		for _, speaker := range activeSpeakers {
@@ -1097,6 +1249,42 @@ func (r *Room) audioUpdateWorker() {
 	}
 }
 
+// SetQualityAlertRules sets rules raising alerts on degraded connections of participants
+func (r *Room) SetQualityAlertRules(rules []config.QualityAlertRule) {
+	var qualityAlerter *QualityAlerter
+	if len(rules) != 0 {
+		qualityAlerter = NewQualityAlerter(QualityAlerterParams{
+			Rules:   rules,
+			Logger:  r.Logger,
+			OnAlert: r.onQualityAlert,
+		})
+	}
+
+	r.lock.Lock()
+	r.qualityAlerter = qualityAlerter
+	r.lock.Unlock()
+}
+
+func (r *Room) onQualityAlert(p types.LocalParticipant, alert *telemetry.QualityAlert, notifyAdmins bool) {
+	r.telemetry.ParticipantQualityAlert(context.Background(), r.ToProto(), p.ToProto(), alert)
+	if notifyAdmins {
+		r.sendToAdmins(QualityAlertTopic, alert)
+	}
+}
+
+func (r *Room) sendConnectionQualityDetails(p types.LocalParticipant) {
+	details := p.GetConnectionQualityDetails()
+	if details == nil {
+		return
+	}
+	dp, dpData, err := newJSONDataPacket(ConnectionQualityTopic, details)
+	if err != nil {
+		r.Logger.Errorw("failed to marshal connection quality details", err)
+		return
+	}
+	_ = p.SendDataPacket(dp, dpData)
+}
+
 func (r *Room) connectionQualityWorker() {
 	ticker := time.NewTicker(connectionquality.UpdateInterval)
 	defer ticker.Stop()
@@ -1119,6 +1307,22 @@ func (r *Room) connectionQualityWorker() {
 			}
 		}
 
+		r.lock.RLock()
+		qualityAlerter := r.qualityAlerter
+		r.lock.RUnlock()
+		if qualityAlerter != nil {
+			qualityAlerter.Update(participants, nowConnectionInfos, time.Now())
+		}
+
+		// participants receive the breakdown of their own quality when it changes
+		for _, p := range participants {
+			prevInfo, prevOk := prevConnectionInfos[p.ID()]
+			nowInfo, nowOk := nowConnectionInfos[p.ID()]
+			if nowOk && (!prevOk || nowInfo.Quality != prevInfo.Quality) {
+				r.sendConnectionQualityDetails(p)
+			}
+		}
+
 		// send an update if there is a change
 		//   - new participant
 		//   - quality change
@@ -1242,3 +1446,16 @@ func BroadcastDataPacketForRoom(r types.Room, source types.LocalParticipant, dp
 		}
 	})
 }
+
+func newSpeakerDetector(audioConfig *config.AudioConfig) audio.SpeakerDetector {
+	switch audioConfig.SpeakerDetector {
+	case config.SpeakerDetectorTypeDominant:
+		return audio.NewDominantSpeakerDetector(audio.DominantSpeakerParams{
+			ImmediateThreshold: float64(audioConfig.DominantSpeaker.ImmediateThreshold),
+			MediumThreshold:    float64(audioConfig.DominantSpeaker.MediumThreshold),
+			LongThreshold:      float64(audioConfig.DominantSpeaker.LongThreshold),
+		})
+	default:
+		return audio.NewPercentileSpeakerDetector()
+	}
+}
@@ -1,6 +1,7 @@
 package rtc
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/livekit-server/version"
+	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/webhook"
@@ -698,6 +700,197 @@ func TestRoomUpdate(t *testing.T) {
 	})
 }
 
+func TestConnectionQualityDetails(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+	defer rm.Close()
+
+	p := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+	p.GetConnectionQualityDetailsReturns(&types.ConnectionQualityDetails{
+		ParticipantSid: string(p.ID()),
+		Quality:        "poor",
+		Score:          30,
+		Limit:          "loss",
+	})
+	rm.sendConnectionQualityDetails(p)
+
+	require.Equal(t, 1, p.SendDataPacketCallCount())
+	dp, _ := p.SendDataPacketArgsForCall(0)
+	require.Equal(t, ConnectionQualityTopic, dp.GetUser().GetTopic())
+	details := &types.ConnectionQualityDetails{}
+	require.NoError(t, json.Unmarshal(dp.GetUser().Payload, details))
+	require.Equal(t, "poor", details.Quality)
+	require.EqualValues(t, "loss", details.Limit)
+}
+
+func TestLobby(t *testing.T) {
+	newLobbyRoom := func(t *testing.T) (*Room, *typesfakes.FakeLocalParticipant) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		rm.SetLobbyEnabled(true)
+
+		admin := newMockParticipant("admin", types.CurrentProtocol, false, false)
+		admin.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true}})
+		require.NoError(t, rm.Join(admin, nil, nil, iceServersForRoom))
+		require.False(t, rm.IsInLobby(admin.Identity()))
+		admin.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		return rm, admin
+	}
+
+	newGuest := func(identity livekit.ParticipantIdentity) *typesfakes.FakeLocalParticipant {
+		guest := newMockParticipant(identity, types.CurrentProtocol, false, false)
+		guest.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{}})
+		return guest
+	}
+
+	lobbyUpdate := func(t *testing.T, p *typesfakes.FakeLocalParticipant) *LobbyUpdate {
+		require.Eventually(t, func() bool {
+			return p.SendDataPacketCallCount() > 0
+		}, time.Second, defaultDelay)
+		dp, _ := p.SendDataPacketArgsForCall(p.SendDataPacketCallCount() - 1)
+		require.Equal(t, LobbyTopic, dp.GetUser().GetTopic())
+		update := &LobbyUpdate{}
+		require.NoError(t, json.Unmarshal(dp.GetUser().Payload, update))
+		return update
+	}
+
+	t.Run("participants wait in the lobby until approved", func(t *testing.T) {
+		rm, admin := newLobbyRoom(t)
+		defer rm.Close()
+
+		guest := newGuest("guest")
+		require.NoError(t, rm.Join(guest, nil, nil, iceServersForRoom))
+		guest.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		require.True(t, rm.IsInLobby("guest"))
+		require.True(t, guest.SetPermissionArgsForCall(0).Hidden)
+		require.Empty(t, guest.SendJoinResponseArgsForCall(0).OtherParticipants)
+
+		update := lobbyUpdate(t, admin)
+		require.Len(t, update.Pending, 1)
+		require.Equal(t, "guest", update.Pending[0].Identity)
+
+		// waiting participants are not counted in the room
+		require.EqualValues(t, 2, rm.updateProto().NumParticipants)
+
+		// pending participants do not receive room data
+		other := rm.GetParticipant("p0").(*typesfakes.FakeLocalParticipant)
+		other.OnDataPacketArgsForCall(0)(other, &livekit.DataPacket{
+			Value: &livekit.DataPacket_User{User: &livekit.UserPacket{Payload: []byte("hello")}},
+		})
+		require.Zero(t, guest.SendDataPacketCallCount())
+
+		// requests from non-admins are ignored
+		approve := &livekit.DataPacket{
+			Value: &livekit.DataPacket_User{User: &livekit.UserPacket{
+				Payload: []byte(`{"action": "approve", "identity": "guest"}`),
+				Topic:   proto.String(LobbyTopic),
+			}},
+		}
+		other.OnDataPacketArgsForCall(0)(other, approve)
+		require.True(t, rm.IsInLobby("guest"))
+
+		admin.OnDataPacketArgsForCall(0)(admin, approve)
+		require.False(t, rm.IsInLobby("guest"))
+		require.Equal(t, 2, guest.SetPermissionCallCount())
+		require.False(t, guest.SetPermissionArgsForCall(1).Hidden)
+		require.Len(t, guest.SendParticipantUpdateArgsForCall(0), 2)
+		require.Empty(t, lobbyUpdate(t, admin).Pending)
+	})
+
+	t.Run("denied participants are removed", func(t *testing.T) {
+		rm, _ := newLobbyRoom(t)
+		defer rm.Close()
+
+		guest := newGuest("guest")
+		require.NoError(t, rm.Join(guest, nil, nil, iceServersForRoom))
+		require.NoError(t, rm.DenyLobbyParticipant("guest"))
+		require.Nil(t, rm.GetParticipant("guest"))
+		_, reason := guest.CloseArgsForCall(0)
+		require.Equal(t, types.ParticipantCloseReasonLobbyDenied, reason)
+		require.ErrorIs(t, rm.DenyLobbyParticipant("guest"), ErrParticipantNotInLobby)
+	})
+
+	t.Run("disabling the lobby approves everyone", func(t *testing.T) {
+		rm, _ := newLobbyRoom(t)
+		defer rm.Close()
+
+		for _, identity := range []livekit.ParticipantIdentity{"guest1", "guest2"} {
+			require.NoError(t, rm.Join(newGuest(identity), nil, nil, iceServersForRoom))
+		}
+		require.Len(t, rm.GetLobbyParticipants(), 2)
+
+		rm.SetLobbyEnabled(false)
+		require.Empty(t, rm.GetLobbyParticipants())
+		require.Len(t, rm.GetLocalParticipants(), 4)
+	})
+}
+
+func TestMoveParticipant(t *testing.T) {
+	src := newRoomWithParticipants(t, testRoomOpts{num: 2})
+	defer src.Close()
+	mover := src.GetParticipant("p0").(*typesfakes.FakeLocalParticipant)
+	moverTrack := &typesfakes.FakeMediaTrack{}
+	moverTrack.IDReturns("TR_mover")
+	mover.GetPublishedTracksReturns([]types.MediaTrack{moverTrack})
+	p1 := src.GetParticipant("p1").(*typesfakes.FakeLocalParticipant)
+
+	dst := newRoomWithParticipants(t, testRoomOpts{})
+	defer dst.Close()
+	host := newMockParticipant("host", types.CurrentProtocol, false, true)
+	require.NoError(t, dst.Join(host, nil, &ParticipantOptions{AutoSubscribe: true}, iceServersForRoom))
+	host.StateReturns(livekit.ParticipantInfo_ACTIVE)
+	hostTrack := &typesfakes.FakeMediaTrack{}
+	hostTrack.IDReturns("TR_host")
+	host.GetPublishedTracksReturns([]types.MediaTrack{hostTrack})
+
+	_, _, err := src.DetachParticipant("unknown")
+	require.ErrorIs(t, err, ErrParticipantNotInRoom)
+
+	requestSource, opts, err := src.DetachParticipant("p0")
+	require.NoError(t, err)
+	require.True(t, opts.AutoSubscribe)
+	require.Nil(t, src.GetParticipant("p0"))
+	require.Zero(t, mover.CloseCallCount())
+
+	// remaining participants let go of the mover's tracks, and the mover is told they're gone
+	require.Equal(t, 1, p1.UnsubscribeFromTrackCallCount())
+	require.Equal(t, livekit.TrackID("TR_mover"), p1.UnsubscribeFromTrackArgsForCall(0))
+	left := mover.SendParticipantUpdateArgsForCall(mover.SendParticipantUpdateCallCount() - 1)
+	require.Len(t, left, 1)
+	require.Equal(t, "p1", left[0].Identity)
+	require.Equal(t, livekit.ParticipantInfo_DISCONNECTED, left[0].State)
+
+	require.NoError(t, dst.AttachParticipant(mover, requestSource, opts))
+	require.Equal(t, mover, dst.GetParticipant("p0"))
+	require.Equal(t, 1, mover.SendRoomUpdateCallCount())
+	require.Equal(t, 1, host.SubscribeToTrackCallCount())
+	require.Equal(t, livekit.TrackID("TR_mover"), host.SubscribeToTrackArgsForCall(0))
+	require.Equal(t, livekit.TrackID("TR_host"), mover.SubscribeToTrackArgsForCall(mover.SubscribeToTrackCallCount()-1))
+
+	require.ErrorIs(t, dst.AttachParticipant(mover, requestSource, opts), ErrAlreadyJoined)
+
+	t.Run("moved participants wait in the destination's lobby", func(t *testing.T) {
+		lobbyRoom := newRoomWithParticipants(t, testRoomOpts{})
+		defer lobbyRoom.Close()
+		lobbyRoom.SetLobbyEnabled(true)
+
+		requestSource, opts, err := dst.DetachParticipant("p0")
+		require.NoError(t, err)
+		mover.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{CanPublish: proto.Bool(true)}})
+		updates := mover.SendParticipantUpdateCallCount()
+		require.NoError(t, lobbyRoom.AttachParticipant(mover, requestSource, opts))
+
+		require.True(t, lobbyRoom.IsInLobby("p0"))
+		require.True(t, mover.SetPermissionArgsForCall(mover.SetPermissionCallCount()-1).Hidden)
+		require.True(t, lobbyRoom.GetLobbyPermission("p0").CanPublish)
+		// others are not revealed until approved
+		require.Equal(t, updates, mover.SendParticipantUpdateCallCount())
+		require.Zero(t, lobbyRoom.updateProto().NumParticipants)
+
+		require.NoError(t, lobbyRoom.ApproveLobbyParticipant("p0"))
+		require.False(t, lobbyRoom.IsInLobby("p0"))
+		require.True(t, mover.SetPermissionArgsForCall(mover.SetPermissionCallCount()-1).CanPublish)
+	})
+}
+
 type testRoomOpts struct {
 	num                  int
 	numHidden            int
@@ -0,0 +1,265 @@
+package rtc
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// LobbyTopic is the data packet topic used to exchange lobby messages with room admins.
+// Admins receive a LobbyUpdate whenever the lobby changes, and send LobbyRequests to approve or deny participants.
+const LobbyTopic = "lk.lobby"
+
+const (
+	LobbyActionApprove = "approve"
+	LobbyActionDeny    = "deny"
+)
+
+type LobbyParticipant struct {
+	Sid      string `json:"sid"`
+	Identity string `json:"identity"`
+	Name     string `json:"name,omitempty"`
+	Metadata string `json:"metadata,omitempty"`
+	JoinedAt int64  `json:"joinedAt"`
+}
+
+type LobbyUpdate struct {
+	Pending []*LobbyParticipant `json:"pending"`
+}
+
+type LobbyRequest struct {
+	Action   string `json:"action"`
+	Identity string `json:"identity"`
+}
+
+type lobbyEntry struct {
+	participant types.LocalParticipant
+	// permission to restore once approved
+	permission *livekit.ParticipantPermission
+	joinedAt   time.Time
+}
+
+// SetLobbyEnabled controls whether participants joining from now on wait in the lobby.
+// Disabling the lobby approves everyone waiting in it.
+func (r *Room) SetLobbyEnabled(enabled bool) {
+	r.lock.Lock()
+	r.lobbyEnabled = enabled
+	var pending []livekit.ParticipantIdentity
+	if !enabled {
+		for identity := range r.lobby {
+			pending = append(pending, identity)
+		}
+	}
+	r.lock.Unlock()
+
+	for _, identity := range pending {
+		_ = r.ApproveLobbyParticipant(identity)
+	}
+}
+
+func (r *Room) LobbyEnabled() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.lobbyEnabled
+}
+
+func (r *Room) IsInLobby(identity livekit.ParticipantIdentity) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	_, ok := r.lobby[identity]
+	return ok
+}
+
+// GetLobbyPermission returns the permission a participant waiting in the lobby is admitted with, nil when it is not waiting
+func (r *Room) GetLobbyPermission(identity livekit.ParticipantIdentity) *livekit.ParticipantPermission {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if entry, ok := r.lobby[identity]; ok {
+		return entry.permission
+	}
+	return nil
+}
+
+// GetLobbyParticipants returns participants waiting in the lobby, in order of arrival
+func (r *Room) GetLobbyParticipants() []*LobbyParticipant {
+	r.lock.RLock()
+	entries := make([]*lobbyEntry, 0, len(r.lobby))
+	for _, e := range r.lobby {
+		entries = append(entries, e)
+	}
+	r.lock.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].joinedAt.Before(entries[j].joinedAt)
+	})
+	pending := make([]*LobbyParticipant, 0, len(entries))
+	for _, e := range entries {
+		pi := e.participant.ToProto()
+		pending = append(pending, &LobbyParticipant{
+			Sid:      pi.Sid,
+			Identity: pi.Identity,
+			Name:     pi.Name,
+			Metadata: pi.Metadata,
+			JoinedAt: e.joinedAt.Unix(),
+		})
+	}
+	return pending
+}
+
+// ApproveLobbyParticipant admits a waiting participant to the room, restoring the permissions it joined with
+func (r *Room) ApproveLobbyParticipant(identity livekit.ParticipantIdentity) error {
+	entry := r.takeLobbyEntry(identity)
+	if entry == nil {
+		return ErrParticipantNotInLobby
+	}
+
+	p := entry.participant
+	r.Logger.Infow("participant approved from lobby", "participant", identity, "pID", p.ID())
+
+	// let the participant know who is in the room before the room learns about the participant
+	if err := p.SendParticipantUpdate(r.getOtherParticipantInfo(identity)); err != nil {
+		r.Logger.Warnw("could not send participant update", err, "participant", identity)
+	}
+	p.SetPermission(entry.permission)
+
+	r.telemetry.ParticipantLobbyApproved(context.Background(), r.ToProto(), p.ToProto())
+	r.sendLobbyUpdate()
+	return nil
+}
+
+// DenyLobbyParticipant removes a waiting participant from the room
+func (r *Room) DenyLobbyParticipant(identity livekit.ParticipantIdentity) error {
+	entry := r.takeLobbyEntry(identity)
+	if entry == nil {
+		return ErrParticipantNotInLobby
+	}
+
+	p := entry.participant
+	r.Logger.Infow("participant denied from lobby", "participant", identity, "pID", p.ID())
+
+	r.telemetry.ParticipantLobbyDenied(context.Background(), r.ToProto(), p.ToProto())
+	r.RemoveParticipant(identity, p.ID(), types.ParticipantCloseReasonLobbyDenied)
+	r.sendLobbyUpdate()
+	return nil
+}
+
+func (r *Room) takeLobbyEntry(identity livekit.ParticipantIdentity) *lobbyEntry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	entry := r.lobby[identity]
+	delete(r.lobby, identity)
+	return entry
+}
+
+// admins, hidden participants and recorders never wait, assumes lock is already acquired
+func (r *Room) shouldWaitInLobbyLocked(participant types.LocalParticipant) bool {
+	if !r.lobbyEnabled || participant.Hidden() || participant.IsRecorder() {
+		return false
+	}
+	return !isRoomAdmin(participant)
+}
+
+func isRoomAdmin(participant types.LocalParticipant) bool {
+	grants := participant.ClaimGrants()
+	return grants != nil && grants.Video != nil && grants.Video.RoomAdmin
+}
+
+func (r *Room) getAdmittedParticipants() []types.LocalParticipant {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	participants := make([]types.LocalParticipant, 0, len(r.participants))
+	for identity, p := range r.participants {
+		if _, ok := r.lobby[identity]; !ok {
+			participants = append(participants, p)
+		}
+	}
+	return participants
+}
+
+func (r *Room) onLobbyJoined(participant types.LocalParticipant) {
+	r.Logger.Infow("participant waiting in lobby", "participant", participant.Identity(), "pID", participant.ID())
+	r.telemetry.ParticipantLobbyJoined(context.Background(), r.ToProto(), participant.ToProto())
+	r.sendLobbyUpdate()
+}
+
+func (r *Room) handleLobbyDataPacket(source types.LocalParticipant, up *livekit.UserPacket) {
+	if !isRoomAdmin(source) {
+		r.Logger.Warnw("lobby request from participant without admin grant", nil, "participant", source.Identity())
+		return
+	}
+
+	req := &LobbyRequest{}
+	if err := json.Unmarshal(up.Payload, req); err != nil {
+		r.Logger.Warnw("could not parse lobby request", err, "participant", source.Identity())
+		return
+	}
+
+	var err error
+	switch req.Action {
+	case LobbyActionApprove:
+		err = r.ApproveLobbyParticipant(livekit.ParticipantIdentity(req.Identity))
+	case LobbyActionDeny:
+		err = r.DenyLobbyParticipant(livekit.ParticipantIdentity(req.Identity))
+	default:
+		err = ErrInvalidLobbyAction
+	}
+	if err != nil {
+		r.Logger.Infow("could not handle lobby request", "error", err, "participant", source.Identity(), "request", req)
+	}
+}
+
+// sends pending participants to admins
+func (r *Room) sendLobbyUpdate() {
+	r.sendToAdmins(LobbyTopic, &LobbyUpdate{Pending: r.GetLobbyParticipants()})
+}
+
+// sends a JSON encoded message to active admins as a reliable data packet
+func (r *Room) sendToAdmins(topic string, message interface{}) {
+	var admins []types.LocalParticipant
+	for _, p := range r.getAdmittedParticipants() {
+		if p.State() == livekit.ParticipantInfo_ACTIVE && isRoomAdmin(p) {
+			admins = append(admins, p)
+		}
+	}
+	if len(admins) == 0 {
+		return
+	}
+
+	dp, dpData, err := newJSONDataPacket(topic, message)
+	if err != nil {
+		r.Logger.Errorw("failed to marshal admin message", err, "topic", topic)
+		return
+	}
+	for _, p := range admins {
+		_ = p.SendDataPacket(dp, dpData)
+	}
+}
+
+func newJSONDataPacket(topic string, message interface{}) (*livekit.DataPacket, []byte, error) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return nil, nil, err
+	}
+	dp := &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload: payload,
+				Topic:   &topic,
+			},
+		},
+	}
+	dpData, err := proto.Marshal(dp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dp, dpData, nil
+}
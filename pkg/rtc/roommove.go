@@ -0,0 +1,161 @@
+package rtc
+
+import (
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// DetachParticipant removes a participant from the room without closing it, so that it can be attached to another room.
+// Its published tracks are withdrawn from the room, and the participant is told that everyone else has left.
+// Moving a participant out of the lobby admits it.
+func (r *Room) DetachParticipant(identity livekit.ParticipantIdentity) (routing.MessageSource, *ParticipantOptions, error) {
+	r.lock.Lock()
+	p, ok := r.participants[identity]
+	if !ok {
+		r.lock.Unlock()
+		return nil, nil, ErrParticipantNotInRoom
+	}
+
+	requestSource := r.participantRequestSources[identity]
+	opts := r.participantOpts[identity]
+	delete(r.participants, identity)
+	delete(r.participantOpts, identity)
+	delete(r.participantRequestSources, identity)
+	entry := r.lobby[identity]
+	delete(r.lobby, identity)
+
+	immediateChange := false
+	if p.IsRecorder() {
+		activeRecording := false
+		for _, op := range r.participants {
+			if op.IsRecorder() {
+				activeRecording = true
+				break
+			}
+		}
+		if r.protoRoom.ActiveRecording != activeRecording {
+			r.protoRoom.ActiveRecording = activeRecording
+			immediateChange = true
+		}
+	}
+	r.lock.Unlock()
+	r.protoProxy.MarkDirty(immediateChange)
+
+	r.speakerDetector.Remove(p.ID())
+
+	p.OnTrackUpdated(nil)
+	p.OnTrackPublished(nil)
+	p.OnTrackUnpublished(nil)
+	p.OnStateChange(nil)
+	p.OnParticipantUpdate(nil)
+	p.OnDataPacket(nil)
+	p.OnSubscribeStatusChanged(nil)
+
+	// withdraw published tracks, subscribers remaining in the room let go of them
+	others := r.GetParticipants()
+	for _, t := range p.GetPublishedTracks() {
+		r.trackManager.RemoveTrack(t)
+		for _, op := range others {
+			op.UnsubscribeFromTrack(t.ID())
+		}
+	}
+
+	if entry != nil {
+		p.SetPermission(entry.permission)
+	}
+
+	r.leftAt.Store(time.Now().Unix())
+	r.Logger.Infow("participant moved out of room", "participant", identity, "pID", p.ID())
+
+	if !p.Hidden() {
+		pi := p.ToProto()
+		pi.State = livekit.ParticipantInfo_DISCONNECTED
+		r.sendParticipantUpdates(r.pushAndDequeueUpdates(pi, true))
+	}
+
+	left := make([]*livekit.ParticipantInfo, 0, len(others))
+	for _, op := range others {
+		if !op.Hidden() {
+			pi := op.ToProto()
+			pi.State = livekit.ParticipantInfo_DISCONNECTED
+			left = append(left, pi)
+		}
+	}
+	if len(left) != 0 {
+		if err := p.SendParticipantUpdate(left); err != nil {
+			r.Logger.Warnw("could not send participant update", err, "participant", identity)
+		}
+	}
+
+	if entry != nil {
+		r.sendLobbyUpdate()
+	}
+	return requestSource, opts, nil
+}
+
+// AttachParticipant adds a connected participant detached from another room.
+// Instead of a join response, the participant is brought up to date with room and participant updates,
+// its published tracks are made available to the room, and it subscribes to tracks already in the room.
+// As on join, the participant waits in the lobby when the room has one, its tracks are then unpublished.
+func (r *Room) AttachParticipant(participant types.LocalParticipant, requestSource routing.MessageSource, opts *ParticipantOptions) error {
+	r.lock.Lock()
+	if err := r.canJoinLocked(participant); err != nil {
+		r.lock.Unlock()
+		return err
+	}
+
+	var lobbyPermission *livekit.ParticipantPermission
+	if r.shouldWaitInLobbyLocked(participant) {
+		lobbyPermission = participant.ClaimGrants().Video.ToPermission()
+		r.lobby[participant.Identity()] = &lobbyEntry{
+			participant: participant,
+			permission:  lobbyPermission,
+			joinedAt:    time.Now(),
+		}
+	}
+	r.addParticipantLocked(participant, requestSource, opts)
+	onParticipantChanged := r.onParticipantChanged
+	r.lock.Unlock()
+
+	if lobbyPermission != nil {
+		// unlike on join, the participant's claims change handlers are already set, they may call back into the room.
+		// Being hidden, nobody hears about it.
+		participant.SetPermission(&livekit.ParticipantPermission{Hidden: true})
+	}
+
+	r.Logger.Infow("participant moved into room",
+		"pID", participant.ID(),
+		"participant", participant.Identity(),
+		"options", opts,
+		"lobby", lobbyPermission != nil)
+
+	if onParticipantChanged != nil {
+		onParticipantChanged(participant)
+	}
+
+	if err := participant.SendRoomUpdate(r.ToProto()); err != nil {
+		r.Logger.Warnw("could not send room update", err, "participant", participant.Identity())
+	}
+	if lobbyPermission != nil {
+		// participants waiting in the lobby learn about others once approved
+		go r.onLobbyJoined(participant)
+	} else {
+		if err := participant.SendParticipantUpdate(r.getOtherParticipantInfo(participant.Identity())); err != nil {
+			r.Logger.Warnw("could not send participant update", err, "participant", participant.Identity())
+		}
+		r.broadcastParticipantState(participant, broadcastOptions{skipSource: true, immediate: true})
+	}
+
+	for _, track := range participant.GetPublishedTracks() {
+		r.onTrackPublished(participant, track)
+	}
+
+	if participant.State() == livekit.ParticipantInfo_ACTIVE {
+		r.subscribeToExistingTracks(participant)
+	}
+	return nil
+}
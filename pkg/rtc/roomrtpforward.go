@@ -0,0 +1,139 @@
+package rtc
+
+import (
+	"net"
+	"sort"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type RTPForwardParams struct {
+	TrackID      livekit.TrackID
+	Address      *net.UDPAddr
+	RTCPAddress  *net.UDPAddr
+	SSRC         uint32
+	PayloadTypes map[string]webrtc.PayloadType
+	SRTP         *SRTPParams
+	VideoQuality livekit.VideoQuality
+}
+
+// StartRTPForward forwards a published track to a UDP destination as plain RTP.
+// The forwarder stops when the track is unpublished or the room closes.
+func (r *Room) StartRTPForward(params RTPForwardParams) (*RTPForwarder, error) {
+	if r.IsClosed() {
+		return nil, ErrRoomClosed
+	}
+	info := r.trackManager.GetTrackInfo(params.TrackID)
+	if info == nil {
+		return nil, ErrTrackNotFound
+	}
+	track := info.Track
+
+	f, err := NewRTPForwarder(RTPForwarderParams{
+		Track:             track,
+		Address:           params.Address,
+		RTCPAddress:       params.RTCPAddress,
+		SSRC:              params.SSRC,
+		PayloadTypes:      params.PayloadTypes,
+		SRTP:              params.SRTP,
+		VideoQuality:      params.VideoQuality,
+		ReceiverConfig:    r.config.Receiver,
+		SubscriberConfig:  r.config.Subscriber,
+		InactivityTimeout: r.config.RTPInactivityTimeout,
+		BufferFactory:     r.bufferFactory.CreateBufferFactory(),
+		Logger:            LoggerWithTrack(r.Logger, track.ID(), false),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changed := r.trackManager.GetOrCreateTrackChangeNotifier(track.ID())
+	changed.AddObserver(f.ID(), func() {
+		f.SetPublisherMuted(track.IsMuted())
+	})
+
+	f.OnClose(func(f *RTPForwarder) {
+		changed.RemoveObserver(f.ID())
+
+		r.lock.Lock()
+		delete(r.rtpForwarders, f.ID())
+		r.lock.Unlock()
+	})
+
+	r.lock.Lock()
+	r.rtpForwarders[f.ID()] = f
+	r.lock.Unlock()
+
+	if r.IsClosed() || f.IsClosed() {
+		// room closed or track went away while starting
+		f.Close()
+		return nil, ErrTrackClosed
+	}
+	return f, nil
+}
+
+func (r *Room) StopRTPForward(forwarderID string) error {
+	r.lock.RLock()
+	f := r.rtpForwarders[forwarderID]
+	r.lock.RUnlock()
+	if f == nil {
+		return ErrRTPForwarderNotFound
+	}
+
+	f.Close()
+	return nil
+}
+
+// GetRTPForwarders returns active forwarders, oldest first
+func (r *Room) GetRTPForwarders() []*RTPForwarder {
+	r.lock.RLock()
+	forwarders := make([]*RTPForwarder, 0, len(r.rtpForwarders))
+	for _, f := range r.rtpForwarders {
+		forwarders = append(forwarders, f)
+	}
+	r.lock.RUnlock()
+
+	sort.Slice(forwarders, func(i, j int) bool {
+		return forwarders[i].StartedAt().Before(forwarders[j].StartedAt())
+	})
+	return forwarders
+}
+
+func (r *Room) closeRTPForwarders() {
+	for _, f := range r.GetRTPForwarders() {
+		f.Close()
+	}
+}
+
+// acquireRTPIngestMux returns the socket RTP ingests of the room receive on, it is opened for the first ingest
+func (r *Room) acquireRTPIngestMux(portRangeStart uint16, portRangeEnd uint16) (*rtpIngestMux, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.rtpIngestMux == nil {
+		mux, err := newRTPIngestMux(portRangeStart, portRangeEnd, r.Logger)
+		if err != nil {
+			return nil, err
+		}
+		r.rtpIngestMux = mux
+	}
+	r.rtpIngestMuxRefs++
+	return r.rtpIngestMux, nil
+}
+
+// releaseRTPIngestMux closes the socket once no ingest uses it
+func (r *Room) releaseRTPIngestMux(mux *rtpIngestMux) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.rtpIngestMux != mux {
+		return
+	}
+	r.rtpIngestMuxRefs--
+	if r.rtpIngestMuxRefs == 0 {
+		mux.close()
+		r.rtpIngestMux = nil
+	}
+}
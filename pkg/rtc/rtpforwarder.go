@@ -0,0 +1,480 @@
+package rtc
+
+import (
+	"encoding/base64"
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/srtp/v2"
+	"github.com/pion/transport/v2/packetio"
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	RTPForwarderPrefix = "RF_"
+
+	rtpForwarderSenderReportInterval = time.Second
+	rtpForwarderMaxPacketSize        = 1500
+)
+
+type SRTPParams struct {
+	Profile    srtp.ProtectionProfile
+	MasterKey  []byte
+	MasterSalt []byte
+}
+
+// SRTP crypto suites by their SDES name (RFC 4568), with master key and salt lengths
+var srtpSuites = map[string]struct {
+	profile srtp.ProtectionProfile
+	keyLen  int
+	saltLen int
+}{
+	"AES_CM_128_HMAC_SHA1_80": {srtp.ProtectionProfileAes128CmHmacSha1_80, 16, 14},
+	"AES_CM_128_HMAC_SHA1_32": {srtp.ProtectionProfileAes128CmHmacSha1_32, 16, 14},
+	"AEAD_AES_128_GCM":        {srtp.ProtectionProfileAeadAes128Gcm, 16, 12},
+	"AEAD_AES_256_GCM":        {srtp.ProtectionProfileAeadAes256Gcm, 32, 12},
+}
+
+// ParseSRTPParams parses an SDES style crypto suite name and base64 encoded master key and salt
+func ParseSRTPParams(suite string, keySalt string) (*SRTPParams, error) {
+	s, ok := srtpSuites[strings.ToUpper(suite)]
+	if !ok {
+		return nil, ErrUnsupportedSRTPSuite
+	}
+	b, err := base64.StdEncoding.DecodeString(keySalt)
+	if err != nil || len(b) != s.keyLen+s.saltLen {
+		return nil, ErrInvalidSRTPKey
+	}
+	return &SRTPParams{
+		Profile:    s.profile,
+		MasterKey:  b[:s.keyLen],
+		MasterSalt: b[s.keyLen:],
+	}, nil
+}
+
+type RTPForwarderParams struct {
+	Track types.MediaTrack
+	// destination of RTP, RTCP is sent to RTCPAddress when set, otherwise multiplexed with RTP
+	Address     *net.UDPAddr
+	RTCPAddress *net.UDPAddr
+	// SSRC of forwarded packets, a random one is picked when 0
+	SSRC uint32
+	// payload type by lower case mime type, the publisher's payload type is kept for codecs not listed
+	PayloadTypes map[string]webrtc.PayloadType
+	SRTP         *SRTPParams
+	// highest quality forwarded for video tracks
+	VideoQuality     livekit.VideoQuality
+	ReceiverConfig   ReceiverConfig
+	SubscriberConfig DirectionConfig
+	// forwarding stops when the destination, once it has sent RTCP, sends none for this long, 0 to never time out
+	InactivityTimeout time.Duration
+	BufferFactory     *buffer.Factory
+	Logger            logger.Logger
+}
+
+// RTPForwarder forwards a published track as plain RTP, or SRTP, to a UDP destination.
+// Packets go through a down track, so layer selection, munging and retransmissions work as for any subscriber.
+// RTCP from the destination is read on the same socket, PLI and NACK reach the publisher.
+// Forwarding stops when the destination says goodbye with an RTCP BYE, or when a destination that sent RTCP
+// goes silent for the inactivity timeout, and a BYE is sent when it stops.
+type RTPForwarder struct {
+	params    RTPForwarderParams
+	id        string
+	conn      *net.UDPConn
+	writer    *rtpForwarderWriter
+	downTrack *sfu.DownTrack
+	receiver  sfu.TrackReceiver
+	codec     webrtc.RTPCodecParameters
+	startedAt time.Time
+
+	srtcpLock sync.Mutex
+	srtcp     *srtp.Context
+
+	// unix nano of the last RTCP packet received from the destination, 0 until one is received
+	lastRTCPAt atomic.Int64
+	closed     atomic.Bool
+	done       chan struct{}
+	onClose    func(f *RTPForwarder)
+}
+
+func NewRTPForwarder(params RTPForwarderParams) (*RTPForwarder, error) {
+	receiver := primaryReceiver(params.Track)
+	if receiver == nil {
+		return nil, ErrTrackNotAttached
+	}
+	if params.SSRC == 0 {
+		params.SSRC = rand.Uint32()
+	}
+
+	f := &RTPForwarder{
+		params:    params,
+		id:        utils.NewGuid(RTPForwarderPrefix),
+		receiver:  receiver,
+		startedAt: time.Now(),
+		done:      make(chan struct{}),
+	}
+	f.params.Logger = params.Logger.WithValues("forwarderID", f.id, "address", params.Address.String())
+
+	var err error
+	f.conn, err = net.ListenUDP("udp", &net.UDPAddr{})
+	if err != nil {
+		return nil, err
+	}
+	f.writer = &rtpForwarderWriter{
+		conn: f.conn,
+		addr: params.Address,
+	}
+	if params.SRTP != nil {
+		if f.writer.srtp, err = srtp.CreateContext(params.SRTP.MasterKey, params.SRTP.MasterSalt, params.SRTP.Profile); err != nil {
+			_ = f.conn.Close()
+			return nil, err
+		}
+		if f.srtcp, err = srtp.CreateContext(params.SRTP.MasterKey, params.SRTP.MasterSalt, params.SRTP.Profile); err != nil {
+			_ = f.conn.Close()
+			return nil, err
+		}
+	}
+
+	if err = f.start(); err != nil {
+		_ = f.conn.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RTPForwarder) start() error {
+	codec := f.receiver.Codec()
+	switch f.params.Track.Kind() {
+	case livekit.TrackType_AUDIO:
+		codec.RTCPFeedback = f.params.SubscriberConfig.RTCPFeedback.Audio
+	case livekit.TrackType_VIDEO:
+		codec.RTCPFeedback = f.params.SubscriberConfig.RTCPFeedback.Video
+	}
+
+	dt, err := sfu.NewDownTrack(
+		[]webrtc.RTPCodecParameters{codec},
+		f.receiver,
+		f.params.BufferFactory,
+		livekit.ParticipantID(f.id),
+		f.params.ReceiverConfig.PacketBufferSize,
+		false,
+		f.params.ReceiverConfig.ConnectionQuality,
+		f.params.Logger,
+	)
+	if err != nil {
+		return err
+	}
+	f.downTrack = dt
+
+	destinationCodec := codec
+	if pt, ok := f.params.PayloadTypes[strings.ToLower(codec.MimeType)]; ok {
+		destinationCodec.PayloadType = pt
+	}
+	if f.codec, err = dt.BindWriter([]webrtc.RTPCodecParameters{destinationCodec}, f.params.SSRC, f.writer); err != nil {
+		return err
+	}
+
+	dt.SetStreamAllocatorListener(&rtpForwarderAllocator{})
+	dt.OnCloseHandler(func(_ bool) {
+		go f.Close()
+	})
+	dt.PubMute(f.params.Track.IsMuted())
+	if f.params.Track.Kind() == livekit.TrackType_VIDEO {
+		// the forwarder is a subscriber of the track's dynacast, the layer is cleared when the down track closes
+		if n, ok := f.params.Track.(subscriberMaxQualityNotifier); ok {
+			dt.OnMaxLayerChanged(func(dt *sfu.DownTrack, layer int32) {
+				n.NotifySubscriberMaxQuality(
+					livekit.ParticipantID(f.id),
+					dt.Codec().MimeType,
+					buffer.SpatialLayerToVideoQuality(layer, f.params.Track.ToProto()),
+				)
+			})
+		}
+		dt.SetMaxSpatialLayer(buffer.VideoQualityToSpatialLayer(f.params.VideoQuality, f.params.Track.ToProto()))
+	}
+	if err = f.receiver.AddDownTrack(dt); err != nil {
+		dt.Close()
+		return err
+	}
+	dt.SetConnected()
+	if f.params.Track.Kind() == livekit.TrackType_VIDEO {
+		dt.AllocateOptimal(true)
+	}
+
+	go f.rtcpReadWorker()
+	go f.rtcpSendWorker()
+
+	f.params.Logger.Infow("forwarding track", "ssrc", f.params.SSRC, "payloadType", f.codec.PayloadType, "srtp", f.params.SRTP != nil)
+	return nil
+}
+
+func (f *RTPForwarder) ID() string {
+	return f.id
+}
+
+func (f *RTPForwarder) Track() types.MediaTrack {
+	return f.params.Track
+}
+
+func (f *RTPForwarder) SSRC() uint32 {
+	return f.params.SSRC
+}
+
+func (f *RTPForwarder) Codec() webrtc.RTPCodecParameters {
+	return f.codec
+}
+
+// LocalAddr is where RTCP from the destination is expected
+func (f *RTPForwarder) LocalAddr() *net.UDPAddr {
+	return f.conn.LocalAddr().(*net.UDPAddr)
+}
+
+func (f *RTPForwarder) Address() *net.UDPAddr {
+	return f.params.Address
+}
+
+func (f *RTPForwarder) StartedAt() time.Time {
+	return f.startedAt
+}
+
+// SetPublisherMuted pauses forwarding while the published track is muted
+func (f *RTPForwarder) SetPublisherMuted(muted bool) {
+	f.downTrack.PubMute(muted)
+}
+
+func (f *RTPForwarder) OnClose(fn func(f *RTPForwarder)) {
+	f.onClose = fn
+}
+
+func (f *RTPForwarder) IsClosed() bool {
+	return f.closed.Load()
+}
+
+func (f *RTPForwarder) Close() {
+	if f.closed.Swap(true) {
+		return
+	}
+	close(f.done)
+
+	f.downTrack.Close()
+	if err := f.writeRTCP([]rtcp.Packet{&rtcp.Goodbye{Sources: []uint32{f.params.SSRC}}}, f.rtcpAddress()); err != nil {
+		f.params.Logger.Debugw("could not send goodbye", "error", err)
+	}
+	_ = f.conn.Close()
+
+	f.params.Logger.Infow("stopped forwarding track")
+	if f.onClose != nil {
+		f.onClose(f)
+	}
+}
+
+func (f *RTPForwarder) rtcpSendWorker() {
+	ticker := time.NewTicker(rtpForwarderSenderReportInterval)
+	defer ticker.Stop()
+
+	addr := f.rtcpAddress()
+	for {
+		select {
+		case <-f.done:
+			return
+		case now := <-ticker.C:
+			if timeout := f.params.InactivityTimeout; timeout != 0 {
+				if last := f.lastRTCPAt.Load(); last != 0 {
+					if idle := now.Sub(time.Unix(0, last)); idle > timeout {
+						f.params.Logger.Infow("destination inactive, stopping forward", "idle", idle)
+						go f.Close()
+						return
+					}
+				}
+			}
+			sr := f.downTrack.CreateSenderReport()
+			if sr == nil {
+				continue
+			}
+			pkts := []rtcp.Packet{sr}
+			if chunks := f.downTrack.CreateSourceDescriptionChunks(); len(chunks) != 0 {
+				pkts = append(pkts, &rtcp.SourceDescription{Chunks: chunks})
+			}
+			if err := f.writeRTCP(pkts, addr); err != nil && !f.IsClosed() {
+				f.params.Logger.Debugw("could not send sender report", "error", err)
+			}
+		}
+	}
+}
+
+func (f *RTPForwarder) rtcpAddress() *net.UDPAddr {
+	if f.params.RTCPAddress != nil {
+		return f.params.RTCPAddress
+	}
+	return f.params.Address
+}
+
+func (f *RTPForwarder) writeRTCP(pkts []rtcp.Packet, addr *net.UDPAddr) error {
+	b, err := rtcp.Marshal(pkts)
+	if err != nil {
+		return err
+	}
+	if f.srtcp != nil {
+		f.srtcpLock.Lock()
+		b, err = f.srtcp.EncryptRTCP(nil, b, nil)
+		f.srtcpLock.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	_, err = f.conn.WriteToUDP(b, addr)
+	return err
+}
+
+func (f *RTPForwarder) rtcpReadWorker() {
+	rr, ok := f.params.BufferFactory.GetOrNew(packetio.RTCPBufferPacket, f.params.SSRC).(*buffer.RTCPReader)
+	if !ok || rr == nil {
+		return
+	}
+
+	b := make([]byte, rtpForwarderMaxPacketSize)
+	for {
+		n, addr, err := f.conn.ReadFromUDP(b)
+		if err != nil {
+			if !f.IsClosed() && !errors.Is(err, net.ErrClosed) {
+				f.params.Logger.Warnw("could not read rtcp", err)
+			}
+			return
+		}
+		// only the destination gives feedback, and only RTCP is expected (RFC 5761)
+		if !addr.IP.Equal(f.params.Address.IP) || n < 8 || b[1] < 192 || b[1] > 223 {
+			continue
+		}
+
+		pkt := b[:n]
+		if f.srtcp != nil {
+			f.srtcpLock.Lock()
+			pkt, err = f.srtcp.DecryptRTCP(nil, pkt, nil)
+			f.srtcpLock.Unlock()
+			if err != nil {
+				f.params.Logger.Debugw("could not decrypt rtcp", "error", err)
+				continue
+			}
+		} else {
+			pkt = append([]byte(nil), pkt...)
+		}
+		f.lastRTCPAt.Store(time.Now().UnixNano())
+		if isRTCPGoodbye(pkt) {
+			f.params.Logger.Infow("destination left")
+			go f.Close()
+			return
+		}
+		_, _ = rr.Write(pkt)
+	}
+}
+
+func isRTCPGoodbye(b []byte) bool {
+	pkts, err := rtcp.Unmarshal(b)
+	if err != nil {
+		return false
+	}
+	for _, pkt := range pkts {
+		if _, ok := pkt.(*rtcp.Goodbye); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func primaryReceiver(track types.MediaTrack) sfu.TrackReceiver {
+	for _, r := range track.Receivers() {
+		if dr, ok := r.(*DummyReceiver); ok {
+			r = dr.Receiver()
+		}
+		if r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+// -------------------------------------------------------
+
+type rtpForwarderWriter struct {
+	lock sync.Mutex
+	conn *net.UDPConn
+	addr *net.UDPAddr
+	srtp *srtp.Context
+	buf  [rtpForwarderMaxPacketSize]byte
+}
+
+func (w *rtpForwarderWriter) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	n, err := header.MarshalTo(w.buf[:])
+	if err != nil {
+		return 0, err
+	}
+	if n+len(payload) > len(w.buf) {
+		return 0, errors.New("packet too large")
+	}
+	n += copy(w.buf[n:], payload)
+	return w.writeLocked(w.buf[:n], header)
+}
+
+func (w *rtpForwarderWriter) Write(b []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.writeLocked(b, nil)
+}
+
+func (w *rtpForwarderWriter) writeLocked(b []byte, header *rtp.Header) (int, error) {
+
+	if w.srtp != nil {
+		encrypted, err := w.srtp.EncryptRTP(nil, b, header)
+		if err != nil {
+			return 0, err
+		}
+		b = encrypted
+	}
+	return w.conn.WriteToUDP(b, w.addr)
+}
+
+// -------------------------------------------------------
+
+// rtpForwarderAllocator stands in for the stream allocator, the forwarded stream is not congestion controlled,
+// it always gets the optimal layer up to the configured quality
+type rtpForwarderAllocator struct{}
+
+func (a *rtpForwarderAllocator) OnREMB(_ *sfu.DownTrack, _ *rtcp.ReceiverEstimatedMaximumBitrate) {}
+func (a *rtpForwarderAllocator) OnTransportCCFeedback(_ *sfu.DownTrack, _ *rtcp.TransportLayerCC) {}
+func (a *rtpForwarderAllocator) OnAvailableLayersChanged(dt *sfu.DownTrack)                       { a.allocate(dt) }
+func (a *rtpForwarderAllocator) OnBitrateAvailabilityChanged(dt *sfu.DownTrack)                   { a.allocate(dt) }
+func (a *rtpForwarderAllocator) OnMaxPublishedSpatialChanged(dt *sfu.DownTrack)                   { a.allocate(dt) }
+func (a *rtpForwarderAllocator) OnMaxPublishedTemporalChanged(dt *sfu.DownTrack)                  { a.allocate(dt) }
+func (a *rtpForwarderAllocator) OnSubscriptionChanged(dt *sfu.DownTrack)                          { a.allocate(dt) }
+func (a *rtpForwarderAllocator) OnSubscribedLayerChanged(dt *sfu.DownTrack, _ buffer.VideoLayer) {
+	a.allocate(dt)
+}
+func (a *rtpForwarderAllocator) OnResume(dt *sfu.DownTrack)                                    { a.allocate(dt) }
+func (a *rtpForwarderAllocator) OnPacketsSent(_ *sfu.DownTrack, _ int)                         {}
+func (a *rtpForwarderAllocator) OnNACK(_ *sfu.DownTrack, _ []sfu.NackInfo)                     {}
+func (a *rtpForwarderAllocator) OnRTCPReceiverReport(_ *sfu.DownTrack, _ rtcp.ReceptionReport) {}
+
+func (a *rtpForwarderAllocator) allocate(dt *sfu.DownTrack) {
+	if dt.Kind() == webrtc.RTPCodecTypeVideo {
+		dt.AllocateOptimal(true)
+	}
+}
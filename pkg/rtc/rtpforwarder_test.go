@@ -0,0 +1,199 @@
+package rtc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
+)
+
+func TestRTPForward(t *testing.T) {
+	room := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	defer room.Close()
+	// retransmissions need a packet history
+	room.config.Receiver.PacketBufferSize = 500
+
+	publisher, err := NewInProcessParticipant(InProcessParticipantParams{
+		Identity:  "speaker",
+		Grants:    &auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}},
+		Telemetry: &telemetryfakes.FakeTelemetryService{},
+	})
+	require.NoError(t, err)
+	require.NoError(t, publisher.Join(room))
+
+	track, err := publisher.PublishTrack(InProcessTrackParams{
+		Name:   "keynote",
+		Source: livekit.TrackSource_MICROPHONE,
+		Codec: webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+			PayloadType:        111,
+		},
+	})
+	require.NoError(t, err)
+
+	consumer, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	_, err = room.StartRTPForward(RTPForwardParams{TrackID: "TR_unknown", Address: consumer.LocalAddr().(*net.UDPAddr)})
+	require.ErrorIs(t, err, ErrTrackNotFound)
+
+	f, err := room.StartRTPForward(RTPForwardParams{
+		TrackID:      track.ID(),
+		Address:      consumer.LocalAddr().(*net.UDPAddr),
+		SSRC:         1234,
+		PayloadTypes: map[string]webrtc.PayloadType{"audio/opus": 100},
+	})
+	require.NoError(t, err)
+	require.Len(t, room.GetRTPForwarders(), 1)
+
+	readRTP := func() *rtp.Packet {
+		b := make([]byte, 1500)
+		require.NoError(t, consumer.SetReadDeadline(time.Now().Add(time.Second)))
+		for {
+			n, err := consumer.Read(b)
+			require.NoError(t, err)
+			// skip sender reports
+			if b[1] >= 192 && b[1] <= 223 {
+				continue
+			}
+			pkt := &rtp.Packet{}
+			require.NoError(t, pkt.Unmarshal(b[:n]))
+			return pkt
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, track.WriteSample(media.Sample{Data: []byte{0xf8, 0xff, 0xfe}, Duration: 20 * time.Millisecond}))
+	}
+	var first *rtp.Packet
+	for i := 0; i < 5; i++ {
+		pkt := readRTP()
+		require.Equal(t, uint32(1234), pkt.SSRC)
+		require.Equal(t, uint8(100), pkt.PayloadType)
+		if first == nil {
+			first = pkt
+		}
+	}
+
+	// NACK from the consumer is answered with a retransmission
+	nack, err := rtcp.Marshal([]rtcp.Packet{&rtcp.TransportLayerNack{
+		MediaSSRC: 1234,
+		Nacks:     []rtcp.NackPair{{PacketID: first.SequenceNumber}},
+	}})
+	require.NoError(t, err)
+	_, err = consumer.WriteToUDP(nack, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: f.LocalAddr().Port})
+	require.NoError(t, err)
+	retransmitted := readRTP()
+	require.Equal(t, first.SequenceNumber, retransmitted.SequenceNumber)
+	require.Equal(t, first.Payload, retransmitted.Payload)
+
+	// unpublishing stops forwarding
+	publisher.UnpublishTrack(track.ID())
+	require.Eventually(t, func() bool {
+		return f.IsClosed() && len(room.GetRTPForwarders()) == 0
+	}, time.Second, 10*time.Millisecond)
+	require.ErrorIs(t, room.StopRTPForward(f.ID()), ErrRTPForwarderNotFound)
+}
+
+func TestRTPForwardToIngest(t *testing.T) {
+	origin := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	defer origin.Close()
+	// forwarding needs a packet history
+	origin.config.Receiver.PacketBufferSize = 500
+	destination := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	defer destination.Close()
+
+	newParticipant := func(room *Room, identity livekit.ParticipantIdentity, params InProcessParticipantParams) *InProcessParticipant {
+		params.Identity = identity
+		params.Grants = &auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}}
+		params.Telemetry = &telemetryfakes.FakeTelemetryService{}
+		p, err := NewInProcessParticipant(params)
+		require.NoError(t, err)
+		require.NoError(t, p.Join(room))
+		return p
+	}
+
+	publisher := newParticipant(origin, "speaker", InProcessParticipantParams{})
+	track, err := publisher.PublishTrack(InProcessTrackParams{
+		Name:   "keynote",
+		Source: livekit.TrackSource_MICROPHONE,
+		Codec: webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+			PayloadType:        111,
+		},
+	})
+	require.NoError(t, err)
+
+	forwarder := newParticipant(destination, "speaker", InProcessParticipantParams{})
+	packets := atomic.NewInt32(0)
+	newParticipant(destination, "listener", InProcessParticipantParams{
+		AutoSubscribe: true,
+		OnTrackPacket: func(_ *InProcessParticipant, _ livekit.TrackID, _ *buffer.ExtPacket, _ int32) {
+			packets.Inc()
+		},
+	})
+
+	start := func(t *testing.T) (*RTPForwarder, *RTPIngest) {
+		offer, err := NewRTPForwardOffer(track, 4321)
+		require.NoError(t, err)
+		ingest, err := NewRTPIngest(RTPIngestParams{
+			Participant: forwarder,
+			SDP:         offer,
+			IP:          net.IPv4(127, 0, 0, 1),
+			TrackName:   track.Name(),
+			TrackSource: track.Source(),
+			Logger:      logger.GetLogger(),
+		})
+		require.NoError(t, err)
+		require.Equal(t, "keynote", ingest.Tracks()[0].Name())
+		require.Equal(t, livekit.TrackSource_MICROPHONE, ingest.Tracks()[0].Source())
+
+		address, err := ParseRTPIngestAddress(ingest.Answer())
+		require.NoError(t, err)
+		require.Equal(t, ingest.Port(), address.Port)
+		f, err := origin.StartRTPForward(RTPForwardParams{TrackID: track.ID(), Address: address, SSRC: 4321})
+		require.NoError(t, err)
+		return f, ingest
+	}
+
+	t.Run("media reaches subscribers and the ingest stops the forwarder", func(t *testing.T) {
+		f, ingest := start(t)
+		require.Eventually(t, func() bool {
+			require.NoError(t, track.WriteSample(media.Sample{Data: []byte{0xf8, 0xff, 0xfe}, Duration: 20 * time.Millisecond}))
+			return packets.Load() > 0
+		}, 2*time.Second, 20*time.Millisecond)
+
+		ingest.Close()
+		require.Eventually(t, f.IsClosed, time.Second, 10*time.Millisecond)
+		require.Empty(t, forwarder.GetPublishedTracks())
+	})
+
+	t.Run("the forwarder stops the ingest", func(t *testing.T) {
+		f, ingest := start(t)
+		// the ingest latches on to the forwarder with the first packet
+		require.Eventually(t, func() bool {
+			require.NoError(t, track.WriteSample(media.Sample{Data: []byte{0xf8, 0xff, 0xfe}, Duration: 20 * time.Millisecond}))
+			return ingest.media[0].ssrc.Load() == f.SSRC()
+		}, 2*time.Second, 20*time.Millisecond)
+
+		require.NoError(t, origin.StopRTPForward(f.ID()))
+		require.Eventually(t, ingest.IsClosed, time.Second, 10*time.Millisecond)
+		require.Eventually(t, func() bool {
+			return len(forwarder.GetPublishedTracks()) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,750 @@
+package rtc
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	mrand "math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/srtp/v2"
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const rtpIngestReceiverReportInterval = time.Second
+
+// codecs accepted by RTP ingest, media is not transcoded
+var rtpIngestCodecs = []webrtc.RTPCodecCapability{
+	{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+	{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+	{MimeType: webrtc.MimeTypeH264, ClockRate: 90000},
+}
+
+type RTPIngestParams struct {
+	// participant publishing the ingested media, it should have joined a room
+	Participant *InProcessParticipant
+	// SDP offer describing the RTP streams
+	SDP string
+	// address advertised in the answer, and the range of ports to listen on on all interfaces, when the room
+	// has no ingest yet. An ephemeral port is used when the range is not set
+	IP             net.IP
+	PortRangeStart uint16
+	PortRangeEnd   uint16
+	// name and source of the published tracks, by default tracks are named after their mid,
+	// and audio is published as microphone and video as camera
+	TrackName   string
+	TrackSource livekit.TrackSource
+	// the ingest is closed when no RTP or RTCP is received from senders for this long, 0 to never time out
+	InactivityTimeout time.Duration
+	Logger            logger.Logger
+}
+
+// RTPIngest receives RTP, or SRTP, streams described by an SDP offer on a local UDP port, and publishes them
+// as tracks of an in-process participant. The port is shared by the ingests of the participant's room, see
+// rtpIngestMux. Within an ingest, streams are demultiplexed by payload type, so all media sections can be sent
+// from and to the same port. Media is only accepted from the offered address, and from the first source it is
+// received from. Key frame requests are sent back as RTCP PLI.
+// Receiver reports are sent to senders every second, which also lets them know the ingest is alive.
+// The ingest is closed once all of its tracks are unpublished, when a sender says goodbye with an RTCP BYE,
+// or when senders go silent for the inactivity timeout, and says goodbye to senders in turn.
+type RTPIngest struct {
+	params RTPIngestParams
+	room   *Room
+	mux    *rtpIngestMux
+	answer string
+	media  []*rtpIngestMedia
+
+	openTracks atomic.Int32
+	// unix nano of the last RTP or RTCP packet accepted from a sender
+	lastReceivedAt atomic.Int64
+	closed         atomic.Bool
+	done           chan struct{}
+	onClose        func(ingest *RTPIngest)
+}
+
+type rtpIngestMedia struct {
+	kind     livekit.TrackType
+	mid      string
+	codec    webrtc.RTPCodecParameters
+	rtcpMux  bool
+	rtcpPort int
+	track    *InProcessTrack
+	// SSRC declared with a=ssrc, 0 when not declared
+	offeredSSRC uint32
+
+	// SRTP using the offered key to decrypt, and the answered key to encrypt RTCP
+	srtp         *srtp.Context
+	srtcp        *srtp.Context
+	answerCrypto string
+
+	// address of the offer, any address when unspecified
+	remoteIP net.IP
+	// where media is received from, latched on the first packet
+	source *net.UDPAddr
+
+	// RTCP is sent to where media comes from once received
+	rtcpLock   sync.Mutex
+	remoteRTCP *net.UDPAddr
+	ssrc       atomic.Uint32
+	senderSSRC uint32
+}
+
+func NewRTPIngest(params RTPIngestParams) (*RTPIngest, error) {
+	i := &RTPIngest{
+		params: params,
+		done:   make(chan struct{}),
+	}
+	offer := &sdp.SessionDescription{}
+	if err := offer.Unmarshal([]byte(params.SDP)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSDP, err)
+	}
+	if err := i.parseOffer(offer); err != nil {
+		return nil, err
+	}
+
+	i.room = params.Participant.getRoom()
+	if i.room == nil {
+		return nil, ErrParticipantNotReady
+	}
+	mux, err := i.room.acquireRTPIngestMux(params.PortRangeStart, params.PortRangeEnd)
+	if err != nil {
+		return nil, err
+	}
+	i.mux = mux
+
+	if i.answer, err = i.createAnswer(offer); err != nil {
+		i.Close()
+		return nil, err
+	}
+
+	for _, m := range i.media {
+		if m == nil {
+			continue
+		}
+		source := livekit.TrackSource_MICROPHONE
+		if m.kind == livekit.TrackType_VIDEO {
+			source = livekit.TrackSource_CAMERA
+		}
+		if params.TrackSource != livekit.TrackSource_UNKNOWN {
+			source = params.TrackSource
+		}
+		name := params.TrackName
+		if name == "" {
+			name = m.mid
+		}
+		if name == "" {
+			name = strings.ToLower(m.kind.String())
+		}
+		track, err := params.Participant.PublishTrack(InProcessTrackParams{
+			Name:   name,
+			Source: source,
+			Codec:  m.codec,
+			Stereo: m.codec.Channels == 2,
+		})
+		if err != nil {
+			i.Close()
+			return nil, err
+		}
+		m.track = track
+
+		i.openTracks.Inc()
+		track.AddOnClose(func() {
+			if i.openTracks.Dec() == 0 {
+				go i.Close()
+			}
+		})
+
+		media := m
+		track.OnKeyFrameRequest(func() {
+			i.sendPLI(media)
+		})
+	}
+
+	i.lastReceivedAt.Store(time.Now().UnixNano())
+	mux.add(i)
+	go i.rtcpWorker()
+
+	params.Logger.Infow("rtp ingest started", "port", i.Port())
+	return i, nil
+}
+
+// Answer returns the SDP answer to the offer, with the local address streams should be sent to
+func (i *RTPIngest) Answer() string {
+	return i.answer
+}
+
+func (i *RTPIngest) Port() int {
+	return i.mux.Port()
+}
+
+func (i *RTPIngest) Tracks() []*InProcessTrack {
+	var tracks []*InProcessTrack
+	for _, m := range i.media {
+		if m != nil && m.track != nil {
+			tracks = append(tracks, m.track)
+		}
+	}
+	return tracks
+}
+
+func (i *RTPIngest) OnClose(f func(ingest *RTPIngest)) {
+	i.onClose = f
+}
+
+func (i *RTPIngest) IsClosed() bool {
+	return i.closed.Load()
+}
+
+// Close stops receiving, the participant is left to the caller
+func (i *RTPIngest) Close() {
+	if i.closed.Swap(true) {
+		return
+	}
+	close(i.done)
+	if i.mux != nil {
+		for _, m := range i.media {
+			if m != nil && m.ssrc.Load() != 0 {
+				i.writeRTCP(m, &rtcp.Goodbye{Sources: []uint32{m.senderSSRC}})
+			}
+		}
+		i.mux.remove(i)
+		i.room.releaseRTPIngestMux(i.mux)
+	}
+	for _, track := range i.Tracks() {
+		i.params.Participant.UnpublishTrack(track.ID())
+	}
+
+	i.params.Logger.Infow("rtp ingest stopped")
+	if i.onClose != nil {
+		i.onClose(i)
+	}
+}
+
+func (i *RTPIngest) parseOffer(offer *sdp.SessionDescription) error {
+	var sessionAddr string
+	if offer.ConnectionInformation != nil && offer.ConnectionInformation.Address != nil {
+		sessionAddr = offer.ConnectionInformation.Address.Address
+	}
+
+	accepted := 0
+	for _, md := range offer.MediaDescriptions {
+		m, err := i.parseMedia(md, sessionAddr)
+		if err != nil {
+			return err
+		}
+		i.media = append(i.media, m)
+		if m != nil {
+			accepted++
+		}
+	}
+	if accepted == 0 {
+		return ErrNoSupportedMedia
+	}
+
+	// streams are demultiplexed by payload type
+	seen := make(map[webrtc.PayloadType]bool)
+	for _, m := range i.media {
+		if m == nil {
+			continue
+		}
+		if seen[m.codec.PayloadType] {
+			return fmt.Errorf("%w: payload type %d is used by more than one media", ErrInvalidSDP, m.codec.PayloadType)
+		}
+		seen[m.codec.PayloadType] = true
+	}
+	return nil
+}
+
+// parseMedia returns nil for media that is not accepted, and an error for a malformed offer
+func (i *RTPIngest) parseMedia(md *sdp.MediaDescription, sessionAddr string) (*rtpIngestMedia, error) {
+	m := &rtpIngestMedia{
+		senderSSRC: mrand.Uint32(),
+	}
+	switch md.MediaName.Media {
+	case "audio":
+		m.kind = livekit.TrackType_AUDIO
+	case "video":
+		m.kind = livekit.TrackType_VIDEO
+	default:
+		return nil, nil
+	}
+	if md.MediaName.Port.Value == 0 {
+		return nil, nil
+	}
+	if _, ok := md.Attribute("recvonly"); ok {
+		return nil, nil
+	}
+	if _, ok := md.Attribute("inactive"); ok {
+		return nil, nil
+	}
+
+	srtpProto := false
+	for _, proto := range md.MediaName.Protos {
+		if proto == "SAVP" || proto == "SAVPF" {
+			srtpProto = true
+		}
+	}
+
+	codec, ok := selectRTPIngestCodec(md)
+	if !ok {
+		return nil, nil
+	}
+	m.codec = codec
+	m.mid, _ = md.Attribute("mid")
+	// a=ssrc:<ssrc> <attribute>[:<value>], the first one identifies the stream
+	if ssrcAttr, ok := md.Attribute("ssrc"); ok {
+		fields := strings.Fields(ssrcAttr)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%w: ssrc attribute without ssrc", ErrInvalidSDP)
+		}
+		ssrc, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil || ssrc == 0 {
+			return nil, fmt.Errorf("%w: invalid ssrc %q", ErrInvalidSDP, fields[0])
+		}
+		m.offeredSSRC = uint32(ssrc)
+	}
+
+	if srtpProto {
+		offered, ok := md.Attribute("crypto")
+		if !ok {
+			return nil, nil
+		}
+		// a=crypto:<tag> <suite> inline:<key||salt>[|lifetime][|MKI]
+		fields := strings.Fields(offered)
+		if len(fields) < 3 || !strings.HasPrefix(fields[2], "inline:") {
+			return nil, nil
+		}
+		keySalt := strings.SplitN(strings.TrimPrefix(fields[2], "inline:"), "|", 2)[0]
+		remote, err := ParseSRTPParams(fields[1], keySalt)
+		if err != nil {
+			return nil, nil
+		}
+		if m.srtp, err = srtp.CreateContext(remote.MasterKey, remote.MasterSalt, remote.Profile); err != nil {
+			return nil, nil
+		}
+
+		local := make([]byte, len(remote.MasterKey)+len(remote.MasterSalt))
+		if _, err = rand.Read(local); err != nil {
+			return nil, nil
+		}
+		if m.srtcp, err = srtp.CreateContext(local[:len(remote.MasterKey)], local[len(remote.MasterKey):], remote.Profile); err != nil {
+			return nil, nil
+		}
+		m.answerCrypto = fmt.Sprintf("%s %s inline:%s", fields[0], fields[1], base64.StdEncoding.EncodeToString(local))
+	}
+
+	addr := sessionAddr
+	if md.ConnectionInformation != nil && md.ConnectionInformation.Address != nil {
+		addr = md.ConnectionInformation.Address.Address
+	}
+	m.rtcpPort = md.MediaName.Port.Value + 1
+	if _, ok := md.Attribute("rtcp-mux"); ok {
+		m.rtcpMux = true
+		m.rtcpPort = md.MediaName.Port.Value
+	} else if rtcpAttr, ok := md.Attribute("rtcp"); ok {
+		// a=rtcp:<port> [<nettype> <addrtype> <address>]
+		fields := strings.Fields(rtcpAttr)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%w: rtcp attribute without port", ErrInvalidSDP)
+		}
+		port, err := strconv.Atoi(fields[0])
+		if err != nil || port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("%w: invalid rtcp port %q", ErrInvalidSDP, fields[0])
+		}
+		m.rtcpPort = port
+	}
+	if ip := net.ParseIP(addr); ip != nil && !ip.IsUnspecified() {
+		m.remoteIP = ip
+		m.remoteRTCP = &net.UDPAddr{IP: ip, Port: m.rtcpPort}
+	}
+	return m, nil
+}
+
+func selectRTPIngestCodec(md *sdp.MediaDescription) (webrtc.RTPCodecParameters, bool) {
+	rtpmaps := make(map[string]string)
+	fmtps := make(map[string]string)
+	for _, a := range md.Attributes {
+		fields := strings.SplitN(a.Value, " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		switch a.Key {
+		case "rtpmap":
+			rtpmaps[fields[0]] = fields[1]
+		case "fmtp":
+			fmtps[fields[0]] = fields[1]
+		}
+	}
+
+	// first supported format in order of preference of the offer
+	for _, format := range md.MediaName.Formats {
+		pt, err := strconv.ParseUint(format, 10, 7)
+		if err != nil {
+			continue
+		}
+		// encoding name/clock rate[/channels]
+		parts := strings.Split(rtpmaps[format], "/")
+		if len(parts) < 2 {
+			continue
+		}
+		clockRate, err := strconv.ParseUint(parts[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		for _, c := range rtpIngestCodecs {
+			if !strings.EqualFold(strings.TrimPrefix(c.MimeType, md.MediaName.Media+"/"), parts[0]) || uint32(clockRate) != c.ClockRate {
+				continue
+			}
+			codec := webrtc.RTPCodecParameters{
+				RTPCodecCapability: c,
+				PayloadType:        webrtc.PayloadType(pt),
+			}
+			codec.SDPFmtpLine = fmtps[format]
+			return codec, true
+		}
+	}
+	return webrtc.RTPCodecParameters{}, false
+}
+
+func (i *RTPIngest) createAnswer(offer *sdp.SessionDescription) (string, error) {
+	ip := i.params.IP
+	if ip == nil || ip.IsUnspecified() {
+		ip = net.IPv4(127, 0, 0, 1)
+	}
+	addrType := "IP4"
+	if ip.To4() == nil {
+		addrType = "IP6"
+	}
+
+	answer := &sdp.SessionDescription{
+		Origin: sdp.Origin{
+			Username:       "-",
+			SessionID:      offer.Origin.SessionID,
+			SessionVersion: offer.Origin.SessionVersion,
+			NetworkType:    "IN",
+			AddressType:    addrType,
+			UnicastAddress: ip.String(),
+		},
+		SessionName: "LiveKit",
+		ConnectionInformation: &sdp.ConnectionInformation{
+			NetworkType: "IN",
+			AddressType: addrType,
+			Address:     &sdp.Address{Address: ip.String()},
+		},
+		TimeDescriptions: []sdp.TimeDescription{{}},
+	}
+
+	for idx, md := range offer.MediaDescriptions {
+		m := i.media[idx]
+		answered := &sdp.MediaDescription{
+			MediaName: sdp.MediaName{
+				Media:   md.MediaName.Media,
+				Protos:  md.MediaName.Protos,
+				Formats: md.MediaName.Formats,
+			},
+		}
+		if m == nil {
+			// rejected
+			answer.MediaDescriptions = append(answer.MediaDescriptions, answered)
+			continue
+		}
+
+		answered.MediaName.Port = sdp.RangedPort{Value: i.Port()}
+		answered.MediaName.Formats = []string{strconv.Itoa(int(m.codec.PayloadType))}
+		if m.mid != "" {
+			answered.WithValueAttribute("mid", m.mid)
+		}
+		rtpmap := fmt.Sprintf("%d %s/%d", m.codec.PayloadType, strings.TrimPrefix(m.codec.MimeType, md.MediaName.Media+"/"), m.codec.ClockRate)
+		if m.codec.Channels > 0 {
+			rtpmap += "/" + strconv.Itoa(int(m.codec.Channels))
+		}
+		answered.WithValueAttribute("rtpmap", rtpmap)
+		if m.codec.SDPFmtpLine != "" {
+			answered.WithValueAttribute("fmtp", fmt.Sprintf("%d %s", m.codec.PayloadType, m.codec.SDPFmtpLine))
+		}
+		if m.kind == livekit.TrackType_VIDEO {
+			answered.WithValueAttribute("rtcp-fb", fmt.Sprintf("%d nack pli", m.codec.PayloadType))
+		}
+		if m.rtcpMux {
+			answered.WithPropertyAttribute("rtcp-mux")
+		}
+		if m.answerCrypto != "" {
+			answered.WithValueAttribute("crypto", m.answerCrypto)
+		}
+		answered.WithPropertyAttribute("recvonly")
+		answer.MediaDescriptions = append(answer.MediaDescriptions, answered)
+	}
+
+	b, err := answer.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// handleRTP is called by the read worker of the mux for packets from sources bound to the ingest
+func (i *RTPIngest) handleRTP(b []byte, addr *net.UDPAddr) {
+	pt := webrtc.PayloadType(b[1] & 0x7f)
+	var m *rtpIngestMedia
+	for _, candidate := range i.media {
+		if candidate != nil && candidate.codec.PayloadType == pt {
+			m = candidate
+			break
+		}
+	}
+	if m == nil || m.track == nil || !m.isFromSource(addr) {
+		return
+	}
+
+	data := b
+	if m.srtp != nil {
+		var err error
+		if data, err = m.srtp.DecryptRTP(nil, data, nil); err != nil {
+			i.params.Logger.Debugw("could not decrypt rtp", "error", err)
+			return
+		}
+	}
+	pkt := &rtp.Packet{}
+	if err := pkt.Unmarshal(data); err != nil {
+		return
+	}
+	if m.offeredSSRC != 0 && pkt.SSRC != m.offeredSSRC {
+		return
+	}
+	if m.source == nil {
+		m.latch(addr)
+	}
+	i.lastReceivedAt.Store(time.Now().UnixNano())
+	m.ssrc.Store(pkt.SSRC)
+	if err := m.track.WriteRTP(pkt); err != nil && !errors.Is(err, ErrTrackClosed) {
+		i.params.Logger.Debugw("could not write rtp", "error", err)
+	}
+}
+
+// isFromSource reports whether a packet from addr is accepted, packets of other senders are dropped
+func (m *rtpIngestMedia) isFromSource(addr *net.UDPAddr) bool {
+	if m.source != nil {
+		return m.source.IP.Equal(addr.IP) && m.source.Port == addr.Port
+	}
+	return m.remoteIP == nil || m.remoteIP.Equal(addr.IP)
+}
+
+// latch accepts media only from the source of the first valid packet, and sends RTCP to it, senders behind NAT
+// do not know their public address
+func (m *rtpIngestMedia) latch(addr *net.UDPAddr) {
+	m.source = addr
+
+	m.rtcpLock.Lock()
+	defer m.rtcpLock.Unlock()
+
+	port := m.rtcpPort
+	if m.rtcpMux {
+		port = addr.Port
+	}
+	m.remoteRTCP = &net.UDPAddr{IP: addr.IP, Port: port, Zone: addr.Zone}
+}
+
+// handleRTCP closes the ingest when a sender says goodbye, other RTCP from senders is not used
+func (i *RTPIngest) handleRTCP(b []byte, addr *net.UDPAddr) {
+	for _, m := range i.media {
+		if m == nil || m.source == nil || !m.source.IP.Equal(addr.IP) || (m.rtcpMux && m.source.Port != addr.Port) {
+			continue
+		}
+
+		data := b
+		if m.srtp != nil {
+			var err error
+			if data, err = m.srtp.DecryptRTCP(nil, b, nil); err != nil {
+				continue
+			}
+		}
+		pkts, err := rtcp.Unmarshal(data)
+		if err != nil {
+			continue
+		}
+		i.lastReceivedAt.Store(time.Now().UnixNano())
+		ssrc := m.ssrc.Load()
+		for _, pkt := range pkts {
+			bye, ok := pkt.(*rtcp.Goodbye)
+			if !ok {
+				continue
+			}
+			for _, source := range bye.Sources {
+				if source == ssrc {
+					i.params.Logger.Infow("rtp sender left", "ssrc", ssrc, "reason", bye.Reason)
+					i.Close()
+					return
+				}
+			}
+		}
+	}
+}
+
+// rtcpWorker sends receiver reports to senders and closes the ingest when they go silent
+func (i *RTPIngest) rtcpWorker() {
+	interval := rtpIngestReceiverReportInterval
+	if timeout := i.params.InactivityTimeout; timeout != 0 && timeout/2 < interval {
+		interval = timeout / 2
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-i.done:
+			return
+		case now := <-ticker.C:
+			if timeout := i.params.InactivityTimeout; timeout != 0 {
+				if idle := now.Sub(time.Unix(0, i.lastReceivedAt.Load())); idle > timeout {
+					i.params.Logger.Infow("rtp senders inactive, stopping ingest", "idle", idle)
+					go i.Close()
+					return
+				}
+			}
+			for _, m := range i.media {
+				if m != nil && m.ssrc.Load() != 0 {
+					i.writeRTCP(m, &rtcp.ReceiverReport{SSRC: m.senderSSRC})
+				}
+			}
+		}
+	}
+}
+
+func (i *RTPIngest) sendPLI(m *rtpIngestMedia) {
+	ssrc := m.ssrc.Load()
+	if ssrc == 0 || i.IsClosed() {
+		return
+	}
+	i.writeRTCP(m, &rtcp.PictureLossIndication{SenderSSRC: m.senderSSRC, MediaSSRC: ssrc})
+}
+
+func (i *RTPIngest) writeRTCP(m *rtpIngestMedia, pkt rtcp.Packet) {
+	b, err := pkt.Marshal()
+	if err != nil {
+		return
+	}
+
+	m.rtcpLock.Lock()
+	addr := m.remoteRTCP
+	if m.srtcp != nil {
+		b, err = m.srtcp.EncryptRTCP(nil, b, nil)
+	}
+	m.rtcpLock.Unlock()
+	if addr == nil || err != nil {
+		return
+	}
+	if err = i.mux.writeTo(b, addr); err != nil {
+		i.params.Logger.Debugw("could not send rtcp", "error", err)
+	}
+}
+
+// NewRTPForwardOffer describes the stream an RTPForwarder of track sends with ssrc, for an RTPIngest to receive it.
+// The sender's address is not known in advance, the ingest latches on to where media with the SSRC comes from.
+func NewRTPForwardOffer(track types.MediaTrack, ssrc uint32) (string, error) {
+	receiver := primaryReceiver(track)
+	if receiver == nil {
+		return "", ErrTrackNotAttached
+	}
+	codec := receiver.Codec()
+	media := strings.ToLower(track.Kind().String())
+
+	offer := &sdp.SessionDescription{
+		Origin: sdp.Origin{
+			Username:       "-",
+			SessionID:      uint64(mrand.Uint32()),
+			SessionVersion: 1,
+			NetworkType:    "IN",
+			AddressType:    "IP4",
+			UnicastAddress: "0.0.0.0",
+		},
+		SessionName: "LiveKit",
+		ConnectionInformation: &sdp.ConnectionInformation{
+			NetworkType: "IN",
+			AddressType: "IP4",
+			Address:     &sdp.Address{Address: "0.0.0.0"},
+		},
+		TimeDescriptions: []sdp.TimeDescription{{}},
+	}
+	md := &sdp.MediaDescription{
+		MediaName: sdp.MediaName{
+			Media:   media,
+			Port:    sdp.RangedPort{Value: 9},
+			Protos:  []string{"RTP", "AVP"},
+			Formats: []string{strconv.Itoa(int(codec.PayloadType))},
+		},
+	}
+	rtpmap := fmt.Sprintf("%d %s/%d", codec.PayloadType, strings.TrimPrefix(strings.ToLower(codec.MimeType), media+"/"), codec.ClockRate)
+	if codec.Channels > 0 {
+		rtpmap += "/" + strconv.Itoa(int(codec.Channels))
+	}
+	md.WithValueAttribute("rtpmap", rtpmap)
+	if codec.SDPFmtpLine != "" {
+		md.WithValueAttribute("fmtp", fmt.Sprintf("%d %s", codec.PayloadType, codec.SDPFmtpLine))
+	}
+	md.WithValueAttribute("ssrc", fmt.Sprintf("%d cname:livekit", ssrc))
+	md.WithPropertyAttribute("rtcp-mux")
+	md.WithPropertyAttribute("sendonly")
+	offer.MediaDescriptions = append(offer.MediaDescriptions, md)
+
+	b, err := offer.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// ParseRTPIngestAddress returns where media of the first accepted media section of an answer is to be sent
+func ParseRTPIngestAddress(answer string) (*net.UDPAddr, error) {
+	sd := &sdp.SessionDescription{}
+	if err := sd.Unmarshal([]byte(answer)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSDP, err)
+	}
+	var addr string
+	if sd.ConnectionInformation != nil && sd.ConnectionInformation.Address != nil {
+		addr = sd.ConnectionInformation.Address.Address
+	}
+	for _, md := range sd.MediaDescriptions {
+		if md.MediaName.Port.Value == 0 {
+			continue
+		}
+		if md.ConnectionInformation != nil && md.ConnectionInformation.Address != nil {
+			addr = md.ConnectionInformation.Address.Address
+		}
+		ip := net.ParseIP(addr)
+		if ip == nil || ip.IsUnspecified() {
+			return nil, fmt.Errorf("%w: invalid address %q", ErrInvalidSDP, addr)
+		}
+		return &net.UDPAddr{IP: ip, Port: md.MediaName.Port.Value}, nil
+	}
+	return nil, ErrNoSupportedMedia
+}
+
+func listenUDPInPortRange(start uint16, end uint16) (*net.UDPConn, error) {
+	if start == 0 || end < start {
+		return net.ListenUDP("udp", &net.UDPAddr{})
+	}
+
+	size := int(end-start) + 1
+	offset := mrand.Intn(size)
+	for n := 0; n < size; n++ {
+		port := int(start) + (offset+n)%size
+		if conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: port}); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, ErrNoPortAvailable
+}
@@ -0,0 +1,281 @@
+package rtc
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
+)
+
+func TestRTPIngest(t *testing.T) {
+	room := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	defer room.Close()
+	// video buffers need a packet history
+	room.bufferFactory = buffer.NewFactoryOfBufferFactory(500)
+
+	newParticipant := func(identity livekit.ParticipantIdentity, params InProcessParticipantParams) *InProcessParticipant {
+		params.Identity = identity
+		params.Grants = &auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}}
+		params.Telemetry = &telemetryfakes.FakeTelemetryService{}
+		p, err := NewInProcessParticipant(params)
+		require.NoError(t, err)
+		require.NoError(t, p.Join(room))
+		return p
+	}
+
+	gateway := newParticipant("gateway", InProcessParticipantParams{})
+	packets := atomic.NewInt32(0)
+	listener := newParticipant("listener", InProcessParticipantParams{
+		AutoSubscribe: true,
+		OnTrackPacket: func(_ *InProcessParticipant, _ livekit.TrackID, _ *buffer.ExtPacket, _ int32) {
+			packets.Inc()
+		},
+	})
+
+	sender, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer sender.Close()
+	port := sender.LocalAddr().(*net.UDPAddr).Port
+
+	offer := func(media ...string) string {
+		return strings.Join(append([]string{
+			"v=0",
+			"o=- 1 1 IN IP4 127.0.0.1",
+			"s=encoder",
+			"c=IN IP4 127.0.0.1",
+			"t=0 0",
+		}, media...), "\r\n") + "\r\n"
+	}
+
+	t.Run("unsupported codecs are rejected", func(t *testing.T) {
+		_, err := NewRTPIngest(RTPIngestParams{
+			Participant: gateway,
+			SDP: offer(
+				fmt.Sprintf("m=audio %d RTP/AVP 0", port),
+				"a=rtpmap:0 PCMU/8000",
+			),
+			Logger: logger.GetLogger(),
+		})
+		require.ErrorIs(t, err, ErrNoSupportedMedia)
+	})
+
+	t.Run("malformed rtcp attribute is rejected", func(t *testing.T) {
+		for _, rtcpAttr := range []string{"a=rtcp", "a=rtcp:", "a=rtcp:port"} {
+			_, err := NewRTPIngest(RTPIngestParams{
+				Participant: gateway,
+				SDP: offer(
+					fmt.Sprintf("m=audio %d RTP/AVP 111", port),
+					"a=rtpmap:111 opus/48000/2",
+					rtcpAttr,
+				),
+				Logger: logger.GetLogger(),
+			})
+			require.ErrorIs(t, err, ErrInvalidSDP, rtcpAttr)
+		}
+	})
+
+	ingest, err := NewRTPIngest(RTPIngestParams{
+		Participant: gateway,
+		SDP: offer(
+			fmt.Sprintf("m=audio %d RTP/AVP 0 111", port),
+			"a=rtpmap:0 PCMU/8000",
+			"a=rtpmap:111 opus/48000/2",
+			"a=sendonly",
+			fmt.Sprintf("m=video %d RTP/AVP 96", port),
+			"a=rtpmap:96 VP8/90000",
+			"a=rtcp-mux",
+			"a=sendonly",
+		),
+		IP:     net.IPv4(127, 0, 0, 1),
+		Logger: logger.GetLogger(),
+	})
+	require.NoError(t, err)
+	defer ingest.Close()
+
+	require.Len(t, ingest.Tracks(), 2)
+	require.Len(t, gateway.GetPublishedTracks(), 2)
+	require.Contains(t, ingest.Answer(), fmt.Sprintf("m=audio %d RTP/AVP 111", ingest.Port()))
+	require.Contains(t, ingest.Answer(), fmt.Sprintf("m=video %d RTP/AVP 96", ingest.Port()))
+	require.Contains(t, ingest.Answer(), "a=rtpmap:111 opus/48000/2")
+
+	require.Eventually(t, func() bool {
+		return len(listener.GetSubscribedParticipants()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	local := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: ingest.Port()}
+	sendAudio := func(conn *net.UDPConn, sn uint16, ssrc uint32) {
+		pkt := &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    111,
+				SequenceNumber: sn,
+				Timestamp:      uint32(sn) * 960,
+				SSRC:           ssrc,
+			},
+			Payload: []byte{0xf8, 0xff, 0xfe},
+		}
+		b, err := pkt.Marshal()
+		require.NoError(t, err)
+		_, err = conn.WriteToUDP(b, local)
+		require.NoError(t, err)
+	}
+	for i := 0; i < 10; i++ {
+		sendAudio(sender, uint16(i), 5678)
+	}
+	require.Eventually(t, func() bool {
+		return packets.Load() == 10
+	}, time.Second, 10*time.Millisecond)
+
+	// media of other sources is dropped once the sender is latched
+	intruder, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer intruder.Close()
+	sendAudio(intruder, 10, 9999)
+	sendAudio(sender, 11, 5678)
+	require.Eventually(t, func() bool {
+		return packets.Load() == 11
+	}, time.Second, 10*time.Millisecond)
+	require.Never(t, func() bool {
+		return packets.Load() > 11
+	}, 100*time.Millisecond, 10*time.Millisecond)
+	require.Equal(t, uint32(5678), ingest.media[0].ssrc.Load())
+
+	// key frame requests of subscribers reach the sender
+	video := ingest.Tracks()[1]
+	require.Equal(t, livekit.TrackType_VIDEO, video.Kind())
+	pkt := &rtp.Packet{
+		Header:  rtp.Header{Version: 2, PayloadType: 96, SSRC: 1234, Timestamp: 90000},
+		Payload: []byte{0x10, 0x00, 0x00, 0x9d, 0x01, 0x2a},
+	}
+	b, err := pkt.Marshal()
+	require.NoError(t, err)
+	_, err = sender.WriteToUDP(b, local)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return ingest.media[1].ssrc.Load() == 1234
+	}, time.Second, 10*time.Millisecond)
+
+	video.handleKeyFrameRequest()
+	require.NoError(t, sender.SetReadDeadline(time.Now().Add(time.Second)))
+	rb := make([]byte, 1500)
+	n, err := sender.Read(rb)
+	require.NoError(t, err)
+	pkts, err := rtcp.Unmarshal(rb[:n])
+	require.NoError(t, err)
+	require.IsType(t, &rtcp.PictureLossIndication{}, pkts[0])
+	require.Equal(t, uint32(1234), pkts[0].(*rtcp.PictureLossIndication).MediaSSRC)
+
+	// closing unpublishes
+	ingest.Close()
+	require.Eventually(t, func() bool {
+		return len(gateway.GetPublishedTracks()) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRTPIngestSharedPort(t *testing.T) {
+	room := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	defer room.Close()
+
+	packets := make(map[livekit.TrackID]int)
+	var packetsLock sync.Mutex
+	countPackets := func(trackID livekit.TrackID) int {
+		packetsLock.Lock()
+		defer packetsLock.Unlock()
+		return packets[trackID]
+	}
+	newParticipant := func(identity livekit.ParticipantIdentity, params InProcessParticipantParams) *InProcessParticipant {
+		params.Identity = identity
+		params.Grants = &auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}}
+		params.Telemetry = &telemetryfakes.FakeTelemetryService{}
+		p, err := NewInProcessParticipant(params)
+		require.NoError(t, err)
+		require.NoError(t, p.Join(room))
+		return p
+	}
+	newParticipant("listener", InProcessParticipantParams{
+		AutoSubscribe: true,
+		OnTrackPacket: func(_ *InProcessParticipant, trackID livekit.TrackID, _ *buffer.ExtPacket, _ int32) {
+			packetsLock.Lock()
+			packets[trackID]++
+			packetsLock.Unlock()
+		},
+	})
+
+	// both senders use the same payload type from the same address, the first declares its SSRC
+	newIngest := func(identity livekit.ParticipantIdentity, ssrcAttr string) *RTPIngest {
+		media := []string{
+			"v=0",
+			"o=- 1 1 IN IP4 127.0.0.1",
+			"s=encoder",
+			"c=IN IP4 127.0.0.1",
+			"t=0 0",
+			"m=audio 5004 RTP/AVP 111",
+			"a=rtpmap:111 opus/48000/2",
+			"a=rtcp-mux",
+		}
+		if ssrcAttr != "" {
+			media = append(media, ssrcAttr)
+		}
+		ingest, err := NewRTPIngest(RTPIngestParams{
+			Participant: newParticipant(identity, InProcessParticipantParams{}),
+			SDP:         strings.Join(media, "\r\n") + "\r\n",
+			IP:          net.IPv4(127, 0, 0, 1),
+			Logger:      logger.GetLogger(),
+		})
+		require.NoError(t, err)
+		return ingest
+	}
+	declared := newIngest("declared", "a=ssrc:1111 cname:encoder")
+	undeclared := newIngest("undeclared", "")
+	require.Equal(t, declared.Port(), undeclared.Port())
+
+	local := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: declared.Port()}
+	send := func(conn *net.UDPConn, ssrc uint32, sn uint16) {
+		b, err := (&rtp.Packet{
+			Header:  rtp.Header{Version: 2, PayloadType: 111, SequenceNumber: sn, Timestamp: uint32(sn) * 960, SSRC: ssrc},
+			Payload: []byte{0xf8, 0xff, 0xfe},
+		}).Marshal()
+		require.NoError(t, err)
+		_, err = conn.WriteToUDP(b, local)
+		require.NoError(t, err)
+	}
+	// the sender without a declared SSRC starts first, it is still not mistaken for the declared one
+	senders := make([]*net.UDPConn, 2)
+	for idx := range senders {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		require.NoError(t, err)
+		defer conn.Close()
+		senders[idx] = conn
+	}
+	for sn := uint16(0); sn < 5; sn++ {
+		send(senders[1], 2222, sn)
+	}
+	for sn := uint16(0); sn < 3; sn++ {
+		send(senders[0], 1111, sn)
+	}
+	require.Eventually(t, func() bool {
+		return countPackets(undeclared.Tracks()[0].ID()) == 5 && countPackets(declared.Tracks()[0].ID()) == 3
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, uint32(2222), undeclared.media[0].ssrc.Load())
+	require.Equal(t, uint32(1111), declared.media[0].ssrc.Load())
+
+	// the port is released with the last ingest of the room
+	declared.Close()
+	require.NotNil(t, room.rtpIngestMux)
+	undeclared.Close()
+	require.Nil(t, room.rtpIngestMux)
+}
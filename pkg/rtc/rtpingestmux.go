@@ -0,0 +1,174 @@
+package rtc
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/logger"
+)
+
+// rtpIngestMux is the UDP socket shared by the RTP ingests of a room. It is opened with the first ingest of the room,
+// and closed with the last one.
+//
+// RTP from a source address that is bound to an ingest goes to that ingest. A packet from a new source binds it to the
+// first ingest, in order of creation, with a media that has not received anything yet, whose offered address
+// matches, and that was offered with the packet's SSRC. Media offered without a=ssrc are matched by payload type
+// instead, after those offered with it. Senders sharing an address should declare their SSRC.
+// RTCP is demultiplexed by sender SSRC, as it may come from another port than media.
+type rtpIngestMux struct {
+	conn   *net.UDPConn
+	logger logger.Logger
+
+	lock     sync.RWMutex
+	ingests  []*RTPIngest
+	bySource map[string]*RTPIngest
+}
+
+func newRTPIngestMux(portRangeStart uint16, portRangeEnd uint16, logger logger.Logger) (*rtpIngestMux, error) {
+	conn, err := listenUDPInPortRange(portRangeStart, portRangeEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &rtpIngestMux{
+		conn:     conn,
+		logger:   logger,
+		bySource: make(map[string]*RTPIngest),
+	}
+	go m.readWorker()
+
+	logger.Infow("rtp ingest port opened", "port", m.Port())
+	return m, nil
+}
+
+func (m *rtpIngestMux) Port() int {
+	return m.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func (m *rtpIngestMux) add(i *RTPIngest) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.ingests = append(m.ingests, i)
+}
+
+// remove returns true when no ingest is left
+func (m *rtpIngestMux) remove(i *RTPIngest) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for idx, ingest := range m.ingests {
+		if ingest == i {
+			m.ingests = append(m.ingests[:idx], m.ingests[idx+1:]...)
+			break
+		}
+	}
+	for source, ingest := range m.bySource {
+		if ingest == i {
+			delete(m.bySource, source)
+		}
+	}
+	return len(m.ingests) == 0
+}
+
+func (m *rtpIngestMux) close() {
+	_ = m.conn.Close()
+	m.logger.Infow("rtp ingest port closed")
+}
+
+func (m *rtpIngestMux) writeTo(b []byte, addr *net.UDPAddr) error {
+	_, err := m.conn.WriteToUDP(b, addr)
+	return err
+}
+
+func (m *rtpIngestMux) readWorker() {
+	b := make([]byte, rtpForwarderMaxPacketSize)
+	for {
+		n, addr, err := m.conn.ReadFromUDP(b)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				m.logger.Warnw("could not read rtp", err)
+			}
+			// ingests still on the socket cannot receive anymore
+			m.lock.RLock()
+			ingests := append([]*RTPIngest(nil), m.ingests...)
+			m.lock.RUnlock()
+			for _, i := range ingests {
+				go i.Close()
+			}
+			return
+		}
+
+		// RTCP is demultiplexed by packet type (RFC 5761), the sender SSRC is not encrypted by SRTCP
+		if n >= 8 && b[1] >= 192 && b[1] <= 223 {
+			if i := m.ingestForRTCP(binary.BigEndian.Uint32(b[4:8])); i != nil {
+				i.handleRTCP(b[:n], addr)
+			}
+			continue
+		}
+		if n < 12 {
+			continue
+		}
+		if i := m.ingestForRTP(addr, webrtc.PayloadType(b[1]&0x7f), binary.BigEndian.Uint32(b[8:12])); i != nil {
+			i.handleRTP(b[:n], addr)
+		}
+	}
+}
+
+func (m *rtpIngestMux) ingestForRTP(addr *net.UDPAddr, pt webrtc.PayloadType, ssrc uint32) *RTPIngest {
+	source := addr.String()
+	m.lock.RLock()
+	i := m.bySource[source]
+	m.lock.RUnlock()
+	if i != nil {
+		return i
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if i = m.bySource[source]; i != nil {
+		return i
+	}
+
+	// media sources are only written by the read worker, so they are stable here
+	i = m.findIngestLocked(func(media *rtpIngestMedia) bool {
+		return media.offeredSSRC != 0 && media.offeredSSRC == ssrc && media.isFromSource(addr)
+	})
+	if i == nil {
+		i = m.findIngestLocked(func(media *rtpIngestMedia) bool {
+			return media.offeredSSRC == 0 && media.codec.PayloadType == pt && media.isFromSource(addr)
+		})
+	}
+	if i != nil {
+		m.bySource[source] = i
+	}
+	return i
+}
+
+func (m *rtpIngestMux) findIngestLocked(matches func(media *rtpIngestMedia) bool) *RTPIngest {
+	for _, i := range m.ingests {
+		for _, media := range i.media {
+			if media != nil && media.track != nil && media.source == nil && matches(media) {
+				return i
+			}
+		}
+	}
+	return nil
+}
+
+func (m *rtpIngestMux) ingestForRTCP(senderSSRC uint32) *RTPIngest {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	for _, i := range m.ingests {
+		for _, media := range i.media {
+			if media != nil && media.ssrc.Load() == senderSSRC {
+				return i
+			}
+		}
+	}
+	return nil
+}
@@ -157,6 +157,20 @@ func (m *SubscriptionManager) UnsubscribeFromTrack(trackID livekit.TrackID) {
 	}
 }
 
+// UnsubscribeFromAll drops every subscription, including ones that have not been fulfilled yet
+func (m *SubscriptionManager) UnsubscribeFromAll() {
+	m.lock.RLock()
+	trackIDs := make([]livekit.TrackID, 0, len(m.subscriptions))
+	for trackID := range m.subscriptions {
+		trackIDs = append(trackIDs, trackID)
+	}
+	m.lock.RUnlock()
+
+	for _, trackID := range trackIDs {
+		m.UnsubscribeFromTrack(trackID)
+	}
+}
+
 func (m *SubscriptionManager) GetSubscribedTracks() []types.SubscribedTrack {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
@@ -27,6 +27,7 @@ import (
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
 	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
@@ -170,6 +171,7 @@ type PCTransport struct {
 	signalingRTT               atomic.Uint32 // milliseconds
 
 	onFullyEstablished func()
+	onICEConnected     func()
 
 	debouncedNegotiate func(func())
 	debouncePending    bool
@@ -601,6 +603,9 @@ func (t *PCTransport) onICEConnectionStateChange(state webrtc.ICEConnectionState
 		} else {
 			t.params.Logger.Infow("selected ICE candidate pair", "pair", pair)
 		}
+		if onICEConnected := t.getOnICEConnected(); onICEConnected != nil {
+			onICEConnected()
+		}
 
 	case webrtc.ICEConnectionStateChecking:
 		t.setICEStartedAt(time.Now())
@@ -728,7 +733,7 @@ func (t *PCTransport) AddTrack(trackLocal webrtc.TrackLocal, params types.AddTra
 		return
 	}
 
-	configureAudioTransceiver(transceiver, params.Stereo, !params.Red || !t.params.ClientInfo.SupportsAudioRED())
+	configureAudioTransceiver(transceiver, params, !params.Red || !t.params.ClientInfo.SupportsAudioRED())
 
 	return
 }
@@ -745,7 +750,7 @@ func (t *PCTransport) AddTransceiverFromTrack(trackLocal webrtc.TrackLocal, para
 		return
 	}
 
-	configureAudioTransceiver(transceiver, params.Stereo, !params.Red || !t.params.ClientInfo.SupportsAudioRED())
+	configureAudioTransceiver(transceiver, params, !params.Red || !t.params.ClientInfo.SupportsAudioRED())
 
 	return
 }
@@ -963,6 +968,20 @@ func (t *PCTransport) getOnFullyEstablished() func() {
 	return t.onFullyEstablished
 }
 
+// OnICEConnected is called each time ICE connects, including after ICE restarts
+func (t *PCTransport) OnICEConnected(f func()) {
+	t.lock.Lock()
+	t.onICEConnected = f
+	t.lock.Unlock()
+}
+
+func (t *PCTransport) getOnICEConnected() func() {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.onICEConnected
+}
+
 func (t *PCTransport) OnFailed(f func(isShortLived bool)) {
 	t.lock.Lock()
 	t.onFailed = f
@@ -1134,6 +1153,14 @@ func (t *PCTransport) SetChannelCapacityOfStreamAllocator(channelCapacity int64)
 	t.streamAllocator.SetChannelCapacity(channelCapacity)
 }
 
+func (t *PCTransport) SetChannelCapacityCeilingOfStreamAllocator(ceiling int64) {
+	if t.streamAllocator == nil {
+		return
+	}
+
+	t.streamAllocator.SetChannelCapacityCeiling(ceiling)
+}
+
 func (t *PCTransport) GetICEConnectionType() types.ICEConnectionType {
 	unknown := types.ICEConnectionTypeUnknown
 	if t.pc == nil {
@@ -1942,8 +1969,8 @@ func (t *PCTransport) handleICERestart(e *event) error {
 	return t.doICERestart()
 }
 
-// configure subscriber transceiver for audio stereo and nack
-func configureAudioTransceiver(tr *webrtc.RTPTransceiver, stereo bool, nack bool) {
+// configure subscriber transceiver for audio stereo, channel layout and nack
+func configureAudioTransceiver(tr *webrtc.RTPTransceiver, params types.AddTrackParams, nack bool) {
 	sender := tr.Sender()
 	if sender == nil {
 		return
@@ -1952,9 +1979,13 @@ func configureAudioTransceiver(tr *webrtc.RTPTransceiver, stereo bool, nack bool
 	codecs := sender.GetParameters().Codecs
 	configCodecs := make([]webrtc.RTPCodecParameters, 0, len(codecs))
 	for _, c := range codecs {
+		if strings.EqualFold(c.MimeType, sfu.MimeTypeMultiOpus) && uint16(c.Channels) != params.Channels {
+			// only the layout of the published track can be forwarded
+			continue
+		}
 		if strings.EqualFold(c.MimeType, webrtc.MimeTypeOpus) {
 			c.SDPFmtpLine = strings.ReplaceAll(c.SDPFmtpLine, ";sprop-stereo=1", "")
-			if stereo {
+			if params.Stereo {
 				c.SDPFmtpLine += ";sprop-stereo=1"
 			}
 			if nack {
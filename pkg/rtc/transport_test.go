@@ -11,6 +11,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
 
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/testutils"
 	"github.com/livekit/protocol/livekit"
 )
@@ -525,7 +527,7 @@ func TestConfigureAudioTransceiver(t *testing.T) {
 			tr, err := pc.AddTransceiverFromKind(webrtc.RTPCodecTypeAudio, webrtc.RtpTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
 			require.NoError(t, err)
 
-			configureAudioTransceiver(tr, testcase.stereo, testcase.nack)
+			configureAudioTransceiver(tr, types.AddTrackParams{Stereo: testcase.stereo}, testcase.nack)
 			codecs := tr.Sender().GetParameters().Codecs
 			for _, codec := range codecs {
 				if strings.Contains(codec.MimeType, webrtc.MimeTypeOpus) {
@@ -543,3 +545,27 @@ func TestConfigureAudioTransceiver(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigureAudioTransceiverMultiOpus(t *testing.T) {
+	me := &webrtc.MediaEngine{}
+	require.NoError(t, registerCodecs(me, []*livekit.Codec{
+		{Mime: webrtc.MimeTypeOpus},
+		{Mime: sfu.MimeTypeMultiOpus},
+	}, RTCPFeedbackConfig{}))
+	pc, err := webrtc.NewAPI(webrtc.WithMediaEngine(me)).NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer pc.Close()
+
+	tr, err := pc.AddTransceiverFromKind(webrtc.RTPCodecTypeAudio, webrtc.RtpTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
+	require.NoError(t, err)
+
+	// only the published layout is offered
+	configureAudioTransceiver(tr, types.AddTrackParams{Stereo: true, Channels: 8}, true)
+	var channels []uint16
+	for _, codec := range tr.Sender().GetParameters().Codecs {
+		if strings.EqualFold(codec.MimeType, sfu.MimeTypeMultiOpus) {
+			channels = append(channels, codec.Channels)
+		}
+	}
+	require.Equal(t, []uint16{8}, channels)
+}
@@ -277,6 +277,10 @@ func (t *TransportManager) OnPrimaryTransportFullyEstablished(f func()) {
 	t.getTransport(true).OnFullyEstablished(f)
 }
 
+func (t *TransportManager) OnPrimaryTransportICEConnected(f func()) {
+	t.getTransport(true).OnICEConnected(f)
+}
+
 func (t *TransportManager) OnAnyTransportFailed(f func()) {
 	t.onAnyTransportFailed = f
 }
@@ -791,3 +795,7 @@ func (t *TransportManager) SetSubscriberAllowPause(allowPause bool) {
 func (t *TransportManager) SetSubscriberChannelCapacity(channelCapacity int64) {
 	t.subscriber.SetChannelCapacityOfStreamAllocator(channelCapacity)
 }
+
+func (t *TransportManager) SetSubscriberChannelCapacityCeiling(ceiling int64) {
+	t.subscriber.SetChannelCapacityCeilingOfStreamAllocator(ceiling)
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
+	"github.com/livekit/livekit-server/pkg/sfu/impairment"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -86,6 +88,7 @@ const (
 	ParticipantCloseReasonMigrationRequested
 	ParticipantCloseReasonOvercommitted
 	ParticipantCloseReasonPublicationError
+	ParticipantCloseReasonLobbyDenied
 )
 
 func (p ParticipantCloseReason) String() string {
@@ -130,6 +133,8 @@ func (p ParticipantCloseReason) String() string {
 		return "OVERCOMMITTED"
 	case ParticipantCloseReasonPublicationError:
 		return "PUBLICATION_ERROR"
+	case ParticipantCloseReasonLobbyDenied:
+		return "LOBBY_DENIED"
 	default:
 		return fmt.Sprintf("%d", int(p))
 	}
@@ -148,7 +153,7 @@ func (p ParticipantCloseReason) ToDisconnectReason() livekit.DisconnectReason {
 		return livekit.DisconnectReason_STATE_MISMATCH
 	case ParticipantCloseReasonDuplicateIdentity, ParticipantCloseReasonMigrationComplete, ParticipantCloseReasonStale:
 		return livekit.DisconnectReason_DUPLICATE_IDENTITY
-	case ParticipantCloseReasonServiceRequestRemoveParticipant:
+	case ParticipantCloseReasonServiceRequestRemoveParticipant, ParticipantCloseReasonLobbyDenied:
 		return livekit.DisconnectReason_PARTICIPANT_REMOVED
 	case ParticipantCloseReasonServiceRequestDeleteRoom:
 		return livekit.DisconnectReason_ROOM_DELETED
@@ -223,9 +228,33 @@ const (
 	ICEConnectionTypeUnknown ICEConnectionType = "unknown"
 )
 
+// ConnectionQualityDetails is the connection quality of a participant with the factors lowering the quality of its tracks
+type ConnectionQualityDetails struct {
+	ParticipantSid string  `json:"participantSid"`
+	Identity       string  `json:"identity"`
+	Quality        string  `json:"quality"`
+	Score          float32 `json:"score"`
+	// factor lowering the track of the lowest quality the most, empty when none
+	Limit  connectionquality.QualityFactor `json:"limit,omitempty"`
+	Tracks []*TrackConnectionQuality       `json:"tracks"`
+}
+
+type TrackConnectionQuality struct {
+	TrackSid string `json:"trackSid"`
+	// upstream for published tracks, downstream for subscribed tracks
+	Direction string                          `json:"direction"`
+	Quality   string                          `json:"quality"`
+	Score     float32                         `json:"score"`
+	Limit     connectionquality.QualityFactor `json:"limit,omitempty"`
+	// how much each factor lowered the score of the last analysis window, out of 100
+	Impairments map[connectionquality.QualityFactor]float64 `json:"impairments,omitempty"`
+}
+
 type AddTrackParams struct {
 	Stereo bool
 	Red    bool
+	// number of channels of multichannel Opus, 0 otherwise
+	Channels uint16
 }
 
 //counterfeiter:generate . LocalParticipant
@@ -256,6 +285,7 @@ type LocalParticipant interface {
 	// permissions
 	ClaimGrants() *auth.ClaimGrants
 	SetPermission(permission *livekit.ParticipantPermission) bool
+	MoveToRoom(roomName livekit.RoomName)
 	CanPublishSource(source livekit.TrackSource) bool
 	CanSubscribe() bool
 	CanPublishData() bool
@@ -264,6 +294,7 @@ type LocalParticipant interface {
 	AddICECandidate(candidate webrtc.ICECandidateInit, target livekit.SignalTarget)
 	HandleOffer(sdp webrtc.SessionDescription)
 	AddTrack(req *livekit.AddTrackRequest)
+	RejectTrack(cid string, reason string)
 	SetTrackMuted(trackID livekit.TrackID, muted bool, fromAdmin bool)
 
 	HandleAnswer(sdp webrtc.SessionDescription)
@@ -289,6 +320,8 @@ type LocalParticipant interface {
 
 	GetAudioLevel() (smoothedLevel float64, active bool)
 	GetConnectionQuality() *livekit.ConnectionQualityInfo
+	// returns the details of the connection quality last returned by GetConnectionQuality
+	GetConnectionQualityDetails() *ConnectionQualityDetails
 
 	// server sent messages
 	SendJoinResponse(joinResponse *livekit.JoinResponse) error
@@ -341,6 +374,8 @@ type LocalParticipant interface {
 	// down stream bandwidth management
 	SetSubscriberAllowPause(allowPause bool)
 	SetSubscriberChannelCapacity(channelCapacity int64)
+	SetSubscriberChannelCapacityCeiling(ceiling int64)
+	SetDownTrackImpairment(config *impairment.Config)
 
 	GetAllowTimestampAdjustment() bool
 }
@@ -418,6 +453,8 @@ type LocalMediaTrack interface {
 
 	GetAudioLevel() (level float64, active bool)
 	GetConnectionScoreAndQuality() (float32, livekit.ConnectionQuality)
+	GetConnectionScoreBreakdown() connectionquality.ScoreBreakdown
+	GetTrackStats() *livekit.RTPStats
 
 	SetRTT(rtt uint32)
 
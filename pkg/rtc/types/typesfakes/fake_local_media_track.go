@@ -6,6 +6,7 @@ import (
 
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
 	"github.com/livekit/protocol/livekit"
 )
 
@@ -72,6 +73,16 @@ type FakeLocalMediaTrack struct {
 		result1 float32
 		result2 livekit.ConnectionQuality
 	}
+	GetConnectionScoreBreakdownStub        func() connectionquality.ScoreBreakdown
+	getConnectionScoreBreakdownMutex       sync.RWMutex
+	getConnectionScoreBreakdownArgsForCall []struct {
+	}
+	getConnectionScoreBreakdownReturns struct {
+		result1 connectionquality.ScoreBreakdown
+	}
+	getConnectionScoreBreakdownReturnsOnCall map[int]struct {
+		result1 connectionquality.ScoreBreakdown
+	}
 	GetNumSubscribersStub        func() int
 	getNumSubscribersMutex       sync.RWMutex
 	getNumSubscribersArgsForCall []struct {
@@ -107,6 +118,16 @@ type FakeLocalMediaTrack struct {
 	getTemporalLayerForSpatialFpsReturnsOnCall map[int]struct {
 		result1 int32
 	}
+	GetTrackStatsStub        func() *livekit.RTPStats
+	getTrackStatsMutex       sync.RWMutex
+	getTrackStatsArgsForCall []struct {
+	}
+	getTrackStatsReturns struct {
+		result1 *livekit.RTPStats
+	}
+	getTrackStatsReturnsOnCall map[int]struct {
+		result1 *livekit.RTPStats
+	}
 	HasSdpCidStub        func(string) bool
 	hasSdpCidMutex       sync.RWMutex
 	hasSdpCidArgsForCall []struct {
@@ -636,6 +657,59 @@ func (fake *FakeLocalMediaTrack) GetConnectionScoreAndQualityReturnsOnCall(i int
 	}{result1, result2}
 }
 
+func (fake *FakeLocalMediaTrack) GetConnectionScoreBreakdown() connectionquality.ScoreBreakdown {
+	fake.getConnectionScoreBreakdownMutex.Lock()
+	ret, specificReturn := fake.getConnectionScoreBreakdownReturnsOnCall[len(fake.getConnectionScoreBreakdownArgsForCall)]
+	fake.getConnectionScoreBreakdownArgsForCall = append(fake.getConnectionScoreBreakdownArgsForCall, struct {
+	}{})
+	stub := fake.GetConnectionScoreBreakdownStub
+	fakeReturns := fake.getConnectionScoreBreakdownReturns
+	fake.recordInvocation("GetConnectionScoreBreakdown", []interface{}{})
+	fake.getConnectionScoreBreakdownMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalMediaTrack) GetConnectionScoreBreakdownCallCount() int {
+	fake.getConnectionScoreBreakdownMutex.RLock()
+	defer fake.getConnectionScoreBreakdownMutex.RUnlock()
+	return len(fake.getConnectionScoreBreakdownArgsForCall)
+}
+
+func (fake *FakeLocalMediaTrack) GetConnectionScoreBreakdownCalls(stub func() connectionquality.ScoreBreakdown) {
+	fake.getConnectionScoreBreakdownMutex.Lock()
+	defer fake.getConnectionScoreBreakdownMutex.Unlock()
+	fake.GetConnectionScoreBreakdownStub = stub
+}
+
+func (fake *FakeLocalMediaTrack) GetConnectionScoreBreakdownReturns(result1 connectionquality.ScoreBreakdown) {
+	fake.getConnectionScoreBreakdownMutex.Lock()
+	defer fake.getConnectionScoreBreakdownMutex.Unlock()
+	fake.GetConnectionScoreBreakdownStub = nil
+	fake.getConnectionScoreBreakdownReturns = struct {
+		result1 connectionquality.ScoreBreakdown
+	}{result1}
+}
+
+func (fake *FakeLocalMediaTrack) GetConnectionScoreBreakdownReturnsOnCall(i int, result1 connectionquality.ScoreBreakdown) {
+	fake.getConnectionScoreBreakdownMutex.Lock()
+	defer fake.getConnectionScoreBreakdownMutex.Unlock()
+	fake.GetConnectionScoreBreakdownStub = nil
+	if fake.getConnectionScoreBreakdownReturnsOnCall == nil {
+		fake.getConnectionScoreBreakdownReturnsOnCall = make(map[int]struct {
+			result1 connectionquality.ScoreBreakdown
+		})
+	}
+	fake.getConnectionScoreBreakdownReturnsOnCall[i] = struct {
+		result1 connectionquality.ScoreBreakdown
+	}{result1}
+}
+
 func (fake *FakeLocalMediaTrack) GetNumSubscribers() int {
 	fake.getNumSubscribersMutex.Lock()
 	ret, specificReturn := fake.getNumSubscribersReturnsOnCall[len(fake.getNumSubscribersArgsForCall)]
@@ -814,6 +888,59 @@ func (fake *FakeLocalMediaTrack) GetTemporalLayerForSpatialFpsReturnsOnCall(i in
 	}{result1}
 }
 
+func (fake *FakeLocalMediaTrack) GetTrackStats() *livekit.RTPStats {
+	fake.getTrackStatsMutex.Lock()
+	ret, specificReturn := fake.getTrackStatsReturnsOnCall[len(fake.getTrackStatsArgsForCall)]
+	fake.getTrackStatsArgsForCall = append(fake.getTrackStatsArgsForCall, struct {
+	}{})
+	stub := fake.GetTrackStatsStub
+	fakeReturns := fake.getTrackStatsReturns
+	fake.recordInvocation("GetTrackStats", []interface{}{})
+	fake.getTrackStatsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalMediaTrack) GetTrackStatsCallCount() int {
+	fake.getTrackStatsMutex.RLock()
+	defer fake.getTrackStatsMutex.RUnlock()
+	return len(fake.getTrackStatsArgsForCall)
+}
+
+func (fake *FakeLocalMediaTrack) GetTrackStatsCalls(stub func() *livekit.RTPStats) {
+	fake.getTrackStatsMutex.Lock()
+	defer fake.getTrackStatsMutex.Unlock()
+	fake.GetTrackStatsStub = stub
+}
+
+func (fake *FakeLocalMediaTrack) GetTrackStatsReturns(result1 *livekit.RTPStats) {
+	fake.getTrackStatsMutex.Lock()
+	defer fake.getTrackStatsMutex.Unlock()
+	fake.GetTrackStatsStub = nil
+	fake.getTrackStatsReturns = struct {
+		result1 *livekit.RTPStats
+	}{result1}
+}
+
+func (fake *FakeLocalMediaTrack) GetTrackStatsReturnsOnCall(i int, result1 *livekit.RTPStats) {
+	fake.getTrackStatsMutex.Lock()
+	defer fake.getTrackStatsMutex.Unlock()
+	fake.GetTrackStatsStub = nil
+	if fake.getTrackStatsReturnsOnCall == nil {
+		fake.getTrackStatsReturnsOnCall = make(map[int]struct {
+			result1 *livekit.RTPStats
+		})
+	}
+	fake.getTrackStatsReturnsOnCall[i] = struct {
+		result1 *livekit.RTPStats
+	}{result1}
+}
+
 func (fake *FakeLocalMediaTrack) HasSdpCid(arg1 string) bool {
 	fake.hasSdpCidMutex.Lock()
 	ret, specificReturn := fake.hasSdpCidReturnsOnCall[len(fake.hasSdpCidArgsForCall)]
@@ -1937,12 +2064,16 @@ func (fake *FakeLocalMediaTrack) Invocations() map[string][][]interface{} {
 	defer fake.getAudioLevelMutex.RUnlock()
 	fake.getConnectionScoreAndQualityMutex.RLock()
 	defer fake.getConnectionScoreAndQualityMutex.RUnlock()
+	fake.getConnectionScoreBreakdownMutex.RLock()
+	defer fake.getConnectionScoreBreakdownMutex.RUnlock()
 	fake.getNumSubscribersMutex.RLock()
 	defer fake.getNumSubscribersMutex.RUnlock()
 	fake.getQualityForDimensionMutex.RLock()
 	defer fake.getQualityForDimensionMutex.RUnlock()
 	fake.getTemporalLayerForSpatialFpsMutex.RLock()
 	defer fake.getTemporalLayerForSpatialFpsMutex.RUnlock()
+	fake.getTrackStatsMutex.RLock()
+	defer fake.getTrackStatsMutex.RUnlock()
 	fake.hasSdpCidMutex.RLock()
 	defer fake.hasSdpCidMutex.RUnlock()
 	fake.iDMutex.RLock()
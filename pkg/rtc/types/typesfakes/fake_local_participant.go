@@ -9,6 +9,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/impairment"
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
@@ -230,6 +231,16 @@ type FakeLocalParticipant struct {
 	getConnectionQualityReturnsOnCall map[int]struct {
 		result1 *livekit.ConnectionQualityInfo
 	}
+	GetConnectionQualityDetailsStub        func() *types.ConnectionQualityDetails
+	getConnectionQualityDetailsMutex       sync.RWMutex
+	getConnectionQualityDetailsArgsForCall []struct {
+	}
+	getConnectionQualityDetailsReturns struct {
+		result1 *types.ConnectionQualityDetails
+	}
+	getConnectionQualityDetailsReturnsOnCall map[int]struct {
+		result1 *types.ConnectionQualityDetails
+	}
 	GetICEConnectionTypeStub        func() types.ICEConnectionType
 	getICEConnectionTypeMutex       sync.RWMutex
 	getICEConnectionTypeArgsForCall []struct {
@@ -458,6 +469,11 @@ type FakeLocalParticipant struct {
 	migrateStateReturnsOnCall map[int]struct {
 		result1 types.MigrateState
 	}
+	MoveToRoomStub        func(livekit.RoomName)
+	moveToRoomMutex       sync.RWMutex
+	moveToRoomArgsForCall []struct {
+		arg1 livekit.RoomName
+	}
 	NegotiateStub        func(bool)
 	negotiateMutex       sync.RWMutex
 	negotiateArgsForCall []struct {
@@ -534,6 +550,12 @@ type FakeLocalParticipant struct {
 	protocolVersionReturnsOnCall map[int]struct {
 		result1 types.ProtocolVersion
 	}
+	RejectTrackStub        func(string, string)
+	rejectTrackMutex       sync.RWMutex
+	rejectTrackArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
 	RemovePublishedTrackStub        func(types.MediaTrack, bool, bool)
 	removePublishedTrackMutex       sync.RWMutex
 	removePublishedTrackArgsForCall []struct {
@@ -631,6 +653,11 @@ type FakeLocalParticipant struct {
 	sendSpeakerUpdateReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetDownTrackImpairmentStub        func(*impairment.Config)
+	setDownTrackImpairmentMutex       sync.RWMutex
+	setDownTrackImpairmentArgsForCall []struct {
+		arg1 *impairment.Config
+	}
 	SetICEConfigStub        func(*livekit.ICEConfig)
 	setICEConfigMutex       sync.RWMutex
 	setICEConfigArgsForCall []struct {
@@ -690,6 +717,11 @@ type FakeLocalParticipant struct {
 	setSubscriberChannelCapacityArgsForCall []struct {
 		arg1 int64
 	}
+	SetSubscriberChannelCapacityCeilingStub        func(int64)
+	setSubscriberChannelCapacityCeilingMutex       sync.RWMutex
+	setSubscriberChannelCapacityCeilingArgsForCall []struct {
+		arg1 int64
+	}
 	SetTrackMutedStub        func(livekit.TrackID, bool, bool)
 	setTrackMutedMutex       sync.RWMutex
 	setTrackMutedArgsForCall []struct {
@@ -1954,6 +1986,59 @@ func (fake *FakeLocalParticipant) GetConnectionQualityReturnsOnCall(i int, resul
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) GetConnectionQualityDetails() *types.ConnectionQualityDetails {
+	fake.getConnectionQualityDetailsMutex.Lock()
+	ret, specificReturn := fake.getConnectionQualityDetailsReturnsOnCall[len(fake.getConnectionQualityDetailsArgsForCall)]
+	fake.getConnectionQualityDetailsArgsForCall = append(fake.getConnectionQualityDetailsArgsForCall, struct {
+	}{})
+	stub := fake.GetConnectionQualityDetailsStub
+	fakeReturns := fake.getConnectionQualityDetailsReturns
+	fake.recordInvocation("GetConnectionQualityDetails", []interface{}{})
+	fake.getConnectionQualityDetailsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetConnectionQualityDetailsCallCount() int {
+	fake.getConnectionQualityDetailsMutex.RLock()
+	defer fake.getConnectionQualityDetailsMutex.RUnlock()
+	return len(fake.getConnectionQualityDetailsArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetConnectionQualityDetailsCalls(stub func() *types.ConnectionQualityDetails) {
+	fake.getConnectionQualityDetailsMutex.Lock()
+	defer fake.getConnectionQualityDetailsMutex.Unlock()
+	fake.GetConnectionQualityDetailsStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetConnectionQualityDetailsReturns(result1 *types.ConnectionQualityDetails) {
+	fake.getConnectionQualityDetailsMutex.Lock()
+	defer fake.getConnectionQualityDetailsMutex.Unlock()
+	fake.GetConnectionQualityDetailsStub = nil
+	fake.getConnectionQualityDetailsReturns = struct {
+		result1 *types.ConnectionQualityDetails
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetConnectionQualityDetailsReturnsOnCall(i int, result1 *types.ConnectionQualityDetails) {
+	fake.getConnectionQualityDetailsMutex.Lock()
+	defer fake.getConnectionQualityDetailsMutex.Unlock()
+	fake.GetConnectionQualityDetailsStub = nil
+	if fake.getConnectionQualityDetailsReturnsOnCall == nil {
+		fake.getConnectionQualityDetailsReturnsOnCall = make(map[int]struct {
+			result1 *types.ConnectionQualityDetails
+		})
+	}
+	fake.getConnectionQualityDetailsReturnsOnCall[i] = struct {
+		result1 *types.ConnectionQualityDetails
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) GetICEConnectionType() types.ICEConnectionType {
 	fake.getICEConnectionTypeMutex.Lock()
 	ret, specificReturn := fake.getICEConnectionTypeReturnsOnCall[len(fake.getICEConnectionTypeArgsForCall)]
@@ -3185,6 +3270,38 @@ func (fake *FakeLocalParticipant) MigrateStateReturnsOnCall(i int, result1 types
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) MoveToRoom(arg1 livekit.RoomName) {
+	fake.moveToRoomMutex.Lock()
+	fake.moveToRoomArgsForCall = append(fake.moveToRoomArgsForCall, struct {
+		arg1 livekit.RoomName
+	}{arg1})
+	stub := fake.MoveToRoomStub
+	fake.recordInvocation("MoveToRoom", []interface{}{arg1})
+	fake.moveToRoomMutex.Unlock()
+	if stub != nil {
+		fake.MoveToRoomStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) MoveToRoomCallCount() int {
+	fake.moveToRoomMutex.RLock()
+	defer fake.moveToRoomMutex.RUnlock()
+	return len(fake.moveToRoomArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) MoveToRoomCalls(stub func(livekit.RoomName)) {
+	fake.moveToRoomMutex.Lock()
+	defer fake.moveToRoomMutex.Unlock()
+	fake.MoveToRoomStub = stub
+}
+
+func (fake *FakeLocalParticipant) MoveToRoomArgsForCall(i int) livekit.RoomName {
+	fake.moveToRoomMutex.RLock()
+	defer fake.moveToRoomMutex.RUnlock()
+	argsForCall := fake.moveToRoomArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) Negotiate(arg1 bool) {
 	fake.negotiateMutex.Lock()
 	fake.negotiateArgsForCall = append(fake.negotiateArgsForCall, struct {
@@ -3655,6 +3772,39 @@ func (fake *FakeLocalParticipant) ProtocolVersionReturnsOnCall(i int, result1 ty
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) RejectTrack(arg1 string, arg2 string) {
+	fake.rejectTrackMutex.Lock()
+	fake.rejectTrackArgsForCall = append(fake.rejectTrackArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.RejectTrackStub
+	fake.recordInvocation("RejectTrack", []interface{}{arg1, arg2})
+	fake.rejectTrackMutex.Unlock()
+	if stub != nil {
+		fake.RejectTrackStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeLocalParticipant) RejectTrackCallCount() int {
+	fake.rejectTrackMutex.RLock()
+	defer fake.rejectTrackMutex.RUnlock()
+	return len(fake.rejectTrackArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) RejectTrackCalls(stub func(string, string)) {
+	fake.rejectTrackMutex.Lock()
+	defer fake.rejectTrackMutex.Unlock()
+	fake.RejectTrackStub = stub
+}
+
+func (fake *FakeLocalParticipant) RejectTrackArgsForCall(i int) (string, string) {
+	fake.rejectTrackMutex.RLock()
+	defer fake.rejectTrackMutex.RUnlock()
+	argsForCall := fake.rejectTrackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeLocalParticipant) RemovePublishedTrack(arg1 types.MediaTrack, arg2 bool, arg3 bool) {
 	fake.removePublishedTrackMutex.Lock()
 	fake.removePublishedTrackArgsForCall = append(fake.removePublishedTrackArgsForCall, struct {
@@ -4194,6 +4344,38 @@ func (fake *FakeLocalParticipant) SendSpeakerUpdateReturnsOnCall(i int, result1
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) SetDownTrackImpairment(arg1 *impairment.Config) {
+	fake.setDownTrackImpairmentMutex.Lock()
+	fake.setDownTrackImpairmentArgsForCall = append(fake.setDownTrackImpairmentArgsForCall, struct {
+		arg1 *impairment.Config
+	}{arg1})
+	stub := fake.SetDownTrackImpairmentStub
+	fake.recordInvocation("SetDownTrackImpairment", []interface{}{arg1})
+	fake.setDownTrackImpairmentMutex.Unlock()
+	if stub != nil {
+		fake.SetDownTrackImpairmentStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) SetDownTrackImpairmentCallCount() int {
+	fake.setDownTrackImpairmentMutex.RLock()
+	defer fake.setDownTrackImpairmentMutex.RUnlock()
+	return len(fake.setDownTrackImpairmentArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SetDownTrackImpairmentCalls(stub func(*impairment.Config)) {
+	fake.setDownTrackImpairmentMutex.Lock()
+	defer fake.setDownTrackImpairmentMutex.Unlock()
+	fake.SetDownTrackImpairmentStub = stub
+}
+
+func (fake *FakeLocalParticipant) SetDownTrackImpairmentArgsForCall(i int) *impairment.Config {
+	fake.setDownTrackImpairmentMutex.RLock()
+	defer fake.setDownTrackImpairmentMutex.RUnlock()
+	argsForCall := fake.setDownTrackImpairmentArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) SetICEConfig(arg1 *livekit.ICEConfig) {
 	fake.setICEConfigMutex.Lock()
 	fake.setICEConfigArgsForCall = append(fake.setICEConfigArgsForCall, struct {
@@ -4556,6 +4738,38 @@ func (fake *FakeLocalParticipant) SetSubscriberChannelCapacityArgsForCall(i int)
 	return argsForCall.arg1
 }
 
+func (fake *FakeLocalParticipant) SetSubscriberChannelCapacityCeiling(arg1 int64) {
+	fake.setSubscriberChannelCapacityCeilingMutex.Lock()
+	fake.setSubscriberChannelCapacityCeilingArgsForCall = append(fake.setSubscriberChannelCapacityCeilingArgsForCall, struct {
+		arg1 int64
+	}{arg1})
+	stub := fake.SetSubscriberChannelCapacityCeilingStub
+	fake.recordInvocation("SetSubscriberChannelCapacityCeiling", []interface{}{arg1})
+	fake.setSubscriberChannelCapacityCeilingMutex.Unlock()
+	if stub != nil {
+		fake.SetSubscriberChannelCapacityCeilingStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberChannelCapacityCeilingCallCount() int {
+	fake.setSubscriberChannelCapacityCeilingMutex.RLock()
+	defer fake.setSubscriberChannelCapacityCeilingMutex.RUnlock()
+	return len(fake.setSubscriberChannelCapacityCeilingArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberChannelCapacityCeilingCalls(stub func(int64)) {
+	fake.setSubscriberChannelCapacityCeilingMutex.Lock()
+	defer fake.setSubscriberChannelCapacityCeilingMutex.Unlock()
+	fake.SetSubscriberChannelCapacityCeilingStub = stub
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberChannelCapacityCeilingArgsForCall(i int) int64 {
+	fake.setSubscriberChannelCapacityCeilingMutex.RLock()
+	defer fake.setSubscriberChannelCapacityCeilingMutex.RUnlock()
+	argsForCall := fake.setSubscriberChannelCapacityCeilingArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) SetTrackMuted(arg1 livekit.TrackID, arg2 bool, arg3 bool) {
 	fake.setTrackMutedMutex.Lock()
 	fake.setTrackMutedArgsForCall = append(fake.setTrackMutedArgsForCall, struct {
@@ -5531,6 +5745,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.getClientConfigurationMutex.RUnlock()
 	fake.getConnectionQualityMutex.RLock()
 	defer fake.getConnectionQualityMutex.RUnlock()
+	fake.getConnectionQualityDetailsMutex.RLock()
+	defer fake.getConnectionQualityDetailsMutex.RUnlock()
 	fake.getICEConnectionTypeMutex.RLock()
 	defer fake.getICEConnectionTypeMutex.RUnlock()
 	fake.getLoggerMutex.RLock()
@@ -5579,6 +5795,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.maybeStartMigrationMutex.RUnlock()
 	fake.migrateStateMutex.RLock()
 	defer fake.migrateStateMutex.RUnlock()
+	fake.moveToRoomMutex.RLock()
+	defer fake.moveToRoomMutex.RUnlock()
 	fake.negotiateMutex.RLock()
 	defer fake.negotiateMutex.RUnlock()
 	fake.onClaimsChangedMutex.RLock()
@@ -5607,6 +5825,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.onTrackUpdatedMutex.RUnlock()
 	fake.protocolVersionMutex.RLock()
 	defer fake.protocolVersionMutex.RUnlock()
+	fake.rejectTrackMutex.RLock()
+	defer fake.rejectTrackMutex.RUnlock()
 	fake.removePublishedTrackMutex.RLock()
 	defer fake.removePublishedTrackMutex.RUnlock()
 	fake.removeTrackFromSubscriberMutex.RLock()
@@ -5625,6 +5845,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.sendRoomUpdateMutex.RUnlock()
 	fake.sendSpeakerUpdateMutex.RLock()
 	defer fake.sendSpeakerUpdateMutex.RUnlock()
+	fake.setDownTrackImpairmentMutex.RLock()
+	defer fake.setDownTrackImpairmentMutex.RUnlock()
 	fake.setICEConfigMutex.RLock()
 	defer fake.setICEConfigMutex.RUnlock()
 	fake.setMetadataMutex.RLock()
@@ -5645,6 +5867,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.setSubscriberAllowPauseMutex.RUnlock()
 	fake.setSubscriberChannelCapacityMutex.RLock()
 	defer fake.setSubscriberChannelCapacityMutex.RUnlock()
+	fake.setSubscriberChannelCapacityCeilingMutex.RLock()
+	defer fake.setSubscriberChannelCapacityCeilingMutex.RUnlock()
 	fake.setTrackMutedMutex.RLock()
 	defer fake.setTrackMutedMutex.RUnlock()
 	fake.startMutex.RLock()
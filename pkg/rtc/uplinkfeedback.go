@@ -0,0 +1,263 @@
+package rtc
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+const (
+	uplinkFeedbackInterval = time.Second
+
+	// unacknowledged bounds are sent every interval for so many times
+	uplinkFeedbackMaxRetries = 3
+	// acknowledged bounds are refreshed every so many intervals, publishers may time them out
+	uplinkFeedbackRefreshIntervals = 5
+
+	// TMMBR cannot remove a bound, a bound that is no longer needed is replaced with this
+	tmmbrUnlimitedBitrate = uint64(math.MaxUint32)
+
+	// REMB is a cap on the whole transport, a cap that is no longer needed is replaced with this
+	rembUnlimitedBitrate = float32(math.MaxUint32)
+
+	// REMB covers all packets on the transport, margin for RTP/SRTP headers and RTX retransmissions
+	// over media bitrate of published tracks
+	rembOverheadMargin = 0.15
+)
+
+// UplinkFeedbackTrack is a published video track
+type UplinkFeedbackTrack interface {
+	GetConsumableBitrates() (map[uint32]int64, bool)
+}
+
+type UplinkFeedbackParams struct {
+	// limits the bitrate of all published tracks, in bps, 0 for no limit
+	MaxPublishBitrate uint64
+	GetTracks         func() []UplinkFeedbackTrack
+	// bitrate of published audio in bps, audio is not limited but shares the transport REMB applies to
+	GetAudioBitrate func() int64
+	WriteRTCP       func(pkts []rtcp.Packet)
+	Logger          logger.Logger
+}
+
+type tmmbrState struct {
+	bitrate uint64
+	acked   bool
+	// sent since bitrate changed
+	sends int
+	// intervals since last sent
+	age int
+}
+
+func (t *tmmbrState) setBitrate(bitrate uint64) {
+	t.bitrate = bitrate
+	t.acked = false
+	t.sends = 0
+	t.age = 0
+}
+
+// UplinkFeedback tells a publisher the bitrate its subscribers can consume, so that it stops encoding what nobody receives.
+// REMB is sent with the aggregate over the transport as that is what browsers act on,
+// TMMBR is sent per stream for publishers that support it.
+type UplinkFeedback struct {
+	params     UplinkFeedbackParams
+	senderSSRC uint32
+
+	lock   sync.Mutex
+	tmmbrs map[uint32]*tmmbrState
+	// a cap was sent and is yet to be lifted
+	rembCapped bool
+	// lifting REMBs sent since the last cap
+	rembLiftSends int
+
+	stop chan struct{}
+	once sync.Once
+}
+
+func NewUplinkFeedback(params UplinkFeedbackParams) *UplinkFeedback {
+	return &UplinkFeedback{
+		params:     params,
+		senderSSRC: rand.Uint32(),
+		tmmbrs:     make(map[uint32]*tmmbrState),
+		stop:       make(chan struct{}),
+	}
+}
+
+func (u *UplinkFeedback) Start() {
+	go u.worker()
+}
+
+func (u *UplinkFeedback) Stop() {
+	u.once.Do(func() {
+		close(u.stop)
+	})
+}
+
+// HandleTMMBN marks bounds acknowledged by the publisher
+func (u *UplinkFeedback) HandleTMMBN(tmmbn *sfu.TMMBN) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	// items of a TMMBN are owned by the requester, the stream is the one of the sender
+	state, ok := u.tmmbrs[tmmbn.SenderSSRC]
+	if !ok {
+		return
+	}
+
+	for _, item := range tmmbn.Items {
+		if item.SSRC != u.senderSSRC {
+			continue
+		}
+
+		// bitrate is rounded down in encoding
+		if item.Bitrate == sfu.TMMBBitrate(state.bitrate) {
+			state.acked = true
+		}
+	}
+}
+
+func (u *UplinkFeedback) worker() {
+	ticker := time.NewTicker(uplinkFeedbackInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-u.stop:
+			return
+
+		case <-ticker.C:
+			if pkts := u.update(); len(pkts) != 0 {
+				u.params.WriteRTCP(pkts)
+			}
+		}
+	}
+}
+
+func (u *UplinkFeedback) update() []rtcp.Packet {
+	limited := true
+	total := int64(0)
+	consumable := make(map[uint32]int64)
+	tracks := u.params.GetTracks()
+	for _, track := range tracks {
+		bitrates, ok := track.GetConsumableBitrates()
+		if !ok {
+			limited = false
+			continue
+		}
+
+		for ssrc, bitrate := range bitrates {
+			consumable[ssrc] = bitrate
+			total += bitrate
+		}
+	}
+	if len(consumable) == 0 {
+		limited = false
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	var pkts []rtcp.Packet
+	// estimates only apply to video, there is nothing to send them for without published video
+	if len(tracks) != 0 {
+		if remb := u.getREMBLocked(limited, total, consumable); remb != nil {
+			pkts = append(pkts, remb)
+		}
+	}
+	if tmmbr := u.getTMMBRLocked(consumable); tmmbr != nil {
+		pkts = append(pkts, tmmbr)
+	}
+	return pkts
+}
+
+func (u *UplinkFeedback) getREMBLocked(limited bool, total int64, consumable map[uint32]int64) *rtcp.ReceiverEstimatedMaximumBitrate {
+	bitrate := rembUnlimitedBitrate
+	if u.params.MaxPublishBitrate != 0 {
+		bitrate = float32(u.params.MaxPublishBitrate)
+	}
+	if limited {
+		if u.params.GetAudioBitrate != nil {
+			total += u.params.GetAudioBitrate()
+		}
+		if capped := float32(float64(total) * (1 + rembOverheadMargin)); capped < bitrate {
+			bitrate = capped
+		}
+	}
+
+	if bitrate == rembUnlimitedBitrate {
+		if !u.rembCapped {
+			return nil
+		}
+		// lifting a cap sent before is repeated a few times as publishers hold on to the last estimate
+		u.rembLiftSends++
+		if u.rembLiftSends >= uplinkFeedbackMaxRetries {
+			u.rembCapped = false
+		}
+	} else {
+		u.rembCapped = true
+		u.rembLiftSends = 0
+	}
+
+	ssrcs := make([]uint32, 0, len(consumable))
+	for ssrc := range consumable {
+		ssrcs = append(ssrcs, ssrc)
+	}
+	return &rtcp.ReceiverEstimatedMaximumBitrate{
+		SenderSSRC: u.senderSSRC,
+		Bitrate:    bitrate,
+		SSRCs:      ssrcs,
+	}
+}
+
+func (u *UplinkFeedback) getTMMBRLocked(consumable map[uint32]int64) *sfu.TMMBR {
+	for ssrc, state := range u.tmmbrs {
+		if _, ok := consumable[ssrc]; !ok && state.bitrate != tmmbrUnlimitedBitrate {
+			state.setBitrate(tmmbrUnlimitedBitrate)
+		}
+	}
+
+	for ssrc, bitrate := range consumable {
+		// a bound of 0 pauses the stream
+		state, ok := u.tmmbrs[ssrc]
+		if !ok {
+			state = &tmmbrState{}
+			u.tmmbrs[ssrc] = state
+			state.setBitrate(uint64(bitrate))
+		} else if state.bitrate != uint64(bitrate) {
+			state.setBitrate(uint64(bitrate))
+		}
+	}
+
+	var items []sfu.TMMBItem
+	for ssrc, state := range u.tmmbrs {
+		if state.bitrate == tmmbrUnlimitedBitrate && (state.acked || state.sends >= uplinkFeedbackMaxRetries) {
+			// lifted bound is done with, publishers not supporting TMMBR never acknowledge
+			delete(u.tmmbrs, ssrc)
+			continue
+		}
+
+		state.age++
+		if (state.acked || state.sends >= uplinkFeedbackMaxRetries) && state.age < uplinkFeedbackRefreshIntervals {
+			continue
+		}
+
+		state.age = 0
+		state.sends++
+		items = append(items, sfu.TMMBItem{SSRC: ssrc, Bitrate: state.bitrate})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	return &sfu.TMMBR{
+		SenderSSRC: u.senderSSRC,
+		Items:      items,
+	}
+}
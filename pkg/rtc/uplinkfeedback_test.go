@@ -0,0 +1,141 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+type testUplinkFeedbackTrack struct {
+	consumable map[uint32]int64
+}
+
+func (t *testUplinkFeedbackTrack) GetConsumableBitrates() (map[uint32]int64, bool) {
+	return t.consumable, t.consumable != nil
+}
+
+func getUplinkFeedbackPackets(pkts []rtcp.Packet) (*rtcp.ReceiverEstimatedMaximumBitrate, *sfu.TMMBR) {
+	var remb *rtcp.ReceiverEstimatedMaximumBitrate
+	var tmmbr *sfu.TMMBR
+	for _, pkt := range pkts {
+		switch pkt := pkt.(type) {
+		case *rtcp.ReceiverEstimatedMaximumBitrate:
+			remb = pkt
+		case *sfu.TMMBR:
+			tmmbr = pkt
+		}
+	}
+	return remb, tmmbr
+}
+
+func TestUplinkFeedback(t *testing.T) {
+	t.Run("nothing sent when unlimited", func(t *testing.T) {
+		track := &testUplinkFeedbackTrack{}
+		u := NewUplinkFeedback(UplinkFeedbackParams{
+			GetTracks: func() []UplinkFeedbackTrack { return []UplinkFeedbackTrack{track} },
+			Logger:    logger.GetLogger(),
+		})
+		require.Empty(t, u.update())
+	})
+
+	t.Run("max publish bitrate", func(t *testing.T) {
+		track := &testUplinkFeedbackTrack{}
+		u := NewUplinkFeedback(UplinkFeedbackParams{
+			MaxPublishBitrate: 1_000_000,
+			GetTracks:         func() []UplinkFeedbackTrack { return []UplinkFeedbackTrack{track} },
+			Logger:            logger.GetLogger(),
+		})
+		remb, tmmbr := getUplinkFeedbackPackets(u.update())
+		require.Equal(t, float32(1_000_000), remb.Bitrate)
+		require.Nil(t, tmmbr)
+
+		// lower of what can be consumed with overhead and the limit
+		track.consumable = map[uint32]int64{1: 200_000, 2: 0}
+		remb, _ = getUplinkFeedbackPackets(u.update())
+		require.InDelta(t, 230_000, remb.Bitrate, 1)
+
+		track.consumable = map[uint32]int64{1: 200_000, 2: 2_000_000}
+		remb, _ = getUplinkFeedbackPackets(u.update())
+		require.Equal(t, float32(1_000_000), remb.Bitrate)
+	})
+
+	t.Run("nothing sent without video", func(t *testing.T) {
+		var tracks []UplinkFeedbackTrack
+		u := NewUplinkFeedback(UplinkFeedbackParams{
+			MaxPublishBitrate: 1_000_000,
+			GetTracks:         func() []UplinkFeedbackTrack { return tracks },
+			Logger:            logger.GetLogger(),
+		})
+		require.Empty(t, u.update())
+
+		tracks = []UplinkFeedbackTrack{&testUplinkFeedbackTrack{}}
+		remb, _ := getUplinkFeedbackPackets(u.update())
+		require.Equal(t, float32(1_000_000), remb.Bitrate)
+	})
+
+	t.Run("limits consumable and lifts limit", func(t *testing.T) {
+		track1 := &testUplinkFeedbackTrack{consumable: map[uint32]int64{1: 200_000, 2: 0}}
+		track2 := &testUplinkFeedbackTrack{consumable: map[uint32]int64{3: 300_000}}
+		u := NewUplinkFeedback(UplinkFeedbackParams{
+			GetTracks:       func() []UplinkFeedbackTrack { return []UplinkFeedbackTrack{track1, track2} },
+			GetAudioBitrate: func() int64 { return 100_000 },
+			Logger:          logger.GetLogger(),
+		})
+
+		// consumable video and published audio, with overhead
+		remb, tmmbr := getUplinkFeedbackPackets(u.update())
+		require.InDelta(t, 690_000, remb.Bitrate, 1)
+		require.ElementsMatch(t, []uint32{1, 2, 3}, remb.SSRCs)
+		require.ElementsMatch(t, []sfu.TMMBItem{
+			{SSRC: 1, Bitrate: 200_000},
+			{SSRC: 2, Bitrate: 0},
+			{SSRC: 3, Bitrate: 300_000},
+		}, tmmbr.Items)
+
+		// acknowledged bounds are not sent again till refresh
+		u.HandleTMMBN(&sfu.TMMBN{SenderSSRC: 1, Items: []sfu.TMMBItem{{SSRC: u.senderSSRC, Bitrate: 200_000}}})
+		u.HandleTMMBN(&sfu.TMMBN{SenderSSRC: 2, Items: []sfu.TMMBItem{{SSRC: u.senderSSRC, Bitrate: 0}}})
+		// bound of another owner
+		u.HandleTMMBN(&sfu.TMMBN{SenderSSRC: 3, Items: []sfu.TMMBItem{{SSRC: u.senderSSRC + 1, Bitrate: 300_000}}})
+		_, tmmbr = getUplinkFeedbackPackets(u.update())
+		require.ElementsMatch(t, []sfu.TMMBItem{{SSRC: 3, Bitrate: 300_000}}, tmmbr.Items)
+		u.HandleTMMBN(&sfu.TMMBN{SenderSSRC: 3, Items: []sfu.TMMBItem{{SSRC: u.senderSSRC, Bitrate: sfu.TMMBBitrate(300_000)}}})
+
+		for i := 0; i < uplinkFeedbackRefreshIntervals-2; i++ {
+			u.update()
+		}
+		_, tmmbr = getUplinkFeedbackPackets(u.update())
+		require.ElementsMatch(t, []sfu.TMMBItem{
+			{SSRC: 1, Bitrate: 200_000},
+			{SSRC: 2, Bitrate: 0},
+		}, tmmbr.Items)
+
+		// a subscriber needs more of track1, lifts its bounds and the aggregate cap
+		track1.consumable = nil
+		remb, tmmbr = getUplinkFeedbackPackets(u.update())
+		require.Equal(t, rembUnlimitedBitrate, remb.Bitrate)
+		require.Contains(t, tmmbr.Items, sfu.TMMBItem{SSRC: 1, Bitrate: tmmbrUnlimitedBitrate})
+		require.Contains(t, tmmbr.Items, sfu.TMMBItem{SSRC: 2, Bitrate: tmmbrUnlimitedBitrate})
+
+		u.HandleTMMBN(&sfu.TMMBN{SenderSSRC: 1, Items: []sfu.TMMBItem{{SSRC: u.senderSSRC, Bitrate: sfu.TMMBBitrate(tmmbrUnlimitedBitrate)}}})
+		u.HandleTMMBN(&sfu.TMMBN{SenderSSRC: 2, Items: []sfu.TMMBItem{{SSRC: u.senderSSRC, Bitrate: sfu.TMMBBitrate(tmmbrUnlimitedBitrate)}}})
+		remb, tmmbr = getUplinkFeedbackPackets(u.update())
+		require.Equal(t, rembUnlimitedBitrate, remb.Bitrate)
+		require.Nil(t, tmmbr)
+		require.NotContains(t, u.tmmbrs, uint32(1))
+		require.NotContains(t, u.tmmbrs, uint32(2))
+
+		// lifting the aggregate cap is not repeated forever
+		for i := 2; i < uplinkFeedbackMaxRetries; i++ {
+			remb, _ = getUplinkFeedbackPackets(u.update())
+			require.Equal(t, rembUnlimitedBitrate, remb.Bitrate)
+		}
+		remb, _ = getUplinkFeedbackPackets(u.update())
+		require.Nil(t, remb)
+	})
+}
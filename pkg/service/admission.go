@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+const (
+	AdmissionEventParticipantJoining = "participant_joining"
+	AdmissionEventTrackPublishing    = "track_publishing"
+)
+
+const (
+	// maximum size of a response read from the admission handler
+	admissionMaxResponseSize = 1 << 20
+	// calls block signalling of the participant, they are never left unbounded
+	admissionDefaultTimeout = 2 * time.Second
+)
+
+// AdmissionRequest is POSTed to the admission handler. The request is signed the same way as webhooks are,
+// so handlers can use webhook.Receive to verify it.
+type AdmissionRequest struct {
+	Event       string                `json:"event"`
+	RoomName    string                `json:"roomName"`
+	Participant *AdmissionParticipant `json:"participant"`
+	Track       json.RawMessage       `json:"track,omitempty"`
+	CreatedAt   int64                 `json:"createdAt"`
+}
+
+type AdmissionParticipant struct {
+	Sid        string          `json:"sid,omitempty"`
+	Identity   string          `json:"identity"`
+	Name       string          `json:"name,omitempty"`
+	Metadata   string          `json:"metadata,omitempty"`
+	Permission json.RawMessage `json:"permission,omitempty"`
+}
+
+// AdmissionResponse is the handler's decision. Fields other than Allow and Reason are optional,
+// when set they replace the corresponding values of the participant or track.
+type AdmissionResponse struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason,omitempty"`
+
+	// participant_joining
+	Name       *string         `json:"name,omitempty"`
+	Metadata   *string         `json:"metadata,omitempty"`
+	Permission json.RawMessage `json:"permission,omitempty"`
+
+	// track_publishing
+	Track *AdmissionTrackSettings `json:"track,omitempty"`
+}
+
+type AdmissionTrackSettings struct {
+	Name       *string `json:"name,omitempty"`
+	Muted      *bool   `json:"muted,omitempty"`
+	DisableDtx *bool   `json:"disableDtx,omitempty"`
+	DisableRed *bool   `json:"disableRed,omitempty"`
+}
+
+// AdmissionHook synchronously asks an external handler whether a participant may join or publish.
+// A nil AdmissionHook admits everything.
+type AdmissionHook struct {
+	conf      config.AdmissionConfig
+	apiKey    string
+	apiSecret string
+	url       string
+	client    *http.Client
+}
+
+func NewAdmissionHook(conf config.AdmissionConfig, apiKey, apiSecret string) (*AdmissionHook, error) {
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = admissionDefaultTimeout
+	}
+
+	h := &AdmissionHook{
+		conf:      conf,
+		apiKey:    apiKey,
+		apiSecret: apiSecret,
+		url:       conf.URL,
+		client:    &http.Client{Timeout: conf.Timeout},
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+	case "unix":
+		socket := u.Path
+		dialer := &net.Dialer{}
+		h.url = "http://unix/"
+		h.client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+	default:
+		return nil, fmt.Errorf("unsupported admission url scheme: %q", u.Scheme)
+	}
+	return h, nil
+}
+
+// AdmitParticipant is called before a participant joins a room, it applies any changes made by the handler to claims
+func (h *AdmissionHook) AdmitParticipant(ctx context.Context, roomName livekit.RoomName, claims *auth.ClaimGrants) error {
+	if h == nil || !h.conf.Join {
+		return nil
+	}
+
+	permission, err := protojson.Marshal(claims.Video.ToPermission())
+	if err != nil {
+		return err
+	}
+	res, err := h.call(ctx, &AdmissionRequest{
+		Event:    AdmissionEventParticipantJoining,
+		RoomName: string(roomName),
+		Participant: &AdmissionParticipant{
+			Identity:   claims.Identity,
+			Name:       claims.Name,
+			Metadata:   claims.Metadata,
+			Permission: permission,
+		},
+	})
+	if err != nil {
+		return h.handleCallError(err, "room", roomName, "participant", claims.Identity)
+	}
+	if !res.Allow {
+		return admissionDenied(res.Reason)
+	}
+
+	if res.Name != nil {
+		claims.Name = *res.Name
+	}
+	if res.Metadata != nil {
+		claims.Metadata = *res.Metadata
+	}
+	if len(res.Permission) != 0 {
+		pp := &livekit.ParticipantPermission{}
+		if err := protojson.Unmarshal(res.Permission, pp); err != nil {
+			return h.handleCallError(err, "room", roomName, "participant", claims.Identity)
+		}
+		claims.Video.UpdateFromPermission(pp)
+	}
+	return nil
+}
+
+// AdmitTrack is called before a participant publishes a track, it applies any changes made by the handler to req.
+// A refused track is rejected so that the client does not wait for a response to its publication.
+// It runs synchronously on the participant's signal goroutine, so a slow handler stalls all signalling of the participant
+// for up to the configured timeout.
+func (h *AdmissionHook) AdmitTrack(ctx context.Context, roomName livekit.RoomName, p types.LocalParticipant, req *livekit.AddTrackRequest) error {
+	if h == nil || !h.conf.Publish {
+		return nil
+	}
+
+	if err := h.admitTrack(ctx, roomName, p, req); err != nil {
+		p.RejectTrack(req.Cid, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (h *AdmissionHook) admitTrack(ctx context.Context, roomName livekit.RoomName, p types.LocalParticipant, req *livekit.AddTrackRequest) error {
+	track, err := protojson.Marshal(req)
+	if err != nil {
+		return err
+	}
+	permission, err := protojson.Marshal(p.ClaimGrants().Video.ToPermission())
+	if err != nil {
+		return err
+	}
+	pi := p.ToProto()
+	res, err := h.call(ctx, &AdmissionRequest{
+		Event:    AdmissionEventTrackPublishing,
+		RoomName: string(roomName),
+		Participant: &AdmissionParticipant{
+			Sid:        pi.Sid,
+			Identity:   pi.Identity,
+			Name:       pi.Name,
+			Metadata:   pi.Metadata,
+			Permission: permission,
+		},
+		Track: track,
+	})
+	if err != nil {
+		return h.handleCallError(err, "room", roomName, "participant", pi.Identity, "track", req.Cid)
+	}
+	if !res.Allow {
+		return admissionDenied(res.Reason)
+	}
+
+	if ts := res.Track; ts != nil {
+		if ts.Name != nil {
+			req.Name = *ts.Name
+		}
+		if ts.Muted != nil {
+			req.Muted = *ts.Muted
+		}
+		if ts.DisableDtx != nil {
+			req.DisableDtx = *ts.DisableDtx
+		}
+		if ts.DisableRed != nil {
+			req.DisableRed = *ts.DisableRed
+		}
+	}
+	return nil
+}
+
+func (h *AdmissionHook) handleCallError(err error, keysAndValues ...interface{}) error {
+	if h.conf.FailOpen {
+		logger.Warnw("admission hook failed, admitting", err, keysAndValues...)
+		return nil
+	}
+	logger.Warnw("admission hook failed", err, keysAndValues...)
+	return ErrAdmissionUnavailable
+}
+
+func (h *AdmissionHook) call(ctx context.Context, ar *AdmissionRequest) (*AdmissionResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.conf.Timeout)
+	defer cancel()
+
+	ar.CreatedAt = time.Now().Unix()
+	encoded, err := json.Marshal(ar)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := utils.NewSignedWebhookRequest(ctx, h.url, h.apiKey, h.apiSecret, encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := h.client.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, admissionMaxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	decision := &AdmissionResponse{}
+	if err = json.Unmarshal(body, decision); err != nil {
+		return nil, err
+	}
+	return decision, nil
+}
+
+func admissionDenied(reason string) error {
+	if reason == "" {
+		return ErrAdmissionDenied
+	}
+	return fmt.Errorf("%w: %s", ErrAdmissionDenied, reason)
+}
@@ -0,0 +1,197 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+const (
+	admissionKey    = "key"
+	admissionSecret = "secret"
+)
+
+func newAdmissionHandler(t *testing.T, decide func(req *service.AdmissionRequest) string) http.Handler {
+	provider := auth.NewSimpleKeyProvider(admissionKey, admissionSecret)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := webhook.Receive(r, provider)
+		require.NoError(t, err)
+
+		req := &service.AdmissionRequest{}
+		require.NoError(t, json.Unmarshal(data, req))
+		_, _ = w.Write([]byte(decide(req)))
+	})
+}
+
+func TestAdmissionHook(t *testing.T) {
+	conf := config.AdmissionConfig{
+		Timeout: time.Second,
+		Join:    true,
+		Publish: true,
+	}
+
+	newClaims := func() *auth.ClaimGrants {
+		return &auth.ClaimGrants{
+			Identity: "alice",
+			Name:     "Alice",
+			Video:    &auth.VideoGrant{RoomJoin: true, Room: "room"},
+		}
+	}
+
+	t.Run("join can be denied", func(t *testing.T) {
+		s := httptest.NewServer(newAdmissionHandler(t, func(req *service.AdmissionRequest) string {
+			require.Equal(t, service.AdmissionEventParticipantJoining, req.Event)
+			require.Equal(t, "room", req.RoomName)
+			require.Equal(t, "alice", req.Participant.Identity)
+			return `{"allow": false, "reason": "banned"}`
+		}))
+		defer s.Close()
+
+		hookConf := conf
+		hookConf.URL = s.URL
+		hook, err := service.NewAdmissionHook(hookConf, admissionKey, admissionSecret)
+		require.NoError(t, err)
+
+		err = hook.AdmitParticipant(context.Background(), "room", newClaims())
+		require.ErrorIs(t, err, service.ErrAdmissionDenied)
+		require.Contains(t, err.Error(), "banned")
+	})
+
+	t.Run("join can be rewritten", func(t *testing.T) {
+		s := httptest.NewServer(newAdmissionHandler(t, func(req *service.AdmissionRequest) string {
+			return `{"allow": true, "name": "Alice (guest)", "metadata": "{\"guest\":true}", "permission": {"canSubscribe": true}}`
+		}))
+		defer s.Close()
+
+		hookConf := conf
+		hookConf.URL = s.URL
+		hook, err := service.NewAdmissionHook(hookConf, admissionKey, admissionSecret)
+		require.NoError(t, err)
+
+		claims := newClaims()
+		require.NoError(t, hook.AdmitParticipant(context.Background(), "room", claims))
+		require.Equal(t, "Alice (guest)", claims.Name)
+		require.Equal(t, `{"guest":true}`, claims.Metadata)
+		require.True(t, claims.Video.GetCanSubscribe())
+		require.False(t, claims.Video.GetCanPublish())
+	})
+
+	t.Run("publish over unix socket", func(t *testing.T) {
+		socket := filepath.Join(t.TempDir(), "admission.sock")
+		l, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+		s := &http.Server{Handler: newAdmissionHandler(t, func(req *service.AdmissionRequest) string {
+			require.Equal(t, service.AdmissionEventTrackPublishing, req.Event)
+			require.Equal(t, "PA_alice", req.Participant.Sid)
+			track := &livekit.AddTrackRequest{}
+			require.NoError(t, protojson.Unmarshal(req.Track, track))
+			require.Equal(t, "camera", track.Name)
+			return `{"allow": true, "track": {"name": "cam", "muted": true}}`
+		})}
+		go func() {
+			_ = s.Serve(l)
+		}()
+		defer s.Close()
+
+		hookConf := conf
+		hookConf.URL = "unix://" + socket
+		hook, err := service.NewAdmissionHook(hookConf, admissionKey, admissionSecret)
+		require.NoError(t, err)
+
+		p := &typesfakes.FakeLocalParticipant{}
+		p.ToProtoReturns(&livekit.ParticipantInfo{Sid: "PA_alice", Identity: "alice"})
+		p.ClaimGrantsReturns(newClaims())
+		req := &livekit.AddTrackRequest{Cid: "cid", Name: "camera", Type: livekit.TrackType_VIDEO}
+		require.NoError(t, hook.AdmitTrack(context.Background(), "room", p, req))
+		require.Equal(t, "cam", req.Name)
+		require.True(t, req.Muted)
+	})
+
+	t.Run("denied publish is rejected", func(t *testing.T) {
+		s := httptest.NewServer(newAdmissionHandler(t, func(req *service.AdmissionRequest) string {
+			return `{"allow": false, "reason": "screen sharing is disabled"}`
+		}))
+		defer s.Close()
+
+		hookConf := conf
+		hookConf.URL = s.URL
+		hook, err := service.NewAdmissionHook(hookConf, admissionKey, admissionSecret)
+		require.NoError(t, err)
+
+		p := &typesfakes.FakeLocalParticipant{}
+		p.ToProtoReturns(&livekit.ParticipantInfo{Sid: "PA_alice", Identity: "alice"})
+		p.ClaimGrantsReturns(newClaims())
+		req := &livekit.AddTrackRequest{Cid: "cid", Type: livekit.TrackType_VIDEO, Source: livekit.TrackSource_SCREEN_SHARE}
+		err = hook.AdmitTrack(context.Background(), "room", p, req)
+		require.ErrorIs(t, err, service.ErrAdmissionDenied)
+
+		require.Equal(t, 1, p.RejectTrackCallCount())
+		cid, reason := p.RejectTrackArgsForCall(0)
+		require.Equal(t, "cid", cid)
+		require.Contains(t, reason, "screen sharing is disabled")
+	})
+
+	t.Run("unreachable handler", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer s.Close()
+
+		hookConf := conf
+		hookConf.URL = s.URL
+		hook, err := service.NewAdmissionHook(hookConf, admissionKey, admissionSecret)
+		require.NoError(t, err)
+		err = hook.AdmitParticipant(context.Background(), "room", newClaims())
+		require.ErrorIs(t, err, service.ErrAdmissionUnavailable)
+
+		hookConf.FailOpen = true
+		hook, err = service.NewAdmissionHook(hookConf, admissionKey, admissionSecret)
+		require.NoError(t, err)
+		require.NoError(t, hook.AdmitParticipant(context.Background(), "room", newClaims()))
+	})
+
+	t.Run("slow handler times out", func(t *testing.T) {
+		release := make(chan struct{})
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer s.Close()
+		defer close(release)
+
+		hookConf := conf
+		hookConf.URL = s.URL
+		hookConf.Timeout = 100 * time.Millisecond
+		hook, err := service.NewAdmissionHook(hookConf, admissionKey, admissionSecret)
+		require.NoError(t, err)
+
+		p := &typesfakes.FakeLocalParticipant{}
+		p.ToProtoReturns(&livekit.ParticipantInfo{Sid: "PA_alice", Identity: "alice"})
+		p.ClaimGrantsReturns(newClaims())
+		start := time.Now()
+		err = hook.AdmitTrack(context.Background(), "room", p, &livekit.AddTrackRequest{Cid: "cid", Type: livekit.TrackType_AUDIO})
+		require.ErrorIs(t, err, service.ErrAdmissionUnavailable)
+		require.Less(t, time.Since(start), time.Second)
+		require.Equal(t, 1, p.RejectTrackCallCount())
+	})
+
+	t.Run("disabled hooks admit everything", func(t *testing.T) {
+		var hook *service.AdmissionHook
+		require.NoError(t, hook.AdmitParticipant(context.Background(), "room", newClaims()))
+	})
+}
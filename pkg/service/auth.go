@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/twitchtv/twirp"
 
 	"github.com/livekit/protocol/auth"
@@ -20,6 +22,16 @@ const (
 
 type grantsKey struct{}
 
+type limitClaimsKey struct{}
+
+type canaryClaimsKey struct{}
+
+// LimitClaims are optional token claims limiting a participant, they are not part of the video grant
+type LimitClaims struct {
+	// bitrate the participant may publish in bps
+	MaxPublishBitrate uint64 `json:"maxPublishBitrate,omitempty"`
+}
+
 var (
 	ErrPermissionDenied          = errors.New("permissions denied")
 	ErrMissingAuthorization      = errors.New("invalid authorization header. Must start with " + bearerPrefix)
@@ -78,7 +90,21 @@ func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request,
 
 		// set grants in context
 		ctx := r.Context()
-		r = r.WithContext(context.WithValue(ctx, grantsKey{}, grants))
+		ctx = context.WithValue(ctx, grantsKey{}, grants)
+
+		// token has been verified above
+		if tok, err := jwt.ParseSigned(authToken); err == nil {
+			limits := &LimitClaims{}
+			if err := tok.UnsafeClaimsWithoutVerification(limits); err == nil {
+				ctx = context.WithValue(ctx, limitClaimsKey{}, limits)
+			}
+			canary := &canaryClaims{}
+			if err := tok.UnsafeClaimsWithoutVerification(canary); err == nil && canary.CanaryKey != "" {
+				canary.apiKey = v.APIKey()
+				ctx = context.WithValue(ctx, canaryClaimsKey{}, canary)
+			}
+		}
+		r = r.WithContext(ctx)
 	}
 
 	next.ServeHTTP(w, r)
@@ -97,6 +123,55 @@ func WithGrants(ctx context.Context, grants *auth.ClaimGrants) context.Context {
 	return context.WithValue(ctx, grantsKey{}, grants)
 }
 
+func GetLimitClaims(ctx context.Context) *LimitClaims {
+	val := ctx.Value(limitClaimsKey{})
+	limits, ok := val.(*LimitClaims)
+	if !ok {
+		return &LimitClaims{}
+	}
+	return limits
+}
+
+func getCanaryClaims(ctx context.Context) *canaryClaims {
+	val := ctx.Value(canaryClaimsKey{})
+	canary, ok := val.(*canaryClaims)
+	if !ok {
+		return nil
+	}
+	return canary
+}
+
+// ToJWTWithLimits serializes the token along with limit claims, which AccessToken cannot carry
+func ToJWTWithLimits(token *auth.AccessToken, secret string, limits LimitClaims) (string, error) {
+	if limits == (LimitClaims{}) {
+		return token.ToJWT()
+	}
+	return toJWTWithClaims(token, secret, limits)
+}
+
+// toJWTWithClaims serializes the token along with claims that AccessToken cannot carry
+func toJWTWithClaims(token *auth.AccessToken, secret string, extra interface{}) (string, error) {
+	signed, err := token.ToJWT()
+	if err != nil {
+		return "", err
+	}
+
+	tok, err := jwt.ParseSigned(signed)
+	if err != nil {
+		return "", err
+	}
+	claims := make(map[string]interface{})
+	if err = tok.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return "", err
+	}
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+	return jwt.Signed(sig).Claims(claims).Claims(extra).CompactSerialize()
+}
+
 func SetAuthorizationToken(r *http.Request, token string) {
 	r.Header.Set(authorizationHeader, bearerPrefix+token)
 }
@@ -5,6 +5,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/protocol/auth"
@@ -58,3 +60,75 @@ func TestAuthMiddleware(t *testing.T) {
 	require.Nil(t, grants)
 	require.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestAuthMiddlewareLimitClaims(t *testing.T) {
+	api := "APIabcdefg"
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	m := service.NewAPIKeyAuthMiddleware(provider)
+	var limits *service.LimitClaims
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limits = service.GetLimitClaims(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)}, (&jose.SignerOptions{}).WithType("JWT"))
+	require.NoError(t, err)
+	token, err := jwt.Signed(signer).
+		Claims(jwt.Claims{Issuer: api, Subject: "guest"}).
+		Claims(map[string]interface{}{
+			"video":             &auth.VideoGrant{Room: "abcdefg", RoomJoin: true},
+			"maxPublishBitrate": 1_500_000,
+		}).
+		CompactSerialize()
+	require.NoError(t, err)
+
+	r := &http.Request{Header: http.Header{}}
+	service.SetAuthorizationToken(r, token)
+	m.ServeHTTP(httptest.NewRecorder(), r, handler)
+	require.Equal(t, uint64(1_500_000), limits.MaxPublishBitrate)
+
+	// no limits in token
+	token, err = auth.NewAccessToken(api, secret).AddGrant(&auth.VideoGrant{Room: "abcdefg", RoomJoin: true}).ToJWT()
+	require.NoError(t, err)
+	r = &http.Request{Header: http.Header{}}
+	service.SetAuthorizationToken(r, token)
+	m.ServeHTTP(httptest.NewRecorder(), r, handler)
+	require.Zero(t, limits.MaxPublishBitrate)
+}
+
+func TestToJWTWithLimits(t *testing.T) {
+	api := "APIabcdefg"
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	m := service.NewAPIKeyAuthMiddleware(provider)
+	var grants *auth.ClaimGrants
+	var limits *service.LimitClaims
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		grants = service.GetGrants(r.Context())
+		limits = service.GetLimitClaims(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	orig := &auth.VideoGrant{Room: "abcdefg", RoomJoin: true}
+	token, err := service.ToJWTWithLimits(
+		auth.NewAccessToken(api, secret).SetIdentity("guest").SetMetadata("md").AddGrant(orig),
+		secret,
+		service.LimitClaims{MaxPublishBitrate: 1_500_000},
+	)
+	require.NoError(t, err)
+
+	r := &http.Request{Header: http.Header{}}
+	w := httptest.NewRecorder()
+	service.SetAuthorizationToken(r, token)
+	m.ServeHTTP(w, r, handler)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "guest", grants.Identity)
+	require.Equal(t, "md", grants.Metadata)
+	require.EqualValues(t, orig, grants.Video)
+	require.Equal(t, uint64(1_500_000), limits.MaxPublishBitrate)
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/frostbyte73/core"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	"github.com/livekit/livekit-server/test/client"
+)
+
+const (
+	canaryPublisherIdentity  = "canary_publisher"
+	canarySubscriberIdentity = "canary_subscriber"
+	canaryAudioBitrate       = 32_000
+)
+
+var ErrCanaryNoMedia = errors.New("no media received from canary publisher")
+
+type CanaryParams struct {
+	Config config.CanaryConfig
+	// websocket URL of this node, e. g. ws://127.0.0.1:7880
+	URL       string
+	APIKey    string
+	APISecret string
+	NodeID    livekit.NodeID
+	// used to create the canary room on this node
+	RoomAllocator RoomAllocator
+	Logger        logger.Logger
+}
+
+// CanaryResult is the outcome of a single canary probe
+type CanaryResult struct {
+	At time.Time
+	// longest time taken by the publisher or the subscriber to join
+	JoinTime time.Duration
+	// median of the time taken by audio frames to reach the subscriber
+	MediaLatency time.Duration
+	Err          error
+}
+
+// canaryClaims mark the tokens signed by the canary, with a key generated at startup that is never sent elsewhere
+type canaryClaims struct {
+	CanaryKey string `json:"canaryKey,omitempty"`
+
+	// API key the token was verified with
+	apiKey string
+}
+
+// Canary periodically joins a reserved hidden room on this node with a publisher and a subscriber,
+// using the test client, to verify that signaling and media flow end to end.
+type Canary struct {
+	params   CanaryParams
+	roomName livekit.RoomName
+	key      string
+
+	lock                sync.RWMutex
+	lastResult          *CanaryResult
+	consecutiveFailures int
+
+	done core.Fuse
+}
+
+func NewCanary(params CanaryParams) *Canary {
+	return &Canary{
+		params:   params,
+		roomName: CanaryRoomName(params.Config, params.NodeID),
+		key:      utils.RandomSecret(),
+		done:     core.NewFuse(),
+	}
+}
+
+// CanaryRoomName is the reserved room joined by the canary of a node
+func CanaryRoomName(conf config.CanaryConfig, nodeID livekit.NodeID) livekit.RoomName {
+	return livekit.RoomName(fmt.Sprintf("%s_%s", conf.Room, nodeID))
+}
+
+// isCanaryParticipant is true for participants joined by the canary of this node, which are not passed to the admission hook.
+// The token has to carry the canary's key and be signed with the canary's API key, the room and identity alone can be
+// chosen by any holder of an API key.
+func (c *Canary) isCanaryParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) bool {
+	if c == nil || roomName != c.roomName {
+		return false
+	}
+	if identity != canaryPublisherIdentity && identity != canarySubscriberIdentity {
+		return false
+	}
+	claims := getCanaryClaims(ctx)
+	if claims == nil || claims.apiKey != c.params.APIKey {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(claims.CanaryKey), []byte(c.key)) == 1
+}
+
+func (c *Canary) token(identity string, isSubscriber bool) (string, error) {
+	grant := &auth.VideoGrant{
+		RoomJoin: true,
+		Room:     string(c.roomName),
+		Hidden:   true,
+	}
+	grant.SetCanPublish(!isSubscriber)
+	grant.SetCanSubscribe(isSubscriber)
+	at := auth.NewAccessToken(c.params.APIKey, c.params.APISecret).
+		AddGrant(grant).
+		SetIdentity(identity).
+		SetValidFor(c.params.Config.Timeout + time.Minute)
+	return toJWTWithClaims(at, c.params.APISecret, canaryClaims{CanaryKey: c.key})
+}
+
+func (c *Canary) Start() {
+	go c.worker()
+}
+
+func (c *Canary) Stop() {
+	c.done.Break()
+}
+
+func (c *Canary) RoomName() livekit.RoomName {
+	return c.roomName
+}
+
+// Ready returns false once the number of consecutive failed probes reaches the failure threshold
+func (c *Canary) Ready() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	threshold := c.params.Config.FailureThreshold
+	if threshold < 1 {
+		threshold = 1
+	}
+	return c.consecutiveFailures < threshold
+}
+
+// LastResult returns the result of the latest probe, nil before the first probe completes
+func (c *Canary) LastResult() *CanaryResult {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.lastResult
+}
+
+func (c *Canary) ConsecutiveFailures() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.consecutiveFailures
+}
+
+func (c *Canary) worker() {
+	ticker := time.NewTicker(c.params.Config.Interval)
+	defer ticker.Stop()
+
+	done := c.done.Watch()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-done
+		cancel()
+	}()
+
+	for {
+		c.Probe(ctx)
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Probe runs a probe and records its result, it fails when it does not complete within the configured timeout
+func (c *Canary) Probe(ctx context.Context) CanaryResult {
+	ctx, cancel := context.WithTimeout(ctx, c.params.Config.Timeout)
+	defer cancel()
+
+	resChan := make(chan CanaryResult, 1)
+	go func() {
+		resChan <- c.probe(ctx)
+	}()
+
+	var res CanaryResult
+	select {
+	case res = <-resChan:
+	case <-ctx.Done():
+		// the probe cleans up in the background
+		res = CanaryResult{Err: ctx.Err()}
+	}
+	res.At = time.Now()
+
+	c.lock.Lock()
+	c.lastResult = &res
+	if res.Err != nil {
+		c.consecutiveFailures++
+	} else {
+		c.consecutiveFailures = 0
+	}
+	c.lock.Unlock()
+
+	ready := c.Ready()
+	prometheus.RecordCanaryProbe(res.Err == nil, res.JoinTime, res.MediaLatency)
+	prometheus.SetCanaryUp(ready)
+	if res.Err != nil {
+		c.params.Logger.Warnw("canary probe failed", res.Err,
+			"room", c.roomName,
+			"consecutiveFailures", c.ConsecutiveFailures(),
+			"ready", ready,
+		)
+	} else {
+		c.params.Logger.Debugw("canary probe succeeded",
+			"room", c.roomName,
+			"joinTime", res.JoinTime,
+			"mediaLatency", res.MediaLatency,
+		)
+	}
+	return res
+}
+
+func (c *Canary) probe(ctx context.Context) (res CanaryResult) {
+	// keep the room around between probes, it closes once probes stop
+	emptyTimeout := uint32(2 * c.params.Config.Interval.Seconds())
+	if emptyTimeout < 1 {
+		emptyTimeout = 1
+	}
+	if _, err := c.params.RoomAllocator.CreateRoom(ctx, &livekit.CreateRoomRequest{
+		Name:         string(c.roomName),
+		EmptyTimeout: emptyTimeout,
+		NodeId:       string(c.params.NodeID),
+	}); err != nil {
+		res.Err = err
+		return
+	}
+
+	publisher, joinTime, err := c.join(ctx, canaryPublisherIdentity, false, nil)
+	if err != nil {
+		res.Err = err
+		return
+	}
+	defer publisher.Stop()
+	res.JoinTime = joinTime
+
+	publisherID := publisher.ID()
+	var latencyLock sync.Mutex
+	var latencies []time.Duration
+	onRTPReceived := func(track *webrtc.TrackRemote, pkt *rtp.Packet) {
+		if pID, _ := rtc.UnpackStreamID(track.StreamID()); pID != publisherID {
+			return
+		}
+		if sentAt, ok := client.SyntheticSendTime(pkt.Payload); ok {
+			latencyLock.Lock()
+			latencies = append(latencies, time.Since(sentAt))
+			latencyLock.Unlock()
+		}
+	}
+	subscriber, joinTime, err := c.join(ctx, canarySubscriberIdentity, true, onRTPReceived)
+	if err != nil {
+		res.Err = err
+		return
+	}
+	defer subscriber.Stop()
+	if joinTime > res.JoinTime {
+		res.JoinTime = joinTime
+	}
+
+	writer, err := publisher.AddSyntheticTrack(
+		webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+		"canary_audio",
+		"canary",
+		canaryAudioBitrate,
+	)
+	if err != nil {
+		res.Err = err
+		return
+	}
+	defer writer.Stop()
+
+	// publish for the media duration, and longer if no media has been received yet
+	timer := time.NewTimer(c.params.Config.MediaDuration)
+	defer timer.Stop()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	select {
+	case <-ctx.Done():
+		res.Err = ctx.Err()
+		return
+	case <-timer.C:
+	}
+	for {
+		latencyLock.Lock()
+		received := append([]time.Duration{}, latencies...)
+		latencyLock.Unlock()
+		if len(received) > 0 {
+			sort.Slice(received, func(i, j int) bool { return received[i] < received[j] })
+			res.MediaLatency = received[len(received)/2]
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			res.Err = ErrCanaryNoMedia
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Canary) join(
+	ctx context.Context,
+	identity string,
+	isSubscriber bool,
+	onRTPReceived func(track *webrtc.TrackRemote, pkt *rtp.Packet),
+) (*client.RTCClient, time.Duration, error) {
+	// probes which timed out should not join and disconnect participants of later probes
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	token, err := c.token(identity, isSubscriber)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := time.Now()
+	conn, err := client.NewWebSocketConn(c.params.URL, token, &client.Options{AutoSubscribe: isSubscriber})
+	if err != nil {
+		return nil, 0, err
+	}
+	rtcClient, err := client.NewRTCClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, 0, err
+	}
+	rtcClient.OnRTPReceived = onRTPReceived
+	go func() {
+		_ = rtcClient.Run()
+	}()
+
+	if err = rtcClient.WaitUntilConnected(); err != nil {
+		rtcClient.Stop()
+		return nil, 0, err
+	}
+	if err = ctx.Err(); err != nil {
+		rtcClient.Stop()
+		return nil, 0, err
+	}
+	return rtcClient, time.Since(start), nil
+}
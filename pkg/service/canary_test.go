@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestCanaryParticipant(t *testing.T) {
+	keys := map[string]string{
+		"APIcanary": "canarysecretencodedinbase62",
+		"APIother":  "othersecretencodedinbase62",
+	}
+	m := NewAPIKeyAuthMiddleware(auth.NewFileBasedKeyProviderFromMap(keys))
+	verify := func(token string) context.Context {
+		var ctx context.Context
+		r := &http.Request{Header: http.Header{}}
+		SetAuthorizationToken(r, token)
+		m.ServeHTTP(httptest.NewRecorder(), r, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx = r.Context()
+		}))
+		require.NotNil(t, ctx)
+		return ctx
+	}
+
+	newCanary := func(apiKey string) *Canary {
+		return NewCanary(CanaryParams{
+			Config:    config.CanaryConfig{Enabled: true, Room: "canary"},
+			APIKey:    apiKey,
+			APISecret: keys[apiKey],
+			NodeID:    "node",
+		})
+	}
+	c := newCanary("APIcanary")
+
+	token, err := c.token(canaryPublisherIdentity, false)
+	require.NoError(t, err)
+	ctx := verify(token)
+	require.True(t, c.isCanaryParticipant(ctx, c.RoomName(), canaryPublisherIdentity))
+	require.False(t, c.isCanaryParticipant(ctx, "room", canaryPublisherIdentity))
+	require.False(t, c.isCanaryParticipant(ctx, c.RoomName(), "participant"))
+
+	// same room and identity, without the canary's key
+	token, err = auth.NewAccessToken("APIcanary", keys["APIcanary"]).
+		AddGrant(&auth.VideoGrant{RoomJoin: true, Room: string(c.RoomName())}).
+		SetIdentity(canarySubscriberIdentity).
+		ToJWT()
+	require.NoError(t, err)
+	require.False(t, c.isCanaryParticipant(verify(token), c.RoomName(), canarySubscriberIdentity))
+
+	// key of another canary, or signed with another API key
+	token, err = newCanary("APIcanary").token(canarySubscriberIdentity, true)
+	require.NoError(t, err)
+	require.False(t, c.isCanaryParticipant(verify(token), c.RoomName(), canarySubscriberIdentity))
+
+	other := newCanary("APIother")
+	other.key = c.key
+	token, err = other.token(canarySubscriberIdentity, true)
+	require.NoError(t, err)
+	require.False(t, c.isCanaryParticipant(verify(token), c.RoomName(), canarySubscriberIdentity))
+
+	// canary disabled
+	var disabled *Canary
+	require.False(t, disabled.isCanaryParticipant(ctx, c.RoomName(), canaryPublisherIdentity))
+}
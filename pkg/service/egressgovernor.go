@@ -0,0 +1,229 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/frostbyte73/core"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+)
+
+const (
+	// ceiling is raised when outgoing bitrate is below this fraction of the budget
+	egressGovernorLowWatermark = 0.85
+	egressGovernorRaiseFactor  = 1.25
+)
+
+type EgressGovernorParams struct {
+	Config config.EgressGovernorConfig
+	// participants hosted on this node
+	GetParticipants func() []types.LocalParticipant
+	// total bytes sent by this node
+	GetBytesOut func() uint64
+	Logger      logger.Logger
+}
+
+// EgressGovernor keeps outgoing bitrate of the node under a budget. Stream allocators of subscribers work
+// independently and cannot see the node's limit, so when the node is over budget, stream allocator of
+// every subscriber is given the same channel capacity ceiling. Subscribers receiving the most are degraded first,
+// evenly across rooms. Only when the node stays over budget with the ceiling at its minimum are
+// subscribers disconnected, if enabled.
+type EgressGovernor struct {
+	params EgressGovernorParams
+
+	lock          sync.Mutex
+	lastBytesOut  uint64
+	lastUpdatedAt time.Time
+	// channel capacity subscribers are capped to in bps, 0 when not governing
+	ceiling      int64
+	capped       map[livekit.ParticipantID]cappedSubscriber
+	overBudgetAt time.Time
+
+	done core.Fuse
+}
+
+type cappedSubscriber struct {
+	participant types.LocalParticipant
+	ceiling     int64
+}
+
+func NewEgressGovernor(params EgressGovernorParams) *EgressGovernor {
+	return &EgressGovernor{
+		params: params,
+		capped: make(map[livekit.ParticipantID]cappedSubscriber),
+		done:   core.NewFuse(),
+	}
+}
+
+func (g *EgressGovernor) Start() {
+	go g.worker()
+}
+
+func (g *EgressGovernor) Stop() {
+	g.done.Break()
+}
+
+// Ceiling returns the channel capacity subscribers are capped to, 0 when not governing
+func (g *EgressGovernor) Ceiling() int64 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.ceiling
+}
+
+func (g *EgressGovernor) worker() {
+	ticker := time.NewTicker(g.params.Config.UpdateInterval)
+	defer ticker.Stop()
+
+	done := g.done.Watch()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			g.Update(now)
+		}
+	}
+}
+
+// Update measures outgoing bitrate since the last update and adjusts the ceiling
+func (g *EgressGovernor) Update(now time.Time) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	bytesOut := g.params.GetBytesOut()
+	if g.lastUpdatedAt.IsZero() {
+		g.lastBytesOut = bytesOut
+		g.lastUpdatedAt = now
+		return
+	}
+
+	elapsed := now.Sub(g.lastUpdatedAt).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	bitrate := int64(float64(bytesOut-g.lastBytesOut) * 8 / elapsed)
+	g.lastBytesOut = bytesOut
+	g.lastUpdatedAt = now
+
+	subscribers := g.getSubscribers()
+	budget := int64(g.params.Config.BytesPerSec * 8)
+	minCeiling := g.params.Config.MinSubscriberBitrate
+
+	prevCeiling := g.ceiling
+	switch {
+	case bitrate > budget && len(subscribers) != 0:
+		ceiling := g.ceiling
+		if ceiling == 0 {
+			// start from the average, caps subscribers receiving more than average
+			ceiling = bitrate / int64(len(subscribers))
+		}
+		ceiling = int64(float64(ceiling) * float64(budget) / float64(bitrate))
+		if ceiling < minCeiling {
+			ceiling = minCeiling
+		}
+		g.ceiling = ceiling
+
+	case g.ceiling != 0 && float64(bitrate) < egressGovernorLowWatermark*float64(budget):
+		g.ceiling = int64(float64(g.ceiling) * egressGovernorRaiseFactor)
+		if g.ceiling >= budget || g.ceiling >= streamallocator.ChannelCapacityInfinity {
+			// high enough to not limit any subscriber
+			g.ceiling = 0
+		}
+	}
+	if g.ceiling != prevCeiling {
+		g.params.Logger.Infow(
+			"egress governor: updating subscriber ceiling",
+			"bitrate", bitrate,
+			"budget", budget,
+			"numSubscribers", len(subscribers),
+			"old(bps)", prevCeiling,
+			"new(bps)", g.ceiling,
+		)
+	}
+
+	g.applyCeiling(subscribers)
+	g.maybeEvict(now, bitrate > budget && g.ceiling == minCeiling, subscribers)
+}
+
+func (g *EgressGovernor) getSubscribers() []types.LocalParticipant {
+	var subscribers []types.LocalParticipant
+	for _, p := range g.params.GetParticipants() {
+		if p.IsClosed() || len(p.GetSubscribedTracks()) == 0 {
+			continue
+		}
+		subscribers = append(subscribers, p)
+	}
+	return subscribers
+}
+
+func (g *EgressGovernor) applyCeiling(subscribers []types.LocalParticipant) {
+	if g.ceiling == 0 {
+		for _, c := range g.capped {
+			c.participant.SetSubscriberChannelCapacityCeiling(0)
+		}
+		g.capped = make(map[livekit.ParticipantID]cappedSubscriber)
+		return
+	}
+
+	// posted only when changed as stream allocator re-allocates all tracks on a new ceiling,
+	// tracks subscribed later are capped by the allocator itself
+	capped := make(map[livekit.ParticipantID]cappedSubscriber, len(subscribers))
+	for _, p := range subscribers {
+		if c, ok := g.capped[p.ID()]; !ok || c.ceiling != g.ceiling {
+			p.SetSubscriberChannelCapacityCeiling(g.ceiling)
+		}
+		capped[p.ID()] = cappedSubscriber{participant: p, ceiling: g.ceiling}
+	}
+	for pID, c := range g.capped {
+		if _, ok := capped[pID]; !ok && !c.participant.IsClosed() {
+			c.participant.SetSubscriberChannelCapacityCeiling(0)
+		}
+	}
+	g.capped = capped
+}
+
+func (g *EgressGovernor) maybeEvict(now time.Time, isOvercommitted bool, subscribers []types.LocalParticipant) {
+	if !isOvercommitted {
+		g.overBudgetAt = time.Time{}
+		return
+	}
+
+	if g.overBudgetAt.IsZero() {
+		g.overBudgetAt = now
+		return
+	}
+
+	if g.params.Config.EvictAfter == 0 || now.Sub(g.overBudgetAt) < g.params.Config.EvictAfter {
+		return
+	}
+
+	// most recently joined subscriber is disconnected, one at a time
+	var newest types.LocalParticipant
+	for _, p := range subscribers {
+		if newest == nil || p.ConnectedAt().After(newest.ConnectedAt()) {
+			newest = p
+		}
+	}
+	if newest == nil {
+		return
+	}
+
+	g.params.Logger.Infow(
+		"egress governor: node overcommitted, disconnecting subscriber",
+		"participant", newest.Identity(),
+		"pID", newest.ID(),
+		"ceiling", g.ceiling,
+	)
+	delete(g.capped, newest.ID())
+	go func() {
+		_ = newest.Close(true, types.ParticipantCloseReasonOvercommitted)
+	}()
+	g.overBudgetAt = now
+}
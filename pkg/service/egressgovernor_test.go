@@ -0,0 +1,143 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func newGovernedParticipant(id string, connectedAt time.Time, subscribed bool) *typesfakes.FakeLocalParticipant {
+	p := &typesfakes.FakeLocalParticipant{}
+	p.IDReturns(livekit.ParticipantID(id))
+	p.IdentityReturns(livekit.ParticipantIdentity(id))
+	p.ConnectedAtReturns(connectedAt)
+	if subscribed {
+		p.GetSubscribedTracksReturns([]types.SubscribedTrack{&typesfakes.FakeSubscribedTrack{}})
+	}
+	return p
+}
+
+func lastCeiling(p *typesfakes.FakeLocalParticipant) int64 {
+	return p.SetSubscriberChannelCapacityCeilingArgsForCall(p.SetSubscriberChannelCapacityCeilingCallCount() - 1)
+}
+
+func TestEgressGovernor(t *testing.T) {
+	start := time.Now()
+
+	t.Run("caps subscribers evenly and releases when under budget", func(t *testing.T) {
+		p1 := newGovernedParticipant("p1", start, true)
+		p2 := newGovernedParticipant("p2", start, true)
+		// not subscribed to anything, not counted and not capped
+		p3 := newGovernedParticipant("p3", start, false)
+
+		bytesOut := uint64(0)
+		g := service.NewEgressGovernor(service.EgressGovernorParams{
+			Config: config.EgressGovernorConfig{
+				BytesPerSec:          1000,
+				MinSubscriberBitrate: 1000,
+			},
+			GetParticipants: func() []types.LocalParticipant {
+				return []types.LocalParticipant{p1, p2, p3}
+			},
+			GetBytesOut: func() uint64 { return bytesOut },
+			Logger:      logger.GetLogger(),
+		})
+
+		now := start
+		update := func(sentBytes uint64) {
+			bytesOut += sentBytes
+			now = now.Add(time.Second)
+			g.Update(now)
+		}
+
+		g.Update(now)
+		require.Zero(t, g.Ceiling())
+
+		// under budget, nothing to do
+		update(900)
+		require.Zero(t, g.Ceiling())
+		require.Zero(t, p1.SetSubscriberChannelCapacityCeilingCallCount())
+
+		// 16 kbps against 8 kbps budget, average of 8 kbps per subscriber scaled down by half
+		update(2000)
+		require.Equal(t, int64(4000), g.Ceiling())
+		require.Equal(t, int64(4000), lastCeiling(p1))
+		require.Equal(t, int64(4000), lastCeiling(p2))
+		require.Zero(t, p3.SetSubscriberChannelCapacityCeilingCallCount())
+		// original override used by simulation is left alone
+		require.Zero(t, p1.SetSubscriberChannelCapacityCallCount())
+
+		// still over budget
+		update(1500)
+		require.Equal(t, int64(2666), g.Ceiling())
+		require.Equal(t, int64(2666), lastCeiling(p1))
+
+		// between low watermark and budget, hold, unchanged ceiling is not posted again
+		numCalls := p1.SetSubscriberChannelCapacityCeilingCallCount()
+		update(900)
+		require.Equal(t, int64(2666), g.Ceiling())
+		require.Equal(t, numCalls, p1.SetSubscriberChannelCapacityCeilingCallCount())
+
+		// under low watermark, raise till released
+		for i := 0; i < 10 && g.Ceiling() != 0; i++ {
+			update(500)
+		}
+		require.Zero(t, g.Ceiling())
+		require.Zero(t, lastCeiling(p1))
+		require.Zero(t, lastCeiling(p2))
+	})
+
+	t.Run("evicts newest subscriber when over budget at minimum", func(t *testing.T) {
+		p1 := newGovernedParticipant("p1", start, true)
+		p2 := newGovernedParticipant("p2", start.Add(time.Minute), true)
+
+		bytesOut := uint64(0)
+		g := service.NewEgressGovernor(service.EgressGovernorParams{
+			Config: config.EgressGovernorConfig{
+				BytesPerSec:          1000,
+				MinSubscriberBitrate: 3000,
+				EvictAfter:           2 * time.Second,
+			},
+			GetParticipants: func() []types.LocalParticipant {
+				return []types.LocalParticipant{p1, p2}
+			},
+			GetBytesOut: func() uint64 { return bytesOut },
+			Logger:      logger.GetLogger(),
+		})
+
+		now := start
+		update := func() {
+			bytesOut += 10_000
+			now = now.Add(time.Second)
+			g.Update(now)
+		}
+
+		g.Update(now)
+		update()
+		require.Equal(t, int64(4000), g.Ceiling())
+
+		// floored at minimum, not evicted till over budget at minimum for long enough
+		for i := 0; i < 2; i++ {
+			update()
+			require.Equal(t, int64(3000), g.Ceiling())
+			require.Zero(t, p2.CloseCallCount())
+		}
+		update()
+
+		require.Eventually(t, func() bool {
+			return p2.CloseCallCount() == 1
+		}, time.Second, 10*time.Millisecond)
+		_, reason := p2.CloseArgsForCall(0)
+		require.Equal(t, types.ParticipantCloseReasonOvercommitted, reason)
+		require.Zero(t, p1.CloseCallCount())
+	})
+}
@@ -5,17 +5,27 @@ import (
 )
 
 var (
-	ErrEgressNotFound        = psrpc.NewErrorf(psrpc.NotFound, "egress does not exist")
-	ErrEgressNotConnected    = psrpc.NewErrorf(psrpc.Internal, "egress not connected (redis required)")
-	ErrIdentityEmpty         = psrpc.NewErrorf(psrpc.InvalidArgument, "identity cannot be empty")
-	ErrIngressNotConnected   = psrpc.NewErrorf(psrpc.Internal, "ingress not connected (redis required)")
-	ErrIngressNotFound       = psrpc.NewErrorf(psrpc.NotFound, "ingress does not exist")
-	ErrMetadataExceedsLimits = psrpc.NewErrorf(psrpc.InvalidArgument, "metadata size exceeds limits")
-	ErrOperationFailed       = psrpc.NewErrorf(psrpc.Internal, "operation cannot be completed")
-	ErrParticipantNotFound   = psrpc.NewErrorf(psrpc.NotFound, "participant does not exist")
-	ErrRoomNotFound          = psrpc.NewErrorf(psrpc.NotFound, "requested room does not exist")
-	ErrRoomLockFailed        = psrpc.NewErrorf(psrpc.Internal, "could not lock room")
-	ErrRoomUnlockFailed      = psrpc.NewErrorf(psrpc.Internal, "could not unlock room, lock token does not match")
-	ErrTrackNotFound         = psrpc.NewErrorf(psrpc.NotFound, "track is not found")
-	ErrWebHookMissingAPIKey  = psrpc.NewErrorf(psrpc.InvalidArgument, "api_key is required to use webhooks")
+	ErrAdmissionDenied        = psrpc.NewErrorf(psrpc.PermissionDenied, "denied by admission hook")
+	ErrAdmissionMissingAPIKey = psrpc.NewErrorf(psrpc.InvalidArgument, "api_key is required to use admission hooks")
+	ErrAdmissionUnavailable   = psrpc.NewErrorf(psrpc.Unavailable, "admission hook unavailable")
+	ErrEgressNotFound         = psrpc.NewErrorf(psrpc.NotFound, "egress does not exist")
+	ErrEgressNotConnected     = psrpc.NewErrorf(psrpc.Internal, "egress not connected (redis required)")
+	ErrForwardAcrossNodes     = psrpc.NewErrorf(psrpc.Unavailable, "destination room is hosted on another node")
+	ErrForwardToSameRoom      = psrpc.NewErrorf(psrpc.InvalidArgument, "track is already in the destination room")
+	ErrForwarderIdentityInUse = psrpc.NewErrorf(psrpc.AlreadyExists, "identity is in use by a participant that is not forwarding tracks")
+	ErrHLSInvalidTracks       = psrpc.NewErrorf(psrpc.InvalidArgument, "at most one H.264 video and one Opus audio track can be packaged")
+	ErrHLSNotFound            = psrpc.NewErrorf(psrpc.NotFound, "hls stream does not exist")
+	ErrIdentityEmpty          = psrpc.NewErrorf(psrpc.InvalidArgument, "identity cannot be empty")
+	ErrIdentityInUse          = psrpc.NewErrorf(psrpc.AlreadyExists, "identity is in use")
+	ErrIngressNotConnected    = psrpc.NewErrorf(psrpc.Internal, "ingress not connected (redis required)")
+	ErrIngressNotFound        = psrpc.NewErrorf(psrpc.NotFound, "ingress does not exist")
+	ErrMetadataExceedsLimits  = psrpc.NewErrorf(psrpc.InvalidArgument, "metadata size exceeds limits")
+	ErrMoveToSameRoom         = psrpc.NewErrorf(psrpc.InvalidArgument, "participant is already in the destination room")
+	ErrOperationFailed        = psrpc.NewErrorf(psrpc.Internal, "operation cannot be completed")
+	ErrParticipantNotFound    = psrpc.NewErrorf(psrpc.NotFound, "participant does not exist")
+	ErrRoomNotFound           = psrpc.NewErrorf(psrpc.NotFound, "requested room does not exist")
+	ErrRoomLockFailed         = psrpc.NewErrorf(psrpc.Internal, "could not lock room")
+	ErrRoomUnlockFailed       = psrpc.NewErrorf(psrpc.Internal, "could not unlock room, lock token does not match")
+	ErrTrackNotFound          = psrpc.NewErrorf(psrpc.NotFound, "track is not found")
+	ErrWebHookMissingAPIKey   = psrpc.NewErrorf(psrpc.InvalidArgument, "api_key is required to use webhooks")
 )
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/hls"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	// HLSPathPrefix is where packaged streams are served, playlists are at HLSPathPrefix + <id>/index.m3u8
+	HLSPathPrefix = "/hls/"
+
+	HLSPrefix = "HLS_"
+)
+
+type HLSInfo struct {
+	ID        string   `json:"id"`
+	Room      string   `json:"room"`
+	TrackSids []string `json:"trackSids"`
+	// path of the multivariant playlist on the main port of the node hosting the room, it is not served
+	// by other nodes
+	PlaylistURL string `json:"playlistUrl"`
+	// IP of the node hosting the room
+	NodeIP    string `json:"nodeIp"`
+	StartedAt int64  `json:"startedAt"`
+}
+
+// HLSService packages tracks into LL-HLS on the node hosting their room. Tracks are received by a hidden
+// in-process participant, the stream stops when its tracks are unpublished or the room closes.
+// Playlists and segments are public, their URLs are not guessable. They are only served by the node
+// hosting the room, requests are not routed between nodes, so the node is the origin to configure on a CDN.
+type HLSService struct {
+	config      config.HLSConfig
+	roomManager *RoomManager
+
+	lock    sync.RWMutex
+	streams map[string]*hlsStream
+}
+
+type hlsStream struct {
+	info        *HLSInfo
+	packager    *hls.Packager
+	participant *rtc.InProcessParticipant
+}
+
+func NewHLSService(conf *config.Config, roomManager *RoomManager) *HLSService {
+	return &HLSService{
+		config:      conf.HLS,
+		roomManager: roomManager,
+		streams:     make(map[string]*hlsStream),
+	}
+}
+
+func (s *HLSService) PathPrefix() string {
+	return HLSPathPrefix
+}
+
+func (s *HLSService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, path, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, HLSPathPrefix), "/")
+
+	s.lock.RLock()
+	stream := s.streams[id]
+	s.lock.RUnlock()
+	if stream == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = "/" + path
+	stream.packager.ServeHTTP(w, r2)
+}
+
+// Start packages up to one H.264 video and one Opus audio track of a room
+func (s *HLSService) Start(ctx context.Context, roomName livekit.RoomName, trackIDs []livekit.TrackID) (*HLSInfo, error) {
+	room := s.roomManager.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	var videoTrackID, audioTrackID livekit.TrackID
+	for _, trackID := range trackIDs {
+		track := findPublishedTrack(room, trackID)
+		if track == nil {
+			return nil, ErrTrackNotFound
+		}
+		ti := track.ToProto()
+		switch {
+		case ti.Type == livekit.TrackType_VIDEO && videoTrackID == "" && strings.EqualFold(ti.MimeType, "video/h264"):
+			videoTrackID = trackID
+		case ti.Type == livekit.TrackType_AUDIO && audioTrackID == "" && strings.EqualFold(ti.MimeType, "audio/opus"):
+			audioTrackID = trackID
+		default:
+			return nil, ErrHLSInvalidTracks
+		}
+	}
+	if videoTrackID == "" && audioTrackID == "" {
+		return nil, ErrHLSInvalidTracks
+	}
+
+	id := utils.NewGuid(HLSPrefix)
+	info := &HLSInfo{
+		ID:          id,
+		Room:        string(roomName),
+		PlaylistURL: HLSPathPrefix + id + "/index.m3u8",
+		NodeIP:      s.roomManager.currentNode.Ip,
+		StartedAt:   time.Now().Unix(),
+	}
+	for _, trackID := range trackIDs {
+		info.TrackSids = append(info.TrackSids, string(trackID))
+	}
+	packager := hls.NewPackager(hls.PackagerParams{
+		Config: hls.Config{
+			PartDuration:    s.config.PartDuration,
+			SegmentDuration: s.config.SegmentDuration,
+			PlaylistSize:    s.config.PlaylistSize,
+		},
+		Logger: room.Logger.WithValues("hlsID", id),
+	})
+
+	canPublish := false
+	participant, err := rtc.NewInProcessParticipant(rtc.InProcessParticipantParams{
+		Identity: livekit.ParticipantIdentity(id),
+		Grants: &auth.ClaimGrants{
+			Identity: id,
+			Video: &auth.VideoGrant{
+				RoomJoin:   true,
+				Room:       string(roomName),
+				CanPublish: &canPublish,
+				Hidden:     true,
+			},
+		},
+		Telemetry:        s.roomManager.telemetry,
+		Logger:           room.Logger,
+		VersionGenerator: s.roomManager.versionGenerator,
+		Config:           s.roomManager.rtcConfig,
+		OnTrackSubscribed: func(p *rtc.InProcessParticipant, track types.MediaTrack, receiver sfu.TrackReceiver) {
+			var err error
+			if track.ID() == videoTrackID {
+				err = packager.AddVideoTrack(track.ToProto(), receiver.Codec())
+				packager.OnKeyFrameRequest(func(layer int32) {
+					receiver.SendPLI(layer, false)
+				})
+			} else {
+				err = packager.AddAudioTrack(receiver.Codec())
+			}
+			if err != nil {
+				p.GetLogger().Warnw("could not package track", err, "trackID", track.ID())
+			}
+		},
+		OnTrackPacket: func(_ *rtc.InProcessParticipant, trackID livekit.TrackID, pkt *buffer.ExtPacket, layer int32) {
+			if trackID == videoTrackID {
+				packager.WriteVideoPacket(pkt, layer)
+			} else {
+				packager.WriteAudioPacket(pkt)
+			}
+		},
+		OnTrackUnsubscribed: func(p *rtc.InProcessParticipant, trackID livekit.TrackID) {
+			if trackID == videoTrackID {
+				packager.RemoveVideoTrack()
+			} else {
+				packager.RemoveAudioTrack()
+			}
+			if packager.IsEmpty() {
+				go p.Leave()
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	participant.OnClose(func(p types.LocalParticipant) {
+		s.lock.Lock()
+		delete(s.streams, id)
+		s.lock.Unlock()
+		packager.Close()
+		p.GetLogger().Infow("hls stopped", "hlsID", id)
+	})
+
+	s.lock.Lock()
+	s.streams[id] = &hlsStream{
+		info:        info,
+		packager:    packager,
+		participant: participant,
+	}
+	s.lock.Unlock()
+
+	if err = participant.Join(room); err != nil {
+		_ = participant.Close(false, types.ParticipantCloseReasonJoinFailed)
+		return nil, err
+	}
+
+	for _, trackID := range trackIDs {
+		participant.SubscribeToTrack(trackID)
+	}
+	room.Logger.Infow("hls started", "hlsID", id, "trackIDs", trackIDs)
+	return info, nil
+}
+
+func (s *HLSService) Stop(roomName livekit.RoomName, id string) error {
+	s.lock.RLock()
+	stream := s.streams[id]
+	s.lock.RUnlock()
+	if stream == nil || stream.info.Room != string(roomName) {
+		return ErrHLSNotFound
+	}
+
+	stream.participant.Leave()
+	return nil
+}
+
+// List returns streams of a room, oldest first
+func (s *HLSService) List(roomName livekit.RoomName) []*HLSInfo {
+	s.lock.RLock()
+	infos := make([]*HLSInfo, 0, len(s.streams))
+	for _, stream := range s.streams {
+		if stream.info.Room == string(roomName) {
+			infos = append(infos, stream.info)
+		}
+	}
+	s.lock.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt < infos[j].StartedAt
+	})
+	return infos
+}
+
+func findPublishedTrack(room *rtc.Room, trackID livekit.TrackID) types.MediaTrack {
+	for _, p := range room.GetParticipants() {
+		if track := p.GetPublishedTrack(trackID); track != nil {
+			return track
+		}
+	}
+	return nil
+}
@@ -3,11 +3,13 @@ package service
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.uber.org/atomic"
 
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	"github.com/livekit/livekit-server/version"
@@ -32,6 +34,19 @@ const (
 	iceConfigTTL         = 5 * time.Minute
 )
 
+// participantSession binds a participant session to the room it is in, which changes when the participant is moved
+type participantSession struct {
+	room atomic.Pointer[rtc.Room]
+	// limits of the participant's token, carried into refreshed tokens
+	limits LimitClaims
+}
+
+func newParticipantSession(room *rtc.Room) *participantSession {
+	s := &participantSession{}
+	s.room.Store(room)
+	return s
+}
+
 type iceConfigCacheEntry struct {
 	iceConfig  *livekit.ICEConfig
 	modifiedAt time.Time
@@ -52,8 +67,13 @@ type RoomManager struct {
 	clientConfManager clientconfiguration.ClientConfigurationManager
 	egressLauncher    rtc.EgressLauncher
 	versionGenerator  utils.TimedVersionGenerator
+	admission         *AdmissionHook
+	egressGovernor    *EgressGovernor
 
-	rooms map[livekit.RoomName]*rtc.Room
+	rooms    map[livekit.RoomName]*rtc.Room
+	sessions map[livekit.ParticipantID]*participantSession
+	// ingests receiving tracks forwarded from other nodes, by the ID of the track in the destination room
+	forwardIngests map[livekit.TrackID]*rtc.RTPIngest
 
 	iceConfigCache map[livekit.ParticipantIdentity]*iceConfigCacheEntry
 }
@@ -67,6 +87,7 @@ func NewLocalRoomManager(
 	clientConfManager clientconfiguration.ClientConfigurationManager,
 	egressLauncher rtc.EgressLauncher,
 	versionGenerator utils.TimedVersionGenerator,
+	admission *AdmissionHook,
 ) (*RoomManager, error) {
 	rtcConf, err := rtc.NewWebRTCConfig(conf)
 	if err != nil {
@@ -83,8 +104,12 @@ func NewLocalRoomManager(
 		clientConfManager: clientConfManager,
 		egressLauncher:    egressLauncher,
 		versionGenerator:  versionGenerator,
+		admission:         admission,
 
-		rooms: make(map[livekit.RoomName]*rtc.Room),
+		rooms:    make(map[livekit.RoomName]*rtc.Room),
+		sessions: make(map[livekit.ParticipantID]*participantSession),
+
+		forwardIngests: make(map[livekit.TrackID]*rtc.RTPIngest),
 
 		iceConfigCache: make(map[livekit.ParticipantIdentity]*iceConfigCacheEntry),
 
@@ -97,6 +122,16 @@ func NewLocalRoomManager(
 		},
 	}
 
+	if conf.Limit.EgressGovernor.BytesPerSec > 0 {
+		r.egressGovernor = NewEgressGovernor(EgressGovernorParams{
+			Config:          conf.Limit.EgressGovernor,
+			GetParticipants: r.getLocalParticipants,
+			GetBytesOut:     prometheus.BytesOut,
+			Logger:          logger.GetLogger(),
+		})
+		r.egressGovernor.Start()
+	}
+
 	// hook up to router
 	router.OnNewParticipantRTC(r.StartSession)
 	router.OnRTCMessage(r.handleRTCMessage)
@@ -183,7 +218,26 @@ func (r *RoomManager) HasParticipants() bool {
 	return false
 }
 
+func (r *RoomManager) getLocalParticipants() []types.LocalParticipant {
+	r.lock.RLock()
+	rooms := make([]*rtc.Room, 0, len(r.rooms))
+	for _, rm := range r.rooms {
+		rooms = append(rooms, rm)
+	}
+	r.lock.RUnlock()
+
+	var participants []types.LocalParticipant
+	for _, room := range rooms {
+		participants = append(participants, room.GetParticipants()...)
+	}
+	return participants
+}
+
 func (r *RoomManager) Stop() {
+	if r.egressGovernor != nil {
+		r.egressGovernor.Stop()
+	}
+
 	// disconnect all clients
 	r.lock.RLock()
 	rooms := make([]*rtc.Room, 0, len(r.rooms))
@@ -229,6 +283,8 @@ func (r *RoomManager) StartSession(
 	if pi.Identity == "" {
 		return nil
 	}
+	// a session of the same identity is replaced when the client fully reconnects
+	replacesSession := false
 	participant := room.GetParticipant(pi.Identity)
 	if participant != nil {
 		// When reconnecting, it means WS has interrupted by underlying peer connection is still ok
@@ -251,12 +307,17 @@ func (r *RoomManager) StartSession(
 				return err
 			}
 			r.telemetry.ParticipantResumed(ctx, room.ToProto(), participant.ToProto(), livekit.NodeID(r.currentNode.Id), pi.ReconnectReason)
-			go r.rtcSessionWorker(room, participant, requestSource)
+			session := r.getSession(participant.ID())
+			if session == nil {
+				session = r.addSession(participant.ID(), room)
+			}
+			go r.rtcSessionWorker(session, participant, requestSource)
 			return nil
 		} else {
 			participant.GetLogger().Infow("removing duplicate participant")
 			// we need to clean up the existing participant, so a new one can join
 			room.RemoveParticipant(participant.Identity(), participant.ID(), types.ParticipantCloseReasonDuplicateIdentity)
+			replacesSession = true
 		}
 	} else if pi.Reconnect {
 		// send leave request if participant is trying to reconnect without keep subscribe state
@@ -315,6 +376,13 @@ func (r *RoomManager) StartSession(
 	if r.config.RTC.AllowTimestampAdjustment != nil {
 		allowTimestampAdjustment = *r.config.RTC.AllowTimestampAdjustment
 	}
+	// limit in participant's token takes precedence over room's
+	maxPublishBitrate := uint64(r.config.Room.MaxPublishBitrate)
+	if pi.MaxPublishBitrate != 0 {
+		maxPublishBitrate = pi.MaxPublishBitrate
+	}
+	session := newParticipantSession(room)
+	session.limits = LimitClaims{MaxPublishBitrate: pi.MaxPublishBitrate}
 	participant, err = rtc.NewParticipant(rtc.ParticipantParams{
 		Identity:                pi.Identity,
 		Name:                    pi.Name,
@@ -337,7 +405,7 @@ func (r *RoomManager) StartSession(
 		AllowTCPFallback:        allowFallback,
 		TURNSEnabled:            r.config.IsTURNSEnabled(),
 		GetParticipantInfo: func(pID livekit.ParticipantID) *livekit.ParticipantInfo {
-			if p := room.GetParticipantByID(pID); p != nil {
+			if p := session.room.Load().GetParticipantByID(pID); p != nil {
 				return p.ToProto()
 			}
 			return nil
@@ -345,11 +413,20 @@ func (r *RoomManager) StartSession(
 		ReconnectOnPublicationError:  reconnectOnPublicationError,
 		ReconnectOnSubscriptionError: reconnectOnSubscriptionError,
 		VersionGenerator:             r.versionGenerator,
-		TrackResolver:                room.ResolveMediaTrackForSubscriber,
-		SubscriberAllowPause:         subscriberAllowPause,
-		SubscriptionLimitAudio:       r.config.Limit.SubscriptionLimitAudio,
-		SubscriptionLimitVideo:       r.config.Limit.SubscriptionLimitVideo,
-		AllowTimestampAdjustment:     allowTimestampAdjustment,
+		TrackResolver: func(identity livekit.ParticipantIdentity, trackID livekit.TrackID) types.MediaResolverResult {
+			return session.room.Load().ResolveMediaTrackForSubscriber(identity, trackID)
+		},
+		SubscriberAllowPause:     subscriberAllowPause,
+		SubscriptionLimitAudio:   r.config.Limit.SubscriptionLimitAudio,
+		SubscriptionLimitVideo:   r.config.Limit.SubscriptionLimitVideo,
+		AllowTimestampAdjustment: allowTimestampAdjustment,
+		MaxPublishBitrate:        maxPublishBitrate,
+		AdmitTrack: func(p types.LocalParticipant, req *livekit.AddTrackRequest) error {
+			if pi.Canary {
+				return nil
+			}
+			return r.admission.AdmitTrack(context.Background(), session.room.Load().Name(), p, req)
+		},
 	})
 	if err != nil {
 		return err
@@ -365,37 +442,40 @@ func (r *RoomManager) StartSession(
 		_ = participant.Close(true, types.ParticipantCloseReasonJoinFailed)
 		return err
 	}
+	r.lock.Lock()
+	r.sessions[participant.ID()] = session
+	r.lock.Unlock()
 	if err = r.roomStore.StoreParticipant(ctx, roomName, participant.ToProto()); err != nil {
 		pLogger.Errorw("could not store participant", err)
 	}
 
-	persistRoomForParticipantCount := func(proto *livekit.Room) {
-		if !participant.Hidden() {
-			err = r.roomStore.StoreRoom(ctx, proto, room.Internal())
-			if err != nil {
-				logger.Errorw("could not store room", err)
-			}
-		}
-	}
-
 	// update room store with new numParticipants
-	persistRoomForParticipantCount(room.ToProto())
+	r.persistRoomForParticipantCount(ctx, room, participant)
 
 	clientMeta := &livekit.AnalyticsClientMeta{Region: r.currentNode.Region, Node: r.currentNode.Id}
 	r.telemetry.ParticipantJoined(ctx, protoRoom, participant.ToProto(), pi.Client, clientMeta, true)
+	if replacesSession {
+		r.telemetry.ParticipantReconnected(ctx, protoRoom, participant.ToProto())
+	}
 	participant.OnClose(func(p types.LocalParticipant) {
-		if err := r.roomStore.DeleteParticipant(ctx, roomName, p.Identity()); err != nil {
+		r.lock.Lock()
+		delete(r.sessions, p.ID())
+		r.lock.Unlock()
+
+		room := session.room.Load()
+		if err := r.roomStore.DeleteParticipant(ctx, room.Name(), p.Identity()); err != nil {
 			pLogger.Errorw("could not delete participant", err)
 		}
 
 		// update room store with new numParticipants
-		proto := room.ToProto()
-		persistRoomForParticipantCount(proto)
-		r.telemetry.ParticipantLeft(ctx, proto, p.ToProto(), true)
+		r.persistRoomForParticipantCount(ctx, room, p)
+		r.telemetry.ParticipantLeft(ctx, room.ToProto(), p.ToProto(), true, telemetry.WebhookExtensions{
+			telemetry.WebhookExtensionParticipation: room.GetParticipantParticipation(p.ID()),
+		})
 	})
 	participant.OnClaimsChanged(func(participant types.LocalParticipant) {
 		pLogger.Debugw("refreshing client token after claims change")
-		if err := r.refreshToken(participant); err != nil {
+		if err := r.refreshToken(session, participant); err != nil {
 			logger.Errorw("could not refresh token", err)
 		}
 	})
@@ -408,10 +488,445 @@ func (r *RoomManager) StartSession(
 		r.lock.Unlock()
 	})
 
-	go r.rtcSessionWorker(room, participant, requestSource)
+	go r.rtcSessionWorker(session, participant, requestSource)
+	return nil
+}
+
+func (r *RoomManager) getSession(participantID livekit.ParticipantID) *participantSession {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.sessions[participantID]
+}
+
+func (r *RoomManager) addSession(participantID livekit.ParticipantID, room *rtc.Room) *participantSession {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	session := r.sessions[participantID]
+	if session == nil {
+		session = newParticipantSession(room)
+		r.sessions[participantID] = session
+	}
+	return session
+}
+
+func (r *RoomManager) persistRoomForParticipantCount(ctx context.Context, room *rtc.Room, participant types.LocalParticipant) {
+	if participant.Hidden() {
+		return
+	}
+	if err := r.roomStore.StoreRoom(ctx, room.ToProto(), room.Internal()); err != nil {
+		logger.Errorw("could not store room", err)
+	}
+}
+
+// MoveParticipant moves a participant hosted on this node to another room. When the destination room is hosted on this node
+// too, the participant keeps its connection and published tracks, otherwise it is asked to reconnect and joins the destination
+// room on the node hosting it. The destination room must have been created.
+func (r *RoomManager) MoveParticipant(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	destinationRoomName livekit.RoomName,
+) error {
+	if roomName == destinationRoomName {
+		return ErrMoveToSameRoom
+	}
+
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return ErrParticipantNotFound
+	}
+
+	node, err := r.router.GetNodeForRoom(ctx, destinationRoomName)
+	if err != nil {
+		return err
+	}
+	grants := grantsForMove(room, participant, destinationRoomName)
+	if node.Id != r.currentNode.Id {
+		// the participant joins the destination room on its node with a token for it, and goes through admission
+		// and the lobby there. The token is sent before the participant is asked to reconnect.
+		participant.GetLogger().Infow("moving participant to remote room", "destinationRoom", destinationRoomName, "nodeID", node.Id)
+		var limits LimitClaims
+		if session := r.getSession(participant.ID()); session != nil {
+			limits = session.limits
+		}
+		if err = r.sendToken(participant, grants, limits); err != nil {
+			return err
+		}
+		participant.IssueFullReconnect(types.ParticipantCloseReasonMigrationRequested)
+		room.RemoveParticipant(identity, participant.ID(), types.ParticipantCloseReasonMigrationRequested)
+		return nil
+	}
+
+	if ip, ok := participant.(*rtc.InProcessParticipant); ok {
+		destination, err := r.getOrCreateRoom(ctx, destinationRoomName)
+		if err != nil {
+			return err
+		}
+		defer destination.Release()
+		return ip.MoveTo(destination)
+	}
+
+	session := r.getSession(participant.ID())
+	if session == nil {
+		return ErrParticipantNotFound
+	}
+	// as when joining, changes made by the admission hook apply in the destination room
+	if err = r.admission.AdmitParticipant(ctx, destinationRoomName, grants); err != nil {
+		return err
+	}
+	destination, err := r.getOrCreateRoom(ctx, destinationRoomName)
+	if err != nil {
+		return err
+	}
+	defer destination.Release()
+
+	requestSource, opts, err := room.DetachParticipant(identity)
+	if err != nil {
+		return ErrParticipantNotFound
+	}
+	participation := room.GetParticipantParticipation(participant.ID())
+
+	session.room.Store(destination)
+	participant.MoveToRoom(destinationRoomName)
+	applyAdmittedGrants(participant, grants)
+	if err = destination.AttachParticipant(participant, requestSource, opts); err != nil {
+		participant.GetLogger().Warnw("could not move participant, returning it to its room", err, "destinationRoom", destinationRoomName)
+		session.room.Store(room)
+		participant.MoveToRoom(roomName)
+		if rerr := room.AttachParticipant(participant, requestSource, opts); rerr != nil {
+			_ = participant.Close(true, types.ParticipantCloseReasonJoinFailed)
+		}
+		return err
+	}
+	participant.GetLogger().Infow("moved participant", "room", roomName, "destinationRoom", destinationRoomName)
+
+	if err = r.roomStore.DeleteParticipant(ctx, roomName, identity); err != nil {
+		participant.GetLogger().Errorw("could not delete participant", err)
+	}
+	r.persistRoomForParticipantCount(ctx, room, participant)
+	if err = r.roomStore.StoreParticipant(ctx, destinationRoomName, participant.ToProto()); err != nil {
+		participant.GetLogger().Errorw("could not store participant", err)
+	}
+	r.persistRoomForParticipantCount(ctx, destination, participant)
+
+	clientMeta := &livekit.AnalyticsClientMeta{Region: r.currentNode.Region, Node: r.currentNode.Id}
+	r.telemetry.ParticipantLeft(ctx, room.ToProto(), participant.ToProto(), true, telemetry.WebhookExtensions{
+		telemetry.WebhookExtensionParticipation: participation,
+	})
+	r.telemetry.ParticipantJoined(ctx, destination.ToProto(), participant.ToProto(), nil, clientMeta, true)
+	if participant.State() == livekit.ParticipantInfo_ACTIVE {
+		r.telemetry.ParticipantActive(ctx, destination.ToProto(), participant.ToProto(), &livekit.AnalyticsClientMeta{
+			ConnectionType: string(participant.GetICEConnectionType()),
+		})
+	}
+	return nil
+}
+
+// grantsForMove are the participant's grants in the destination room of a move, a participant waiting in the lobby
+// of the room it leaves is admitted
+func grantsForMove(room *rtc.Room, participant types.LocalParticipant, destinationRoomName livekit.RoomName) *auth.ClaimGrants {
+	grants := participant.ClaimGrants()
+	grants.Video.Room = string(destinationRoomName)
+	if permission := room.GetLobbyPermission(participant.Identity()); permission != nil {
+		grants.Video.UpdateFromPermission(permission)
+	}
+	return grants
+}
+
+func applyAdmittedGrants(participant types.LocalParticipant, grants *auth.ClaimGrants) {
+	current := participant.ClaimGrants()
+	if grants.Name != current.Name {
+		participant.SetName(grants.Name)
+	}
+	if grants.Metadata != current.Metadata {
+		participant.SetMetadata(grants.Metadata)
+	}
+	participant.SetPermission(grants.Video.ToPermission())
+}
+
+// ForwardTrack mirrors a track published in roomName into another room hosted on this node. In the destination room
+// the track is published by an in-process participant, identified by the publisher's identity unless identity is set.
+// Media is not published again, subscribers in the destination room are fed by the receivers of the origin track.
+func (r *RoomManager) ForwardTrack(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	trackID livekit.TrackID,
+	destinationRoomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+) (*livekit.TrackInfo, error) {
+	if roomName == destinationRoomName {
+		return nil, ErrForwardToSameRoom
+	}
+
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	var publisher types.LocalParticipant
+	for _, p := range room.GetParticipants() {
+		if p.GetPublishedTrack(trackID) != nil {
+			publisher = p
+			break
+		}
+	}
+	if publisher == nil {
+		return nil, ErrTrackNotFound
+	}
+	if identity == "" {
+		identity = publisher.Identity()
+	}
+
+	// receivers are shared, both rooms must be hosted here. Tracks are forwarded to rooms hosted on other nodes
+	// over RTP, see ForwardRemoteTrack
+	node, err := r.router.GetNodeForRoom(ctx, destinationRoomName)
+	if err != nil {
+		return nil, err
+	}
+	if node.Id != r.currentNode.Id {
+		return nil, ErrForwardAcrossNodes
+	}
+	destination, err := r.getOrCreateRoom(ctx, destinationRoomName)
+	if err != nil {
+		return nil, err
+	}
+	defer destination.Release()
+
+	forwarder, err := r.getOrCreateForwarder(destination, identity, publisher.ClaimGrants().Name)
+	if err != nil {
+		return nil, err
+	}
+	ft, err := forwarder.ForwardTrack(room, trackID)
+	if err != nil {
+		if errors.Is(err, rtc.ErrTrackNotFound) {
+			return nil, ErrTrackNotFound
+		}
+		return nil, err
+	}
+
+	// the forwarding participant leaves once it has nothing left to forward
+	ft.AddOnClose(func() {
+		if !forwarder.IsClosed() && len(forwarder.GetPublishedTracks()) == 0 {
+			go forwarder.Leave()
+		}
+	})
+	return ft.ToProto(), nil
+}
+
+// StopForwardTrack stops forwarding a track, trackID is the ID of the track in the destination room
+func (r *RoomManager) StopForwardTrack(
+	ctx context.Context,
+	destinationRoomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	trackID livekit.TrackID,
+) error {
+	destination := r.GetRoom(ctx, destinationRoomName)
+	if destination == nil {
+		return ErrRoomNotFound
+	}
+	forwarder, ok := destination.GetParticipant(identity).(*rtc.InProcessParticipant)
+	if !ok {
+		return ErrParticipantNotFound
+	}
+	if _, ok := forwarder.GetPublishedTrack(trackID).(*rtc.ForwardedTrack); ok {
+		forwarder.UnpublishTrack(trackID)
+		return nil
+	}
+
+	r.lock.RLock()
+	ingest := r.forwardIngests[trackID]
+	r.lock.RUnlock()
+	if ingest == nil || forwarder.GetPublishedTrack(trackID) == nil {
+		return ErrTrackNotFound
+	}
+	// the origin node stops forwarding once it receives the ingest's goodbye
+	ingest.Close()
 	return nil
 }
 
+// ForwardRemoteTrack receives a track forwarded from a room hosted on another node, described by an SDP offer,
+// and publishes it in a room hosted on this node. It returns the track and the SDP answer with the address the
+// origin node is to send RTP to.
+func (r *RoomManager) ForwardRemoteTrack(
+	ctx context.Context,
+	destinationRoomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	name string,
+	trackName string,
+	trackSource livekit.TrackSource,
+	offer string,
+) (*livekit.TrackInfo, string, error) {
+	destination := r.GetRoom(ctx, destinationRoomName)
+	if destination == nil {
+		return nil, "", ErrRoomNotFound
+	}
+	forwarder, err := r.getOrCreateForwarder(destination, identity, name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ingest, err := rtc.NewRTPIngest(rtc.RTPIngestParams{
+		Participant:       forwarder,
+		SDP:               offer,
+		IP:                net.ParseIP(r.currentNode.Ip),
+		PortRangeStart:    r.config.RTC.RTPIngestPortRangeStart,
+		PortRangeEnd:      r.config.RTC.RTPIngestPortRangeEnd,
+		TrackName:         trackName,
+		TrackSource:       trackSource,
+		InactivityTimeout: r.config.RTC.RTPInactivityTimeout,
+		Logger:            forwarder.GetLogger(),
+	})
+	if err != nil {
+		if len(forwarder.GetPublishedTracks()) == 0 {
+			forwarder.Leave()
+		}
+		return nil, "", err
+	}
+	track := ingest.Tracks()[0]
+
+	r.lock.Lock()
+	r.forwardIngests[track.ID()] = ingest
+	r.lock.Unlock()
+
+	ingest.OnClose(func(_ *rtc.RTPIngest) {
+		r.lock.Lock()
+		delete(r.forwardIngests, track.ID())
+		r.lock.Unlock()
+	})
+	// the forwarding participant leaves once it has nothing left to forward
+	track.AddOnClose(func() {
+		if !forwarder.IsClosed() && len(forwarder.GetPublishedTracks()) == 0 {
+			go forwarder.Leave()
+		}
+	})
+	return track.ToProto(), ingest.Answer(), nil
+}
+
+// StartRTPIngest publishes RTP streams described by an SDP offer as tracks of a new participant.
+// The participant leaves when the ingest is closed, and removing it stops the ingest.
+func (r *RoomManager) StartRTPIngest(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	name string,
+	offer string,
+) (*rtc.InProcessParticipant, *rtc.RTPIngest, error) {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, nil, ErrRoomNotFound
+	}
+	if room.GetParticipant(identity) != nil {
+		return nil, nil, ErrIdentityInUse
+	}
+
+	canSubscribe := false
+	participant, err := rtc.NewInProcessParticipant(rtc.InProcessParticipantParams{
+		Identity: identity,
+		Name:     livekit.ParticipantName(name),
+		Grants: &auth.ClaimGrants{
+			Identity: string(identity),
+			Name:     name,
+			Video: &auth.VideoGrant{
+				RoomJoin:     true,
+				Room:         string(roomName),
+				CanSubscribe: &canSubscribe,
+			},
+		},
+		VideoConfig:       r.config.Video,
+		PLIThrottleConfig: r.config.RTC.PLIThrottle,
+		Telemetry:         r.telemetry,
+		Logger:            room.Logger,
+		VersionGenerator:  r.versionGenerator,
+		Config:            r.rtcConfig,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = participant.Join(room); err != nil {
+		_ = participant.Close(false, types.ParticipantCloseReasonJoinFailed)
+		if errors.Is(err, rtc.ErrAlreadyJoined) {
+			return nil, nil, ErrIdentityInUse
+		}
+		return nil, nil, err
+	}
+
+	ingest, err := rtc.NewRTPIngest(rtc.RTPIngestParams{
+		Participant:       participant,
+		SDP:               offer,
+		IP:                net.ParseIP(r.currentNode.Ip),
+		PortRangeStart:    r.config.RTC.RTPIngestPortRangeStart,
+		PortRangeEnd:      r.config.RTC.RTPIngestPortRangeEnd,
+		InactivityTimeout: r.config.RTC.RTPInactivityTimeout,
+		Logger:            participant.GetLogger(),
+	})
+	if err != nil {
+		participant.Leave()
+		return nil, nil, err
+	}
+	ingest.OnClose(func(_ *rtc.RTPIngest) {
+		if !participant.IsClosed() {
+			go participant.Leave()
+		}
+	})
+	participant.OnClose(func(p types.LocalParticipant) {
+		ingest.Close()
+		if err := r.roomStore.DeleteParticipant(context.Background(), room.Name(), p.Identity()); err != nil {
+			p.GetLogger().Errorw("could not delete participant", err)
+		}
+	})
+	return participant, ingest, nil
+}
+
+func (r *RoomManager) getOrCreateForwarder(room *rtc.Room, identity livekit.ParticipantIdentity, name string) (*rtc.InProcessParticipant, error) {
+	if p := room.GetParticipant(identity); p != nil {
+		forwarder, ok := p.(*rtc.InProcessParticipant)
+		if !ok {
+			return nil, ErrForwarderIdentityInUse
+		}
+		return forwarder, nil
+	}
+
+	canSubscribe := false
+	forwarder, err := rtc.NewInProcessParticipant(rtc.InProcessParticipantParams{
+		Identity: identity,
+		Name:     livekit.ParticipantName(name),
+		Grants: &auth.ClaimGrants{
+			Identity: string(identity),
+			Video: &auth.VideoGrant{
+				RoomJoin:     true,
+				Room:         string(room.Name()),
+				CanSubscribe: &canSubscribe,
+			},
+		},
+		VideoConfig:       r.config.Video,
+		PLIThrottleConfig: r.config.RTC.PLIThrottle,
+		Telemetry:         r.telemetry,
+		Logger:            room.Logger,
+		VersionGenerator:  r.versionGenerator,
+		Config:            r.rtcConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = forwarder.Join(room); err != nil {
+		_ = forwarder.Close(false, types.ParticipantCloseReasonJoinFailed)
+		if errors.Is(err, rtc.ErrAlreadyJoined) {
+			// created concurrently
+			return r.getOrCreateForwarder(room, identity, name)
+		}
+		return nil, err
+	}
+	forwarder.OnClose(func(p types.LocalParticipant) {
+		if err := r.roomStore.DeleteParticipant(context.Background(), room.Name(), p.Identity()); err != nil {
+			p.GetLogger().Errorw("could not delete participant", err)
+		}
+	})
+	return forwarder, nil
+}
+
 // create the actual room object, to be used on RTC node
 func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.RoomName) (*rtc.Room, error) {
 	r.lock.RLock()
@@ -444,10 +959,14 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 
 	// construct ice servers
 	newRoom := rtc.NewRoom(ri, internal, *r.rtcConfig, &r.config.Audio, r.serverInfo, r.telemetry, r.egressLauncher)
+	newRoom.SetLobbyEnabled(r.config.Room.Lobby)
+	newRoom.SetQualityAlertRules(r.config.Room.QualityAlerts)
 
 	newRoom.OnClose(func() {
 		roomInfo := newRoom.ToProto()
-		r.telemetry.RoomEnded(ctx, roomInfo)
+		r.telemetry.RoomEnded(ctx, roomInfo, telemetry.WebhookExtensions{
+			telemetry.WebhookExtensionParticipation: newRoom.GetParticipation(),
+		})
 		prometheus.RoomEnded(time.Unix(roomInfo.CreationTime, 0))
 		if err := r.DeleteRoom(ctx, roomName); err != nil {
 			newRoom.Logger.Errorw("could not delete room", err)
@@ -483,7 +1002,8 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 }
 
 // manages an RTC session for a participant, runs on the RTC node
-func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.LocalParticipant, requestSource routing.MessageSource) {
+func (r *RoomManager) rtcSessionWorker(session *participantSession, participant types.LocalParticipant, requestSource routing.MessageSource) {
+	room := session.room.Load()
 	pLogger := rtc.LoggerWithParticipant(
 		rtc.LoggerWithRoom(logger.GetLogger(), room.Name(), room.ID()),
 		participant.Identity(),
@@ -502,7 +1022,7 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.LocalPa
 	}()
 
 	// send first refresh for cases when client token is close to expiring
-	_ = r.refreshToken(participant)
+	_ = r.refreshToken(session, participant)
 	tokenTicker := time.NewTicker(tokenRefreshInterval)
 	defer tokenTicker.Stop()
 	stateCheckTicker := time.NewTicker(time.Millisecond * 50)
@@ -516,21 +1036,21 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.LocalPa
 			}
 		case <-tokenTicker.C:
 			// refresh token with the first API Key/secret pair
-			if err := r.refreshToken(participant); err != nil {
+			if err := r.refreshToken(session, participant); err != nil {
 				pLogger.Errorw("could not refresh token", err)
 			}
 		case obj := <-requestSource.ReadChan():
 			// In single node mode, the request source is directly tied to the signal message channel
 			// this means ICE restart isn't possible in single node mode
 			if obj == nil {
-				if room.GetParticipantRequestSource(participant.Identity()) == requestSource {
+				if session.room.Load().GetParticipantRequestSource(participant.Identity()) == requestSource {
 					participant.SetSignalSourceValid(false)
 				}
 				return
 			}
 
 			req := obj.(*livekit.SignalRequest)
-			if err := rtc.HandleParticipantSignal(room, participant, req, pLogger); err != nil {
+			if err := rtc.HandleParticipantSignal(session.room.Load(), participant, req, pLogger); err != nil {
 				// more specific errors are already logged
 				// treat errors returned as fatal
 				return
@@ -693,16 +1213,23 @@ func (r *RoomManager) iceServersForRoom(ri *livekit.Room, tlsOnly bool) []*livek
 	return iceServers
 }
 
-func (r *RoomManager) refreshToken(participant types.LocalParticipant) error {
+func (r *RoomManager) refreshToken(session *participantSession, participant types.LocalParticipant) error {
+	// permissions are restricted while waiting in the lobby, they must not end up in a token
+	if session.room.Load().IsInLobby(participant.Identity()) {
+		return nil
+	}
+	return r.sendToken(participant, participant.ClaimGrants(), session.limits)
+}
+
+func (r *RoomManager) sendToken(participant types.LocalParticipant, grants *auth.ClaimGrants, limits LimitClaims) error {
 	for key, secret := range r.config.Keys {
-		grants := participant.ClaimGrants()
 		token := auth.NewAccessToken(key, secret)
 		token.SetName(grants.Name).
 			SetIdentity(string(participant.Identity())).
 			SetValidFor(tokenDefaultTTL).
 			SetMetadata(grants.Metadata).
 			AddGrant(grants.Video)
-		jwt, err := token.ToJWT()
+		jwt, err := ToJWTWithLimits(token, secret, limits)
 		if err == nil {
 			err = participant.SendRefreshToken(jwt)
 		}
@@ -0,0 +1,1158 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/client"
+	"github.com/livekit/psrpc/pkg/info"
+	"github.com/livekit/psrpc/pkg/server"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu/impairment"
+)
+
+// RoomServiceExtPathPrefix is where RoomServiceExt methods are served, following twirp conventions,
+// i. e. a JSON request is POSTed to RoomServiceExtPathPrefix + <method name>
+const RoomServiceExtPathPrefix = "/twirp/livekit.RoomServiceExt/"
+
+const (
+	roomServiceExtRPCService = "RoomServiceExt"
+	roomServiceExtRPCMethod  = "Relay"
+	roomServiceExtRPCTimeout = 10 * time.Second
+
+	// methods only handled when relayed from another node
+	roomServiceExtForwardRemoteTrack = "ForwardRemoteTrack"
+)
+
+type roomServiceExtMethod func(ctx context.Context, body []byte) (interface{}, error)
+
+// relayed to the node hosting the room, grants of the caller are checked there
+type roomServiceExtRelayRequest struct {
+	Method string            `json:"method"`
+	Grants *auth.ClaimGrants `json:"grants"`
+	Body   json.RawMessage   `json:"body"`
+}
+
+// RoomServiceExt serves room APIs that are not part of livekit.RoomService.
+// Requests are relayed over the message bus to the node hosting the room, and handled locally
+// when the room is not assigned to a node.
+type RoomServiceExt struct {
+	roomManager   *RoomManager
+	roomAllocator RoomAllocator
+	hlsService    *HLSService
+	router        routing.Router
+	nodeID        string
+	methods       map[string]roomServiceExtMethod
+	// methods only handled when relayed from another node
+	relayMethods map[string]roomServiceExtMethod
+	// network impairment degrades media of real participants, it is only allowed in development mode
+	allowNetworkImpairment bool
+
+	rpcClient *client.RPCClient
+	rpcServer *server.RPCServer
+}
+
+func NewRoomServiceExt(
+	conf *config.Config,
+	roomManager *RoomManager,
+	roomAllocator RoomAllocator,
+	hlsService *HLSService,
+	router routing.Router,
+	currentNode routing.LocalNode,
+	bus psrpc.MessageBus,
+) (*RoomServiceExt, error) {
+	s := &RoomServiceExt{
+		roomManager:   roomManager,
+		roomAllocator: roomAllocator,
+		hlsService:    hlsService,
+		router:        router,
+		nodeID:        currentNode.Id,
+
+		allowNetworkImpairment: conf.Development,
+	}
+	s.methods = map[string]roomServiceExtMethod{
+		"GetRoomParticipation":            s.getRoomParticipation,
+		"GetParticipantConnectionQuality": s.getParticipantConnectionQuality,
+		"SimulateNetworkImpairment":       s.simulateNetworkImpairment,
+		"UpdateRoomLobby":                 s.updateRoomLobby,
+		"ListLobbyParticipants":           s.listLobbyParticipants,
+		"ApproveLobbyParticipant":         s.approveLobbyParticipant,
+		"DenyLobbyParticipant":            s.denyLobbyParticipant,
+		"MoveParticipant":                 s.moveParticipant,
+		"ForwardTrack":                    s.forwardTrack,
+		"StopForwardTrack":                s.stopForwardTrack,
+		"StartRTPForward":                 s.startRTPForward,
+		"StopRTPForward":                  s.stopRTPForward,
+		"ListRTPForwards":                 s.listRTPForwards,
+		"StartRTPIngest":                  s.startRTPIngest,
+		"StartHLS":                        s.startHLS,
+		"StopHLS":                         s.stopHLS,
+		"ListHLS":                         s.listHLS,
+	}
+	s.relayMethods = map[string]roomServiceExtMethod{
+		roomServiceExtForwardRemoteTrack: s.forwardRemoteTrack,
+	}
+
+	clientDef := &info.ServiceDefinition{Name: roomServiceExtRPCService, ID: currentNode.Id}
+	clientDef.RegisterMethod(roomServiceExtRPCMethod, false, false, true)
+	rpcClient, err := client.NewRPCClient(clientDef, bus, psrpc.WithClientTimeout(roomServiceExtRPCTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	serverDef := &info.ServiceDefinition{Name: roomServiceExtRPCService, ID: currentNode.Id}
+	rpcServer := server.NewRPCServer(serverDef, bus)
+	serverDef.RegisterMethod(roomServiceExtRPCMethod, false, false, true)
+	if err = server.RegisterHandler(rpcServer, roomServiceExtRPCMethod, []string{currentNode.Id}, s.handleRelay, nil); err != nil {
+		rpcServer.Close(true)
+		rpcClient.Close()
+		return nil, err
+	}
+
+	s.rpcClient = rpcClient
+	s.rpcServer = rpcServer
+	return s, nil
+}
+
+func (s *RoomServiceExt) Stop() {
+	s.rpcServer.Close(false)
+	s.rpcClient.Close()
+}
+
+func (s *RoomServiceExt) PathPrefix() string {
+	return RoomServiceExtPathPrefix
+}
+
+func (s *RoomServiceExt) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		_ = twirp.WriteError(w, twirp.NewError(twirp.BadRoute, "unsupported method "+r.Method))
+		return
+	}
+
+	methodName := strings.TrimPrefix(r.URL.Path, RoomServiceExtPathPrefix)
+	method, ok := s.methods[methodName]
+	if !ok {
+		_ = twirp.WriteError(w, twirp.NewError(twirp.BadRoute, "no handler for path "+r.URL.Path))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		_ = twirp.WriteError(w, twirp.InvalidArgumentError("body", err.Error()))
+		return
+	}
+
+	var encoded []byte
+	nodeID, err := s.getNodeForRequest(r.Context(), body)
+	if err == nil {
+		if nodeID == "" || nodeID == s.nodeID {
+			encoded, err = s.handle(r.Context(), method, body)
+		} else {
+			encoded, err = s.relay(r.Context(), nodeID, methodName, body)
+		}
+	}
+	if err != nil {
+		logger.Debugw("room service ext request failed", "method", methodName, "error", err)
+		_ = twirp.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(encoded)
+}
+
+func (s *RoomServiceExt) handle(ctx context.Context, method roomServiceExtMethod, body []byte) ([]byte, error) {
+	res, err := method(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(res)
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+	return encoded, nil
+}
+
+// getNodeForRequest returns the node hosting the room of the request, empty when the room is not assigned to a node
+func (s *RoomServiceExt) getNodeForRequest(ctx context.Context, body []byte) (string, error) {
+	req := &struct {
+		Room string `json:"room"`
+	}{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return "", twirp.InvalidArgumentError("body", err.Error())
+	}
+	if req.Room == "" {
+		return "", nil
+	}
+
+	node, err := s.router.GetNodeForRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		if errors.Is(err, routing.ErrNotFound) {
+			return "", nil
+		}
+		return "", twirp.InternalErrorWith(err)
+	}
+	return node.Id, nil
+}
+
+func (s *RoomServiceExt) relay(ctx context.Context, nodeID string, methodName string, body []byte) ([]byte, error) {
+	encoded, err := json.Marshal(&roomServiceExtRelayRequest{
+		Method: methodName,
+		Grants: GetGrants(ctx),
+		Body:   body,
+	})
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	res, err := client.RequestSingle[*wrapperspb.BytesValue](ctx, s.rpcClient, roomServiceExtRPCMethod, []string{nodeID}, wrapperspb.Bytes(encoded))
+	if err != nil {
+		var twerr twirp.Error
+		if errors.As(err, &twerr) {
+			return nil, twerr
+		}
+		return nil, twirp.InternalErrorWith(err)
+	}
+	return res.Value, nil
+}
+
+func (s *RoomServiceExt) handleRelay(ctx context.Context, req *wrapperspb.BytesValue) (*wrapperspb.BytesValue, error) {
+	relayReq := &roomServiceExtRelayRequest{}
+	if err := json.Unmarshal(req.Value, relayReq); err != nil {
+		return nil, psrpc.NewError(psrpc.MalformedRequest, err)
+	}
+	method, ok := s.methods[relayReq.Method]
+	if !ok {
+		method, ok = s.relayMethods[relayReq.Method]
+	}
+	if !ok {
+		return nil, psrpc.NewErrorf(psrpc.Unimplemented, "no handler for method %s", relayReq.Method)
+	}
+	if relayReq.Grants != nil {
+		ctx = WithGrants(ctx, relayReq.Grants)
+	}
+
+	encoded, err := s.handle(ctx, method, relayReq.Body)
+	if err != nil {
+		var twerr twirp.Error
+		if errors.As(err, &twerr) {
+			// psrpc and twirp share error codes
+			return nil, psrpc.NewError(psrpc.ErrorCode(twerr.Code()), errors.New(twerr.Msg()))
+		}
+		return nil, err
+	}
+	return wrapperspb.Bytes(encoded), nil
+}
+
+func (s *RoomServiceExt) getLocalRoom(ctx context.Context, roomName livekit.RoomName) (*rtc.Room, error) {
+	if err := EnsureAdminPermission(ctx, roomName); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	room := s.roomManager.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, twirp.NotFoundError(ErrRoomNotFound.Error())
+	}
+	return room, nil
+}
+
+// the destination room is created on this node unless it is already hosted elsewhere
+func (s *RoomServiceExt) createDestinationRoom(ctx context.Context, roomName livekit.RoomName) error {
+	if err := s.roomAllocator.ValidateCreateRoom(ctx, roomName); err != nil {
+		if errors.Is(err, ErrRoomNotFound) {
+			return twirp.NotFoundError(err.Error())
+		}
+		return twirp.InternalErrorWith(err)
+	}
+	if _, err := s.roomAllocator.CreateRoom(ctx, &livekit.CreateRoomRequest{
+		Name:   string(roomName),
+		NodeId: s.roomManager.currentNode.Id,
+	}); err != nil {
+		return twirp.InternalErrorWith(err)
+	}
+	return nil
+}
+
+// -----------------------------------------------
+
+type GetRoomParticipationRequest struct {
+	Room string `json:"room"`
+}
+
+func (s *RoomServiceExt) getRoomParticipation(ctx context.Context, body []byte) (interface{}, error) {
+	req := &GetRoomParticipationRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room)
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	return room.GetParticipation(), nil
+}
+
+// -----------------------------------------------
+
+type GetParticipantConnectionQualityRequest struct {
+	Room string `json:"room"`
+	// all participants of the room when not set
+	Identity string `json:"identity,omitempty"`
+}
+
+type GetParticipantConnectionQualityResponse struct {
+	Participants []*types.ConnectionQualityDetails `json:"participants"`
+}
+
+func (s *RoomServiceExt) getParticipantConnectionQuality(ctx context.Context, body []byte) (interface{}, error) {
+	req := &GetParticipantConnectionQualityRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &GetParticipantConnectionQualityResponse{Participants: []*types.ConnectionQualityDetails{}}
+	for _, p := range room.GetParticipants() {
+		if req.Identity != "" && p.Identity() != livekit.ParticipantIdentity(req.Identity) {
+			continue
+		}
+		// quality is calculated once participants are active
+		if details := p.GetConnectionQualityDetails(); details != nil {
+			res.Participants = append(res.Participants, details)
+		}
+	}
+	if req.Identity != "" && len(res.Participants) == 0 {
+		return nil, twirp.NotFoundError(ErrParticipantNotFound.Error())
+	}
+	return res, nil
+}
+
+// -----------------------------------------------
+
+type SimulateNetworkImpairmentRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// "upstream" for media published by the participant, "downstream" for media sent to it
+	Direction string `json:"direction"`
+	// clears the impairment in the direction
+	Clear             bool    `json:"clear,omitempty"`
+	LossPercentage    float64 `json:"lossPercentage,omitempty"`
+	DelayMs           uint32  `json:"delayMs,omitempty"`
+	JitterMs          uint32  `json:"jitterMs,omitempty"`
+	ReorderPercentage float64 `json:"reorderPercentage,omitempty"`
+	// bandwidth cap in bps, not capped when 0
+	Bandwidth int64 `json:"bandwidth,omitempty"`
+	// makes loss, jitter and reordering reproducible
+	Seed int64 `json:"seed,omitempty"`
+}
+
+type SimulateNetworkImpairmentResponse struct{}
+
+func (s *RoomServiceExt) simulateNetworkImpairment(ctx context.Context, body []byte) (interface{}, error) {
+	if !s.allowNetworkImpairment {
+		return nil, twirp.NewError(twirp.PermissionDenied, "network impairment is only available in development mode")
+	}
+
+	req := &SimulateNetworkImpairmentRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "direction", req.Direction)
+	var direction livekit.StreamType
+	switch strings.ToLower(req.Direction) {
+	case "upstream":
+		direction = livekit.StreamType_UPSTREAM
+	case "downstream":
+		direction = livekit.StreamType_DOWNSTREAM
+	default:
+		return nil, twirp.InvalidArgumentError("direction", "must be upstream or downstream")
+	}
+
+	var config *impairment.Config
+	if !req.Clear {
+		config = &impairment.Config{
+			LossPercentage:    req.LossPercentage,
+			Delay:             time.Duration(req.DelayMs) * time.Millisecond,
+			Jitter:            time.Duration(req.JitterMs) * time.Millisecond,
+			ReorderPercentage: req.ReorderPercentage,
+			Bandwidth:         req.Bandwidth,
+			Seed:              req.Seed,
+		}
+		if err := config.Validate(); err != nil {
+			return nil, twirp.InvalidArgumentError("body", err.Error())
+		}
+	}
+
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	participant := room.GetParticipant(livekit.ParticipantIdentity(req.Identity))
+	if participant == nil {
+		return nil, twirp.NotFoundError(ErrParticipantNotFound.Error())
+	}
+
+	if err := room.SimulateNetworkImpairment(participant, direction, config); err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+	return &SimulateNetworkImpairmentResponse{}, nil
+}
+
+// -----------------------------------------------
+
+type UpdateRoomLobbyRequest struct {
+	// created when it does not exist
+	Room string `json:"room"`
+	// when disabled, participants waiting in the lobby are approved
+	Enabled bool `json:"enabled"`
+}
+
+type UpdateRoomLobbyResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+func (s *RoomServiceExt) updateRoomLobby(ctx context.Context, body []byte) (interface{}, error) {
+	req := &UpdateRoomLobbyRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "enabled", req.Enabled)
+	roomName := livekit.RoomName(req.Room)
+	if err := EnsureAdminPermission(ctx, roomName); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	// the room is created, so that the lobby is in place before anyone joins
+	if err := s.createDestinationRoom(ctx, roomName); err != nil {
+		return nil, err
+	}
+	room, err := s.roomManager.getOrCreateRoom(ctx, roomName)
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+	defer room.Release()
+
+	room.SetLobbyEnabled(req.Enabled)
+	return &UpdateRoomLobbyResponse{Enabled: room.LobbyEnabled()}, nil
+}
+
+type ListLobbyParticipantsRequest struct {
+	Room string `json:"room"`
+}
+
+type ListLobbyParticipantsResponse struct {
+	Participants []*rtc.LobbyParticipant `json:"participants"`
+}
+
+func (s *RoomServiceExt) listLobbyParticipants(ctx context.Context, body []byte) (interface{}, error) {
+	req := &ListLobbyParticipantsRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room)
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListLobbyParticipantsResponse{Participants: room.GetLobbyParticipants()}, nil
+}
+
+type LobbyParticipantRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+type LobbyParticipantResponse struct{}
+
+func (s *RoomServiceExt) approveLobbyParticipant(ctx context.Context, body []byte) (interface{}, error) {
+	return s.handleLobbyParticipant(ctx, body, (*rtc.Room).ApproveLobbyParticipant)
+}
+
+func (s *RoomServiceExt) denyLobbyParticipant(ctx context.Context, body []byte) (interface{}, error) {
+	return s.handleLobbyParticipant(ctx, body, (*rtc.Room).DenyLobbyParticipant)
+}
+
+func (s *RoomServiceExt) handleLobbyParticipant(
+	ctx context.Context,
+	body []byte,
+	action func(room *rtc.Room, identity livekit.ParticipantIdentity) error,
+) (interface{}, error) {
+	req := &LobbyParticipantRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = action(room, livekit.ParticipantIdentity(req.Identity)); err != nil {
+		if errors.Is(err, rtc.ErrParticipantNotInLobby) {
+			return nil, twirp.NotFoundError(err.Error())
+		}
+		return nil, twirp.InternalErrorWith(err)
+	}
+	return &LobbyParticipantResponse{}, nil
+}
+
+// -----------------------------------------------
+
+type MoveParticipantRequest struct {
+	Room            string `json:"room"`
+	Identity        string `json:"identity"`
+	DestinationRoom string `json:"destinationRoom"`
+}
+
+type MoveParticipantResponse struct{}
+
+func (s *RoomServiceExt) moveParticipant(ctx context.Context, body []byte) (interface{}, error) {
+	req := &MoveParticipantRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "destinationRoom", req.DestinationRoom)
+	if req.DestinationRoom == "" {
+		return nil, twirp.RequiredArgumentError("destinationRoom")
+	}
+	if req.DestinationRoom == req.Room {
+		return nil, twirp.InvalidArgumentError("destinationRoom", ErrMoveToSameRoom.Error())
+	}
+	destinationRoom := livekit.RoomName(req.DestinationRoom)
+	if err := EnsureAdminPermission(ctx, destinationRoom); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.createDestinationRoom(ctx, destinationRoom); err != nil {
+		return nil, err
+	}
+
+	err = s.roomManager.MoveParticipant(ctx, room.Name(), livekit.ParticipantIdentity(req.Identity), destinationRoom)
+	switch {
+	case err == nil:
+		return &MoveParticipantResponse{}, nil
+	case errors.Is(err, ErrParticipantNotFound), errors.Is(err, ErrRoomNotFound):
+		return nil, twirp.NotFoundError(err.Error())
+	case errors.Is(err, rtc.ErrMaxParticipantsExceeded), errors.Is(err, rtc.ErrAlreadyJoined), errors.Is(err, rtc.ErrRoomClosed):
+		return nil, twirp.NewError(twirp.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrAdmissionDenied):
+		return nil, twirp.NewError(twirp.PermissionDenied, err.Error())
+	case errors.Is(err, ErrAdmissionUnavailable):
+		return nil, twirp.NewError(twirp.Unavailable, err.Error())
+	default:
+		return nil, twirp.InternalErrorWith(err)
+	}
+}
+
+// -----------------------------------------------
+
+type ForwardTrackRequest struct {
+	Room            string `json:"room"`
+	TrackSid        string `json:"trackSid"`
+	DestinationRoom string `json:"destinationRoom"`
+	// identity of the participant publishing the track in the destination room, defaults to the publisher's identity
+	Identity string `json:"identity,omitempty"`
+}
+
+type ForwardTrackResponse struct {
+	Identity string `json:"identity"`
+	// track published in the destination room
+	Track json.RawMessage `json:"track"`
+}
+
+func (s *RoomServiceExt) forwardTrack(ctx context.Context, body []byte) (interface{}, error) {
+	req := &ForwardTrackRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "trackID", req.TrackSid, "destinationRoom", req.DestinationRoom)
+	if req.DestinationRoom == "" {
+		return nil, twirp.RequiredArgumentError("destinationRoom")
+	}
+	if req.DestinationRoom == req.Room {
+		return nil, twirp.InvalidArgumentError("destinationRoom", ErrForwardToSameRoom.Error())
+	}
+	destinationRoom := livekit.RoomName(req.DestinationRoom)
+	if err := EnsureAdminPermission(ctx, destinationRoom); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	if err = s.createDestinationRoom(ctx, destinationRoom); err != nil {
+		return nil, err
+	}
+	node, err := s.router.GetNodeForRoom(ctx, destinationRoom)
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+	if node.Id != s.nodeID {
+		return s.forwardTrackToNode(ctx, room, req, node.Id)
+	}
+
+	ti, err := s.roomManager.ForwardTrack(ctx, room.Name(), livekit.TrackID(req.TrackSid), destinationRoom, livekit.ParticipantIdentity(req.Identity))
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrTrackNotFound), errors.Is(err, ErrRoomNotFound):
+		return nil, twirp.NotFoundError(err.Error())
+	case errors.Is(err, ErrForwardAcrossNodes):
+		return nil, twirp.NewError(twirp.Unavailable, err.Error())
+	case errors.Is(err, ErrForwarderIdentityInUse):
+		return nil, twirp.NewError(twirp.AlreadyExists, err.Error())
+	case errors.Is(err, rtc.ErrNoTrackPermission), errors.Is(err, rtc.ErrNoPublishPermission):
+		return nil, twirp.NewError(twirp.PermissionDenied, err.Error())
+	default:
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	track, err := protojson.Marshal(ti)
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+	identity := req.Identity
+	if identity == "" {
+		for _, p := range room.GetParticipants() {
+			if p.GetPublishedTrack(livekit.TrackID(req.TrackSid)) != nil {
+				identity = string(p.Identity())
+				break
+			}
+		}
+	}
+	return &ForwardTrackResponse{Identity: identity, Track: track}, nil
+}
+
+// forwardTrackToNode forwards a track to a room hosted on another node. The track is sent over RTP by a forwarder
+// in this room, and received by an ingest publishing it in the destination room. Each side stops when the other
+// says goodbye with an RTCP BYE.
+func (s *RoomServiceExt) forwardTrackToNode(ctx context.Context, room *rtc.Room, req *ForwardTrackRequest, nodeID string) (interface{}, error) {
+	trackID := livekit.TrackID(req.TrackSid)
+	var publisher types.LocalParticipant
+	for _, p := range room.GetParticipants() {
+		if p.GetPublishedTrack(trackID) != nil {
+			publisher = p
+			break
+		}
+	}
+	if publisher == nil {
+		return nil, twirp.NotFoundError(ErrTrackNotFound.Error())
+	}
+	identity := livekit.ParticipantIdentity(req.Identity)
+	if identity == "" {
+		identity = publisher.Identity()
+	}
+	res := room.ResolveMediaTrackForSubscriber(identity, trackID)
+	if res.Track == nil {
+		return nil, twirp.NotFoundError(ErrTrackNotFound.Error())
+	}
+	if !res.HasPermission {
+		return nil, twirp.NewError(twirp.PermissionDenied, rtc.ErrNoTrackPermission.Error())
+	}
+
+	// the forward is told apart from others sent to the destination room's port by its SSRC
+	ssrc := rand.Uint32()
+	for ssrc == 0 {
+		ssrc = rand.Uint32()
+	}
+	offer, err := rtc.NewRTPForwardOffer(res.Track, ssrc)
+	if err != nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, err.Error())
+	}
+	body, err := json.Marshal(&ForwardRemoteTrackRequest{
+		Room:        req.DestinationRoom,
+		Identity:    string(identity),
+		Name:        publisher.ClaimGrants().Name,
+		TrackName:   res.Track.Name(),
+		TrackSource: res.Track.Source(),
+		Sdp:         offer,
+	})
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+	encoded, err := s.relay(ctx, nodeID, roomServiceExtForwardRemoteTrack, body)
+	if err != nil {
+		return nil, err
+	}
+	remote := &ForwardRemoteTrackResponse{}
+	if err = json.Unmarshal(encoded, remote); err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	address, err := rtc.ParseRTPIngestAddress(remote.Sdp)
+	if err == nil {
+		_, err = room.StartRTPForward(rtc.RTPForwardParams{
+			TrackID:      trackID,
+			Address:      address,
+			SSRC:         ssrc,
+			VideoQuality: livekit.VideoQuality_HIGH,
+		})
+	}
+	if err != nil {
+		// stop receiving in the destination room
+		if body, merr := json.Marshal(&StopForwardTrackRequest{
+			Room:     req.DestinationRoom,
+			Identity: string(identity),
+			TrackSid: remote.TrackSid,
+		}); merr == nil {
+			if _, rerr := s.relay(ctx, nodeID, "StopForwardTrack", body); rerr != nil {
+				logger.Warnw("could not stop forwarded track", rerr, "room", req.DestinationRoom, "trackID", remote.TrackSid)
+			}
+		}
+		if errors.Is(err, rtc.ErrTrackNotAttached) || errors.Is(err, rtc.ErrTrackClosed) || errors.Is(err, rtc.ErrRoomClosed) {
+			return nil, twirp.NewError(twirp.FailedPrecondition, err.Error())
+		}
+		return nil, twirp.InternalErrorWith(err)
+	}
+	return &ForwardTrackResponse{Identity: string(identity), Track: remote.Track}, nil
+}
+
+// ForwardRemoteTrackRequest is relayed to the node hosting the destination room of ForwardTrack,
+// when the origin room is hosted on another node
+type ForwardRemoteTrackRequest struct {
+	Room        string              `json:"room"`
+	Identity    string              `json:"identity"`
+	Name        string              `json:"name,omitempty"`
+	TrackName   string              `json:"trackName,omitempty"`
+	TrackSource livekit.TrackSource `json:"trackSource,omitempty"`
+	// SDP offer describing the forwarded RTP stream
+	Sdp string `json:"sdp"`
+}
+
+// ForwardRemoteTrackResponse includes the SDP answer with the address to forward RTP to
+type ForwardRemoteTrackResponse struct {
+	TrackSid string          `json:"trackSid"`
+	Track    json.RawMessage `json:"track"`
+	Sdp      string          `json:"sdp"`
+}
+
+func (s *RoomServiceExt) forwardRemoteTrack(ctx context.Context, body []byte) (interface{}, error) {
+	req := &ForwardRemoteTrackRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+	if req.Identity == "" {
+		return nil, twirp.RequiredArgumentError("identity")
+	}
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	ti, answer, err := s.roomManager.ForwardRemoteTrack(
+		ctx,
+		room.Name(),
+		livekit.ParticipantIdentity(req.Identity),
+		req.Name,
+		req.TrackName,
+		req.TrackSource,
+		req.Sdp,
+	)
+	switch {
+	case err == nil:
+	case errors.Is(err, rtc.ErrInvalidSDP):
+		return nil, twirp.InvalidArgumentError("sdp", err.Error())
+	case errors.Is(err, rtc.ErrNoSupportedMedia):
+		// forwarding across nodes does not transcode either
+		return nil, twirp.NewError(twirp.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrForwarderIdentityInUse):
+		return nil, twirp.NewError(twirp.AlreadyExists, err.Error())
+	case errors.Is(err, ErrRoomNotFound):
+		return nil, twirp.NotFoundError(err.Error())
+	case errors.Is(err, rtc.ErrNoPublishPermission):
+		return nil, twirp.NewError(twirp.PermissionDenied, err.Error())
+	case errors.Is(err, rtc.ErrNoPortAvailable):
+		return nil, twirp.NewError(twirp.ResourceExhausted, err.Error())
+	default:
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	track, err := protojson.Marshal(ti)
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+	return &ForwardRemoteTrackResponse{TrackSid: ti.Sid, Track: track, Sdp: answer}, nil
+}
+
+type StopForwardTrackRequest struct {
+	// destination room, and identity and track sid returned by ForwardTrack
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	TrackSid string `json:"trackSid"`
+}
+
+type StopForwardTrackResponse struct{}
+
+func (s *RoomServiceExt) stopForwardTrack(ctx context.Context, body []byte) (interface{}, error) {
+	req := &StopForwardTrackRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "trackID", req.TrackSid)
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.roomManager.StopForwardTrack(ctx, room.Name(), livekit.ParticipantIdentity(req.Identity), livekit.TrackID(req.TrackSid))
+	switch {
+	case err == nil:
+		return &StopForwardTrackResponse{}, nil
+	case errors.Is(err, ErrTrackNotFound), errors.Is(err, ErrParticipantNotFound), errors.Is(err, ErrRoomNotFound):
+		return nil, twirp.NotFoundError(err.Error())
+	default:
+		return nil, twirp.InternalErrorWith(err)
+	}
+}
+
+// -----------------------------------------------
+
+type StartRTPForwardRequest struct {
+	Room     string `json:"room"`
+	TrackSid string `json:"trackSid"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	// RTCP is multiplexed with RTP unless a port is given
+	RTCPPort int    `json:"rtcpPort,omitempty"`
+	Ssrc     uint32 `json:"ssrc,omitempty"`
+	// payload type by mime type, e.g. {"video/vp8": 100}
+	PayloadTypes map[string]uint8 `json:"payloadTypes,omitempty"`
+	// SDES crypto suite, e.g. AES_CM_128_HMAC_SHA1_80, with base64 encoded master key and salt
+	SrtpSuite string `json:"srtpSuite,omitempty"`
+	SrtpKey   string `json:"srtpKey,omitempty"`
+	// LOW, MEDIUM or HIGH, defaults to HIGH
+	VideoQuality string `json:"videoQuality,omitempty"`
+}
+
+type RTPForwardInfo struct {
+	ForwarderId string `json:"forwarderId"`
+	TrackSid    string `json:"trackSid"`
+	Address     string `json:"address"`
+	// local port receiving RTCP from the destination
+	LocalPort   int    `json:"localPort"`
+	Ssrc        uint32 `json:"ssrc"`
+	MimeType    string `json:"mimeType"`
+	PayloadType uint8  `json:"payloadType"`
+	StartedAt   int64  `json:"startedAt"`
+}
+
+func toRTPForwardInfo(f *rtc.RTPForwarder) *RTPForwardInfo {
+	return &RTPForwardInfo{
+		ForwarderId: f.ID(),
+		TrackSid:    string(f.Track().ID()),
+		Address:     f.Address().String(),
+		LocalPort:   f.LocalAddr().Port,
+		Ssrc:        f.SSRC(),
+		MimeType:    f.Codec().MimeType,
+		PayloadType: uint8(f.Codec().PayloadType),
+		StartedAt:   f.StartedAt().Unix(),
+	}
+}
+
+func (s *RoomServiceExt) startRTPForward(ctx context.Context, body []byte) (interface{}, error) {
+	req := &StartRTPForwardRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "trackID", req.TrackSid, "host", req.Host, "port", req.Port)
+	params := rtc.RTPForwardParams{
+		TrackID:      livekit.TrackID(req.TrackSid),
+		SSRC:         req.Ssrc,
+		VideoQuality: livekit.VideoQuality_HIGH,
+	}
+	if req.Host == "" {
+		return nil, twirp.RequiredArgumentError("host")
+	}
+	if req.Port <= 0 || req.Port > 65535 {
+		return nil, twirp.InvalidArgumentError("port", "must be a valid port")
+	}
+	address, err := net.ResolveUDPAddr("udp", net.JoinHostPort(req.Host, strconv.Itoa(req.Port)))
+	if err != nil {
+		return nil, twirp.InvalidArgumentError("host", err.Error())
+	}
+	params.Address = address
+	if req.RTCPPort != 0 {
+		if req.RTCPPort < 0 || req.RTCPPort > 65535 {
+			return nil, twirp.InvalidArgumentError("rtcpPort", "must be a valid port")
+		}
+		params.RTCPAddress = &net.UDPAddr{IP: address.IP, Port: req.RTCPPort, Zone: address.Zone}
+	}
+	if len(req.PayloadTypes) != 0 {
+		params.PayloadTypes = make(map[string]webrtc.PayloadType, len(req.PayloadTypes))
+		for mime, pt := range req.PayloadTypes {
+			if pt > 127 {
+				return nil, twirp.InvalidArgumentError("payloadTypes", "payload type must be between 0 and 127")
+			}
+			params.PayloadTypes[strings.ToLower(mime)] = webrtc.PayloadType(pt)
+		}
+	}
+	if req.SrtpSuite != "" || req.SrtpKey != "" {
+		if params.SRTP, err = rtc.ParseSRTPParams(req.SrtpSuite, req.SrtpKey); err != nil {
+			return nil, twirp.InvalidArgumentError("srtpKey", err.Error())
+		}
+	}
+	if req.VideoQuality != "" {
+		quality, ok := livekit.VideoQuality_value[strings.ToUpper(req.VideoQuality)]
+		if !ok || livekit.VideoQuality(quality) == livekit.VideoQuality_OFF {
+			return nil, twirp.InvalidArgumentError("videoQuality", "must be one of LOW, MEDIUM or HIGH")
+		}
+		params.VideoQuality = livekit.VideoQuality(quality)
+	}
+
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := room.StartRTPForward(params)
+	switch {
+	case err == nil:
+		return toRTPForwardInfo(f), nil
+	case errors.Is(err, rtc.ErrTrackNotFound):
+		return nil, twirp.NotFoundError(err.Error())
+	case errors.Is(err, rtc.ErrTrackNotAttached), errors.Is(err, rtc.ErrTrackClosed), errors.Is(err, rtc.ErrRoomClosed):
+		return nil, twirp.NewError(twirp.FailedPrecondition, err.Error())
+	default:
+		return nil, twirp.InternalErrorWith(err)
+	}
+}
+
+type StopRTPForwardRequest struct {
+	Room        string `json:"room"`
+	ForwarderId string `json:"forwarderId"`
+}
+
+type StopRTPForwardResponse struct{}
+
+func (s *RoomServiceExt) stopRTPForward(ctx context.Context, body []byte) (interface{}, error) {
+	req := &StopRTPForwardRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "forwarderID", req.ForwarderId)
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = room.StopRTPForward(req.ForwarderId); err != nil {
+		return nil, twirp.NotFoundError(err.Error())
+	}
+	return &StopRTPForwardResponse{}, nil
+}
+
+type ListRTPForwardsRequest struct {
+	Room string `json:"room"`
+}
+
+type ListRTPForwardsResponse struct {
+	Forwards []*RTPForwardInfo `json:"forwards"`
+}
+
+func (s *RoomServiceExt) listRTPForwards(ctx context.Context, body []byte) (interface{}, error) {
+	req := &ListRTPForwardsRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room)
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ListRTPForwardsResponse{Forwards: []*RTPForwardInfo{}}
+	for _, f := range room.GetRTPForwarders() {
+		res.Forwards = append(res.Forwards, toRTPForwardInfo(f))
+	}
+	return res, nil
+}
+
+// -----------------------------------------------
+
+type StartRTPIngestRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Name     string `json:"name,omitempty"`
+	// SDP offer describing the RTP streams that will be sent
+	Sdp string `json:"sdp"`
+}
+
+// StartRTPIngestResponse includes the SDP answer with the address to send to.
+// Ingest is stopped by removing the participant.
+type StartRTPIngestResponse struct {
+	ParticipantSid string `json:"participantSid"`
+	Identity       string `json:"identity"`
+	Sdp            string `json:"sdp"`
+	Port           int    `json:"port"`
+}
+
+func (s *RoomServiceExt) startRTPIngest(ctx context.Context, body []byte) (interface{}, error) {
+	req := &StartRTPIngestRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+	if req.Identity == "" {
+		return nil, twirp.RequiredArgumentError("identity")
+	}
+	if req.Sdp == "" {
+		return nil, twirp.RequiredArgumentError("sdp")
+	}
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	participant, ingest, err := s.roomManager.StartRTPIngest(ctx, room.Name(), livekit.ParticipantIdentity(req.Identity), req.Name, req.Sdp)
+	switch {
+	case err == nil:
+		return &StartRTPIngestResponse{
+			ParticipantSid: string(participant.ID()),
+			Identity:       string(participant.Identity()),
+			Sdp:            ingest.Answer(),
+			Port:           ingest.Port(),
+		}, nil
+	case errors.Is(err, rtc.ErrInvalidSDP), errors.Is(err, rtc.ErrNoSupportedMedia):
+		return nil, twirp.InvalidArgumentError("sdp", err.Error())
+	case errors.Is(err, ErrIdentityInUse):
+		return nil, twirp.NewError(twirp.AlreadyExists, err.Error())
+	case errors.Is(err, ErrRoomNotFound):
+		return nil, twirp.NotFoundError(err.Error())
+	case errors.Is(err, rtc.ErrNoPortAvailable):
+		return nil, twirp.NewError(twirp.ResourceExhausted, err.Error())
+	default:
+		return nil, twirp.InternalErrorWith(err)
+	}
+}
+
+// -----------------------------------------------
+
+type StartHLSRequest struct {
+	Room string `json:"room"`
+	// up to one H.264 video track and one Opus audio track
+	TrackSids []string `json:"trackSids"`
+}
+
+func (s *RoomServiceExt) startHLS(ctx context.Context, body []byte) (interface{}, error) {
+	req := &StartHLSRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "trackIDs", req.TrackSids)
+	if len(req.TrackSids) == 0 {
+		return nil, twirp.RequiredArgumentError("trackSids")
+	}
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	trackIDs := make([]livekit.TrackID, 0, len(req.TrackSids))
+	for _, trackSid := range req.TrackSids {
+		trackIDs = append(trackIDs, livekit.TrackID(trackSid))
+	}
+	info, err := s.hlsService.Start(ctx, room.Name(), trackIDs)
+	switch {
+	case err == nil:
+		return info, nil
+	case errors.Is(err, ErrHLSInvalidTracks):
+		return nil, twirp.InvalidArgumentError("trackSids", err.Error())
+	case errors.Is(err, ErrTrackNotFound), errors.Is(err, ErrRoomNotFound):
+		return nil, twirp.NotFoundError(err.Error())
+	default:
+		return nil, twirp.InternalErrorWith(err)
+	}
+}
+
+type StopHLSRequest struct {
+	Room string `json:"room"`
+	Id   string `json:"id"`
+}
+
+type StopHLSResponse struct{}
+
+func (s *RoomServiceExt) stopHLS(ctx context.Context, body []byte) (interface{}, error) {
+	req := &StopHLSRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room, "hlsID", req.Id)
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.hlsService.Stop(room.Name(), req.Id); err != nil {
+		return nil, twirp.NotFoundError(err.Error())
+	}
+	return &StopHLSResponse{}, nil
+}
+
+type ListHLSRequest struct {
+	Room string `json:"room"`
+}
+
+type ListHLSResponse struct {
+	Streams []*HLSInfo `json:"streams"`
+}
+
+func (s *RoomServiceExt) listHLS(ctx context.Context, body []byte) (interface{}, error) {
+	req := &ListHLSRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, twirp.InvalidArgumentError("body", err.Error())
+	}
+
+	AppendLogFields(ctx, "room", req.Room)
+	room, err := s.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	return &ListHLSResponse{Streams: s.hlsService.List(room.Name())}, nil
+}
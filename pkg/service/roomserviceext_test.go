@@ -0,0 +1,187 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+)
+
+func TestRoomServiceExt(t *testing.T) {
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomReturns(nil, routing.ErrNotFound)
+	svc := newTestRoomServiceExt(t, &config.Config{Development: true}, "node", router, psrpc.NewLocalMessageBus())
+	request := func(method string, body string, grants *auth.ClaimGrants) *httptest.ResponseRecorder {
+		return serveRoomServiceExt(svc, method, body, grants)
+	}
+
+	t.Run("unknown method", func(t *testing.T) {
+		w := request("Unknown", "{}", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("missing permissions", func(t *testing.T) {
+		w := request("GetRoomParticipation", `{"room": "testroom"}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true, Room: "otherroom"},
+		})
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("room not on this node", func(t *testing.T) {
+		w := request("GetRoomParticipation", `{"room": "testroom"}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true, Room: "testroom"},
+		})
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("connection quality of room not on this node", func(t *testing.T) {
+		w := request("GetParticipantConnectionQuality", `{"room": "testroom", "identity": "alice"}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true, Room: "testroom"},
+		})
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("network impairment with invalid direction", func(t *testing.T) {
+		w := request("SimulateNetworkImpairment", `{"room": "testroom", "identity": "alice", "direction": "sideways", "lossPercentage": 5}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true},
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("network impairment with invalid loss", func(t *testing.T) {
+		w := request("SimulateNetworkImpairment", `{"room": "testroom", "identity": "alice", "direction": "upstream", "lossPercentage": 120}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true},
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("network impairment outside development mode", func(t *testing.T) {
+		prodSvc := newTestRoomServiceExt(t, &config.Config{}, "node", router, psrpc.NewLocalMessageBus())
+		w := serveRoomServiceExt(prodSvc, "SimulateNetworkImpairment", `{"room": "testroom", "identity": "alice", "direction": "upstream", "lossPercentage": 5}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true},
+		})
+		require.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("move to the same room", func(t *testing.T) {
+		w := request("MoveParticipant", `{"room": "testroom", "identity": "alice", "destinationRoom": "testroom"}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true},
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("move requires admin of the destination room", func(t *testing.T) {
+		w := request("MoveParticipant", `{"room": "testroom", "identity": "alice", "destinationRoom": "breakout"}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true, Room: "testroom"},
+		})
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("forward to the same room", func(t *testing.T) {
+		w := request("ForwardTrack", `{"room": "testroom", "trackSid": "TR_video", "destinationRoom": "testroom"}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true},
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("rtp forward with invalid srtp key", func(t *testing.T) {
+		w := request("StartRTPForward", `{"room": "testroom", "trackSid": "TR_video", "host": "127.0.0.1", "port": 5004, "srtpSuite": "AES_CM_128_HMAC_SHA1_80", "srtpKey": "c2hvcnQ="}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true},
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("hls without tracks", func(t *testing.T) {
+		w := request("StartHLS", `{"room": "testroom", "trackSids": []}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true},
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("rtp ingest without sdp", func(t *testing.T) {
+		w := request("StartRTPIngest", `{"room": "testroom", "identity": "encoder"}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true},
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestRoomServiceExtRelay(t *testing.T) {
+	bus := psrpc.NewLocalMessageBus()
+
+	// the room is hosted on the other node
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomReturns(&livekit.Node{Id: "other"}, nil)
+	svc := newTestRoomServiceExt(t, &config.Config{}, "node", router, bus)
+	otherRouter := &routingfakes.FakeRouter{}
+	newTestRoomServiceExt(t, &config.Config{}, "other", otherRouter, bus)
+
+	t.Run("grants are checked on the hosting node", func(t *testing.T) {
+		w := serveRoomServiceExt(svc, "GetRoomParticipation", `{"room": "testroom"}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true, Room: "otherroom"},
+		})
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("errors of the hosting node are returned", func(t *testing.T) {
+		w := serveRoomServiceExt(svc, "GetRoomParticipation", `{"room": "testroom"}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true, Room: "testroom"},
+		})
+		require.Equal(t, http.StatusNotFound, w.Code)
+		// relayed requests are not routed again
+		require.Zero(t, otherRouter.GetNodeForRoomCallCount())
+	})
+
+	t.Run("invalid arguments are returned", func(t *testing.T) {
+		w := serveRoomServiceExt(svc, "StartRTPIngest", `{"room": "testroom", "identity": "encoder"}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true},
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("relay only methods are not served", func(t *testing.T) {
+		w := serveRoomServiceExt(svc, "ForwardRemoteTrack", `{"room": "testroom", "identity": "speaker", "sdp": "v=0"}`, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true, Room: "testroom"},
+		})
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func newTestRoomServiceExt(t *testing.T, conf *config.Config, nodeID string, router routing.Router, bus psrpc.MessageBus) *service.RoomServiceExt {
+	roomManager := &service.RoomManager{}
+	svc, err := service.NewRoomServiceExt(
+		conf,
+		roomManager,
+		&servicefakes.FakeRoomAllocator{},
+		service.NewHLSService(conf, roomManager),
+		router,
+		&livekit.Node{Id: nodeID},
+		bus,
+	)
+	require.NoError(t, err)
+	t.Cleanup(svc.Stop)
+	return svc
+}
+
+func serveRoomServiceExt(svc *service.RoomServiceExt, method string, body string, grants *auth.ClaimGrants) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, svc.PathPrefix()+method, bytes.NewBufferString(body))
+	if grants != nil {
+		r = r.WithContext(service.WithGrants(context.Background(), grants))
+	}
+	w := httptest.NewRecorder()
+	svc.ServeHTTP(w, r)
+	return w
+}
@@ -37,6 +37,8 @@ type RTCService struct {
 	limits        config.LimitConfig
 	parser        *uaparser.Parser
 	telemetry     telemetry.TelemetryService
+	admission     *AdmissionHook
+	canary        *Canary
 }
 
 func NewRTCService(
@@ -46,6 +48,8 @@ func NewRTCService(
 	router routing.MessageRouter,
 	currentNode routing.LocalNode,
 	telemetry telemetry.TelemetryService,
+	admission *AdmissionHook,
+	canary *Canary,
 ) *RTCService {
 	s := &RTCService{
 		router:        router,
@@ -58,6 +62,8 @@ func NewRTCService(
 		limits:        conf.Limit,
 		parser:        uaparser.NewFromSaved(),
 		telemetry:     telemetry,
+		admission:     admission,
+		canary:        canary,
 	}
 
 	// allow connections from any origin, since script may be hosted anywhere
@@ -141,14 +147,16 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 	}
 
 	pi = routing.ParticipantInit{
-		Reconnect:       boolValue(reconnectParam),
-		ReconnectReason: livekit.ReconnectReason(reconnectReason),
-		Identity:        livekit.ParticipantIdentity(claims.Identity),
-		Name:            livekit.ParticipantName(claims.Name),
-		AutoSubscribe:   true,
-		Client:          s.ParseClientInfo(r),
-		Grants:          claims,
-		Region:          region,
+		Reconnect:         boolValue(reconnectParam),
+		ReconnectReason:   livekit.ReconnectReason(reconnectReason),
+		Identity:          livekit.ParticipantIdentity(claims.Identity),
+		Name:              livekit.ParticipantName(claims.Name),
+		AutoSubscribe:     true,
+		Client:            s.ParseClientInfo(r),
+		Grants:            claims,
+		Region:            region,
+		MaxPublishBitrate: GetLimitClaims(r.Context()).MaxPublishBitrate,
+		Canary:            s.canary.isCanaryParticipant(r.Context(), roomName, livekit.ParticipantIdentity(claims.Identity)),
 	}
 	if pi.Reconnect {
 		pi.ID = livekit.ParticipantID(participantID)
@@ -168,6 +176,23 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 	return roomName, pi, http.StatusOK, nil
 }
 
+// admit asks the admission hook whether a new participant may join, only when actually joining
+// so that validating a token does not count as a join. Probes of the canary are not passed to the hook.
+func (s *RTCService) admit(ctx context.Context, roomName livekit.RoomName, pi *routing.ParticipantInit) (int, error) {
+	if pi.Reconnect || pi.Canary {
+		return http.StatusOK, nil
+	}
+
+	if err := s.admission.AdmitParticipant(ctx, roomName, pi.Grants); err != nil {
+		if errors.Is(err, ErrAdmissionDenied) {
+			return http.StatusForbidden, err
+		}
+		return http.StatusServiceUnavailable, err
+	}
+	pi.Name = livekit.ParticipantName(pi.Grants.Name)
+	return http.StatusOK, nil
+}
+
 func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// reject non websocket requests
 	if !websocket.IsWebSocketUpgrade(r) {
@@ -180,6 +205,10 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		handleError(w, code, err)
 		return
 	}
+	if code, err = s.admit(r.Context(), roomName, &pi); err != nil {
+		handleError(w, code, err)
+		return
+	}
 
 	// for logger
 	loggerFields := []interface{}{
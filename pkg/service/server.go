@@ -28,23 +28,27 @@ import (
 )
 
 type LivekitServer struct {
-	config       *config.Config
-	ioService    *IOInfoService
-	rtcService   *RTCService
-	httpServer   *http.Server
-	promServer   *http.Server
-	router       routing.Router
-	roomManager  *RoomManager
-	signalServer *SignalServer
-	turnServer   *turn.Server
-	currentNode  routing.LocalNode
-	running      atomic.Bool
-	doneChan     chan struct{}
-	closedChan   chan struct{}
+	config         *config.Config
+	ioService      *IOInfoService
+	rtcService     *RTCService
+	httpServer     *http.Server
+	promServer     *http.Server
+	router         routing.Router
+	roomManager    *RoomManager
+	signalServer   *SignalServer
+	roomServiceExt *RoomServiceExt
+	turnServer     *turn.Server
+	canary         *Canary
+	currentNode    routing.LocalNode
+	running        atomic.Bool
+	doneChan       chan struct{}
+	closedChan     chan struct{}
 }
 
 func NewLivekitServer(conf *config.Config,
 	roomService livekit.RoomService,
+	roomServiceExt *RoomServiceExt,
+	hlsService *HLSService,
 	egressService *EgressService,
 	ingressService *IngressService,
 	ioService *IOInfoService,
@@ -54,17 +58,20 @@ func NewLivekitServer(conf *config.Config,
 	roomManager *RoomManager,
 	signalServer *SignalServer,
 	turnServer *turn.Server,
+	canary *Canary,
 	currentNode routing.LocalNode,
 ) (s *LivekitServer, err error) {
 	s = &LivekitServer{
-		config:       conf,
-		ioService:    ioService,
-		rtcService:   rtcService,
-		router:       router,
-		roomManager:  roomManager,
-		signalServer: signalServer,
+		config:         conf,
+		ioService:      ioService,
+		rtcService:     rtcService,
+		router:         router,
+		roomManager:    roomManager,
+		signalServer:   signalServer,
+		roomServiceExt: roomServiceExt,
 		// turn server starts automatically
 		turnServer:  turnServer,
+		canary:      canary,
 		currentNode: currentNode,
 		closedChan:  make(chan struct{}),
 	}
@@ -105,10 +112,13 @@ func NewLivekitServer(conf *config.Config,
 		mux.HandleFunc("/debug/rooms", s.debugInfo)
 	}
 	mux.Handle(roomServer.PathPrefix(), roomServer)
+	mux.Handle(roomServiceExt.PathPrefix(), roomServiceExt)
+	mux.Handle(hlsService.PathPrefix(), hlsService)
 	mux.Handle(egressServer.PathPrefix(), egressServer)
 	mux.Handle(ingressServer.PathPrefix(), ingressServer)
 	mux.Handle("/rtc", rtcService)
 	mux.HandleFunc("/rtc/validate", rtcService.Validate)
+	mux.HandleFunc("/ready", s.readinessCheck)
 	mux.HandleFunc("/", s.defaultHandler)
 
 	s.httpServer = &http.Server{
@@ -243,8 +253,16 @@ func (s *LivekitServer) Start() error {
 
 	s.running.Store(true)
 
+	if s.canary != nil {
+		s.canary.Start()
+	}
+
 	<-s.doneChan
 
+	if s.canary != nil {
+		s.canary.Stop()
+	}
+
 	// wait for shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
@@ -256,6 +274,7 @@ func (s *LivekitServer) Start() error {
 
 	s.roomManager.Stop()
 	s.signalServer.Stop()
+	s.roomServiceExt.Stop()
 	s.ioService.Stop()
 
 	close(s.closedChan)
@@ -289,6 +308,10 @@ func (s *LivekitServer) RoomManager() *RoomManager {
 	return s.roomManager
 }
 
+func (s *LivekitServer) Canary() *Canary {
+	return s.canary
+}
+
 func (s *LivekitServer) debugGoroutines(w http.ResponseWriter, _ *http.Request) {
 	_ = pprof.Lookup("goroutine").WriteTo(w, 2)
 }
@@ -319,11 +342,7 @@ func (s *LivekitServer) defaultHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *LivekitServer) healthCheck(w http.ResponseWriter, _ *http.Request) {
-	var updatedAt time.Time
-	if s.Node().Stats != nil {
-		updatedAt = time.Unix(s.Node().Stats.UpdatedAt, 0)
-	}
-	if time.Since(updatedAt) > 4*time.Second {
+	if updatedAt, ok := s.nodeStatsUpdated(); !ok {
 		w.WriteHeader(http.StatusNotAcceptable)
 		_, _ = w.Write([]byte(fmt.Sprintf("Not Ready\nNode Updated At %s", updatedAt)))
 		return
@@ -333,6 +352,35 @@ func (s *LivekitServer) healthCheck(w http.ResponseWriter, _ *http.Request) {
 	_, _ = w.Write([]byte("OK"))
 }
 
+// readinessCheck additionally fails when the canary, if enabled, keeps failing to join and receive media
+func (s *LivekitServer) readinessCheck(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.nodeStatsUpdated(); !ok || s.canary == nil {
+		s.healthCheck(w, r)
+		return
+	}
+
+	if !s.canary.Ready() {
+		msg := fmt.Sprintf("Not Ready\nCanary Failed %d Consecutive Probes", s.canary.ConsecutiveFailures())
+		if res := s.canary.LastResult(); res != nil && res.Err != nil {
+			msg += fmt.Sprintf("\nLast Error: %v", res.Err)
+		}
+		w.WriteHeader(http.StatusNotAcceptable)
+		_, _ = w.Write([]byte(msg))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK"))
+}
+
+func (s *LivekitServer) nodeStatsUpdated() (time.Time, bool) {
+	var updatedAt time.Time
+	if s.Node().Stats != nil {
+		updatedAt = time.Unix(s.Node().Stats.UpdatedAt, 0)
+	}
+	return updatedAt, time.Since(updatedAt) <= 4*time.Second
+}
+
 // worker to perform periodic tasks per node
 func (s *LivekitServer) backgroundWorker() {
 	roomTicker := time.NewTicker(1 * time.Second)
@@ -5,7 +5,10 @@ package service
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"sort"
+	"strconv"
 
 	"github.com/google/wire"
 	"github.com/pion/turn/v2"
@@ -19,6 +22,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
 	redisLiveKit "github.com/livekit/protocol/redis"
 	"github.com/livekit/protocol/rpc"
 	"github.com/livekit/protocol/utils"
@@ -34,6 +38,8 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		wire.Bind(new(ServiceStore), new(ObjectStore)),
 		createKeyProvider,
 		createWebhookNotifier,
+		createAdmissionHook,
+		createCanary,
 		createClientConfiguration,
 		routing.CreateRouter,
 		getRoomConf,
@@ -54,6 +60,8 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		NewIngressService,
 		NewRoomAllocator,
 		NewRoomService,
+		NewRoomServiceExt,
+		NewHLSService,
 		NewRTCService,
 		getSignalRelayConfig,
 		NewDefaultSignalServer,
@@ -112,7 +120,7 @@ func createKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
 	return auth.NewFileBasedKeyProviderFromMap(conf.Keys), nil
 }
 
-func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webhook.QueuedNotifier, error) {
+func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider, currentNode routing.LocalNode) (webhook.QueuedNotifier, error) {
 	wc := conf.WebHook
 	if len(wc.URLs) == 0 {
 		return nil, nil
@@ -122,7 +130,61 @@ func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webh
 		return nil, ErrWebHookMissingAPIKey
 	}
 
-	return webhook.NewDefaultNotifier(wc.APIKey, secret, wc.URLs), nil
+	notifier := telemetry.NewWebhookNotifier(wc.APIKey, secret, wc.URLs)
+	if conf.Canary.Enabled {
+		notifier.IgnoreRoom(string(CanaryRoomName(conf.Canary, livekit.NodeID(currentNode.Id))))
+	}
+	return notifier, nil
+}
+
+func createAdmissionHook(conf *config.Config, provider auth.KeyProvider) (*AdmissionHook, error) {
+	ac := conf.Admission
+	if ac.URL == "" {
+		return nil, nil
+	}
+	apiKey := ac.APIKey
+	if apiKey == "" {
+		apiKey = conf.WebHook.APIKey
+	}
+	secret := provider.GetSecret(apiKey)
+	if secret == "" {
+		return nil, ErrAdmissionMissingAPIKey
+	}
+
+	return NewAdmissionHook(ac, apiKey, secret)
+}
+
+func createCanary(conf *config.Config, roomAllocator RoomAllocator, currentNode routing.LocalNode) *Canary {
+	if !conf.Canary.Enabled {
+		return nil
+	}
+
+	// sign with any of the keys, picked consistently
+	apiKeys := make([]string, 0, len(conf.Keys))
+	for apiKey := range conf.Keys {
+		apiKeys = append(apiKeys, apiKey)
+	}
+	sort.Strings(apiKeys)
+	var apiKey string
+	if len(apiKeys) != 0 {
+		apiKey = apiKeys[0]
+	}
+
+	// connect over loopback unless the server listens on a specific address only
+	host := "127.0.0.1"
+	if len(conf.BindAddresses) != 0 && conf.BindAddresses[0] != "" && conf.BindAddresses[0] != "0.0.0.0" {
+		host = conf.BindAddresses[0]
+	}
+
+	return NewCanary(CanaryParams{
+		Config:        conf.Canary,
+		URL:           "ws://" + net.JoinHostPort(host, strconv.Itoa(int(conf.Port))),
+		APIKey:        apiKey,
+		APISecret:     conf.Keys[apiKey],
+		NodeID:        livekit.NodeID(currentNode.Id),
+		RoomAllocator: roomAllocator,
+		Logger:        logger.GetLogger(),
+	})
 }
 
 func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
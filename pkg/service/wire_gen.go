@@ -14,6 +14,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
 	redis2 "github.com/livekit/protocol/redis"
 	"github.com/livekit/protocol/rpc"
 	"github.com/livekit/protocol/utils"
@@ -23,7 +24,10 @@ import (
 	"github.com/pkg/errors"
 	"github.com/redis/go-redis/v9"
 	"gopkg.in/yaml.v3"
+	"net"
 	"os"
+	"sort"
+	"strconv"
 )
 
 import (
@@ -61,7 +65,7 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	queuedNotifier, err := createWebhookNotifier(conf, keyProvider)
+	queuedNotifier, err := createWebhookNotifier(conf, keyProvider, currentNode)
 	if err != nil {
 		return nil, err
 	}
@@ -84,10 +88,15 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	rtcService := NewRTCService(conf, roomAllocator, objectStore, router, currentNode, telemetryService)
+	admissionHook, err := createAdmissionHook(conf, keyProvider)
+	if err != nil {
+		return nil, err
+	}
+	canary := createCanary(conf, roomAllocator, currentNode)
+	rtcService := NewRTCService(conf, roomAllocator, objectStore, router, currentNode, telemetryService, admissionHook, canary)
 	clientConfigurationManager := createClientConfiguration()
 	timedVersionGenerator := utils.NewDefaultTimedVersionGenerator()
-	roomManager, err := NewLocalRoomManager(conf, objectStore, currentNode, router, telemetryService, clientConfigurationManager, rtcEgressLauncher, timedVersionGenerator)
+	roomManager, err := NewLocalRoomManager(conf, objectStore, currentNode, router, telemetryService, clientConfigurationManager, rtcEgressLauncher, timedVersionGenerator, admissionHook)
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +109,12 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	livekitServer, err := NewLivekitServer(conf, roomService, egressService, ingressService, ioInfoService, rtcService, keyProvider, router, roomManager, signalServer, server, currentNode)
+	hlsService := NewHLSService(conf, roomManager)
+	roomServiceExt, err := NewRoomServiceExt(conf, roomManager, roomAllocator, hlsService, router, currentNode, messageBus)
+	if err != nil {
+		return nil, err
+	}
+	livekitServer, err := NewLivekitServer(conf, roomService, roomServiceExt, hlsService, egressService, ingressService, ioInfoService, rtcService, keyProvider, router, roomManager, signalServer, server, canary, currentNode)
 	if err != nil {
 		return nil, err
 	}
@@ -157,7 +171,7 @@ func createKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
 	return auth.NewFileBasedKeyProviderFromMap(conf.Keys), nil
 }
 
-func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webhook.QueuedNotifier, error) {
+func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider, currentNode routing.LocalNode) (webhook.QueuedNotifier, error) {
 	wc := conf.WebHook
 	if len(wc.URLs) == 0 {
 		return nil, nil
@@ -167,7 +181,59 @@ func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webh
 		return nil, ErrWebHookMissingAPIKey
 	}
 
-	return webhook.NewDefaultNotifier(wc.APIKey, secret, wc.URLs), nil
+	notifier := telemetry.NewWebhookNotifier(wc.APIKey, secret, wc.URLs)
+	if conf.Canary.Enabled {
+		notifier.IgnoreRoom(string(CanaryRoomName(conf.Canary, livekit.NodeID(currentNode.Id))))
+	}
+	return notifier, nil
+}
+
+func createAdmissionHook(conf *config.Config, provider auth.KeyProvider) (*AdmissionHook, error) {
+	ac := conf.Admission
+	if ac.URL == "" {
+		return nil, nil
+	}
+	apiKey := ac.APIKey
+	if apiKey == "" {
+		apiKey = conf.WebHook.APIKey
+	}
+	secret := provider.GetSecret(apiKey)
+	if secret == "" {
+		return nil, ErrAdmissionMissingAPIKey
+	}
+
+	return NewAdmissionHook(ac, apiKey, secret)
+}
+
+func createCanary(conf *config.Config, roomAllocator RoomAllocator, currentNode routing.LocalNode) *Canary {
+	if !conf.Canary.Enabled {
+		return nil
+	}
+
+	apiKeys := make([]string, 0, len(conf.Keys))
+	for apiKey := range conf.Keys {
+		apiKeys = append(apiKeys, apiKey)
+	}
+	sort.Strings(apiKeys)
+	var apiKey string
+	if len(apiKeys) != 0 {
+		apiKey = apiKeys[0]
+	}
+
+	host := "127.0.0.1"
+	if len(conf.BindAddresses) != 0 && conf.BindAddresses[0] != "" && conf.BindAddresses[0] != "0.0.0.0" {
+		host = conf.BindAddresses[0]
+	}
+
+	return NewCanary(CanaryParams{
+		Config:        conf.Canary,
+		URL:           "ws://" + net.JoinHostPort(host, strconv.Itoa(int(conf.Port))),
+		APIKey:        apiKey,
+		APISecret:     conf.Keys[apiKey],
+		NodeID:        livekit.NodeID(currentNode.Id),
+		RoomAllocator: roomAllocator,
+		Logger:        logger.GetLogger(),
+	})
 }
 
 func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
@@ -0,0 +1,280 @@
+package audio
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// Dominant speaker identification based on
+// I. Volfin and I. Cohen, "Dominant Speaker Identification for Multipoint Videoconferencing".
+//
+// Speech activity of each participant is scored on three time scales. One immediate sample is taken
+// per update interval, mediums cover dsImmediatesPerMedium immediates and the long time scale covers
+// dsMediumsPerLong mediums. A challenger replaces the dominant speaker only when it beats the
+// dominant speaker on all three time scales, which keeps short interjections and background talkers
+// from taking over.
+const (
+	dsMaxEnergy           = 127
+	dsImmediateBands      = 13
+	dsImmediateSubunit    = (dsMaxEnergy + dsImmediateBands - 1) / dsImmediateBands
+	dsImmediatesPerMedium = 3
+	dsMediumsPerLong      = 4
+	dsNumImmediates       = dsImmediatesPerMedium * dsMediumsPerLong
+
+	// an immediate needs more than this many active bands to count towards a medium
+	dsMediumThreshold = 7
+	// a medium needs more than this many active immediates to count towards a long
+	dsLongThreshold = dsImmediatesPerMedium - 1
+
+	dsSpeechProbability = 0.5
+	dsImmediateLambda   = 0.78
+	dsMediumLambda      = 24
+	dsLongLambda        = 47
+	dsMinScore          = 1.0e-10
+)
+
+var (
+	// a participant becomes active once most immediates of the current medium are active
+	dsActivateMediumScore = speechActivityScore(dsImmediatesPerMedium-1, dsImmediatesPerMedium, dsMediumLambda)
+	// and stays active until neither the current medium nor the long time scale show speech
+	dsDeactivateMediumScore = speechActivityScore(1, dsImmediatesPerMedium, dsMediumLambda)
+	dsDeactivateLongScore   = speechActivityScore(1, dsMediumsPerLong, dsLongLambda)
+)
+
+type DominantSpeakerParams struct {
+	// log-likelihood ratios a challenger has to exceed on each time scale to become dominant
+	ImmediateThreshold float64
+	MediumThreshold    float64
+	LongThreshold      float64
+}
+
+type dominantSpeakerState struct {
+	// most recent first
+	immediates [dsNumImmediates]uint8
+	mediums    [dsMediumsPerLong]uint8
+	long       uint8
+
+	immediateScore float64
+	mediumScore    float64
+	longScore      float64
+
+	active bool
+}
+
+// observe records a level sample, returns true if the participant became active or inactive
+func (s *dominantSpeakerState) observe(level float64) bool {
+	copy(s.immediates[1:], s.immediates[:dsNumImmediates-1])
+	s.immediates[0] = levelToImmediate(level)
+
+	s.long = 0
+	for i := range s.mediums {
+		s.mediums[i] = 0
+		for _, immediate := range s.immediates[i*dsImmediatesPerMedium : (i+1)*dsImmediatesPerMedium] {
+			if immediate > dsMediumThreshold {
+				s.mediums[i]++
+			}
+		}
+		if s.mediums[i] > dsLongThreshold {
+			s.long++
+		}
+	}
+
+	s.immediateScore = speechActivityScore(s.immediates[0], dsImmediateBands, dsImmediateLambda)
+	s.mediumScore = speechActivityScore(s.mediums[0], dsImmediatesPerMedium, dsMediumLambda)
+	s.longScore = speechActivityScore(s.long, dsMediumsPerLong, dsLongLambda)
+
+	// hysteresis, activating needs more speech than staying active
+	wasActive := s.active
+	if s.active {
+		s.active = s.mediumScore >= dsDeactivateMediumScore || s.longScore >= dsDeactivateLongScore
+	} else {
+		s.active = s.mediumScore >= dsActivateMediumScore
+	}
+	return s.active != wasActive
+}
+
+func (s *dominantSpeakerState) hasActivity() bool {
+	for _, medium := range s.mediums {
+		if medium > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// DominantSpeakerDetector keeps a stable dominant speaker, listed ahead of other participants
+// that have sustained speech activity
+type DominantSpeakerDetector struct {
+	params DominantSpeakerParams
+
+	lock     sync.Mutex
+	speakers map[livekit.ParticipantID]*dominantSpeakerState
+	dominant livekit.ParticipantID
+
+	onSpeakersChanged func()
+}
+
+func NewDominantSpeakerDetector(params DominantSpeakerParams) *DominantSpeakerDetector {
+	return &DominantSpeakerDetector{
+		params:   params,
+		speakers: make(map[livekit.ParticipantID]*dominantSpeakerState),
+	}
+}
+
+func (d *DominantSpeakerDetector) OnSpeakersChanged(f func()) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.onSpeakersChanged = f
+}
+
+func (d *DominantSpeakerDetector) Observe(levels []SpeakerLevel) {
+	d.lock.Lock()
+	changed := false
+	for _, level := range levels {
+		s := d.speakers[level.ParticipantID]
+		if s == nil {
+			s = &dominantSpeakerState{}
+			d.speakers[level.ParticipantID] = s
+		}
+		if s.observe(level.Level) {
+			changed = true
+		}
+	}
+
+	if d.updateDominantLocked() {
+		changed = true
+	}
+	onSpeakersChanged := d.onSpeakersChanged
+	d.lock.Unlock()
+
+	if changed && onSpeakersChanged != nil {
+		onSpeakersChanged()
+	}
+}
+
+func (d *DominantSpeakerDetector) GetActiveSpeakers(levels []SpeakerLevel) []SpeakerLevel {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	speakers := make([]SpeakerLevel, 0, len(levels))
+	for _, level := range levels {
+		s := d.speakers[level.ParticipantID]
+		if s == nil {
+			continue
+		}
+		if s.active || (level.ParticipantID == d.dominant && s.hasActivity()) {
+			level.Active = true
+			speakers = append(speakers, level)
+		}
+	}
+
+	sort.Slice(speakers, func(i, j int) bool {
+		if speakers[i].ParticipantID == d.dominant {
+			return true
+		}
+		if speakers[j].ParticipantID == d.dominant {
+			return false
+		}
+		return d.speakers[speakers[i].ParticipantID].mediumScore > d.speakers[speakers[j].ParticipantID].mediumScore
+	})
+	return speakers
+}
+
+func (d *DominantSpeakerDetector) GetDominantSpeaker() livekit.ParticipantID {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.dominant
+}
+
+func (d *DominantSpeakerDetector) Remove(participantID livekit.ParticipantID) {
+	d.lock.Lock()
+	s := d.speakers[participantID]
+	delete(d.speakers, participantID)
+	changed := s != nil && s.active
+	if d.dominant == participantID {
+		d.updateDominantLocked()
+		changed = true
+	}
+	onSpeakersChanged := d.onSpeakersChanged
+	d.lock.Unlock()
+
+	if changed && onSpeakersChanged != nil {
+		onSpeakersChanged()
+	}
+}
+
+// updateDominantLocked returns true if the dominant speaker changed
+func (d *DominantSpeakerDetector) updateDominantLocked() bool {
+	previous := d.dominant
+	current := d.speakers[d.dominant]
+	if current == nil {
+		// no dominant speaker, pick the most active one
+		d.dominant = ""
+		maxScore := 0.0
+		for participantID, s := range d.speakers {
+			if s.active && s.mediumScore > maxScore {
+				d.dominant = participantID
+				maxScore = s.mediumScore
+			}
+		}
+		return d.dominant != previous
+	}
+
+	// a challenger has to beat the dominant speaker on all time scales
+	maxMediumRatio := d.params.MediumThreshold
+	for participantID, s := range d.speakers {
+		if participantID == d.dominant {
+			continue
+		}
+
+		immediateRatio := math.Log(s.immediateScore / current.immediateScore)
+		mediumRatio := math.Log(s.mediumScore / current.mediumScore)
+		longRatio := math.Log(s.longScore / current.longScore)
+		if immediateRatio > d.params.ImmediateThreshold &&
+			mediumRatio > maxMediumRatio &&
+			longRatio > d.params.LongThreshold {
+			d.dominant = participantID
+			maxMediumRatio = mediumRatio
+		}
+	}
+	return d.dominant != previous
+}
+
+// -----------------------------------------------
+
+// converts a linear level to the number of active bands,
+// energy is the inverse of audio level in -dBov, i. e. 0 is silent, 127 is loudest
+func levelToImmediate(level float64) uint8 {
+	if level <= 0 {
+		return 0
+	}
+
+	energy := dsMaxEnergy + 20*math.Log10(level)
+	if energy <= 0 {
+		return 0
+	}
+	if energy > dsMaxEnergy {
+		energy = dsMaxEnergy
+	}
+	return uint8(energy / dsImmediateSubunit)
+}
+
+func speechActivityScore(active uint8, total uint8, lambda float64) float64 {
+	lgN, _ := math.Lgamma(float64(total) + 1)
+	lgK, _ := math.Lgamma(float64(active) + 1)
+	lgNK, _ := math.Lgamma(float64(total-active) + 1)
+	score := lgN - lgK - lgNK +
+		float64(active)*math.Log(dsSpeechProbability) +
+		float64(total-active)*math.Log(1-dsSpeechProbability) -
+		math.Log(lambda) +
+		lambda*float64(active)
+	if score < dsMinScore {
+		score = dsMinScore
+	}
+	return score
+}
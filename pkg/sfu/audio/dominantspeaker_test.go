@@ -0,0 +1,146 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	loudLevel       = 25 // -25dBov
+	backgroundLevel = 50
+)
+
+func TestDominantSpeaker(t *testing.T) {
+	t.Run("picks sustained speaker", func(t *testing.T) {
+		d := createDominantSpeakerDetector()
+		observeLevels(d, 5, map[livekit.ParticipantID]uint8{"a": loudLevel, "b": silentAudioLevel})
+
+		require.Equal(t, livekit.ParticipantID("a"), d.GetDominantSpeaker())
+		speakers := d.GetActiveSpeakers(speakerLevels(map[livekit.ParticipantID]uint8{"a": loudLevel, "b": silentAudioLevel}))
+		require.Len(t, speakers, 1)
+		require.Equal(t, livekit.ParticipantID("a"), speakers[0].ParticipantID)
+		require.True(t, speakers[0].Active)
+	})
+
+	t.Run("background talker does not become active", func(t *testing.T) {
+		d := createDominantSpeakerDetector()
+		observeLevels(d, dsNumImmediates, map[livekit.ParticipantID]uint8{"a": backgroundLevel})
+
+		require.Empty(t, d.GetDominantSpeaker())
+		require.Empty(t, d.GetActiveSpeakers(speakerLevels(map[livekit.ParticipantID]uint8{"a": backgroundLevel})))
+	})
+
+	t.Run("short interjection does not switch", func(t *testing.T) {
+		d := createDominantSpeakerDetector()
+		observeLevels(d, dsNumImmediates, map[livekit.ParticipantID]uint8{"a": loudLevel, "b": silentAudioLevel})
+		observeLevels(d, 1, map[livekit.ParticipantID]uint8{"a": silentAudioLevel, "b": loudLevel})
+
+		require.Equal(t, livekit.ParticipantID("a"), d.GetDominantSpeaker())
+
+		// a single loud interval does not make a participant active
+		speakers := d.GetActiveSpeakers(speakerLevels(map[livekit.ParticipantID]uint8{"a": silentAudioLevel, "b": loudLevel}))
+		require.Len(t, speakers, 1)
+		require.Equal(t, livekit.ParticipantID("a"), speakers[0].ParticipantID)
+
+		// once active, both are listed with the dominant speaker first
+		observeLevels(d, 1, map[livekit.ParticipantID]uint8{"a": silentAudioLevel, "b": loudLevel})
+		speakers = d.GetActiveSpeakers(speakerLevels(map[livekit.ParticipantID]uint8{"a": silentAudioLevel, "b": loudLevel}))
+		require.Len(t, speakers, 2)
+		require.Equal(t, livekit.ParticipantID("a"), speakers[0].ParticipantID)
+	})
+
+	t.Run("short pause does not deactivate", func(t *testing.T) {
+		d := createDominantSpeakerDetector()
+		levels := map[livekit.ParticipantID]uint8{"a": loudLevel, "b": loudLevel + 5}
+		observeLevels(d, dsNumImmediates, levels)
+		require.Len(t, d.GetActiveSpeakers(speakerLevels(levels)), 2)
+
+		// b pauses for a few intervals, but keeps being active thanks to the long time scale
+		levels = map[livekit.ParticipantID]uint8{"a": loudLevel, "b": silentAudioLevel}
+		observeLevels(d, dsImmediatesPerMedium+1, levels)
+		require.Len(t, d.GetActiveSpeakers(speakerLevels(levels)), 2)
+
+		// and becomes inactive after a sustained pause
+		observeLevels(d, dsNumImmediates, levels)
+		speakers := d.GetActiveSpeakers(speakerLevels(levels))
+		require.Len(t, speakers, 1)
+		require.Equal(t, livekit.ParticipantID("a"), speakers[0].ParticipantID)
+	})
+
+	t.Run("notifies on stable transitions only", func(t *testing.T) {
+		d := createDominantSpeakerDetector()
+		changes := 0
+		d.OnSpeakersChanged(func() {
+			changes++
+		})
+
+		// a becomes active and dominant
+		observeLevels(d, dsNumImmediates, map[livekit.ParticipantID]uint8{"a": loudLevel, "b": silentAudioLevel})
+		require.Equal(t, 1, changes)
+
+		// short interjection does not change anything
+		observeLevels(d, 1, map[livekit.ParticipantID]uint8{"a": loudLevel, "b": loudLevel})
+		require.Equal(t, 1, changes)
+
+		d.Remove("a")
+		require.Equal(t, 2, changes)
+	})
+
+	t.Run("switches after sustained speech", func(t *testing.T) {
+		d := createDominantSpeakerDetector()
+		observeLevels(d, dsNumImmediates, map[livekit.ParticipantID]uint8{"a": loudLevel, "b": silentAudioLevel})
+		observeLevels(d, dsNumImmediates, map[livekit.ParticipantID]uint8{"a": silentAudioLevel, "b": loudLevel})
+
+		require.Equal(t, livekit.ParticipantID("b"), d.GetDominantSpeaker())
+	})
+
+	t.Run("removing dominant speaker picks another", func(t *testing.T) {
+		d := createDominantSpeakerDetector()
+		observeLevels(d, dsImmediatesPerMedium, map[livekit.ParticipantID]uint8{"a": loudLevel, "b": silentAudioLevel})
+		observeLevels(d, dsNumImmediates, map[livekit.ParticipantID]uint8{"a": loudLevel, "b": loudLevel + 5})
+		require.Equal(t, livekit.ParticipantID("a"), d.GetDominantSpeaker())
+
+		d.Remove("a")
+		require.Equal(t, livekit.ParticipantID("b"), d.GetDominantSpeaker())
+	})
+}
+
+func TestPercentileSpeakerDetector(t *testing.T) {
+	d := NewPercentileSpeakerDetector()
+	speakers := d.GetActiveSpeakers([]SpeakerLevel{
+		{ParticipantID: "a", Level: 0.2, Active: true},
+		{ParticipantID: "b", Level: 0.5, Active: true},
+		{ParticipantID: "c", Level: 0.01, Active: false},
+	})
+	require.Len(t, speakers, 2)
+	require.Equal(t, livekit.ParticipantID("b"), speakers[0].ParticipantID)
+	require.Equal(t, livekit.ParticipantID("a"), speakers[1].ParticipantID)
+}
+
+func createDominantSpeakerDetector() *DominantSpeakerDetector {
+	return NewDominantSpeakerDetector(DominantSpeakerParams{
+		ImmediateThreshold: 3,
+		MediumThreshold:    2,
+		LongThreshold:      0,
+	})
+}
+
+func speakerLevels(levels map[livekit.ParticipantID]uint8) []SpeakerLevel {
+	speakerLevels := make([]SpeakerLevel, 0, len(levels))
+	for participantID, level := range levels {
+		speakerLevels = append(speakerLevels, SpeakerLevel{
+			ParticipantID: participantID,
+			Level:         ConvertAudioLevel(float64(level)),
+		})
+	}
+	return speakerLevels
+}
+
+func observeLevels(d *DominantSpeakerDetector, count int, levels map[livekit.ParticipantID]uint8) {
+	for i := 0; i < count; i++ {
+		d.Observe(speakerLevels(levels))
+	}
+}
@@ -0,0 +1,60 @@
+package audio
+
+import (
+	"sort"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type SpeakerLevel struct {
+	ParticipantID livekit.ParticipantID
+	// linear level (0, 1], as returned by AudioLevel.GetLevel
+	Level  float64
+	Active bool
+}
+
+// SpeakerDetector decides which participants of a room are considered to be speaking,
+// based on the audio levels of their published tracks
+type SpeakerDetector interface {
+	// Observe records a sample of levels of all participants, expected to be called once every update interval
+	Observe(levels []SpeakerLevel)
+	// GetActiveSpeakers returns participants considered to be speaking, most prominent first
+	GetActiveSpeakers(levels []SpeakerLevel) []SpeakerLevel
+	// Remove clears any state kept for a participant
+	Remove(participantID livekit.ParticipantID)
+}
+
+// SpeakerTransitionNotifier is implemented by detectors that decide when active speakers change,
+// instead of every change of level being treated as a speaker change
+type SpeakerTransitionNotifier interface {
+	// OnSpeakersChanged registers a callback invoked on stable transitions of the dominant or active speakers
+	OnSpeakersChanged(f func())
+}
+
+// --------------------------------------------
+
+// PercentileSpeakerDetector treats every participant whose audio level crossed
+// the active threshold as a speaker, loudest first
+type PercentileSpeakerDetector struct{}
+
+func NewPercentileSpeakerDetector() *PercentileSpeakerDetector {
+	return &PercentileSpeakerDetector{}
+}
+
+func (p *PercentileSpeakerDetector) Observe(_ []SpeakerLevel) {}
+
+func (p *PercentileSpeakerDetector) GetActiveSpeakers(levels []SpeakerLevel) []SpeakerLevel {
+	speakers := make([]SpeakerLevel, 0, len(levels))
+	for _, level := range levels {
+		if level.Active {
+			speakers = append(speakers, level)
+		}
+	}
+
+	sort.Slice(speakers, func(i, j int) bool {
+		return speakers[i].Level > speakers[j].Level
+	})
+	return speakers
+}
+
+func (p *PercentileSpeakerDetector) Remove(_ livekit.ParticipantID) {}
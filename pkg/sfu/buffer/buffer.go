@@ -24,10 +24,14 @@ import (
 	"github.com/livekit/protocol/logger"
 
 	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
+	"github.com/livekit/livekit-server/pkg/sfu/impairment"
 )
 
 const (
 	ReportDelta = time.Second
+
+	// telephone events waiting to be delivered, further events are dropped
+	dtmfQueueSize = 32
 )
 
 type pendingPacket struct {
@@ -73,6 +77,13 @@ type Buffer struct {
 	audioLevelParams audio.AudioLevelParams
 	audioLevel       *audio.AudioLevel
 
+	// clock rate of negotiated telephone-event payload types, those packets share the sequence space of the track
+	telephoneEvents  map[uint8]uint32
+	lastDTMFEndTS    uint32
+	lastDTMFEndValid bool
+	// events are delivered by a single goroutine, in the order they are received
+	dtmfEvents chan DTMFEvent
+
 	lastPacketRead int
 
 	pliThrottle int64
@@ -89,6 +100,7 @@ type Buffer struct {
 	onRtcpSenderReport func(*RTCPSenderReportData)
 	onFpsChanged       func()
 	onFinalRtpStats    func(*RTPStats)
+	onDTMF             func(DTMFEvent)
 
 	// logger
 	logger logger.Logger
@@ -101,6 +113,8 @@ type Buffer struct {
 	paused              bool
 	frameRateCalculator [DefaultMaxLayerSpatial + 1]FrameRateCalculator
 	frameRateCalculated bool
+
+	impairment atomic.Pointer[impairment.Impairment]
 }
 
 // NewBuffer constructs a new Buffer
@@ -191,6 +205,22 @@ func (b *Buffer) Bind(params webrtc.RTPParameters, codec webrtc.RTPCodecCapabili
 	case strings.HasPrefix(b.mime, "audio/"):
 		b.codecType = webrtc.RTPCodecTypeAudio
 		b.bucket = bucket.NewBucket(b.audioPool.Get().(*[]byte))
+		if b.audioLevel == nil && b.isG711() {
+			// telephony endpoints seldom send the audio level extension, level is measured from samples instead
+			b.audioLevel = audio.NewAudioLevel(b.audioLevelParams)
+		}
+		for _, c := range params.Codecs {
+			if strings.EqualFold(c.MimeType, MimeTypeTelephoneEvent) {
+				if b.telephoneEvents == nil {
+					b.telephoneEvents = make(map[uint8]uint32)
+				}
+				b.telephoneEvents[uint8(c.PayloadType)] = c.ClockRate
+			}
+		}
+		if b.telephoneEvents != nil && b.dtmfEvents == nil {
+			b.dtmfEvents = make(chan DTMFEvent, dtmfQueueSize)
+			go b.dtmfWorker(b.dtmfEvents)
+		}
 	case strings.HasPrefix(b.mime, "video/"):
 		b.codecType = webrtc.RTPCodecTypeVideo
 		b.bucket = bucket.NewBucket(b.videoPool.Get().(*[]byte))
@@ -245,8 +275,41 @@ func (b *Buffer) Bind(params webrtc.RTPParameters, codec webrtc.RTPCodecCapabili
 	b.bound = true
 }
 
+// SetImpairment runs incoming packets through a simulated network link before they are processed,
+// the buffer takes ownership of imp and closes any previous one, nil removes the impairment.
+func (b *Buffer) SetImpairment(imp *impairment.Impairment) {
+	if b.closed.Load() {
+		if imp != nil {
+			imp.Close()
+		}
+		return
+	}
+
+	if prev := b.impairment.Swap(imp); prev != nil {
+		prev.Close()
+	}
+}
+
 // Write adds an RTP Packet, out of order, new packet may be arrived later
 func (b *Buffer) Write(pkt []byte) (n int, err error) {
+	if imp := b.impairment.Load(); imp != nil {
+		if b.closed.Load() {
+			err = io.EOF
+			return
+		}
+
+		packet := make([]byte, len(pkt))
+		copy(packet, pkt)
+		imp.Process(packet, func(p []byte) {
+			_, _ = b.write(p)
+		})
+		return
+	}
+
+	return b.write(pkt)
+}
+
+func (b *Buffer) write(pkt []byte) (n int, err error) {
 	b.Lock()
 	defer b.Unlock()
 
@@ -328,6 +391,13 @@ func (b *Buffer) Close() error {
 		}
 
 		b.closed.Store(true)
+		if b.dtmfEvents != nil {
+			close(b.dtmfEvents)
+		}
+
+		if imp := b.impairment.Swap(nil); imp != nil {
+			imp.Close()
+		}
 
 		if b.rtpStats != nil {
 			b.rtpStats.Stop()
@@ -421,6 +491,12 @@ func (b *Buffer) calc(pkt []byte, arrivalTime time.Time) {
 		return
 	}
 
+	if clockRate, ok := b.telephoneEvents[p.PayloadType]; ok {
+		b.processTelephoneEvent(&p, clockRate)
+		// not forwarded, downstream treats it as a padding only packet
+		p.Payload = nil
+	}
+
 	b.updateStreamState(&p, arrivalTime)
 	b.processHeaderExtensions(&p, arrivalTime)
 
@@ -509,25 +585,83 @@ func (b *Buffer) processHeaderExtensions(p *rtp.Packet, arrivalTime time.Time) {
 		}
 	}
 
-	if b.audioLevelExt != 0 {
+	if b.audioLevel != nil {
 		if !b.latestTSForAudioLevelInitialized {
 			b.latestTSForAudioLevelInitialized = true
 			b.latestTSForAudioLevel = p.Timestamp
 		}
+		if level, ok := b.getAudioLevel(p); ok {
+			if (p.Timestamp - b.latestTSForAudioLevel) < (1 << 31) {
+				duration := (int64(p.Timestamp) - int64(b.latestTSForAudioLevel)) * 1e3 / int64(b.clockRate)
+				if duration > 0 {
+					b.audioLevel.Observe(level, uint32(duration))
+				}
+
+				b.latestTSForAudioLevel = p.Timestamp
+			}
+		}
+	}
+}
+
+func (b *Buffer) getAudioLevel(p *rtp.Packet) (uint8, bool) {
+	if b.audioLevelExt != 0 {
 		if e := p.GetExtension(b.audioLevelExt); e != nil {
 			ext := rtp.AudioLevelExtension{}
 			if err := ext.Unmarshal(e); err == nil {
-				if (p.Timestamp - b.latestTSForAudioLevel) < (1 << 31) {
-					duration := (int64(p.Timestamp) - int64(b.latestTSForAudioLevel)) * 1e3 / int64(b.clockRate)
-					if duration > 0 {
-						b.audioLevel.Observe(ext.Level, uint32(duration))
-					}
-
-					b.latestTSForAudioLevel = p.Timestamp
-				}
+				return ext.Level, true
 			}
 		}
 	}
+
+	if b.isG711() && len(p.Payload) != 0 {
+		return G711AudioLevel(p.Payload, b.mime == MimeTypePCMA), true
+	}
+	return 0, false
+}
+
+func (b *Buffer) isG711() bool {
+	return b.mime == MimeTypePCMU || b.mime == MimeTypePCMA
+}
+
+func (b *Buffer) processTelephoneEvent(p *rtp.Packet, clockRate uint32) {
+	te := telephoneEvent{}
+	if err := te.Unmarshal(p.Payload); err != nil || !te.end {
+		return
+	}
+
+	// the end of an event is sent more than once, all of them have the timestamp of the event start
+	if b.lastDTMFEndValid && b.lastDTMFEndTS == p.Timestamp {
+		return
+	}
+	b.lastDTMFEndValid = true
+	b.lastDTMFEndTS = p.Timestamp
+
+	if clockRate == 0 {
+		clockRate = 8000
+	}
+	if b.onDTMF == nil || b.dtmfEvents == nil {
+		return
+	}
+	select {
+	case b.dtmfEvents <- DTMFEvent{
+		Code:       te.event,
+		Volume:     te.volume,
+		DurationMs: uint32(te.duration) * 1000 / clockRate,
+	}:
+	default:
+		b.logger.Warnw("dtmf queue full, dropping event", nil, "code", te.event)
+	}
+}
+
+func (b *Buffer) dtmfWorker(events <-chan DTMFEvent) {
+	for event := range events {
+		b.RLock()
+		onDTMF := b.onDTMF
+		b.RUnlock()
+		if onDTMF != nil {
+			onDTMF(event)
+		}
+	}
 }
 
 func (b *Buffer) getExtPacket(rtpPacket *rtp.Packet, arrivalTime time.Time) *ExtPacket {
@@ -587,6 +721,8 @@ func (b *Buffer) getExtPacket(rtpPacket *rtp.Packet, arrivalTime time.Time) *Ext
 		ep.KeyFrame = IsVP9KeyFrame(rtpPacket.Payload)
 	case "video/h264":
 		ep.KeyFrame = IsH264KeyFrame(rtpPacket.Payload)
+	case "video/h265":
+		ep.KeyFrame = IsH265KeyFrame(rtpPacket.Payload)
 	case "video/av1":
 		ep.KeyFrame = IsAV1KeyFrame(rtpPacket.Payload)
 	}
@@ -726,6 +862,13 @@ func (b *Buffer) OnFinalRtpStats(fn func(*RTPStats)) {
 	b.onFinalRtpStats = fn
 }
 
+// OnDTMF is called for every telephone event received on the track, events are not forwarded as media
+func (b *Buffer) OnDTMF(fn func(DTMFEvent)) {
+	b.Lock()
+	b.onDTMF = fn
+	b.Unlock()
+}
+
 // GetMediaSSRC returns the associated SSRC of the RTP stream
 func (b *Buffer) GetMediaSSRC() uint32 {
 	return b.mediaSSRC
@@ -247,3 +247,114 @@ func TestFractionLostReport(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestTelephoneEvent(t *testing.T) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 10*1500)
+			return &b
+		},
+	}
+	pcmuCodec := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: "audio/PCMU", ClockRate: 8000},
+		PayloadType:        0,
+	}
+	telephoneEventCodec := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: "audio/telephone-event", ClockRate: 8000, SDPFmtpLine: "0-16"},
+		PayloadType:        101,
+	}
+
+	buff := NewBuffer(123, pool, pool)
+	events := make(chan DTMFEvent, 2)
+	buff.OnDTMF(func(event DTMFEvent) {
+		events <- event
+	})
+	buff.Bind(webrtc.RTPParameters{
+		Codecs: []webrtc.RTPCodecParameters{pcmuCodec, telephoneEventCodec},
+	}, pcmuCodec.RTPCodecCapability)
+
+	write := func(sn uint16, pt uint8, ts uint32, payload []byte) {
+		pkt := rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: sn, PayloadType: pt, Timestamp: ts},
+			Payload: payload,
+		}
+		b, err := pkt.Marshal()
+		require.NoError(t, err)
+		_, err = buff.Write(b)
+		require.NoError(t, err)
+	}
+	samples := make([]byte, 160)
+	for i := range samples {
+		samples[i] = 0xff
+	}
+	write(1, 0, 0, samples)
+	// digit 5, progress then end sent three times
+	write(2, 101, 160, []byte{5, 10, 0x01, 0x40})
+	for sn := uint16(3); sn <= 5; sn++ {
+		write(sn, 101, 160, []byte{5, 0x80 | 10, 0x03, 0x20})
+	}
+	write(6, 0, 960, samples)
+
+	select {
+	case event := <-events:
+		require.Equal(t, DTMFEvent{Code: 5, Volume: 10, DurationMs: 100}, event)
+		require.Equal(t, "5", event.Digit())
+	case <-time.After(time.Second):
+		t.Fatal("no dtmf event")
+	}
+	select {
+	case <-events:
+		t.Fatal("duplicate dtmf event")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// telephone events are not forwarded as media
+	buf := make([]byte, 1500)
+	for sn := uint16(1); sn <= 6; sn++ {
+		ep, err := buff.ReadExtended(buf)
+		require.NoError(t, err)
+		require.Equal(t, sn, ep.Packet.SequenceNumber)
+		require.Equal(t, sn == 1 || sn == 6, len(ep.Packet.Payload) != 0)
+	}
+
+	// digits are delivered in order, even when the callback is slow
+	var digits []string
+	done := make(chan struct{})
+	buff.OnDTMF(func(event DTMFEvent) {
+		time.Sleep(5 * time.Millisecond)
+		digits = append(digits, event.Digit())
+		if len(digits) == 4 {
+			close(done)
+		}
+	})
+	for i, code := range []byte{1, 2, 3, 4} {
+		write(uint16(7+i), 101, uint32(1120+i*160), []byte{code, 0x80 | 10, 0x03, 0x20})
+	}
+	select {
+	case <-done:
+		require.Equal(t, []string{"1", "2", "3", "4"}, digits)
+	case <-time.After(time.Second):
+		t.Fatal("missing dtmf events")
+	}
+	require.NoError(t, buff.Close())
+}
+
+func TestG711AudioLevel(t *testing.T) {
+	silence := make([]byte, 160)
+	for i := range silence {
+		silence[i] = 0xff
+	}
+	require.Equal(t, uint8(127), G711AudioLevel(silence, false))
+	for i := range silence {
+		silence[i] = 0xd5
+	}
+	require.Equal(t, uint8(72), G711AudioLevel(silence, true))
+
+	loud := make([]byte, 160)
+	for i := range loud {
+		// full scale samples of alternating sign
+		loud[i] = byte(i%2) << 7
+	}
+	require.Equal(t, uint8(0), G711AudioLevel(loud, false))
+	require.Equal(t, uint8(127), G711AudioLevel(nil, false))
+}
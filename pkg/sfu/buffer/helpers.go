@@ -296,6 +296,55 @@ func IsH264KeyFrame(payload []byte) bool {
 
 // -------------------------------------
 
+const (
+	h265NALUTypeAP = 48
+	h265NALUTypeFU = 49
+)
+
+// IsH265KeyFrame detects if h265 payload is a keyframe, i. e. it carries an IRAP picture or
+// the parameter sets sent ahead of one. Aggregation packets and starting fragments are inspected,
+// DONL fields are not expected as sprop-max-don-diff is not negotiated.
+func IsH265KeyFrame(payload []byte) bool {
+	if len(payload) < 2 {
+		return false
+	}
+	nalu := (payload[0] >> 1) & 0x3F
+	switch nalu {
+	case h265NALUTypeAP:
+		i := 2
+		for i+2 <= len(payload) {
+			length := int(payload[i])<<8 | int(payload[i+1])
+			i += 2
+			if length < 2 || i+length > len(payload) {
+				return false
+			}
+			if isH265KeyFrameNALU((payload[i] >> 1) & 0x3F) {
+				return true
+			}
+			i += length
+		}
+		return false
+	case h265NALUTypeFU:
+		if len(payload) < 3 {
+			return false
+		}
+		if (payload[2] & 0x80) == 0 {
+			// not a starting fragment
+			return false
+		}
+		return isH265KeyFrameNALU(payload[2] & 0x3F)
+	default:
+		return isH265KeyFrameNALU(nalu)
+	}
+}
+
+func isH265KeyFrameNALU(nalu uint8) bool {
+	// BLA_W_LP..CRA_NUT are IRAP pictures, VPS, SPS and PPS precede them
+	return (nalu >= 16 && nalu <= 21) || (nalu >= 32 && nalu <= 34)
+}
+
+// -------------------------------------
+
 func IsVP9KeyFrame(payload []byte) bool {
 	payloadLen := len(payload)
 	if payloadLen < 1 {
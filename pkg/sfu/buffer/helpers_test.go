@@ -94,3 +94,37 @@ func TestVP8Helper_Unmarshal(t *testing.T) {
 }
 
 // ------------------------------------------
+
+func TestIsH265KeyFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  []byte
+		keyFrame bool
+	}{
+		{name: "empty", payload: []byte{}, keyFrame: false},
+		{name: "IDR_W_RADL", payload: []byte{19 << 1, 0x01, 0xaf}, keyFrame: true},
+		{name: "CRA", payload: []byte{21 << 1, 0x01, 0xaf}, keyFrame: true},
+		{name: "VPS", payload: []byte{32 << 1, 0x01, 0x0c}, keyFrame: true},
+		{name: "TRAIL_R", payload: []byte{1 << 1, 0x01, 0xd0}, keyFrame: false},
+		{
+			name:     "AP with SPS",
+			payload:  []byte{48 << 1, 0x01, 0x00, 0x03, 1 << 1, 0x01, 0xd0, 0x00, 0x03, 33 << 1, 0x01, 0x01},
+			keyFrame: true,
+		},
+		{
+			name:     "AP without IRAP",
+			payload:  []byte{48 << 1, 0x01, 0x00, 0x03, 1 << 1, 0x01, 0xd0, 0x00, 0x03, 0 << 1, 0x01, 0x01},
+			keyFrame: false,
+		},
+		{name: "AP truncated", payload: []byte{48 << 1, 0x01, 0x00, 0x08, 19 << 1, 0x01}, keyFrame: false},
+		{name: "FU start of IDR", payload: []byte{49 << 1, 0x01, 0x80 | 19, 0xaf}, keyFrame: true},
+		{name: "FU continuation of IDR", payload: []byte{49 << 1, 0x01, 19, 0xaf}, keyFrame: false},
+		{name: "FU start of TRAIL_R", payload: []byte{49 << 1, 0x01, 0x80 | 1, 0xaf}, keyFrame: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.keyFrame, IsH265KeyFrame(tt.payload))
+		})
+	}
+}
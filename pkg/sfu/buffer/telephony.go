@@ -0,0 +1,121 @@
+package buffer
+
+import (
+	"math"
+)
+
+const (
+	silentAudioLevel = 127
+
+	MimeTypePCMU           = "audio/pcmu"
+	MimeTypePCMA           = "audio/pcma"
+	MimeTypeTelephoneEvent = "audio/telephone-event"
+)
+
+// DTMFEvent is a completed RFC 4733 telephone event
+type DTMFEvent struct {
+	// event code, 0-9 for digits, 10 for *, 11 for #, 12-15 for A-D
+	Code uint8
+	// power level of the tone in -dBm0
+	Volume     uint8
+	DurationMs uint32
+}
+
+func (e DTMFEvent) Digit() string {
+	switch {
+	case e.Code <= 9:
+		return string(rune('0' + e.Code))
+	case e.Code == 10:
+		return "*"
+	case e.Code == 11:
+		return "#"
+	case e.Code <= 15:
+		return string(rune('A' + e.Code - 12))
+	}
+	return ""
+}
+
+// telephoneEvent is the payload of a telephone-event packet
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|     event     |E|R| volume    |          duration             |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+type telephoneEvent struct {
+	event    uint8
+	end      bool
+	volume   uint8
+	duration uint16
+}
+
+func (t *telephoneEvent) Unmarshal(payload []byte) error {
+	if len(payload) < 4 {
+		return errShortPacket
+	}
+	t.event = payload[0]
+	t.end = payload[1]&0x80 != 0
+	t.volume = payload[1] & 0x3f
+	t.duration = uint16(payload[2])<<8 | uint16(payload[3])
+	return nil
+}
+
+// -------------------------------------
+
+// G711AudioLevel returns the level of G.711 samples in -dBov, the way RFC 6464 expresses it
+func G711AudioLevel(payload []byte, aLaw bool) uint8 {
+	if len(payload) == 0 {
+		return silentAudioLevel
+	}
+
+	var sumSquares float64
+	for _, b := range payload {
+		var s int16
+		if aLaw {
+			s = aLawToLinear(b)
+		} else {
+			s = uLawToLinear(b)
+		}
+		sumSquares += float64(s) * float64(s)
+	}
+	rms := math.Sqrt(sumSquares/float64(len(payload))) / 32768
+	if rms == 0 {
+		return silentAudioLevel
+	}
+	level := -20 * math.Log10(rms)
+	if level > silentAudioLevel {
+		return silentAudioLevel
+	}
+	return uint8(math.Round(level))
+}
+
+// uLawToLinear expands an ITU-T G.711 mu-law sample into a 16 bit linear sample
+func uLawToLinear(u uint8) int16 {
+	u = ^u
+	t := (int16(u&0x0f) << 3) + 0x84
+	t <<= (u & 0x70) >> 4
+	if u&0x80 != 0 {
+		return 0x84 - t
+	}
+	return t - 0x84
+}
+
+// aLawToLinear expands an ITU-T G.711 A-law sample into a 16 bit linear sample
+func aLawToLinear(a uint8) int16 {
+	a ^= 0x55
+	t := int16(a&0x0f) << 4
+	seg := (a & 0x70) >> 4
+	switch seg {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t += 0x108
+		t <<= seg - 1
+	}
+	if a&0x80 != 0 {
+		return t
+	}
+	return -t
+}
@@ -10,6 +10,8 @@ var (
 	ErrNotVP8                          = errors.New("not VP8")
 	ErrOutOfOrderVP8PictureIdCacheMiss = errors.New("out-of-order VP8 picture id not found in cache")
 	ErrFilteredVP8TemporalLayer        = errors.New("filtered VP8 temporal layer")
+	ErrNotVP9                          = errors.New("not VP9")
+	ErrOutOfOrderVP9PictureIdCacheMiss = errors.New("out-of-order VP9 picture id not found in cache")
 )
 
 type CodecMunger interface {
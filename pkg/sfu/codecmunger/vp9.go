@@ -0,0 +1,280 @@
+package codecmunger
+
+import (
+	"fmt"
+
+	"github.com/elliotchance/orderedmap/v2"
+	"github.com/pion/rtp/codecs"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// -----------------------------------------------------------
+
+type VP9State struct {
+	ExtLastPictureId int32
+	PictureIdUsed    bool
+	LastTl0PicIdx    uint8
+	Tl0PicIdxUsed    bool
+	FlexibleMode     bool
+}
+
+func (v VP9State) String() string {
+	return fmt.Sprintf("VP9State{extLastPictureId: %d, pictureIdUsed: %+v, lastTl0PicIdx: %d, tl0PicIdxUsed: %+v, flexibleMode: %+v)",
+		v.ExtLastPictureId, v.PictureIdUsed, v.LastTl0PicIdx, v.Tl0PicIdxUsed, v.FlexibleMode)
+}
+
+// -----------------------------------------------------------
+
+// VP9 keeps picture id and TL0PICIDX of a VP9 stream continuous across source switches and padding.
+//
+// Spatial and temporal layers are selected by the video layer selector, all layers of a picture share
+// the picture id and a dropped picture is never compacted out of the picture id space. That keeps
+// flexible mode reference indices (P_DIFF), which are picture id differences, valid. So, offsets
+// change only when the source changes or when padding pictures are inserted.
+type VP9 struct {
+	logger logger.Logger
+
+	pictureIdWrapHandler VP8PictureIdWrapHandler
+	extLastPictureId     int32
+	pictureIdOffset      int32
+	pictureIdUsed        bool
+	lastTl0PicIdx        uint8
+	tl0PicIdxOffset      uint8
+	tl0PicIdxUsed        bool
+	flexibleMode         bool
+
+	missingPictureIds *orderedmap.OrderedMap[int32, int32]
+}
+
+func NewVP9(logger logger.Logger) *VP9 {
+	return &VP9{
+		logger:            logger,
+		missingPictureIds: orderedmap.NewOrderedMap[int32, int32](),
+	}
+}
+
+func NewVP9FromNull(cm CodecMunger, logger logger.Logger) *VP9 {
+	v := NewVP9(logger)
+	v.SeedState(cm.(*Null).GetSeededState())
+	return v
+}
+
+func (v *VP9) GetState() interface{} {
+	return VP9State{
+		ExtLastPictureId: v.extLastPictureId,
+		PictureIdUsed:    v.pictureIdUsed,
+		LastTl0PicIdx:    v.lastTl0PicIdx,
+		Tl0PicIdxUsed:    v.tl0PicIdxUsed,
+		FlexibleMode:     v.flexibleMode,
+	}
+}
+
+func (v *VP9) SeedState(seed interface{}) {
+	if state, ok := seed.(VP9State); ok {
+		v.extLastPictureId = state.ExtLastPictureId
+		v.pictureIdUsed = state.PictureIdUsed
+		v.lastTl0PicIdx = state.LastTl0PicIdx
+		v.tl0PicIdxUsed = state.Tl0PicIdxUsed
+		v.flexibleMode = state.FlexibleMode
+	}
+}
+
+func (v *VP9) SetLast(extPkt *buffer.ExtPacket) {
+	vp9, mBit, _, ok := getVP9(extPkt)
+	if !ok {
+		return
+	}
+
+	v.pictureIdUsed = vp9.I
+	if v.pictureIdUsed {
+		v.pictureIdWrapHandler.Init(int32(vp9.PictureID)-1, mBit)
+		v.extLastPictureId = int32(vp9.PictureID)
+	}
+
+	v.flexibleMode = vp9.F
+	v.tl0PicIdxUsed = vp9.L && !vp9.F
+	if v.tl0PicIdxUsed {
+		v.lastTl0PicIdx = vp9.TL0PICIDX
+	}
+}
+
+func (v *VP9) UpdateOffsets(extPkt *buffer.ExtPacket) {
+	vp9, mBit, _, ok := getVP9(extPkt)
+	if !ok {
+		return
+	}
+
+	if v.pictureIdUsed {
+		v.pictureIdWrapHandler.Init(int32(vp9.PictureID)-1, mBit)
+		v.pictureIdOffset = int32(vp9.PictureID) - v.extLastPictureId - 1
+	}
+
+	if v.tl0PicIdxUsed {
+		v.tl0PicIdxOffset = vp9.TL0PICIDX - v.lastTl0PicIdx - 1
+	}
+
+	// clear picture id cache on source switch
+	v.missingPictureIds = orderedmap.NewOrderedMap[int32, int32]()
+}
+
+func (v *VP9) UpdateAndGet(extPkt *buffer.ExtPacket, snOutOfOrder bool, snHasGap bool, _maxTemporalLayer int32) ([]byte, error) {
+	vp9, mBit, headerSize, ok := getVP9(extPkt)
+	if !ok {
+		return nil, ErrNotVP9
+	}
+
+	extPictureId := v.pictureIdWrapHandler.Unwrap(vp9.PictureID, mBit)
+
+	// if out-of-order, look up missing picture id cache,
+	// see VP8 munger for why the entry is not deleted on use
+	if snOutOfOrder {
+		pictureIdOffset, ok := v.missingPictureIds.Get(extPictureId)
+		if !ok {
+			return nil, ErrOutOfOrderVP9PictureIdCacheMiss
+		}
+
+		return marshalMungedVP9(
+			extPkt.Packet.Payload[:headerSize],
+			&vp9,
+			mBit,
+			uint16((extPictureId-pictureIdOffset)&0x7fff),
+			vp9.TL0PICIDX-v.tl0PicIdxOffset,
+		), nil
+	}
+
+	prevMaxPictureId := v.pictureIdWrapHandler.MaxPictureId()
+	v.pictureIdWrapHandler.UpdateMaxPictureId(extPictureId, mBit)
+
+	// if there is a gap in sequence number, record possible pictures that
+	// the missing packets can belong to in missing picture id cache.
+	if snHasGap {
+		for lostPictureId := prevMaxPictureId; lostPictureId <= extPictureId; lostPictureId++ {
+			v.missingPictureIds.Set(lostPictureId, v.pictureIdOffset)
+		}
+
+		// trim cache if necessary
+		for v.missingPictureIds.Len() > missingPictureIdsThreshold {
+			el := v.missingPictureIds.Front()
+			v.missingPictureIds.Delete(el.Key)
+		}
+	}
+
+	extMungedPictureId := extPictureId - v.pictureIdOffset
+	mungedTl0PicIdx := vp9.TL0PICIDX - v.tl0PicIdxOffset
+
+	if vp9.I {
+		v.extLastPictureId = extMungedPictureId
+	}
+	if vp9.L && !vp9.F {
+		v.lastTl0PicIdx = mungedTl0PicIdx
+	}
+
+	return marshalMungedVP9(
+		extPkt.Packet.Payload[:headerSize],
+		&vp9,
+		mBit,
+		uint16(extMungedPictureId&0x7fff),
+		mungedTl0PicIdx,
+	), nil
+}
+
+func (v *VP9) UpdateAndGetPadding(newPicture bool) ([]byte, error) {
+	offset := 0
+	if newPicture {
+		offset = 1
+	}
+
+	// a single layer, independently decodable picture that starts and ends a frame
+	firstByte := byte(0x08 | 0x04) // B, E
+	if v.flexibleMode {
+		firstByte |= 0x10 // F
+	}
+	header := []byte{firstByte}
+
+	if v.pictureIdUsed {
+		extPictureId := v.extLastPictureId + int32(offset)
+		v.extLastPictureId = extPictureId
+		v.pictureIdOffset -= int32(offset)
+
+		header[0] |= 0x80 // I
+		pictureId := uint16(extPictureId & 0x7fff)
+		if pictureId > 127 {
+			header = append(header, 0x80|byte(pictureId>>8), byte(pictureId))
+		} else {
+			header = append(header, byte(pictureId))
+		}
+	}
+
+	if v.tl0PicIdxUsed {
+		tl0PicIdx := v.lastTl0PicIdx + uint8(offset)
+		v.lastTl0PicIdx = tl0PicIdx
+		v.tl0PicIdxOffset -= uint8(offset)
+
+		header[0] |= 0x20                        // L
+		header = append(header, 0x00, tl0PicIdx) // TID 0, SID 0
+	}
+
+	return header, nil
+}
+
+// for testing only
+func (v *VP9) PictureIdOffset(extPictureId int32) (int32, bool) {
+	return v.missingPictureIds.Get(extPictureId)
+}
+
+// -----------------------------
+
+// getVP9 returns the parsed payload descriptor of a VP9 packet,
+// along with the M bit of the picture id and the size of the descriptor
+func getVP9(extPkt *buffer.ExtPacket) (codecs.VP9Packet, bool, int, bool) {
+	vp9, ok := extPkt.Payload.(codecs.VP9Packet)
+	if !ok || extPkt.Packet == nil {
+		return vp9, false, 0, false
+	}
+
+	payload := extPkt.Packet.Payload
+	headerSize := len(payload) - len(vp9.Payload)
+	if headerSize < 1 || headerSize > len(payload) {
+		return vp9, false, 0, false
+	}
+
+	mBit := vp9.I && headerSize > 1 && payload[1]&0x80 != 0
+	return vp9, mBit, headerSize, true
+}
+
+// marshalMungedVP9 rewrites picture id and TL0PICIDX of a VP9 payload descriptor, leaving the rest as is.
+// As with VP8, the picture id is written with 15 bits only if it does not fit in 7.
+func marshalMungedVP9(header []byte, vp9 *codecs.VP9Packet, mBit bool, pictureId uint16, tl0PicIdx uint8) []byte {
+	munged := make([]byte, 0, len(header)+1)
+	munged = append(munged, header[0])
+
+	pos := 1
+	if vp9.I {
+		if mBit {
+			pos += 2
+		} else {
+			pos += 1
+		}
+
+		if pictureId > 127 {
+			munged = append(munged, 0x80|byte(pictureId>>8), byte(pictureId))
+		} else {
+			munged = append(munged, byte(pictureId))
+		}
+	}
+
+	if vp9.L {
+		munged = append(munged, header[pos])
+		pos++
+
+		if !vp9.F {
+			munged = append(munged, tl0PicIdx)
+			pos++
+		}
+	}
+
+	return append(munged, header[pos:]...)
+}
@@ -0,0 +1,184 @@
+package codecmunger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/testutils"
+)
+
+func newVP9() *VP9 {
+	return NewVP9(logger.GetLogger())
+}
+
+// non-flexible mode descriptor with 15 bit picture id, layer indices and TL0PICIDX
+func vp9NonFlexibleHeader(pictureId uint16, tid uint8, sid uint8, tl0PicIdx uint8) []byte {
+	return []byte{
+		0xa8, // I, L, B
+		0x80 | byte(pictureId>>8),
+		byte(pictureId),
+		tid<<5 | sid<<1,
+		tl0PicIdx,
+	}
+}
+
+func getVP9Packet(t *testing.T, sn uint16, header []byte) *buffer.ExtPacket {
+	params := &testutils.TestExtPacketParams{
+		SequenceNumber: sn,
+		Timestamp:      0xabcdef,
+		SSRC:           0x12345678,
+		PayloadSize:    20,
+	}
+	extPkt, err := testutils.GetTestExtPacketVP9(params, header)
+	require.NoError(t, err)
+	return extPkt
+}
+
+func TestVP9SetLast(t *testing.T) {
+	v := newVP9()
+
+	v.SetLast(getVP9Packet(t, 23333, vp9NonFlexibleHeader(13467, 0, 0, 233)))
+	require.Equal(t, VP9State{
+		ExtLastPictureId: 13467,
+		PictureIdUsed:    true,
+		LastTl0PicIdx:    233,
+		Tl0PicIdxUsed:    true,
+	}, v.GetState())
+
+	// flexible mode does not carry TL0PICIDX
+	v = newVP9()
+	v.SetLast(getVP9Packet(t, 23333, []byte{0xb8, 0x85, 0x00, 0x20}))
+	require.Equal(t, VP9State{
+		ExtLastPictureId: 0x500,
+		PictureIdUsed:    true,
+		FlexibleMode:     true,
+	}, v.GetState())
+}
+
+func TestVP9UpdateOffsets(t *testing.T) {
+	v := newVP9()
+	v.SetLast(getVP9Packet(t, 23333, vp9NonFlexibleHeader(13467, 0, 0, 233)))
+
+	// switch to a different source
+	extPkt := getVP9Packet(t, 56789, vp9NonFlexibleHeader(345, 0, 0, 12))
+	v.UpdateOffsets(extPkt)
+	require.Equal(t, int32(345-13467-1), v.pictureIdOffset)
+	require.Equal(t, uint8(12-233-1+256), v.tl0PicIdxOffset)
+
+	// munged picture id and TL0PICIDX continue from the previous source
+	codecBytes, err := v.UpdateAndGet(extPkt, false, false, 2)
+	require.NoError(t, err)
+	require.Equal(t, vp9NonFlexibleHeader(13468, 0, 0, 234), codecBytes)
+
+	// all spatial layers of a picture get the same picture id
+	codecBytes, err = v.UpdateAndGet(getVP9Packet(t, 56790, vp9NonFlexibleHeader(345, 0, 1, 12)), false, false, 2)
+	require.NoError(t, err)
+	require.Equal(t, vp9NonFlexibleHeader(13468, 0, 1, 234), codecBytes)
+
+	codecBytes, err = v.UpdateAndGet(getVP9Packet(t, 56791, vp9NonFlexibleHeader(346, 1, 0, 12)), false, false, 2)
+	require.NoError(t, err)
+	require.Equal(t, vp9NonFlexibleHeader(13469, 1, 0, 234), codecBytes)
+}
+
+func TestVP9FlexibleMode(t *testing.T) {
+	v := newVP9()
+	// I, P, L, F, B with 7 bit picture id, TID 1 and two reference indices
+	header := []byte{0xf8, 0x20, 0x20, 0x03, 0x04}
+	v.SetLast(getVP9Packet(t, 100, header))
+	v.UpdateOffsets(getVP9Packet(t, 100, header))
+
+	// picture id that does not fit in 7 bits anymore after munging, reference indices are untouched
+	v.extLastPictureId = 200
+	v.UpdateOffsets(getVP9Packet(t, 100, header))
+	codecBytes, err := v.UpdateAndGet(getVP9Packet(t, 100, header), false, false, 2)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xf8, 0x80, 201, 0x20, 0x03, 0x04}, codecBytes)
+
+	// a picture dropped by the layer selector is not compacted out
+	header = []byte{0xf8, 0x22, 0x20, 0x03, 0x04}
+	codecBytes, err = v.UpdateAndGet(getVP9Packet(t, 101, header), false, false, 2)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xf8, 0x80, 203, 0x20, 0x03, 0x04}, codecBytes)
+}
+
+func TestVP9OutOfOrderPictureId(t *testing.T) {
+	v := newVP9()
+	v.SetLast(getVP9Packet(t, 23333, vp9NonFlexibleHeader(13467, 0, 0, 233)))
+	_, err := v.UpdateAndGet(getVP9Packet(t, 23333, vp9NonFlexibleHeader(13467, 0, 0, 233)), false, false, 2)
+	require.NoError(t, err)
+
+	// padding picture shifts offsets
+	_, err = v.UpdateAndGetPadding(true)
+	require.NoError(t, err)
+
+	// out-of-order packet of a picture not seen in a gap
+	_, err = v.UpdateAndGet(getVP9Packet(t, 23332, vp9NonFlexibleHeader(13466, 0, 0, 232)), true, false, 2)
+	require.ErrorIs(t, err, ErrOutOfOrderVP9PictureIdCacheMiss)
+
+	// gap, missing pictures are recorded with the offset before the gap
+	codecBytes, err := v.UpdateAndGet(getVP9Packet(t, 23337, vp9NonFlexibleHeader(13470, 0, 0, 236)), false, true, 2)
+	require.NoError(t, err)
+	require.Equal(t, vp9NonFlexibleHeader(13471, 0, 0, 237), codecBytes)
+
+	offset, ok := v.PictureIdOffset(13468)
+	require.True(t, ok)
+	require.Equal(t, int32(-1), offset)
+
+	codecBytes, err = v.UpdateAndGet(getVP9Packet(t, 23335, vp9NonFlexibleHeader(13468, 0, 0, 234)), true, false, 2)
+	require.NoError(t, err)
+	require.Equal(t, vp9NonFlexibleHeader(13469, 0, 0, 235), codecBytes)
+}
+
+func TestVP9UpdateAndGetPadding(t *testing.T) {
+	v := newVP9()
+	extPkt := getVP9Packet(t, 23333, vp9NonFlexibleHeader(13467, 0, 0, 233))
+	v.SetLast(extPkt)
+	_, err := v.UpdateAndGet(extPkt, false, false, 2)
+	require.NoError(t, err)
+
+	// same picture
+	codecBytes, err := v.UpdateAndGetPadding(false)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xac, 0x80 | byte(13467>>8), byte(13467 & 0xff), 0x00, 233}, codecBytes)
+
+	// new picture
+	codecBytes, err = v.UpdateAndGetPadding(true)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xac, 0x80 | byte(13468>>8), byte(13468 & 0xff), 0x00, 234}, codecBytes)
+
+	// forwarded stream continues after the padding picture
+	codecBytes, err = v.UpdateAndGet(getVP9Packet(t, 23334, vp9NonFlexibleHeader(13468, 0, 0, 234)), false, false, 2)
+	require.NoError(t, err)
+	require.Equal(t, vp9NonFlexibleHeader(13469, 0, 0, 235), codecBytes)
+}
+
+func TestVP9SeedState(t *testing.T) {
+	v := newVP9()
+	v.SetLast(getVP9Packet(t, 23333, vp9NonFlexibleHeader(13467, 0, 0, 233)))
+	state := v.GetState()
+
+	null := NewNull(logger.GetLogger())
+	null.SeedState(state)
+	migrated := NewVP9FromNull(null, logger.GetLogger())
+	require.Equal(t, state, migrated.GetState())
+
+	// stream after migration continues from seeded state
+	extPkt := getVP9Packet(t, 100, vp9NonFlexibleHeader(20, 0, 0, 3))
+	migrated.UpdateOffsets(extPkt)
+	codecBytes, err := migrated.UpdateAndGet(extPkt, false, false, 2)
+	require.NoError(t, err)
+	require.Equal(t, vp9NonFlexibleHeader(13468, 0, 0, 234), codecBytes)
+}
+
+func TestVP9NotVP9(t *testing.T) {
+	v := newVP9()
+	extPkt, err := testutils.GetTestExtPacket(&testutils.TestExtPacketParams{SequenceNumber: 1, PayloadSize: 10})
+	require.NoError(t, err)
+
+	_, err = v.UpdateAndGet(extPkt, false, false, 2)
+	require.ErrorIs(t, err, ErrNotVP9)
+}
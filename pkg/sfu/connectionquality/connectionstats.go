@@ -9,6 +9,7 @@ import (
 	"github.com/pion/webrtc/v3"
 	"go.uber.org/atomic"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
@@ -30,7 +31,9 @@ type ConnectionStatsParams struct {
 	GetDeltaStats             func() map[uint32]*buffer.StreamStatsWithLayers
 	GetDeltaStatsOverridden   func() map[uint32]*buffer.StreamStatsWithLayers
 	GetLastReceiverReportTime func() time.Time
-	Logger                    logger.Logger
+	// defaults to config.DefaultConnectionQualityConfig when not set
+	Config config.ConnectionQualityConfig
+	Logger logger.Logger
 }
 
 type ConnectionStats struct {
@@ -50,13 +53,20 @@ type ConnectionStats struct {
 }
 
 func NewConnectionStats(params ConnectionStatsParams) *ConnectionStats {
+	conf := params.Config
+	if conf == (config.ConnectionQualityConfig{}) {
+		conf = config.DefaultConnectionQualityConfig
+	}
+
 	return &ConnectionStats{
 		params: params,
 		scorer: newQualityScorer(qualityScorerParams{
-			PacketLossWeight: getPacketLossWeight(params.MimeType, params.IsFECEnabled), // LK-TODO: have to notify codec change?
-			IncludeRTT:       params.IncludeRTT,
-			IncludeJitter:    params.IncludeJitter,
-			Logger:           params.Logger,
+			Scorer:             NewScorer(conf, params.MimeType, params.IsFECEnabled), // LK-TODO: have to notify codec change?
+			IncludeRTT:         params.IncludeRTT,
+			IncludeJitter:      params.IncludeJitter,
+			ExcellentThreshold: float64(conf.ExcellentThreshold),
+			GoodThreshold:      float64(conf.GoodThreshold),
+			Logger:             params.Logger,
 		}),
 		done: core.NewFuse(),
 	}
@@ -102,6 +112,10 @@ func (cs *ConnectionStats) GetScoreAndQuality() (float32, livekit.ConnectionQual
 	return cs.scorer.GetMOSAndQuality()
 }
 
+func (cs *ConnectionStats) GetScoreBreakdown() ScoreBreakdown {
+	return cs.scorer.GetScoreBreakdown()
+}
+
 func (cs *ConnectionStats) updateScoreWithAggregate(agg *buffer.RTPDeltaInfo, at time.Time) float32 {
 	var stat windowStat
 	if agg != nil {
@@ -275,7 +289,7 @@ func (cs *ConnectionStats) updateStatsWorker() {
 // It is codec dependent.
 // For audio:
 //
-//	o Opus without FEC or RED suffers the most through packet loss, hence has the highest weight
+//	o Opus without FEC or RED suffers the most through packet loss, hence has the highest weight, multichannel Opus alike
 //	o RED with two packet redundancy can absorb two out of every three packets lost, so packet loss is not as detrimental and therefore lower weight
 //
 // For video:
@@ -284,7 +298,7 @@ func (cs *ConnectionStats) updateStatsWorker() {
 func getPacketLossWeight(mimeType string, isFecEnabled bool) float64 {
 	var plw float64
 	switch {
-	case strings.EqualFold(mimeType, webrtc.MimeTypeOpus):
+	case strings.EqualFold(mimeType, webrtc.MimeTypeOpus), strings.EqualFold(mimeType, "audio/multiopus"):
 		// 2.5%: fall to GOOD, 7.5%: fall to POOR
 		plw = 8.0
 		if isFecEnabled {
@@ -0,0 +1,80 @@
+package connectionquality
+
+import (
+	"math"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	distanceWeight = float64(35.0) // each spatial layer missed drops a quality level
+)
+
+// defaultScorer takes the lowest of a packet score based on loss and delay, a bitrate score and a layer score
+type defaultScorer struct {
+	packetLossWeight float64
+	lossWeight       float64
+	delayWeight      float64
+	bitrateWeight    float64
+	layerWeight      float64
+}
+
+func newDefaultScorer(conf config.ConnectionQualityConfig, packetLossWeight float64) *defaultScorer {
+	return &defaultScorer{
+		packetLossWeight: packetLossWeight,
+		lossWeight:       float64(conf.LossWeight),
+		delayWeight:      float64(conf.DelayWeight),
+		bitrateWeight:    float64(conf.BitrateWeight),
+		layerWeight:      float64(conf.LayerWeight),
+	}
+}
+
+func (d *defaultScorer) Score(input *ScorerInput) ScoreBreakdown {
+	// this is based on simplified E-model based on packet loss, rtt, jitter as
+	// outlined at https://www.pingman.com/kb/article/how-is-mos-calculated-in-pingplotter-pro-50.html.
+	effectiveDelay := float64(input.RTT)/2.0 + (input.Jitter*2.0)/1000.0
+	delayEffect := effectiveDelay / 40.0
+	if effectiveDelay > 160.0 {
+		delayEffect = (effectiveDelay - 120.0) / 10.0
+	}
+	delayEffect *= d.delayWeight
+
+	lossEffect := input.lossPercentage() * d.packetLossWeight * input.LossScale * d.lossWeight
+
+	packetScore := math.Max(maxScore-delayEffect-lossEffect, 0.0)
+	bitrateScore := d.getBitrateScore(input.Bytes, input.ExpectedBits)
+	layerScore := math.Max(math.Min(maxScore, maxScore-(input.ExpectedDistance*distanceWeight*d.layerWeight)), 0.0)
+
+	return ScoreBreakdown{
+		Score: math.Min(math.Min(packetScore, bitrateScore), layerScore),
+		Impairments: map[QualityFactor]float64{
+			QualityFactorLoss:    math.Min(lossEffect, maxScore),
+			QualityFactorDelay:   math.Min(delayEffect, maxScore),
+			QualityFactorBitrate: maxScore - bitrateScore,
+			QualityFactorLayer:   maxScore - layerScore,
+		},
+	}
+}
+
+func (d *defaultScorer) getBitrateScore(bytes uint64, expectedBits int64) float64 {
+	if expectedBits == 0 {
+		// unsupported mode OR all layers stopped
+		return maxScore
+	}
+
+	var score float64
+	if bytes != 0 {
+		// using the ratio of expectedBits / actualBits
+		// the quality inflection points are approximately
+		// GOOD at ~2.7x, POOR at ~20.1x
+		score = maxScore - 20*math.Log(float64(expectedBits)/float64(bytes*8))*d.bitrateWeight
+		if score > maxScore {
+			score = maxScore
+		}
+		if score < 0.0 {
+			score = 0.0
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,67 @@
+package connectionquality
+
+import (
+	"math"
+	"strings"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// ITU-T G.107 E-model, https://www.itu.int/rec/T-REC-G.107
+const (
+	// basic signal-to-noise ratio with default values of the planning parameters
+	eModelR0 = float64(93.2)
+
+	// losses are assumed to be random
+	eModelBurstR = float64(1.0)
+
+	eModelDelayThreshold = float64(177.3)
+)
+
+// eModelScorer scores audio with the E-model transmission rating, R = R0 - Id - Ie-eff.
+// Equipment impairment of the supported codecs is taken as 0, leaving Ie-eff to packet loss.
+// Bitrate and layers do not apply to audio and are not scored.
+type eModelScorer struct {
+	// packet-loss robustness factor of the codec
+	bpl float64
+}
+
+func newEModelScorer(mimeType string, isFECEnabled bool) *eModelScorer {
+	// ITU-T G.113 Appendix I has values for G.711 with packet loss concealment,
+	// Opus is not listed and is treated as a codec with no impairment of its own and similar robustness,
+	// FEC and RED are considered more robust
+	e := &eModelScorer{
+		bpl: 20.0,
+	}
+	switch {
+	case strings.EqualFold(mimeType, webrtc.MimeTypePCMU), strings.EqualFold(mimeType, webrtc.MimeTypePCMA):
+		e.bpl = 25.1
+
+	case strings.EqualFold(mimeType, "audio/red"):
+		e.bpl = 40.0
+	}
+	if isFECEnabled {
+		e.bpl *= 1.5
+	}
+	return e
+}
+
+func (e *eModelScorer) Score(input *ScorerInput) ScoreBreakdown {
+	// one way delay, half of the round trip and a jitter buffer of twice the jitter
+	delay := float64(input.RTT)/2.0 + (input.Jitter*2.0)/1000.0
+	id := 0.024 * delay
+	if delay > eModelDelayThreshold {
+		id += 0.11 * (delay - eModelDelayThreshold)
+	}
+
+	ppl := input.lossPercentage() * input.LossScale
+	ieEff := 95.0 * ppl / (ppl/eModelBurstR + e.bpl)
+
+	return ScoreBreakdown{
+		Score: math.Max(eModelR0-id-ieEff, 0.0),
+		Impairments: map[QualityFactor]float64{
+			QualityFactorLoss:  ieEff,
+			QualityFactorDelay: id,
+		},
+	}
+}
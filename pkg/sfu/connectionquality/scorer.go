@@ -2,12 +2,14 @@ package connectionquality
 
 import (
 	"fmt"
-	"math"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
 )
 
 const (
@@ -21,13 +23,92 @@ const (
 	increaseFactor = float64(0.4) // slower increase, i. e. when score is recovering move up slower -> conservative
 	decreaseFactor = float64(0.7) // faster decrease, i. e. when score is dropping move down faster -> aggressive to be responsive to quality drops
 
-	distanceWeight = float64(35.0) // each spatial layer missed drops a quality level
-
 	unmuteTimeThreshold = float64(0.5)
 )
 
 // ------------------------------------------
 
+// QualityFactor is an aspect of a track that lowers its score
+type QualityFactor string
+
+const (
+	// packets lost in the network
+	QualityFactorLoss QualityFactor = "loss"
+	// round trip time and jitter
+	QualityFactorDelay QualityFactor = "delay"
+	// bitrate short of the bitrate expected of the layers sent, e. g. a publisher limited by CPU or bandwidth
+	QualityFactorBitrate QualityFactor = "bitrate"
+	// layers short of the layers requested, e. g. a subscriber limited by bandwidth
+	QualityFactorLayer QualityFactor = "layer"
+	// no packets while the track is not muted
+	QualityFactorNoMedia QualityFactor = "no_media"
+)
+
+// ScoreBreakdown is a score, out of 100, with how much each factor lowered it
+type ScoreBreakdown struct {
+	Score       float64
+	Impairments map[QualityFactor]float64
+}
+
+// Limit returns the factor lowering the score the most, empty if no factor lowers it
+func (s ScoreBreakdown) Limit() QualityFactor {
+	limit := QualityFactor("")
+	maxImpairment := 0.0
+	for factor, impairment := range s.Impairments {
+		if impairment <= 0.0 {
+			continue
+		}
+		// ties are broken by name to be stable
+		if impairment > maxImpairment || (impairment == maxImpairment && factor < limit) {
+			limit = factor
+			maxImpairment = impairment
+		}
+	}
+	return limit
+}
+
+// ScorerInput is an analysis window of a track
+type ScorerInput struct {
+	Duration        time.Duration
+	PacketsExpected uint32
+	// packets lost in the network, packets missing at the source are not included
+	PacketsLost uint32
+	// media payload bytes
+	Bytes uint64
+	// max RTT in ms and max jitter in us, 0 when not included in scoring
+	RTT    uint32
+	Jitter float64
+	// scales the effect of loss, less than 1 when sending at a fraction of the peak packet rate, e. g. audio DTX
+	LossScale float64
+	// bits expected of the layers sent, 0 when unknown
+	ExpectedBits int64
+	// average number of spatial layers short of the requested layer
+	ExpectedDistance float64
+}
+
+func (s *ScorerInput) lossPercentage() float64 {
+	if s.PacketsExpected == 0 {
+		return 0.0
+	}
+	return float64(s.PacketsLost) * 100.0 / float64(s.PacketsExpected)
+}
+
+// Scorer scores an analysis window of a track
+type Scorer interface {
+	Score(input *ScorerInput) ScoreBreakdown
+}
+
+// NewScorer returns the scorer of the configured model for a track of the mime type
+func NewScorer(conf config.ConnectionQualityConfig, mimeType string, isFECEnabled bool) Scorer {
+	if conf.Model == config.ConnectionQualityModelEModel && strings.HasPrefix(strings.ToLower(mimeType), "audio/") {
+		return newEModelScorer(mimeType, isFECEnabled)
+	}
+
+	return newDefaultScorer(conf, getPacketLossWeight(mimeType, isFECEnabled))
+}
+
+// ------------------------------------------
+
 type windowStat struct {
 	startedAt       time.Time
 	duration        time.Duration
@@ -39,66 +120,33 @@ type windowStat struct {
 	jitterMax       float64
 }
 
-func (w *windowStat) calculatePacketScore(plw float64, includeRTT bool, includeJitter bool) float64 {
-	// this is based on simplified E-model based on packet loss, rtt, jitter as
-	// outlined at https://www.pingman.com/kb/article/how-is-mos-calculated-in-pingplotter-pro-50.html.
-	effectiveDelay := 0.0
+func (w *windowStat) toScorerInput(lossScale float64, includeRTT bool, includeJitter bool, expectedBits int64, expectedDistance float64) *ScorerInput {
+	actualLost := w.packetsLost - w.packetsMissing
+	if int32(actualLost) < 0 {
+		actualLost = 0
+	}
+
+	input := &ScorerInput{
+		Duration:         w.duration,
+		PacketsExpected:  w.packetsExpected,
+		PacketsLost:      actualLost,
+		Bytes:            w.bytes,
+		LossScale:        lossScale,
+		ExpectedBits:     expectedBits,
+		ExpectedDistance: expectedDistance,
+	}
 	// discount the dependent factors if dependency indicated.
 	// for example,
 	// 1. in the up stream, RTT cannot be measured without RTCP-XR, it is using down stream RTT.
 	// 2. in the down stream, up stream jitter affects it. although jitter can be adjusted to account for up stream
 	//    jitter, this lever can be used to discount jitter in scoring.
 	if includeRTT {
-		effectiveDelay += float64(w.rttMax) / 2.0
+		input.RTT = w.rttMax
 	}
 	if includeJitter {
-		effectiveDelay += (w.jitterMax * 2.0) / 1000.0
+		input.Jitter = w.jitterMax
 	}
-	delayEffect := effectiveDelay / 40.0
-	if effectiveDelay > 160.0 {
-		delayEffect = (effectiveDelay - 120.0) / 10.0
-	}
-
-	actualLost := w.packetsLost - w.packetsMissing
-	if int32(actualLost) < 0 {
-		actualLost = 0
-	}
-
-	var lossEffect float64
-	if w.packetsExpected > 0 {
-		lossEffect = float64(actualLost) * 100.0 / float64(w.packetsExpected)
-	}
-	lossEffect *= plw
-
-	score := maxScore - delayEffect - lossEffect
-	if score < 0.0 {
-		score = 0.0
-	}
-
-	return score
-}
-
-func (w *windowStat) calculateBitrateScore(expectedBitrate int64) float64 {
-	if expectedBitrate == 0 {
-		// unsupported mode OR all layers stopped
-		return maxScore
-	}
-
-	var score float64
-	if w.bytes != 0 {
-		// using the ratio of expectedBitrate / actualBitrate
-		// the quality inflection points are approximately
-		// GOOD at ~2.7x, POOR at ~20.1x
-		score = maxScore - 20*math.Log(float64(expectedBitrate)/float64(w.bytes*8))
-		if score > maxScore {
-			score = maxScore
-		}
-		if score < 0.0 {
-			score = 0.0
-		}
-	}
-
-	return score
+	return input
 }
 
 func (w *windowStat) String() string {
@@ -127,10 +175,12 @@ type layerTransition struct {
 }
 
 type qualityScorerParams struct {
-	PacketLossWeight float64
-	IncludeRTT       bool
-	IncludeJitter    bool
-	Logger           logger.Logger
+	Scorer             Scorer
+	IncludeRTT         bool
+	IncludeJitter      bool
+	ExcellentThreshold float64
+	GoodThreshold      float64
+	Logger             logger.Logger
 }
 
 type qualityScorer struct {
@@ -139,8 +189,9 @@ type qualityScorer struct {
 	lock         sync.RWMutex
 	lastUpdateAt time.Time
 
-	score float64
-	stat  windowStat
+	score     float64
+	stat      windowStat
+	breakdown ScoreBreakdown
 
 	mutedAt   time.Time
 	unmutedAt time.Time
@@ -156,8 +207,9 @@ type qualityScorer struct {
 
 func newQualityScorer(params qualityScorerParams) *qualityScorer {
 	return &qualityScorer{
-		params: params,
-		score:  maxScore,
+		params:    params,
+		score:     maxScore,
+		breakdown: ScoreBreakdown{Score: maxScore},
 	}
 }
 
@@ -175,6 +227,7 @@ func (q *qualityScorer) UpdateMute(isMuted bool, at time.Time) {
 	if isMuted {
 		q.mutedAt = at
 		q.score = maxScore
+		q.breakdown = ScoreBreakdown{Score: maxScore}
 	} else {
 		q.unmutedAt = at
 	}
@@ -193,6 +246,7 @@ func (q *qualityScorer) AddBitrateTransition(bitrate int64, at time.Time) {
 		if !q.isLayerMuted() {
 			q.layerMutedAt = at
 			q.score = maxScore
+			q.breakdown = ScoreBreakdown{Score: maxScore}
 		}
 	} else {
 		if q.isLayerMuted() {
@@ -217,6 +271,7 @@ func (q *qualityScorer) UpdateLayerMute(isMuted bool, at time.Time) {
 			})
 			q.layerMutedAt = at
 			q.score = maxScore
+			q.breakdown = ScoreBreakdown{Score: maxScore}
 		}
 	} else {
 		if q.isLayerMuted() {
@@ -240,7 +295,7 @@ func (q *qualityScorer) Update(stat *windowStat, at time.Time) {
 	defer q.lock.Unlock()
 
 	// always update transitions
-	expectedBitrate := q.getExpectedBitsAndUpdateTransitions(at)
+	expectedBits := q.getExpectedBitsAndUpdateTransitions(at)
 	expectedDistance := q.getExpectedDistanceAndUpdateTransitions(at)
 
 	// nothing to do when muted or not unmuted for long enough
@@ -254,33 +309,18 @@ func (q *qualityScorer) Update(stat *windowStat, at time.Time) {
 		return
 	}
 
-	plw := q.getPacketLossWeight(stat)
-	reason := "none"
+	lossScale := q.getLossScale(stat)
+	var breakdown ScoreBreakdown
 	var score float64
 	if stat.packetsExpected == 0 {
-		reason = "dry"
+		breakdown = ScoreBreakdown{
+			Score:       poorScore,
+			Impairments: map[QualityFactor]float64{QualityFactorNoMedia: maxScore - poorScore},
+		}
 		score = poorScore
 	} else {
-		packetScore := stat.calculatePacketScore(plw, q.params.IncludeRTT, q.params.IncludeJitter)
-		bitrateScore := stat.calculateBitrateScore(expectedBitrate)
-		layerScore := math.Max(math.Min(maxScore, maxScore-(expectedDistance*distanceWeight)), 0.0)
-
-		minScore := math.Min(packetScore, bitrateScore)
-		minScore = math.Min(minScore, layerScore)
-
-		switch {
-		case packetScore == minScore:
-			reason = "packet"
-			score = packetScore
-
-		case bitrateScore == minScore:
-			reason = "bitrate"
-			score = bitrateScore
-
-		case layerScore == minScore:
-			reason = "layer"
-			score = layerScore
-		}
+		breakdown = q.params.Scorer.Score(stat.toScorerInput(lossScale, q.params.IncludeRTT, q.params.IncludeJitter, expectedBits, expectedDistance))
+		score = breakdown.Score
 
 		factor := increaseFactor
 		if score < q.score {
@@ -295,25 +335,27 @@ func (q *qualityScorer) Update(stat *windowStat, at time.Time) {
 		score = minScore
 	}
 	// WARNING NOTE: comparing protobuf enum values directly (livekit.ConnectionQuality)
-	if scoreToConnectionQuality(q.score) > scoreToConnectionQuality(score) {
+	if q.scoreToConnectionQuality(q.score) > q.scoreToConnectionQuality(score) {
 		q.params.Logger.Infow(
 			"quality drop",
-			"reason", reason,
+			"reason", breakdown.Limit(),
+			"impairments", breakdown.Impairments,
 			"prevScore", q.score,
-			"prevQuality", scoreToConnectionQuality(q.score),
+			"prevQuality", q.scoreToConnectionQuality(q.score),
 			"prevStat", &q.stat,
 			"score", score,
-			"quality", scoreToConnectionQuality(score),
+			"quality", q.scoreToConnectionQuality(score),
 			"stat", stat,
-			"packetLossWeight", plw,
+			"lossScale", lossScale,
 			"maxPPS", q.maxPPS,
-			"expectedBitrate", expectedBitrate,
+			"expectedBits", expectedBits,
 			"expectedDistance", expectedDistance,
 		)
 	}
 
 	q.score = score
 	q.stat = *stat
+	q.breakdown = breakdown
 	q.lastUpdateAt = at
 }
 
@@ -350,9 +392,9 @@ func (q *qualityScorer) isLayerMuted() bool {
 	return !q.layerMutedAt.IsZero() && (q.layerUnmutedAt.IsZero() || q.layerMutedAt.After(q.layerUnmutedAt))
 }
 
-func (q *qualityScorer) getPacketLossWeight(stat *windowStat) float64 {
+func (q *qualityScorer) getLossScale(stat *windowStat) float64 {
 	if stat == nil || stat.duration == 0 {
-		return q.params.PacketLossWeight
+		return 1.0
 	}
 
 	// packet loss is weighted by comparing against max packet rate seen.
@@ -367,11 +409,11 @@ func (q *qualityScorer) getPacketLossWeight(stat *windowStat) float64 {
 	}
 
 	if q.maxPPS == 0 {
-		return q.params.PacketLossWeight
+		return 1.0
 	}
 
 	packetRatio := pps / q.maxPPS
-	return packetRatio * packetRatio * q.params.PacketLossWeight
+	return packetRatio * packetRatio
 }
 
 func (q *qualityScorer) getExpectedBitsAndUpdateTransitions(at time.Time) int64 {
@@ -472,19 +514,32 @@ func (q *qualityScorer) GetScoreAndQuality() (float32, livekit.ConnectionQuality
 	q.lock.RLock()
 	defer q.lock.RUnlock()
 
-	return float32(q.score), scoreToConnectionQuality(q.score)
+	return float32(q.score), q.scoreToConnectionQuality(q.score)
 }
 
 func (q *qualityScorer) GetMOSAndQuality() (float32, livekit.ConnectionQuality) {
 	q.lock.RLock()
 	defer q.lock.RUnlock()
 
-	return scoreToMOS(q.score), scoreToConnectionQuality(q.score)
+	return scoreToMOS(q.score), q.scoreToConnectionQuality(q.score)
 }
 
-// ------------------------------------------
+// GetScoreBreakdown returns the score of the last analysis window, before smoothing, with the factors lowering it
+func (q *qualityScorer) GetScoreBreakdown() ScoreBreakdown {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	impairments := make(map[QualityFactor]float64, len(q.breakdown.Impairments))
+	for factor, impairment := range q.breakdown.Impairments {
+		impairments[factor] = impairment
+	}
+	return ScoreBreakdown{
+		Score:       q.breakdown.Score,
+		Impairments: impairments,
+	}
+}
 
-func scoreToConnectionQuality(score float64) livekit.ConnectionQuality {
+func (q *qualityScorer) scoreToConnectionQuality(score float64) livekit.ConnectionQuality {
 	// R-factor -> livekit.ConnectionQuality scale mapping roughly based on
 	// https://www.itu.int/ITU-T/2005-2008/com12/emodelv1/tut.htm
 	//
@@ -493,11 +548,11 @@ func scoreToConnectionQuality(score float64) livekit.ConnectionQuality {
 	// that a score of 60 does not correspond to `POOR` quality. Repair
 	// mechanisms and use of algorithms like de-jittering makes the experience
 	// better even under harsh conditions.
-	if score > 80.0 {
+	if score > q.params.ExcellentThreshold {
 		return livekit.ConnectionQuality_EXCELLENT
 	}
 
-	if score > 40.0 {
+	if score > q.params.GoodThreshold {
 		return livekit.ConnectionQuality_GOOD
 	}
 
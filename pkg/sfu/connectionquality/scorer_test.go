@@ -0,0 +1,207 @@
+package connectionquality
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+func TestScoreBreakdownLimit(t *testing.T) {
+	require.Equal(t, QualityFactor(""), ScoreBreakdown{Score: maxScore}.Limit())
+	require.Equal(t, QualityFactor(""), ScoreBreakdown{
+		Score:       maxScore,
+		Impairments: map[QualityFactor]float64{QualityFactorLoss: 0, QualityFactorDelay: 0},
+	}.Limit())
+	require.Equal(t, QualityFactorDelay, ScoreBreakdown{
+		Score:       50,
+		Impairments: map[QualityFactor]float64{QualityFactorLoss: 10, QualityFactorDelay: 50},
+	}.Limit())
+	// ties are stable
+	require.Equal(t, QualityFactorBitrate, ScoreBreakdown{
+		Score:       0,
+		Impairments: map[QualityFactor]float64{QualityFactorLayer: 100, QualityFactorBitrate: 100},
+	}.Limit())
+}
+
+func TestDefaultScorer(t *testing.T) {
+	t.Run("factors", func(t *testing.T) {
+		scorer := NewScorer(config.DefaultConnectionQualityConfig, "video/vp8", false)
+
+		// 5% loss, video loss weight of 10
+		breakdown := scorer.Score(&ScorerInput{
+			Duration:        5 * time.Second,
+			PacketsExpected: 1000,
+			PacketsLost:     50,
+			Bytes:           500_000,
+			LossScale:       1.0,
+		})
+		require.InDelta(t, 50.0, breakdown.Score, 0.01)
+		require.InDelta(t, 50.0, breakdown.Impairments[QualityFactorLoss], 0.01)
+		require.Equal(t, QualityFactorLoss, breakdown.Limit())
+
+		// sending an eighth of the expected bits
+		breakdown = scorer.Score(&ScorerInput{
+			Duration:        5 * time.Second,
+			PacketsExpected: 1000,
+			Bytes:           500_000,
+			LossScale:       1.0,
+			ExpectedBits:    8 * 4_000_000,
+		})
+		require.Equal(t, QualityFactorBitrate, breakdown.Limit())
+		require.Less(t, breakdown.Score, 80.0)
+		require.Zero(t, breakdown.Impairments[QualityFactorLoss])
+
+		// two layers short
+		breakdown = scorer.Score(&ScorerInput{
+			Duration:         5 * time.Second,
+			PacketsExpected:  1000,
+			Bytes:            500_000,
+			LossScale:        1.0,
+			ExpectedDistance: 2.0,
+		})
+		require.Equal(t, QualityFactorLayer, breakdown.Limit())
+		require.InDelta(t, 30.0, breakdown.Score, 0.01)
+
+		// 400 ms RTT
+		breakdown = scorer.Score(&ScorerInput{
+			Duration:        5 * time.Second,
+			PacketsExpected: 1000,
+			Bytes:           500_000,
+			RTT:             400,
+			LossScale:       1.0,
+		})
+		require.Equal(t, QualityFactorDelay, breakdown.Limit())
+		require.InDelta(t, 92.0, breakdown.Score, 0.01)
+	})
+
+	t.Run("weights", func(t *testing.T) {
+		conf := config.DefaultConnectionQualityConfig
+		conf.LossWeight = 0.5
+		conf.LayerWeight = 0
+		scorer := NewScorer(conf, "video/vp8", false)
+
+		breakdown := scorer.Score(&ScorerInput{
+			Duration:         5 * time.Second,
+			PacketsExpected:  1000,
+			PacketsLost:      50,
+			Bytes:            500_000,
+			LossScale:        1.0,
+			ExpectedDistance: 2.0,
+		})
+		require.InDelta(t, 75.0, breakdown.Score, 0.01)
+		require.Zero(t, breakdown.Impairments[QualityFactorLayer])
+		require.Equal(t, QualityFactorLoss, breakdown.Limit())
+	})
+}
+
+func TestEModelScorer(t *testing.T) {
+	conf := config.DefaultConnectionQualityConfig
+	conf.Model = config.ConnectionQualityModelEModel
+
+	// video is scored with the default model
+	_, ok := NewScorer(conf, "video/vp8", false).(*defaultScorer)
+	require.True(t, ok)
+
+	scorer := NewScorer(conf, "audio/opus", false)
+	_, ok = scorer.(*eModelScorer)
+	require.True(t, ok)
+
+	// no impairment
+	breakdown := scorer.Score(&ScorerInput{
+		Duration:        5 * time.Second,
+		PacketsExpected: 250,
+		LossScale:       1.0,
+	})
+	require.InDelta(t, eModelR0, breakdown.Score, 0.01)
+	require.Equal(t, QualityFactor(""), breakdown.Limit())
+
+	// 5% random loss, Ie-eff = 95 * 5 / (5 + 20)
+	breakdown = scorer.Score(&ScorerInput{
+		Duration:        5 * time.Second,
+		PacketsExpected: 1000,
+		PacketsLost:     50,
+		LossScale:       1.0,
+	})
+	require.InDelta(t, 19.0, breakdown.Impairments[QualityFactorLoss], 0.01)
+	require.InDelta(t, eModelR0-19.0, breakdown.Score, 0.01)
+	require.Equal(t, QualityFactorLoss, breakdown.Limit())
+
+	// one way delay of 300 ms, Id = 0.024 * 300 + 0.11 * (300 - 177.3)
+	breakdown = scorer.Score(&ScorerInput{
+		Duration:        5 * time.Second,
+		PacketsExpected: 250,
+		RTT:             600,
+		LossScale:       1.0,
+	})
+	require.InDelta(t, 20.697, breakdown.Impairments[QualityFactorDelay], 0.01)
+	require.Equal(t, QualityFactorDelay, breakdown.Limit())
+
+	// G.711 is more robust to loss than Opus without FEC
+	g711 := NewScorer(conf, "audio/PCMU", false).Score(&ScorerInput{
+		Duration:        5 * time.Second,
+		PacketsExpected: 1000,
+		PacketsLost:     50,
+		LossScale:       1.0,
+	})
+	require.Greater(t, g711.Score, eModelR0-19.0)
+}
+
+func TestConnectionStatsScoreBreakdown(t *testing.T) {
+	conf := config.DefaultConnectionQualityConfig
+	conf.ExcellentThreshold = 95
+	cs := NewConnectionStats(ConnectionStatsParams{
+		MimeType: "video/vp8",
+		Config:   conf,
+		Logger:   logger.GetLogger(),
+	})
+
+	duration := 5 * time.Second
+	now := time.Now()
+	cs.Start(&livekit.TrackInfo{Type: livekit.TrackType_VIDEO}, now.Add(-duration))
+
+	breakdown := cs.GetScoreBreakdown()
+	require.Equal(t, QualityFactor(""), breakdown.Limit())
+
+	// 0.75% loss is EXCELLENT with the default thresholds, but not with a higher threshold
+	streams := map[uint32]*buffer.StreamStatsWithLayers{
+		1: {
+			RTPStats: &buffer.RTPDeltaInfo{
+				StartTime:   now,
+				Duration:    duration,
+				Packets:     400,
+				PacketsLost: 3,
+				Bytes:       500_000,
+			},
+		},
+	}
+	cs.updateScore(streams, now.Add(duration))
+	_, quality := cs.GetScoreAndQuality()
+	require.Equal(t, livekit.ConnectionQuality_GOOD, quality)
+
+	breakdown = cs.GetScoreBreakdown()
+	require.Equal(t, QualityFactorLoss, breakdown.Limit())
+	require.InDelta(t, 7.5, breakdown.Impairments[QualityFactorLoss], 0.01)
+
+	// no packets
+	now = now.Add(duration)
+	streams = map[uint32]*buffer.StreamStatsWithLayers{
+		1: {
+			RTPStats: &buffer.RTPDeltaInfo{
+				StartTime: now,
+				Duration:  duration,
+			},
+		},
+	}
+	cs.updateScore(streams, now.Add(duration))
+	require.Equal(t, QualityFactorNoMedia, cs.GetScoreBreakdown().Limit())
+
+	// muting clears the breakdown
+	cs.UpdateMute(true, now.Add(duration))
+	require.Equal(t, QualityFactor(""), cs.GetScoreBreakdown().Limit())
+}
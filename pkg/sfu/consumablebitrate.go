@@ -0,0 +1,121 @@
+package sfu
+
+import (
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// headroom over measured bitrate of layers that are needed,
+// to not constrain the publisher's encoder at the current bitrate
+const consumableBitrateHeadroom = 1.2
+
+// LayerAllocation is implemented by track senders that are allocated a layer,
+// subscribers' down tracks allocated by the stream allocator.
+type LayerAllocation interface {
+	TargetLayer() buffer.VideoLayer
+	MaxLayer() buffer.VideoLayer
+	IsDeficient() bool
+}
+
+// GetConsumableBitrates returns the bitrate of each spatial layer that current subscribers
+// can receive given the layers allocated to them, 0 for a layer nobody can receive.
+// Returns false if there is no limit, for example when a needed layer is not being received,
+// or when a track sender is not allocated layers.
+func (w *WebRTCReceiver) GetConsumableBitrates() ([]int64, bool) {
+	var allocations []LayerAllocation
+	for _, dt := range w.downTrackSpreader.GetDownTracks() {
+		allocation, ok := dt.(LayerAllocation)
+		if !ok {
+			return nil, false
+		}
+		allocations = append(allocations, allocation)
+	}
+
+	maxPublishedSpatial := buffer.InvalidLayerSpatial
+	if w.isSVC {
+		maxPublishedSpatial = buffer.VideoQualityToSpatialLayer(livekit.VideoQuality_HIGH, w.trackInfo)
+	} else {
+		w.upTrackMu.RLock()
+		for layer, track := range w.upTracks {
+			if track != nil {
+				maxPublishedSpatial = int32(layer)
+			}
+		}
+		w.upTrackMu.RUnlock()
+	}
+
+	_, brs := w.GetLayeredBitrate()
+	return getConsumableBitrates(brs, allocations, maxPublishedSpatial, w.isSVC)
+}
+
+func getConsumableBitrates(brs Bitrates, allocations []LayerAllocation, maxPublishedSpatial int32, isSVC bool) ([]int64, bool) {
+	// lowest layer is always kept for subscribers to fall back on
+	maxNeededSpatial := int32(0)
+	for _, allocation := range allocations {
+		if neededSpatial := getNeededSpatial(allocation); neededSpatial > maxNeededSpatial {
+			maxNeededSpatial = neededSpatial
+		}
+	}
+	if maxNeededSpatial > buffer.DefaultMaxLayerSpatial {
+		maxNeededSpatial = buffer.DefaultMaxLayerSpatial
+	}
+
+	// limiting the highest published layer would hold back its encoder,
+	// only limit when there are layers above what is needed.
+	// Published layers are used rather than layers with bitrate as layers above could be paused by the limit.
+	if maxNeededSpatial >= maxPublishedSpatial {
+		return nil, false
+	}
+
+	consumable := make([]int64, len(brs))
+	if isSVC {
+		// all spatial layers in one stream, bitrates are cumulative across spatial layers
+		bitrate := getHighestTemporalBitrate(brs[maxNeededSpatial])
+		if bitrate == 0 {
+			return nil, false
+		}
+
+		consumable[0] = int64(float64(bitrate) * consumableBitrateHeadroom)
+		return consumable, true
+	}
+
+	for spatial := int32(0); spatial <= maxNeededSpatial; spatial++ {
+		bitrate := getHighestTemporalBitrate(brs[spatial])
+		if bitrate == 0 {
+			return nil, false
+		}
+
+		consumable[spatial] = int64(float64(bitrate) * consumableBitrateHeadroom)
+	}
+	return consumable, true
+}
+
+// getNeededSpatial returns the highest spatial layer a subscriber needs from the publisher.
+// A subscriber that is not deficient, but is allocated lower than its max layer,
+// is held back by the higher layer not being available, so it needs the next higher layer.
+func getNeededSpatial(allocation LayerAllocation) int32 {
+	target := allocation.TargetLayer()
+	max := allocation.MaxLayer()
+	if !target.IsValid() {
+		if !allocation.IsDeficient() && max.IsValid() {
+			// paused as nothing is available
+			return 0
+		}
+		return buffer.InvalidLayerSpatial
+	}
+
+	if !allocation.IsDeficient() && target.Spatial < max.Spatial {
+		return target.Spatial + 1
+	}
+	return target.Spatial
+}
+
+func getHighestTemporalBitrate(brs [buffer.DefaultMaxLayerTemporal + 1]int64) int64 {
+	for temporal := len(brs) - 1; temporal >= 0; temporal-- {
+		if brs[temporal] != 0 {
+			return brs[temporal]
+		}
+	}
+	return 0
+}
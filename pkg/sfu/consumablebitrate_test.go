@@ -0,0 +1,123 @@
+package sfu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+type testLayerAllocation struct {
+	target    buffer.VideoLayer
+	max       buffer.VideoLayer
+	deficient bool
+}
+
+func (t *testLayerAllocation) TargetLayer() buffer.VideoLayer { return t.target }
+func (t *testLayerAllocation) MaxLayer() buffer.VideoLayer    { return t.max }
+func (t *testLayerAllocation) IsDeficient() bool              { return t.deficient }
+
+func TestGetConsumableBitrates(t *testing.T) {
+	brs := Bitrates{
+		{100_000, 150_000, 0, 0},
+		{300_000, 500_000, 0, 0},
+		{1_000_000, 1_500_000, 0, 0},
+	}
+
+	t.Run("no subscribers keep lowest layer", func(t *testing.T) {
+		consumable, ok := getConsumableBitrates(brs, nil, 2, false)
+		require.True(t, ok)
+		require.Equal(t, []int64{180_000, 0, 0}, consumable)
+	})
+
+	t.Run("highest needed layer across subscribers", func(t *testing.T) {
+		consumable, ok := getConsumableBitrates(brs, []LayerAllocation{
+			&testLayerAllocation{
+				target: buffer.VideoLayer{Spatial: 0, Temporal: 1},
+				max:    buffer.VideoLayer{Spatial: 0, Temporal: 3},
+			},
+			&testLayerAllocation{
+				target:    buffer.VideoLayer{Spatial: 1, Temporal: 1},
+				max:       buffer.VideoLayer{Spatial: 2, Temporal: 3},
+				deficient: true,
+			},
+		}, 2, false)
+		require.True(t, ok)
+		require.Equal(t, []int64{180_000, 600_000, 0}, consumable)
+	})
+
+	t.Run("subscriber wanting more needs next layer", func(t *testing.T) {
+		_, ok := getConsumableBitrates(brs, []LayerAllocation{
+			&testLayerAllocation{
+				target: buffer.VideoLayer{Spatial: 1, Temporal: 1},
+				max:    buffer.VideoLayer{Spatial: 2, Temporal: 3},
+			},
+		}, 2, false)
+		require.False(t, ok)
+	})
+
+	t.Run("highest published layer needed", func(t *testing.T) {
+		_, ok := getConsumableBitrates(brs, []LayerAllocation{
+			&testLayerAllocation{
+				target:    buffer.VideoLayer{Spatial: 1, Temporal: 1},
+				max:       buffer.VideoLayer{Spatial: 2, Temporal: 3},
+				deficient: true,
+			},
+		}, 1, false)
+		require.False(t, ok)
+	})
+
+	t.Run("layer above paused by limit", func(t *testing.T) {
+		consumable, ok := getConsumableBitrates(Bitrates{
+			{100_000, 150_000, 0, 0},
+			{300_000, 500_000, 0, 0},
+			{0, 0, 0, 0},
+		}, []LayerAllocation{
+			&testLayerAllocation{
+				target: buffer.VideoLayer{Spatial: 1, Temporal: 1},
+				max:    buffer.VideoLayer{Spatial: 1, Temporal: 3},
+			},
+		}, 2, false)
+		require.True(t, ok)
+		require.Equal(t, []int64{180_000, 600_000, 0}, consumable)
+	})
+
+	t.Run("paused subscriber needs nothing", func(t *testing.T) {
+		consumable, ok := getConsumableBitrates(brs, []LayerAllocation{
+			&testLayerAllocation{
+				target: buffer.InvalidLayer,
+				max:    buffer.InvalidLayer,
+			},
+		}, 2, false)
+		require.True(t, ok)
+		require.Equal(t, []int64{180_000, 0, 0}, consumable)
+	})
+
+	t.Run("needed layer not received", func(t *testing.T) {
+		_, ok := getConsumableBitrates(Bitrates{
+			{100_000, 150_000, 0, 0},
+			{0, 0, 0, 0},
+			{1_000_000, 1_500_000, 0, 0},
+		}, []LayerAllocation{
+			&testLayerAllocation{
+				target:    buffer.VideoLayer{Spatial: 1, Temporal: 1},
+				max:       buffer.VideoLayer{Spatial: 2, Temporal: 3},
+				deficient: true,
+			},
+		}, 2, false)
+		require.False(t, ok)
+	})
+
+	t.Run("svc", func(t *testing.T) {
+		consumable, ok := getConsumableBitrates(brs, []LayerAllocation{
+			&testLayerAllocation{
+				target:    buffer.VideoLayer{Spatial: 1, Temporal: 1},
+				max:       buffer.VideoLayer{Spatial: 2, Temporal: 3},
+				deficient: true,
+			},
+		}, 2, true)
+		require.True(t, ok)
+		require.Equal(t, []int64{600_000, 0, 0}, consumable)
+	})
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/v2/packetio"
 	"github.com/pion/webrtc/v3"
@@ -19,9 +20,11 @@ import (
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
 	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
+	"github.com/livekit/livekit-server/pkg/sfu/impairment"
 )
 
 // TrackSender defines an interface send media to remote peer
@@ -90,6 +93,34 @@ var (
 	}
 	H264KeyFrame2x2 = [][]byte{H264KeyFrame2x2SPS, H264KeyFrame2x2PPS, H264KeyFrame2x2IDR}
 
+	// 16x16 black IDR picture (Main profile, level 1) coded as a single PCM coding unit
+	H265KeyFrame16x16VPS = []byte{
+		0x40, 0x01, 0x0c, 0x01, 0xff, 0xff, 0x01, 0x60,
+		0x00, 0x00, 0x03, 0x00, 0x90, 0x00, 0x00, 0x03,
+		0x00, 0x00, 0x03, 0x00, 0x1e, 0xf0, 0x24,
+	}
+	H265KeyFrame16x16SPS = []byte{
+		0x42, 0x01, 0x01, 0x01, 0x60, 0x00, 0x00, 0x03,
+		0x00, 0x90, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03,
+		0x00, 0x1e, 0xa0, 0x88, 0x45, 0xfe, 0xab, 0x10,
+		0x05, 0xc1,
+	}
+	H265KeyFrame16x16PPS = []byte{
+		0x44, 0x01, 0xc0, 0x71, 0x80, 0xa4, 0x80,
+	}
+	H265KeyFrame16x16IDR = []byte{
+		0x26, 0x01, 0xaf, 0x86, 0x80, 0x00, 0x00, 0x03,
+		0x00, 0x00, 0x03, 0x00, 0x00, 0x03, 0x00, 0x00,
+		0x03, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03, 0x00,
+		0x00, 0x03, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03,
+		0x00, 0x00, 0x03, 0x00, 0x00, 0x03, 0x00, 0x00,
+		0x03, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03, 0x00,
+		0x00, 0x03, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xfe, 0x80,
+	}
+	H265KeyFrame16x16 = [][]byte{H265KeyFrame16x16VPS, H265KeyFrame16x16SPS, H265KeyFrame16x16PPS, H265KeyFrame16x16IDR}
+
 	OpusSilenceFrame = []byte{
 		0xf8, 0xff, 0xfe, 0x00, 0x00, 0x00, 0x00, 0x00,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
@@ -230,6 +261,8 @@ type DownTrack struct {
 
 	activePaddingOnMuteUpTrack atomic.Bool
 
+	impairment atomic.Pointer[impairment.Impairment]
+
 	streamAllocatorLock             sync.RWMutex
 	streamAllocatorListener         DownTrackStreamAllocatorListener
 	streamAllocatorReportGeneration int
@@ -255,6 +288,7 @@ func NewDownTrack(
 	subID livekit.ParticipantID,
 	mt int,
 	allowTimestampAdjustment bool,
+	cqConfig config.ConnectionQualityConfig,
 	logger logger.Logger,
 ) (*DownTrack, error) {
 	var kind webrtc.RTPCodecType
@@ -302,10 +336,11 @@ func NewDownTrack(
 
 	d.connectionStats = connectionquality.NewConnectionStats(connectionquality.ConnectionStatsParams{
 		MimeType:                  codecs[0].MimeType, // LK-TODO have to notify on codec change
-		IsFECEnabled:              strings.EqualFold(codecs[0].MimeType, webrtc.MimeTypeOpus) && strings.Contains(strings.ToLower(codecs[0].SDPFmtpLine), "fec"),
+		IsFECEnabled:              IsOpusCodec(codecs[0].MimeType) && strings.Contains(strings.ToLower(codecs[0].SDPFmtpLine), "fec"),
 		GetDeltaStats:             d.getDeltaStats,
 		GetDeltaStatsOverridden:   d.getDeltaStatsOverridden,
 		GetLastReceiverReportTime: func() time.Time { return d.rtpStats.LastReceiverReport() },
+		Config:                    cqConfig,
 		Logger:                    d.logger.WithValues("direction", "down"),
 	})
 	d.connectionStats.OnStatsUpdate(func(_cs *connectionquality.ConnectionStats, stat *livekit.AnalyticsStat) {
@@ -321,6 +356,17 @@ func NewDownTrack(
 // This asserts that the code requested is supported by the remote peer.
 // If so it sets up all the state (SSRC and PayloadType) to have a call
 func (d *DownTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters, error) {
+	return d.bind(t.CodecParameters(), uint32(t.SSRC()), t.WriteStream())
+}
+
+// BindWriter binds the down track to a writer outside of a peer connection, e.g. a plain RTP socket.
+// The codec is negotiated against the given codecs, payload type is taken from the match.
+// RTCP for the SSRC is read from the RTCP reader of the down track's buffer factory.
+func (d *DownTrack) BindWriter(codecs []webrtc.RTPCodecParameters, ssrc uint32, writeStream webrtc.TrackLocalWriter) (webrtc.RTPCodecParameters, error) {
+	return d.bind(codecs, ssrc, writeStream)
+}
+
+func (d *DownTrack) bind(codecs []webrtc.RTPCodecParameters, ssrc uint32, writeStream webrtc.TrackLocalWriter) (webrtc.RTPCodecParameters, error) {
 	d.bindLock.Lock()
 	if d.bound.Load() {
 		d.bindLock.Unlock()
@@ -328,7 +374,7 @@ func (d *DownTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters,
 	}
 	var codec webrtc.RTPCodecParameters
 	for _, c := range d.upstreamCodecs {
-		matchCodec, err := codecParametersFuzzySearch(c, t.CodecParameters())
+		matchCodec, err := codecParametersFuzzySearch(c, codecs)
 		if err == nil {
 			codec = matchCodec
 			break
@@ -347,12 +393,12 @@ func (d *DownTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters,
 		return codec, nil
 	}
 
-	d.logger.Debugw("DownTrack.Bind", "codecs", d.upstreamCodecs, "matchCodec", codec, "ssrc", t.SSRC())
-	d.ssrc = uint32(t.SSRC())
+	d.logger.Debugw("DownTrack.Bind", "codecs", d.upstreamCodecs, "matchCodec", codec, "ssrc", ssrc)
+	d.ssrc = ssrc
 	d.payloadType = uint8(codec.PayloadType)
-	d.writeStream = t.WriteStream()
+	d.writeStream = writeStream
 	d.mime = strings.ToLower(codec.MimeType)
-	if rr := d.bufferFactory.GetOrNew(packetio.RTCPBufferPacket, uint32(t.SSRC())).(*buffer.RTCPReader); rr != nil {
+	if rr := d.bufferFactory.GetOrNew(packetio.RTCPBufferPacket, ssrc).(*buffer.RTCPReader); rr != nil {
 		rr.OnPacket(func(pkt []byte) {
 			d.handleRTCP(pkt)
 		})
@@ -584,9 +630,15 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 
 	payload := extPkt.Packet.Payload
 	if len(tp.codecBytes) != 0 {
-		incomingVP8, _ := extPkt.Payload.(buffer.VP8)
+		incomingHeaderSize := 0
+		switch incoming := extPkt.Payload.(type) {
+		case buffer.VP8:
+			incomingHeaderSize = incoming.HeaderSize
+		case codecs.VP9Packet:
+			incomingHeaderSize = len(extPkt.Packet.Payload) - len(incoming.Payload)
+		}
 		pool = PacketFactory.Get().(*[]byte)
-		payload = d.translateVP8PacketTo(extPkt.Packet, &incomingVP8, tp.codecBytes, pool)
+		payload = d.translateVPxPacketTo(extPkt.Packet, incomingHeaderSize, tp.codecBytes, pool)
 	}
 
 	if d.sequencer != nil {
@@ -606,7 +658,7 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 		return err
 	}
 
-	_, err = d.writeStream.WriteRTP(hdr, payload)
+	_, err = d.writeRTP(hdr, payload)
 	if err != nil {
 		if errors.Is(err, io.ErrClosedPipe) {
 			writeIOErrors := d.writeIOErrors.Inc()
@@ -719,7 +771,7 @@ func (d *DownTrack) WritePaddingRTP(bytesToSend int, paddingOnMute bool) int {
 		// last byte of padding has padding size including that byte
 		payload[RTPPaddingMaxPayloadSize-1] = byte(RTPPaddingMaxPayloadSize)
 
-		_, err = d.writeStream.WriteRTP(&hdr, payload)
+		_, err = d.writeRTP(&hdr, payload)
 		if err != nil {
 			return bytesSent
 		}
@@ -886,6 +938,10 @@ func (d *DownTrack) CloseWithFlush(flush bool) {
 
 	d.stopKeyFrameRequester()
 	d.ClearStreamAllocatorReportInterval()
+
+	if imp := d.impairment.Swap(nil); imp != nil {
+		imp.Close()
+	}
 }
 
 func (d *DownTrack) SetMaxSpatialLayer(spatialLayer int32) {
@@ -921,10 +977,18 @@ func (d *DownTrack) SetMaxTemporalLayer(temporalLayer int32) {
 	}
 }
 
+func (d *DownTrack) SetLayerPreference(layerPreference VideoLayerPreference) {
+	d.forwarder.SetLayerPreference(layerPreference)
+}
+
 func (d *DownTrack) MaxLayer() buffer.VideoLayer {
 	return d.forwarder.MaxLayer()
 }
 
+func (d *DownTrack) TargetLayer() buffer.VideoLayer {
+	return d.forwarder.TargetLayer()
+}
+
 func (d *DownTrack) GetState() DownTrackState {
 	dts := DownTrackState{
 		RTPStats:                       d.rtpStats,
@@ -1138,17 +1202,21 @@ func (d *DownTrack) writeBlankFrameRTP(duration float32, generation uint32) chan
 			writeBlankFrame = d.writeOpusBlankFrame
 		case "audio/red":
 			writeBlankFrame = d.writeOpusRedBlankFrame
+		case MimeTypeMultiOpus:
+			writeBlankFrame = d.writeMultiOpusBlankFrame
 		case "video/vp8":
 			writeBlankFrame = d.writeVP8BlankFrame
 		case "video/h264":
 			writeBlankFrame = d.writeH264BlankFrame
+		case "video/h265":
+			writeBlankFrame = d.writeH265BlankFrame
 		default:
 			close(done)
 			return
 		}
 
 		frameRate := uint32(30)
-		if d.mime == "audio/opus" || d.mime == "audio/red" {
+		if d.mime == "audio/opus" || d.mime == "audio/red" || d.mime == MimeTypeMultiOpus {
 			frameRate = 50
 		}
 
@@ -1224,7 +1292,7 @@ func (d *DownTrack) writeOpusBlankFrame(hdr *rtp.Header, frameEndNeeded bool) (i
 	payload := make([]byte, len(OpusSilenceFrame))
 	copy(payload[0:], OpusSilenceFrame)
 
-	_, err := d.writeStream.WriteRTP(hdr, payload)
+	_, err := d.writeRTP(hdr, payload)
 	if err == nil {
 		d.rtpStats.Update(hdr, len(payload), 0, time.Now())
 	}
@@ -1243,7 +1311,18 @@ func (d *DownTrack) writeOpusRedBlankFrame(hdr *rtp.Header, frameEndNeeded bool)
 	payload[0] = opusPT
 	copy(payload[1:], OpusSilenceFrame)
 
-	_, err := d.writeStream.WriteRTP(hdr, payload)
+	_, err := d.writeRTP(hdr, payload)
+	if err == nil {
+		d.rtpStats.Update(hdr, len(payload), 0, time.Now())
+	}
+	return hdr.MarshalSize() + len(payload), err
+}
+
+func (d *DownTrack) writeMultiOpusBlankFrame(hdr *rtp.Header, frameEndNeeded bool) (int, error) {
+	// silence in every stream, a packet with fewer streams cannot be decoded
+	payload := multiOpusSilenceFrame(MultiOpusStreams(d.codec.SDPFmtpLine))
+
+	_, err := d.writeRTP(hdr, payload)
 	if err == nil {
 		d.rtpStats.Update(hdr, len(payload), 0, time.Now())
 	}
@@ -1264,7 +1343,7 @@ func (d *DownTrack) writeVP8BlankFrame(hdr *rtp.Header, frameEndNeeded bool) (in
 	copy(payload[:len(blankVP8)], blankVP8)
 	copy(payload[len(blankVP8):], VP8KeyFrame8x8)
 
-	_, err = d.writeStream.WriteRTP(hdr, payload)
+	_, err = d.writeRTP(hdr, payload)
 	if err == nil {
 		d.rtpStats.Update(hdr, len(payload), 0, time.Now())
 	}
@@ -1286,13 +1365,40 @@ func (d *DownTrack) writeH264BlankFrame(hdr *rtp.Header, frameEndNeeded bool) (i
 		offset += len(payload)
 	}
 	payload := buf[:offset]
-	_, err := d.writeStream.WriteRTP(hdr, payload)
+	_, err := d.writeRTP(hdr, payload)
 	if err == nil {
 		d.rtpStats.Update(hdr, len(payload), 0, time.Now())
 	}
 	return hdr.MarshalSize() + offset, err
 }
 
+func (d *DownTrack) writeH265BlankFrame(hdr *rtp.Header, frameEndNeeded bool) (int, error) {
+	payload := h265AggregationPacket(H265KeyFrame16x16)
+	_, err := d.writeRTP(hdr, payload)
+	if err == nil {
+		d.rtpStats.Update(hdr, len(payload), 0, time.Now())
+	}
+	return hdr.MarshalSize() + len(payload), err
+}
+
+// h265AggregationPacket packs NAL units into a single aggregation packet (RFC 7798, section 4.4.2)
+func h265AggregationPacket(nalus [][]byte) []byte {
+	size := 2
+	for _, nalu := range nalus {
+		size += 2 + len(nalu)
+	}
+	buf := make([]byte, size)
+	buf[0] = 48 << 1 // AP, F = 0, LayerId = 0
+	buf[1] = 0x01    // TID = 1
+	offset := 2
+	for _, nalu := range nalus {
+		binary.BigEndian.PutUint16(buf[offset:], uint16(len(nalu)))
+		offset += 2
+		offset += copy(buf[offset:], nalu)
+	}
+	return buf
+}
+
 func (d *DownTrack) handleRTCP(bytes []byte) {
 	pkts, err := rtcp.Unmarshal(bytes)
 	if err != nil {
@@ -1488,8 +1594,17 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 
 			if len(meta.codecBytes) != 0 {
 				pool = PacketFactory.Get().(*[]byte)
-				payload = d.translateVP8PacketTo(&pkt, &incomingVP8, meta.codecBytes, pool)
+				payload = d.translateVPxPacketTo(&pkt, incomingVP8.HeaderSize, meta.codecBytes, pool)
+			}
+		} else if d.mime == "video/vp9" && len(pkt.Payload) > 0 && len(meta.codecBytes) != 0 {
+			var incomingVP9 codecs.VP9Packet
+			if _, err = incomingVP9.Unmarshal(pkt.Payload); err != nil {
+				d.logger.Errorw("unmarshalling VP9 packet err", err)
+				continue
 			}
+
+			pool = PacketFactory.Get().(*[]byte)
+			payload = d.translateVPxPacketTo(&pkt, len(pkt.Payload)-len(incomingVP9.Payload), meta.codecBytes, pool)
 		}
 
 		var extraExtensions []extensionData
@@ -1505,7 +1620,7 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 			continue
 		}
 
-		if _, err = d.writeStream.WriteRTP(&pkt.Header, payload); err != nil {
+		if _, err = d.writeRTP(&pkt.Header, payload); err != nil {
 			d.logger.Errorw("writing rtx packet err", err)
 		} else {
 			d.streamAllocatorBytesCounter.Add(uint32(pkt.Header.MarshalSize() + len(payload)))
@@ -1593,13 +1708,14 @@ func (d *DownTrack) getTranslatedRTPHeader(extPkt *buffer.ExtPacket, tp *Transla
 	return &hdr, nil
 }
 
-func (d *DownTrack) translateVP8PacketTo(pkt *rtp.Packet, incomingVP8 *buffer.VP8, translatedVP8 []byte, outbuf *[]byte) []byte {
-	buf := (*outbuf)[:len(pkt.Payload)+len(translatedVP8)-incomingVP8.HeaderSize]
-	srcPayload := pkt.Payload[incomingVP8.HeaderSize:]
-	dstPayload := buf[len(translatedVP8):]
+// translateVPxPacketTo replaces the incoming VP8/VP9 payload descriptor with the munged one
+func (d *DownTrack) translateVPxPacketTo(pkt *rtp.Packet, incomingHeaderSize int, translatedHeader []byte, outbuf *[]byte) []byte {
+	buf := (*outbuf)[:len(pkt.Payload)+len(translatedHeader)-incomingHeaderSize]
+	srcPayload := pkt.Payload[incomingHeaderSize:]
+	dstPayload := buf[len(translatedHeader):]
 	copy(dstPayload, srcPayload)
 
-	copy(buf[:len(translatedVP8)], translatedVP8)
+	copy(buf[:len(translatedHeader)], translatedHeader)
 	return buf
 }
 
@@ -1644,6 +1760,43 @@ func (d *DownTrack) GetConnectionScoreAndQuality() (float32, livekit.ConnectionQ
 	return d.connectionStats.GetScoreAndQuality()
 }
 
+func (d *DownTrack) GetConnectionScoreBreakdown() connectionquality.ScoreBreakdown {
+	return d.connectionStats.GetScoreBreakdown()
+}
+
+// SetImpairment runs outgoing packets, including retransmissions and padding, through a simulated network link,
+// the down track takes ownership of imp and closes any previous one, nil removes the impairment.
+func (d *DownTrack) SetImpairment(imp *impairment.Impairment) {
+	if d.isClosed.Load() {
+		if imp != nil {
+			imp.Close()
+		}
+		return
+	}
+
+	if prev := d.impairment.Swap(imp); prev != nil {
+		prev.Close()
+	}
+}
+
+func (d *DownTrack) writeRTP(hdr *rtp.Header, payload []byte) (int, error) {
+	imp := d.impairment.Load()
+	if imp == nil {
+		return d.writeStream.WriteRTP(hdr, payload)
+	}
+
+	pkt, err := (&rtp.Packet{Header: *hdr, Payload: payload}).Marshal()
+	if err != nil {
+		return 0, err
+	}
+
+	writeStream := d.writeStream
+	imp.Process(pkt, func(p []byte) {
+		_, _ = writeStream.Write(p)
+	})
+	return len(pkt), nil
+}
+
 func (d *DownTrack) GetTrackStats() *livekit.RTPStats {
 	return d.rtpStats.ToProto()
 }
@@ -1704,7 +1857,7 @@ func (d *DownTrack) sendPaddingOnMute() {
 
 	if d.kind == webrtc.RTPCodecTypeVideo {
 		d.sendPaddingOnMuteForVideo()
-	} else if d.mime == "audio/opus" {
+	} else if d.mime == "audio/opus" || d.mime == MimeTypeMultiOpus {
 		d.sendSilentFrameOnMuteForOpus()
 	}
 }
@@ -1752,10 +1905,15 @@ func (d *DownTrack) sendSilentFrameOnMuteForOpus() {
 				return
 			}
 
-			payload := make([]byte, len(OpusSilenceFrame))
-			copy(payload[0:], OpusSilenceFrame)
+			var payload []byte
+			if d.mime == MimeTypeMultiOpus {
+				payload = multiOpusSilenceFrame(MultiOpusStreams(d.codec.SDPFmtpLine))
+			} else {
+				payload = make([]byte, len(OpusSilenceFrame))
+				copy(payload[0:], OpusSilenceFrame)
+			}
 
-			_, err := d.writeStream.WriteRTP(&hdr, payload)
+			_, err := d.writeRTP(&hdr, payload)
 			if err != nil {
 				d.logger.Warnw("could not write blank frame", err)
 				return
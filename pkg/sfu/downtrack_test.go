@@ -0,0 +1,27 @@
+package sfu
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func TestH265BlankFrame(t *testing.T) {
+	payload := h265AggregationPacket(H265KeyFrame16x16)
+	require.True(t, buffer.IsH265KeyFrame(payload))
+
+	// VPS, SPS, PPS, IDR_W_RADL
+	expectedTypes := []uint8{32, 33, 34, 19}
+	offset := 2
+	for i, nalu := range H265KeyFrame16x16 {
+		size := int(binary.BigEndian.Uint16(payload[offset:]))
+		offset += 2
+		require.Equal(t, nalu, payload[offset:offset+size])
+		require.Equal(t, expectedTypes[i], (payload[offset]>>1)&0x3f)
+		offset += size
+	}
+	require.Equal(t, len(payload), offset)
+}
@@ -59,6 +59,29 @@ func (v VideoPauseReason) String() string {
 
 // -------------------------------------------------------------------
 
+// VideoLayerPreference decides what is given up first when bandwidth is not enough for the desired layer
+type VideoLayerPreference int
+
+const (
+	// keep frame rate at the cost of resolution, i. e. fill temporal layers before moving to a higher spatial layer
+	VideoLayerPreferenceFrameRate VideoLayerPreference = iota
+	// keep resolution at the cost of frame rate, i. e. move to a higher spatial layer at a reduced temporal layer
+	VideoLayerPreferenceResolution
+)
+
+func (v VideoLayerPreference) String() string {
+	switch v {
+	case VideoLayerPreferenceFrameRate:
+		return "FRAME_RATE"
+	case VideoLayerPreferenceResolution:
+		return "RESOLUTION"
+	default:
+		return fmt.Sprintf("%d", int(v))
+	}
+}
+
+// -------------------------------------------------------------------
+
 type VideoAllocation struct {
 	PauseReason         VideoPauseReason
 	IsDeficient         bool
@@ -151,6 +174,8 @@ func (f ForwarderState) String() string {
 	switch codecState := f.Codec.(type) {
 	case codecmunger.VP8State:
 		codecString = codecState.String()
+	case codecmunger.VP9State:
+		codecString = codecState.String()
 	}
 	return fmt.Sprintf("ForwarderState{started: %v, preStartTime: %s, firstTS: %d, refTSOffset: %d, rtp: %s, codec: %s}",
 		f.Started,
@@ -175,6 +200,8 @@ type Forwarder struct {
 	muted    bool
 	pubMuted bool
 
+	layerPreference VideoLayerPreference
+
 	started               bool
 	preStartTime          time.Time
 	firstTS               uint32
@@ -249,6 +276,20 @@ func (f *Forwarder) SetMaxTemporalLayerSeen(maxTemporalLayerSeen int32) bool {
 	return true
 }
 
+func (f *Forwarder) SetLayerPreference(layerPreference VideoLayerPreference) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.layerPreference = layerPreference
+}
+
+func (f *Forwarder) LayerPreference() VideoLayerPreference {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.layerPreference
+}
+
 func (f *Forwarder) OnParkedLayerExpired(fn func()) {
 	f.lock.Lock()
 	defer f.lock.Unlock()
@@ -281,7 +322,7 @@ func (f *Forwarder) DetermineCodec(codec webrtc.RTPCodecCapability, extensions [
 			f.vls = videolayerselector.NewSimulcast(f.logger)
 		}
 		f.vls.SetTemporalLayerSelector(temporallayerselector.NewVP8(f.logger))
-	case "video/h264":
+	case "video/h264", "video/h265":
 		if f.vls != nil {
 			f.vls = videolayerselector.NewSimulcastFromNull(f.vls)
 		} else {
@@ -304,6 +345,9 @@ func (f *Forwarder) DetermineCodec(codec webrtc.RTPCodecCapability, extensions [
 				f.vls = videolayerselector.NewDependencyDescriptor(f.logger)
 			}
 		} else {
+			// picture id is munged only when layers are described by the VP9 payload descriptor,
+			// the descriptor is not parsed when the dependency descriptor is available
+			f.codecMunger = codecmunger.NewVP9FromNull(f.codecMunger, f.logger)
 			if f.vls != nil {
 				f.vls = videolayerselector.NewVP9FromNull(f.vls)
 			} else {
@@ -711,6 +755,11 @@ func (f *Forwarder) ProvisionalAllocate(availableChannelCapacity int64, layer bu
 
 	alreadyAllocatedBitrate := int64(0)
 	if f.provisional.allocatedLayer.IsValid() {
+		// when preferring resolution, layers are offered temporal layer first, i. e. (0, 1) comes after (2, 0),
+		// a lower layer than the allocated one is not a request to move down
+		if f.layerPreference == VideoLayerPreferenceResolution && !layer.GreaterThan(f.provisional.allocatedLayer) {
+			return 0
+		}
 		alreadyAllocatedBitrate = f.provisional.Bitrates[f.provisional.allocatedLayer.Spatial][f.provisional.allocatedLayer.Temporal]
 	}
 
@@ -1145,24 +1194,49 @@ func (f *Forwarder) AllocateNextHigher(availableChannelCapacity int64, available
 	var allocation VideoAllocation
 	boosted := false
 
-	// try moving temporal layer up in currently streaming spatial layer
-	if targetLayer.IsValid() {
+	if f.layerPreference == VideoLayerPreferenceResolution {
+		// try moving spatial layer up first
 		done, allocation, boosted = doAllocation(
-			targetLayer.Spatial, targetLayer.Spatial,
-			targetLayer.Temporal+1, maxLayer.Temporal,
+			targetLayer.Spatial+1, maxLayer.Spatial,
+			0, maxLayer.Temporal,
 		)
+		if boosted {
+			return allocation, boosted
+		}
+
+		// higher spatial layer is not available or does not fit, try moving temporal layer up in currently streaming spatial layer
+		if targetLayer.IsValid() {
+			temporalDone, temporalAllocation, temporalBoosted := doAllocation(
+				targetLayer.Spatial, targetLayer.Spatial,
+				targetLayer.Temporal+1, maxLayer.Temporal,
+			)
+			if temporalDone {
+				return temporalAllocation, temporalBoosted
+			}
+		}
 		if done {
 			return allocation, boosted
 		}
-	}
+	} else {
+		// try moving temporal layer up in currently streaming spatial layer
+		if targetLayer.IsValid() {
+			done, allocation, boosted = doAllocation(
+				targetLayer.Spatial, targetLayer.Spatial,
+				targetLayer.Temporal+1, maxLayer.Temporal,
+			)
+			if done {
+				return allocation, boosted
+			}
+		}
 
-	// try moving spatial layer up if temporal layer move up is not available
-	done, allocation, boosted = doAllocation(
-		targetLayer.Spatial+1, maxLayer.Spatial,
-		0, maxLayer.Temporal,
-	)
-	if done {
-		return allocation, boosted
+		// try moving spatial layer up if temporal layer move up is not available
+		done, allocation, boosted = doAllocation(
+			targetLayer.Spatial+1, maxLayer.Spatial,
+			0, maxLayer.Temporal,
+		)
+		if done {
+			return allocation, boosted
+		}
 	}
 
 	if allowOvershoot && f.vls.IsOvershootOkay() && maxLayer.IsValid() {
@@ -1230,8 +1304,19 @@ func (f *Forwarder) GetNextHigherTransition(brs Bitrates, allowOvershoot bool) (
 	var transition VideoTransition
 	isAvailable := false
 
-	// try moving temporal layer up in currently streaming spatial layer
 	maxLayer := f.vls.GetMax()
+	if f.layerPreference == VideoLayerPreferenceResolution {
+		// try moving spatial layer up first
+		done, transition, isAvailable = findNextHigher(
+			targetLayer.Spatial+1, maxLayer.Spatial,
+			0, maxLayer.Temporal,
+		)
+		if done {
+			return transition, isAvailable
+		}
+	}
+
+	// try moving temporal layer up in currently streaming spatial layer
 	if targetLayer.IsValid() {
 		done, transition, isAvailable = findNextHigher(
 			targetLayer.Spatial, targetLayer.Spatial,
@@ -1243,12 +1328,14 @@ func (f *Forwarder) GetNextHigherTransition(brs Bitrates, allowOvershoot bool) (
 	}
 
 	// try moving spatial layer up if temporal layer move up is not available
-	done, transition, isAvailable = findNextHigher(
-		targetLayer.Spatial+1, maxLayer.Spatial,
-		0, maxLayer.Temporal,
-	)
-	if done {
-		return transition, isAvailable
+	if f.layerPreference != VideoLayerPreferenceResolution {
+		done, transition, isAvailable = findNextHigher(
+			targetLayer.Spatial+1, maxLayer.Spatial,
+			0, maxLayer.Temporal,
+		)
+		if done {
+			return transition, isAvailable
+		}
 	}
 
 	if allowOvershoot && f.vls.IsOvershootOkay() && maxLayer.IsValid() {
@@ -1312,8 +1399,11 @@ func (f *Forwarder) Pause(availableLayers []int32, brs Bitrates) VideoAllocation
 
 func (f *Forwarder) updateAllocation(alloc VideoAllocation, reason string) VideoAllocation {
 	// restrict target temporal to 0 if codec does not support temporal layers
-	if alloc.TargetLayer.IsValid() && strings.ToLower(f.codec.MimeType) == "video/h264" {
-		alloc.TargetLayer.Temporal = 0
+	if alloc.TargetLayer.IsValid() {
+		switch strings.ToLower(f.codec.MimeType) {
+		case "video/h264", "video/h265":
+			alloc.TargetLayer.Temporal = 0
+		}
 	}
 
 	if alloc.IsDeficient != f.lastAllocation.IsDeficient ||
@@ -1604,7 +1694,7 @@ func (f *Forwarder) getTranslationParamsVideo(extPkt *buffer.ExtPacket, layer in
 	if err != nil {
 		tp.rtp = nil
 		tp.shouldDrop = true
-		if err == codecmunger.ErrFilteredVP8TemporalLayer || err == codecmunger.ErrOutOfOrderVP8PictureIdCacheMiss {
+		if err == codecmunger.ErrFilteredVP8TemporalLayer || err == codecmunger.ErrOutOfOrderVP8PictureIdCacheMiss || err == codecmunger.ErrOutOfOrderVP9PictureIdCacheMiss {
 			if err == codecmunger.ErrFilteredVP8TemporalLayer {
 				// filtered temporal layer, update sequence number offset to prevent holes
 				f.rtpMunger.PacketDropped(extPkt)
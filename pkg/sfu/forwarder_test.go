@@ -1086,6 +1086,65 @@ func TestForwarderAllocateNextHigher(t *testing.T) {
 	require.True(t, boosted)
 }
 
+func TestForwarderLayerPreferenceResolution(t *testing.T) {
+	f := newForwarder(testutils.TestVP8Codec, webrtc.RTPCodecTypeVideo)
+	f.SetMaxSpatialLayer(buffer.DefaultMaxLayerSpatial)
+	f.SetMaxTemporalLayer(buffer.DefaultMaxLayerTemporal)
+	f.SetMaxPublishedLayer(buffer.DefaultMaxLayerSpatial)
+	f.SetMaxTemporalLayerSeen(buffer.DefaultMaxLayerTemporal)
+	f.SetLayerPreference(VideoLayerPreferenceResolution)
+
+	bitrates := Bitrates{
+		{1, 2, 3, 4},
+		{5, 6, 7, 8},
+		{20, 22, 24, 26},
+	}
+
+	// layers offered spatial first, lower layers offered after a higher one do not move allocation down
+	f.ProvisionalAllocatePrepare(nil, bitrates)
+	available := int64(21)
+	for _, layer := range []buffer.VideoLayer{
+		{Spatial: 0, Temporal: 0},
+		{Spatial: 1, Temporal: 0},
+		{Spatial: 2, Temporal: 0},
+		{Spatial: 0, Temporal: 1},
+		{Spatial: 1, Temporal: 1},
+		{Spatial: 2, Temporal: 1},
+	} {
+		available -= f.ProvisionalAllocate(available, layer, true, false)
+	}
+	require.Equal(t, int64(1), available)
+	result := f.ProvisionalAllocateCommit()
+	require.Equal(t, buffer.VideoLayer{Spatial: 2, Temporal: 0}, result.TargetLayer)
+	require.True(t, result.IsDeficient)
+
+	// next higher moves spatial layer up before temporal layer
+	f.vls.SetTarget(buffer.VideoLayer{Spatial: 1, Temporal: 0})
+	f.vls.SetCurrent(buffer.VideoLayer{Spatial: 1, Temporal: 0})
+	transition, available2 := f.GetNextHigherTransition(bitrates, false)
+	require.True(t, available2)
+	require.Equal(t, buffer.VideoLayer{Spatial: 2, Temporal: 0}, transition.To)
+
+	result, boosted := f.AllocateNextHigher(100, nil, bitrates, false)
+	require.True(t, boosted)
+	require.Equal(t, buffer.VideoLayer{Spatial: 2, Temporal: 0}, result.TargetLayer)
+
+	// higher spatial layer does not fit, move temporal layer up
+	f.vls.SetTarget(buffer.VideoLayer{Spatial: 1, Temporal: 0})
+	f.vls.SetCurrent(buffer.VideoLayer{Spatial: 1, Temporal: 0})
+	result, boosted = f.AllocateNextHigher(2, nil, bitrates, false)
+	require.True(t, boosted)
+	require.Equal(t, buffer.VideoLayer{Spatial: 1, Temporal: 1}, result.TargetLayer)
+
+	// camera preference moves temporal layer up first
+	f.SetLayerPreference(VideoLayerPreferenceFrameRate)
+	f.vls.SetTarget(buffer.VideoLayer{Spatial: 1, Temporal: 0})
+	f.vls.SetCurrent(buffer.VideoLayer{Spatial: 1, Temporal: 0})
+	result, boosted = f.AllocateNextHigher(100, nil, bitrates, false)
+	require.True(t, boosted)
+	require.Equal(t, buffer.VideoLayer{Spatial: 1, Temporal: 1}, result.TargetLayer)
+}
+
 func TestForwarderPause(t *testing.T) {
 	f := newForwarder(testutils.TestVP8Codec, webrtc.RTPCodecTypeVideo)
 	f.SetMaxSpatialLayer(buffer.DefaultMaxLayerSpatial)
@@ -1953,3 +2012,144 @@ func TestForwardGetPaddingVP8(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, marshalledVP8, blankVP8)
 }
+
+func TestForwarderAllocateH265(t *testing.T) {
+	f := newForwarder(testutils.TestH265Codec, webrtc.RTPCodecTypeVideo)
+	f.SetMaxSpatialLayer(buffer.DefaultMaxLayerSpatial)
+	f.SetMaxTemporalLayer(buffer.DefaultMaxLayerTemporal)
+	f.SetMaxPublishedLayer(buffer.DefaultMaxLayerSpatial)
+
+	// h265 simulcast layers do not have temporal layers, target should stay at temporal layer 0
+	bitrates := Bitrates{
+		{2, 0, 0, 0},
+		{4, 0, 0, 0},
+		{8, 0, 0, 0},
+	}
+	result := f.AllocateOptimal([]int32{0, 1, 2}, bitrates, true)
+	require.Equal(t, buffer.VideoLayer{Spatial: 2, Temporal: 0}, result.TargetLayer)
+	require.Equal(t, int64(8), result.BandwidthRequested)
+
+	// restricting max spatial layer should move target down a simulcast layer
+	f.SetMaxSpatialLayer(1)
+	result = f.AllocateOptimal([]int32{0, 1, 2}, bitrates, false)
+	require.Equal(t, buffer.VideoLayer{Spatial: 1, Temporal: 0}, result.TargetLayer)
+	require.Equal(t, int64(4), result.BandwidthRequested)
+}
+
+func TestForwarderGetTranslationParamsH265(t *testing.T) {
+	f := newForwarder(testutils.TestH265Codec, webrtc.RTPCodecTypeVideo)
+
+	params := &testutils.TestExtPacketParams{
+		SequenceNumber: 23333,
+		Timestamp:      0xabcdef,
+		SSRC:           0x12345678,
+		PayloadSize:    20,
+		SetMarker:      true,
+	}
+	extPkt, _ := testutils.GetTestExtPacket(params)
+
+	// no target layers, should drop
+	actualTP, err := f.GetTranslationParams(extPkt, 0)
+	require.NoError(t, err)
+	require.Equal(t, TranslationParams{shouldDrop: true}, *actualTP)
+
+	// although target layer matches, not a key frame, so should drop
+	f.vls.SetTarget(buffer.VideoLayer{
+		Spatial:  0,
+		Temporal: 0,
+	})
+	actualTP, err = f.GetTranslationParams(extPkt, 0)
+	require.NoError(t, err)
+	require.Equal(t, TranslationParams{shouldDrop: true}, *actualTP)
+
+	// should lock onto key frame
+	params.IsKeyFrame = true
+	extPkt, _ = testutils.GetTestExtPacket(params)
+	expectedTP := TranslationParams{
+		isSwitchingToMaxSpatial: true,
+		isResuming:              true,
+		rtp: &TranslationParamsRTP{
+			snOrdering:     SequenceNumberOrderingContiguous,
+			sequenceNumber: 23333,
+			timestamp:      0xabcdef,
+		},
+		marker: true,
+	}
+	actualTP, err = f.GetTranslationParams(extPkt, 0)
+	require.NoError(t, err)
+	require.Equal(t, expectedTP, *actualTP)
+	require.Equal(t, f.lastSSRC, params.SSRC)
+
+	// in order packet on current layer should be forwarded
+	params = &testutils.TestExtPacketParams{
+		SequenceNumber: 23334,
+		Timestamp:      0xabcdef,
+		SSRC:           0x12345678,
+		PayloadSize:    20,
+		SetMarker:      true,
+	}
+	extPkt, _ = testutils.GetTestExtPacket(params)
+	expectedTP = TranslationParams{
+		rtp: &TranslationParamsRTP{
+			snOrdering:     SequenceNumberOrderingContiguous,
+			sequenceNumber: 23334,
+			timestamp:      0xabcdef,
+		},
+		marker: true,
+	}
+	actualTP, err = f.GetTranslationParams(extPkt, 0)
+	require.NoError(t, err)
+	require.Equal(t, expectedTP, *actualTP)
+
+	// switch up a simulcast layer, higher layer packets should be dropped till a key frame arrives
+	f.vls.SetTarget(buffer.VideoLayer{
+		Spatial:  1,
+		Temporal: 0,
+	})
+	params = &testutils.TestExtPacketParams{
+		SequenceNumber: 123,
+		Timestamp:      0xfedcba,
+		SSRC:           0x87654321,
+		PayloadSize:    20,
+	}
+	extPkt, _ = testutils.GetTestExtPacket(params)
+	actualTP, err = f.GetTranslationParams(extPkt, 1)
+	require.NoError(t, err)
+	require.Equal(t, TranslationParams{shouldDrop: true}, *actualTP)
+
+	// key frame on the higher layer should switch, sequence number should be contiguous
+	params = &testutils.TestExtPacketParams{
+		SequenceNumber: 124,
+		Timestamp:      0xfedcba,
+		SSRC:           0x87654321,
+		PayloadSize:    20,
+		IsKeyFrame:     true,
+	}
+	extPkt, _ = testutils.GetTestExtPacket(params)
+	expectedTP = TranslationParams{
+		isSwitchingToMaxSpatial: true,
+		rtp: &TranslationParamsRTP{
+			snOrdering:     SequenceNumberOrderingContiguous,
+			sequenceNumber: 23335,
+			timestamp:      0xabcdf0,
+		},
+	}
+	actualTP, err = f.GetTranslationParams(extPkt, 1)
+	require.NoError(t, err)
+	require.Equal(t, expectedTP, *actualTP)
+	require.Equal(t, f.lastSSRC, params.SSRC)
+	require.Equal(t, buffer.VideoLayer{Spatial: 1, Temporal: 0}, f.vls.GetCurrent())
+
+	// lower layer packets should be dropped after the switch
+	params = &testutils.TestExtPacketParams{
+		SequenceNumber: 23335,
+		Timestamp:      0xabcdef,
+		SSRC:           0x12345678,
+		PayloadSize:    20,
+		IsKeyFrame:     true,
+	}
+	extPkt, _ = testutils.GetTestExtPacket(params)
+	actualTP, err = f.GetTranslationParams(extPkt, 0)
+	require.NoError(t, err)
+	require.Equal(t, TranslationParams{shouldDrop: true}, *actualTP)
+}
@@ -17,9 +17,12 @@ func codecParametersFuzzySearch(needle webrtc.RTPCodecParameters, haystack []web
 		}
 	}
 
-	// Fallback to just MimeType
+	// Fallback to just MimeType, channel layout of multichannel Opus has to match though
 	for _, c := range haystack {
 		if strings.EqualFold(c.RTPCodecCapability.MimeType, needle.RTPCodecCapability.MimeType) {
+			if strings.EqualFold(needle.MimeType, MimeTypeMultiOpus) && c.Channels != needle.Channels {
+				continue
+			}
 			return c, nil
 		}
 	}
@@ -0,0 +1,240 @@
+package impairment
+
+import (
+	"container/heap"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// packets picked for reordering are held back by this much on top of their delay
+	reorderDelay = 20 * time.Millisecond
+
+	// packets which would wait longer than this for the bandwidth cap are dropped
+	maxQueueDelay = 500 * time.Millisecond
+)
+
+var (
+	ErrInvalidPercentage = errors.New("percentage should be between 0 and 100")
+	ErrNegativeValue     = errors.New("delay, jitter and bandwidth cannot be negative")
+)
+
+// Config describes network conditions to apply to a packet stream, zero values leave packets unimpaired.
+type Config struct {
+	LossPercentage    float64
+	Delay             time.Duration
+	Jitter            time.Duration
+	ReorderPercentage float64
+	// bandwidth cap in bps, 0 is not capped
+	Bandwidth int64
+	// random decisions are reproducible for a given seed and packet sequence
+	Seed int64
+}
+
+func (c Config) Validate() error {
+	if c.LossPercentage < 0 || c.LossPercentage > 100 || c.ReorderPercentage < 0 || c.ReorderPercentage > 100 {
+		return ErrInvalidPercentage
+	}
+	if c.Delay < 0 || c.Jitter < 0 || c.Bandwidth < 0 {
+		return ErrNegativeValue
+	}
+	return nil
+}
+
+type Stats struct {
+	Packets        uint64
+	Lost           uint64
+	Reordered      uint64
+	BandwidthDrops uint64
+}
+
+type queuedPacket struct {
+	due    time.Time
+	seq    uint64
+	packet []byte
+	send   func([]byte)
+}
+
+type packetQueue []*queuedPacket
+
+func (q packetQueue) Len() int { return len(q) }
+
+func (q packetQueue) Less(i, j int) bool {
+	if q[i].due.Equal(q[j].due) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].due.Before(q[j].due)
+}
+
+func (q packetQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *packetQueue) Push(x interface{}) { *q = append(*q, x.(*queuedPacket)) }
+
+func (q *packetQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	p := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return p
+}
+
+// Impairment emulates a network link in-process, dropping, delaying, reordering and rate limiting packets
+// before handing them to the send function. Packets are sent from a single goroutine in order of their due time.
+type Impairment struct {
+	config Config
+
+	lock       sync.Mutex
+	rand       *rand.Rand
+	queue      packetQueue
+	seq        uint64
+	linkFreeAt time.Time
+	stats      Stats
+	closed     bool
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+func New(config Config) *Impairment {
+	i := &Impairment{
+		config: config,
+		rand:   rand.New(rand.NewSource(config.Seed)),
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go i.worker()
+	return i
+}
+
+func (i *Impairment) Config() Config {
+	return i.config
+}
+
+// Process takes ownership of packet and calls send with it once it makes it through the impaired link.
+func (i *Impairment) Process(packet []byte, send func([]byte)) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.closed {
+		return
+	}
+
+	// draw all random values for every packet so that decisions depend only on the seed and the packet sequence
+	lossDraw := i.rand.Float64() * 100
+	jitterDraw := i.rand.Float64()*2 - 1
+	reorderDraw := i.rand.Float64() * 100
+
+	i.stats.Packets++
+	if lossDraw < i.config.LossPercentage {
+		i.stats.Lost++
+		return
+	}
+
+	now := time.Now()
+	departure := now
+	if i.config.Bandwidth > 0 {
+		start := now
+		if i.linkFreeAt.After(now) {
+			start = i.linkFreeAt
+		}
+		if start.Sub(now) > maxQueueDelay {
+			i.stats.BandwidthDrops++
+			return
+		}
+		i.linkFreeAt = start.Add(time.Duration(float64(len(packet)*8) / float64(i.config.Bandwidth) * float64(time.Second)))
+		departure = i.linkFreeAt
+	}
+
+	delay := i.config.Delay + time.Duration(jitterDraw*float64(i.config.Jitter))
+	if delay < 0 {
+		delay = 0
+	}
+	if reorderDraw < i.config.ReorderPercentage {
+		i.stats.Reordered++
+		delay += reorderDelay
+	}
+
+	i.seq++
+	heap.Push(&i.queue, &queuedPacket{
+		due:    departure.Add(delay),
+		seq:    i.seq,
+		packet: packet,
+		send:   send,
+	})
+
+	select {
+	case i.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (i *Impairment) GetStats() Stats {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return i.stats
+}
+
+// Close stops the impairment, packets still in flight are dropped.
+func (i *Impairment) Close() {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.closed {
+		return
+	}
+	i.closed = true
+	i.queue = nil
+	close(i.done)
+}
+
+func (i *Impairment) worker() {
+	for {
+		i.lock.Lock()
+		if i.closed {
+			i.lock.Unlock()
+			return
+		}
+
+		wait := time.Duration(-1)
+		for len(i.queue) > 0 {
+			if d := time.Until(i.queue[0].due); d > 0 {
+				wait = d
+				break
+			}
+
+			p := heap.Pop(&i.queue).(*queuedPacket)
+			i.lock.Unlock()
+			p.send(p.packet)
+			i.lock.Lock()
+
+			if i.closed {
+				i.lock.Unlock()
+				return
+			}
+		}
+		i.lock.Unlock()
+
+		if wait < 0 {
+			select {
+			case <-i.wake:
+			case <-i.done:
+				return
+			}
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-i.wake:
+		case <-i.done:
+			timer.Stop()
+			return
+		}
+		timer.Stop()
+	}
+}
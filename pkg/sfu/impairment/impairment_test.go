@@ -0,0 +1,127 @@
+package impairment
+
+import (
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type collector struct {
+	lock     sync.Mutex
+	received []uint32
+	at       []time.Time
+}
+
+func (c *collector) send(packet []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.received = append(c.received, binary.BigEndian.Uint32(packet))
+	c.at = append(c.at, time.Now())
+}
+
+func (c *collector) get() ([]uint32, []time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]uint32{}, c.received...), append([]time.Time{}, c.at...)
+}
+
+func runPackets(t *testing.T, config Config, numPackets int, size int) ([]uint32, []time.Time, Stats) {
+	i := New(config)
+	defer i.Close()
+
+	c := &collector{}
+	for n := 0; n < numPackets; n++ {
+		packet := make([]byte, size)
+		binary.BigEndian.PutUint32(packet, uint32(n))
+		i.Process(packet, c.send)
+	}
+
+	stats := i.GetStats()
+	expected := int(stats.Packets - stats.Lost - stats.BandwidthDrops)
+	require.Eventually(t, func() bool {
+		received, _ := c.get()
+		return len(received) == expected
+	}, 5*time.Second, 10*time.Millisecond)
+
+	received, at := c.get()
+	return received, at, stats
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.NoError(t, Config{}.Validate())
+	require.NoError(t, Config{LossPercentage: 100, ReorderPercentage: 5, Delay: time.Second, Bandwidth: 1000}.Validate())
+	require.ErrorIs(t, Config{LossPercentage: 101}.Validate(), ErrInvalidPercentage)
+	require.ErrorIs(t, Config{ReorderPercentage: -1}.Validate(), ErrInvalidPercentage)
+	require.ErrorIs(t, Config{Jitter: -time.Millisecond}.Validate(), ErrNegativeValue)
+	require.ErrorIs(t, Config{Bandwidth: -1}.Validate(), ErrNegativeValue)
+}
+
+func TestImpairment(t *testing.T) {
+	t.Run("pass through", func(t *testing.T) {
+		received, _, stats := runPackets(t, Config{}, 100, 100)
+		require.Equal(t, uint64(100), stats.Packets)
+		for n, sn := range received {
+			require.Equal(t, uint32(n), sn)
+		}
+	})
+
+	t.Run("loss is deterministic", func(t *testing.T) {
+		config := Config{LossPercentage: 30, Seed: 42}
+		received1, _, stats := runPackets(t, config, 1000, 100)
+		received2, _, _ := runPackets(t, config, 1000, 100)
+		require.Equal(t, received1, received2)
+		require.InDelta(t, 300, stats.Lost, 60)
+
+		config.Seed = 43
+		received3, _, _ := runPackets(t, config, 1000, 100)
+		require.NotEqual(t, received1, received3)
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		start := time.Now()
+		_, at, _ := runPackets(t, Config{Delay: 50 * time.Millisecond}, 10, 100)
+		for _, a := range at {
+			require.GreaterOrEqual(t, a.Sub(start), 50*time.Millisecond)
+		}
+	})
+
+	t.Run("reorder", func(t *testing.T) {
+		received, _, stats := runPackets(t, Config{ReorderPercentage: 50, Seed: 1}, 100, 100)
+		require.Len(t, received, 100)
+		require.NotZero(t, stats.Reordered)
+
+		inOrder := true
+		for n := 1; n < len(received); n++ {
+			if received[n] < received[n-1] {
+				inOrder = false
+				break
+			}
+		}
+		require.False(t, inOrder)
+	})
+
+	t.Run("bandwidth cap", func(t *testing.T) {
+		// 100 kbps drains 1000 byte packets at 12.5 packets / second, 500 ms of queue holds about 6
+		received, at, stats := runPackets(t, Config{Bandwidth: 100_000}, 20, 1000)
+		require.NotZero(t, stats.BandwidthDrops)
+		require.Less(t, len(received), 20)
+		require.GreaterOrEqual(t, at[len(at)-1].Sub(at[0]), 400*time.Millisecond)
+	})
+
+	t.Run("close drops in flight", func(t *testing.T) {
+		i := New(Config{Delay: time.Hour})
+		c := &collector{}
+		i.Process(make([]byte, 4), c.send)
+		i.Close()
+		i.Process(make([]byte, 4), c.send)
+
+		received, _ := c.get()
+		require.Empty(t, received)
+		require.Equal(t, uint64(1), i.GetStats().Packets)
+	})
+}
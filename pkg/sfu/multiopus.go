@@ -0,0 +1,99 @@
+package sfu
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// MimeTypeMultiOpus is the multichannel extension of Opus used by libwebrtc for surround sound.
+// A packet carries one Opus stream per coupled (stereo) or uncoupled (mono) group of channels,
+// all but the last using self-delimited framing (RFC 6716, Appendix B).
+const MimeTypeMultiOpus = "audio/multiopus"
+
+// IsOpusCodec returns whether the codec is Opus, stereo or multichannel
+func IsOpusCodec(mime string) bool {
+	return strings.EqualFold(mime, webrtc.MimeTypeOpus) || strings.EqualFold(mime, MimeTypeMultiOpus)
+}
+
+// MultiOpusChannelsParam is the media type parameter carrying the channel count of a multichannel track
+// in TrackInfo.MimeType, e.g. audio/multiopus;channels=6, as TrackInfo has no channel count field
+const MultiOpusChannelsParam = "channels"
+
+// MultiOpusLayout is the channel layout of a multiopus stream
+type MultiOpusLayout struct {
+	Channels int
+	// number of Opus streams in a packet
+	NumStreams int
+	// number of streams carrying a channel pair, the others carry one channel
+	CoupledStreams int
+}
+
+// ParseMultiOpusLayout reads the layout from the channels (or channel_mapping), num_streams and
+// coupled_streams fmtp parameters
+func ParseMultiOpusLayout(fmtpLine string) MultiOpusLayout {
+	layout := MultiOpusLayout{NumStreams: 1}
+	mappedChannels := 0
+	for _, param := range strings.Split(fmtpLine, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found {
+			continue
+		}
+		if strings.EqualFold(key, "channel_mapping") {
+			mappedChannels = len(strings.Split(value, ","))
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "channels":
+			if n > 0 {
+				layout.Channels = n
+			}
+		case "num_streams":
+			if n > 0 {
+				layout.NumStreams = n
+			}
+		case "coupled_streams":
+			if n > 0 {
+				layout.CoupledStreams = n
+			}
+		}
+	}
+	if layout.CoupledStreams > layout.NumStreams {
+		layout.CoupledStreams = layout.NumStreams
+	}
+	if layout.Channels == 0 {
+		layout.Channels = mappedChannels
+	}
+	if layout.Channels == 0 {
+		layout.Channels = layout.NumStreams + layout.CoupledStreams
+	}
+	return layout
+}
+
+// MultiOpusStreams returns the number of Opus streams in a multiopus packet, from the num_streams fmtp parameter
+func MultiOpusStreams(fmtpLine string) int {
+	return ParseMultiOpusLayout(fmtpLine).NumStreams
+}
+
+// MultiOpusMimeType returns the mime type of a multichannel track with its channel count
+func MultiOpusMimeType(channels int) string {
+	return fmt.Sprintf("%s;%s=%d", MimeTypeMultiOpus, MultiOpusChannelsParam, channels)
+}
+
+// multiOpusSilenceFrame returns a silence frame for every stream of a multiopus packet
+func multiOpusSilenceFrame(numStreams int) []byte {
+	// TOC and frame of the silence frame, with the frame length between them for self-delimited streams
+	silence := OpusSilenceFrame[1:]
+	payload := make([]byte, 0, numStreams*(len(OpusSilenceFrame)+1))
+	for i := 0; i < numStreams-1; i++ {
+		payload = append(payload, OpusSilenceFrame[0], byte(len(silence)))
+		payload = append(payload, silence...)
+	}
+	return append(payload, OpusSilenceFrame...)
+}
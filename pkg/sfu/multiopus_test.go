@@ -0,0 +1,69 @@
+package sfu
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiOpusStreams(t *testing.T) {
+	require.Equal(t, 4, MultiOpusStreams("channel_mapping=0,4,1,2,3,5;coupled_streams=2;minptime=10;num_streams=4;useinbandfec=1"))
+	require.Equal(t, 5, MultiOpusStreams("num_streams=5; coupled_streams=3"))
+	require.Equal(t, 1, MultiOpusStreams("minptime=10;useinbandfec=1"))
+	require.Equal(t, 1, MultiOpusStreams("num_streams=x"))
+}
+
+func TestParseMultiOpusLayout(t *testing.T) {
+	require.Equal(t, MultiOpusLayout{Channels: 6, NumStreams: 4, CoupledStreams: 2},
+		ParseMultiOpusLayout("channel_mapping=0,4,1,2,3,5;coupled_streams=2;minptime=10;num_streams=4;useinbandfec=1"))
+	require.Equal(t, MultiOpusLayout{Channels: 8, NumStreams: 5, CoupledStreams: 3},
+		ParseMultiOpusLayout("channel_mapping=0,6,1,2,3,4,5,7;coupled_streams=3;minptime=10;num_streams=5;useinbandfec=1"))
+	// explicit channel count wins over the mapping
+	require.Equal(t, MultiOpusLayout{Channels: 6, NumStreams: 4, CoupledStreams: 2},
+		ParseMultiOpusLayout("channels=6;channel_mapping=0,1;num_streams=4;coupled_streams=2"))
+	// without a mapping, every coupled stream adds a channel
+	require.Equal(t, MultiOpusLayout{Channels: 6, NumStreams: 4, CoupledStreams: 2},
+		ParseMultiOpusLayout("num_streams=4;coupled_streams=2"))
+	require.Equal(t, MultiOpusLayout{Channels: 1, NumStreams: 1}, ParseMultiOpusLayout("minptime=10"))
+}
+
+func TestMultiOpusMimeType(t *testing.T) {
+	require.Equal(t, "audio/multiopus;channels=6", MultiOpusMimeType(6))
+}
+
+func TestMultiOpusSilenceFrame(t *testing.T) {
+	require.Equal(t, OpusSilenceFrame, multiOpusSilenceFrame(1))
+
+	payload := multiOpusSilenceFrame(4)
+	// three self-delimited streams followed by a regular one
+	offset := 0
+	for i := 0; i < 3; i++ {
+		require.Equal(t, OpusSilenceFrame[0], payload[offset])
+		length := int(payload[offset+1])
+		require.Equal(t, len(OpusSilenceFrame)-1, length)
+		require.Equal(t, OpusSilenceFrame[1:], payload[offset+2:offset+2+length])
+		offset += 2 + length
+	}
+	require.Equal(t, OpusSilenceFrame, payload[offset:])
+}
+
+func TestCodecParametersFuzzySearchMultiOpus(t *testing.T) {
+	surround51 := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: MimeTypeMultiOpus, ClockRate: 48000, Channels: 6, SDPFmtpLine: "channel_mapping=0,4,1,2,3,5;coupled_streams=2;num_streams=4"},
+		PayloadType:        112,
+	}
+	surround71 := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: MimeTypeMultiOpus, ClockRate: 48000, Channels: 8, SDPFmtpLine: "channel_mapping=0,6,1,2,3,4,5,7;coupled_streams=3;num_streams=5"},
+		PayloadType:        113,
+	}
+
+	needle := surround51
+	needle.SDPFmtpLine = "num_streams=4;coupled_streams=2;channel_mapping=0,4,1,2,3,5"
+	match, err := codecParametersFuzzySearch(needle, []webrtc.RTPCodecParameters{surround71, surround51})
+	require.NoError(t, err)
+	require.Equal(t, webrtc.PayloadType(112), match.PayloadType)
+
+	_, err = codecParametersFuzzySearch(needle, []webrtc.RTPCodecParameters{surround71})
+	require.ErrorIs(t, err, webrtc.ErrCodecNotFound)
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu/audio"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
+	"github.com/livekit/livekit-server/pkg/sfu/impairment"
 )
 
 var (
@@ -74,12 +75,14 @@ type WebRTCReceiver struct {
 
 	pliThrottleConfig config.PLIThrottleConfig
 	audioConfig       config.AudioConfig
+	cqConfig          config.ConnectionQualityConfig
 
 	trackID        livekit.TrackID
 	streamID       string
 	kind           webrtc.RTPCodecType
 	receiver       *webrtc.RTPReceiver
 	codec          webrtc.RTPCodecParameters
+	headerExts     []webrtc.RTPHeaderExtensionParameter
 	isSVC          bool
 	isRED          bool
 	onCloseHandler func()
@@ -96,8 +99,10 @@ type WebRTCReceiver struct {
 	buffers  [buffer.DefaultMaxLayerSpatial + 1]*buffer.Buffer
 	rtt      uint32
 
+	impairmentConfig *impairment.Config
+
 	upTrackMu sync.RWMutex
-	upTracks  [buffer.DefaultMaxLayerSpatial + 1]*webrtc.TrackRemote
+	upTracks  [buffer.DefaultMaxLayerSpatial + 1]*upTrack
 
 	lbThreshold int
 
@@ -129,6 +134,13 @@ func IsRedCodec(mime string) bool {
 	return strings.HasSuffix(strings.ToLower(mime), "red")
 }
 
+// upTrack describes the stream received for a single layer
+type upTrack struct {
+	ssrc webrtc.SSRC
+	rid  string
+	msid string
+}
+
 type ReceiverOpts func(w *WebRTCReceiver) *WebRTCReceiver
 
 // WithPliThrottleConfig indicates minimum time(ms) between sending PLIs
@@ -147,6 +159,14 @@ func WithAudioConfig(audioConfig config.AudioConfig) ReceiverOpts {
 	}
 }
 
+// WithConnectionQualityConfig sets up scoring of connection quality
+func WithConnectionQualityConfig(cqConfig config.ConnectionQualityConfig) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.cqConfig = cqConfig
+		return w
+	}
+}
+
 // WithStreamTrackers enables StreamTracker use for simulcast
 func WithStreamTrackers() ReceiverOpts {
 	return func(w *WebRTCReceiver) *WebRTCReceiver {
@@ -190,7 +210,42 @@ func NewWebRTCReceiver(
 		isRED:     IsRedCodec(track.Codec().MimeType),
 	}
 
-	w.streamTrackerManager = NewStreamTrackerManager(logger, trackInfo, w.isSVC, w.codec.ClockRate, trackersConfig)
+	return w.init(trackersConfig, opts...)
+}
+
+// NewInProcessReceiver creates a receiver for a track that is not received over a PeerConnection.
+// Packets are written directly into the buffers added with AddUpTrackBuffer.
+func NewInProcessReceiver(
+	trackID livekit.TrackID,
+	streamID string,
+	codec webrtc.RTPCodecParameters,
+	headerExtensions []webrtc.RTPHeaderExtensionParameter,
+	trackInfo *livekit.TrackInfo,
+	logger logger.Logger,
+	trackersConfig config.StreamTrackersConfig,
+	opts ...ReceiverOpts,
+) *WebRTCReceiver {
+	kind := webrtc.RTPCodecTypeAudio
+	if strings.HasPrefix(strings.ToLower(codec.MimeType), "video/") {
+		kind = webrtc.RTPCodecTypeVideo
+	}
+	w := &WebRTCReceiver{
+		logger:     logger,
+		trackID:    trackID,
+		streamID:   streamID,
+		codec:      codec,
+		kind:       kind,
+		headerExts: headerExtensions,
+		trackInfo:  trackInfo,
+		isSVC:      IsSvcCodec(codec.MimeType),
+		isRED:      IsRedCodec(codec.MimeType),
+	}
+
+	return w.init(trackersConfig, opts...)
+}
+
+func (w *WebRTCReceiver) init(trackersConfig config.StreamTrackersConfig, opts ...ReceiverOpts) *WebRTCReceiver {
+	w.streamTrackerManager = NewStreamTrackerManager(w.logger, w.trackInfo, w.isSVC, w.codec.ClockRate, trackersConfig)
 	w.streamTrackerManager.SetListener(w)
 
 	for _, opt := range opts {
@@ -199,13 +254,14 @@ func NewWebRTCReceiver(
 
 	w.downTrackSpreader = NewDownTrackSpreader(DownTrackSpreaderParams{
 		Threshold: w.lbThreshold,
-		Logger:    logger,
+		Logger:    w.logger,
 	})
 
 	w.connectionStats = connectionquality.NewConnectionStats(connectionquality.ConnectionStatsParams{
 		MimeType:      w.codec.MimeType,
-		IsFECEnabled:  strings.EqualFold(w.codec.MimeType, webrtc.MimeTypeOpus) && strings.Contains(strings.ToLower(w.codec.SDPFmtpLine), "fec"),
+		IsFECEnabled:  IsOpusCodec(w.codec.MimeType) && strings.Contains(strings.ToLower(w.codec.SDPFmtpLine), "fec"),
 		GetDeltaStats: w.getDeltaStats,
+		Config:        w.cqConfig,
 		Logger:        w.logger.WithValues("direction", "up"),
 	})
 	w.connectionStats.OnStatsUpdate(func(_cs *connectionquality.ConnectionStats, stat *livekit.AnalyticsStat) {
@@ -236,6 +292,31 @@ func (w *WebRTCReceiver) GetConnectionScoreAndQuality() (float32, livekit.Connec
 	return w.connectionStats.GetScoreAndQuality()
 }
 
+// SetNetworkImpairment simulates network conditions on packets received from the publisher,
+// applies to current and future layers, nil removes the impairment
+func (w *WebRTCReceiver) SetNetworkImpairment(config *impairment.Config) {
+	w.bufferMu.Lock()
+	w.impairmentConfig = config
+	buffers := w.buffers
+	w.bufferMu.Unlock()
+
+	for layer, buff := range buffers {
+		if buff == nil {
+			continue
+		}
+
+		if config == nil {
+			buff.SetImpairment(nil)
+		} else {
+			buff.SetImpairment(newLayerImpairment(config, int32(layer)))
+		}
+	}
+}
+
+func (w *WebRTCReceiver) GetConnectionScoreBreakdown() connectionquality.ScoreBreakdown {
+	return w.connectionStats.GetScoreBreakdown()
+}
+
 func (w *WebRTCReceiver) IsClosed() bool {
 	return w.closed.Load()
 }
@@ -273,7 +354,7 @@ func (w *WebRTCReceiver) SSRC(layer int) uint32 {
 	defer w.upTrackMu.RUnlock()
 
 	if track := w.upTracks[layer]; track != nil {
-		return uint32(track.SSRC())
+		return uint32(track.ssrc)
 	}
 	return 0
 }
@@ -283,6 +364,9 @@ func (w *WebRTCReceiver) Codec() webrtc.RTPCodecParameters {
 }
 
 func (w *WebRTCReceiver) HeaderExtensions() []webrtc.RTPHeaderExtensionParameter {
+	if w.receiver == nil {
+		return w.headerExts
+	}
 	return w.receiver.GetParameters().HeaderExtensions
 }
 
@@ -299,6 +383,23 @@ func (w *WebRTCReceiver) AddUpTrack(track *webrtc.TrackRemote, buff *buffer.Buff
 	if w.Kind() == webrtc.RTPCodecTypeVideo && !w.isSVC {
 		layer = buffer.RidToSpatialLayer(track.RID(), w.trackInfo)
 	}
+	w.addUpTrack(layer, &upTrack{ssrc: track.SSRC(), rid: track.RID(), msid: track.Msid()}, buff)
+}
+
+// AddUpTrackBuffer adds the buffer of a layer that is fed without a PeerConnection,
+// the buffer is expected to be bound by the caller
+func (w *WebRTCReceiver) AddUpTrackBuffer(layer int32, ssrc uint32, buff *buffer.Buffer) {
+	if w.closed.Load() {
+		return
+	}
+
+	if w.isSVC {
+		layer = 0
+	}
+	w.addUpTrack(layer, &upTrack{ssrc: webrtc.SSRC(ssrc), msid: w.streamID + " " + string(w.trackID)}, buff)
+}
+
+func (w *WebRTCReceiver) addUpTrack(layer int32, track *upTrack, buff *buffer.Buffer) {
 	buff.SetLogger(w.logger.WithValues("layer", layer))
 	buff.SetTWCC(w.twcc)
 	buff.SetAudioLevelParams(audio.AudioLevelParams{
@@ -338,8 +439,12 @@ func (w *WebRTCReceiver) AddUpTrack(track *webrtc.TrackRemote, buff *buffer.Buff
 	w.bufferMu.Lock()
 	w.buffers[layer] = buff
 	rtt := w.rtt
+	impairmentConfig := w.impairmentConfig
 	w.bufferMu.Unlock()
 	buff.SetRTT(rtt)
+	if impairmentConfig != nil {
+		buff.SetImpairment(newLayerImpairment(impairmentConfig, layer))
+	}
 	buff.SetPaused(w.streamTrackerManager.IsPaused())
 
 	if w.Kind() == webrtc.RTPCodecTypeVideo && w.useTrackers {
@@ -681,9 +786,9 @@ func (w *WebRTCReceiver) DebugInfo() map[string]interface{} {
 		if ut != nil {
 			upTrackInfo = append(upTrackInfo, map[string]interface{}{
 				"Layer": layer,
-				"SSRC":  ut.SSRC(),
-				"Msid":  ut.Msid(),
-				"RID":   ut.RID(),
+				"SSRC":  ut.ssrc,
+				"Msid":  ut.msid,
+				"RID":   ut.rid,
 			})
 		}
 	}
@@ -713,7 +818,8 @@ func (w *WebRTCReceiver) GetPrimaryReceiverForRed() TrackReceiver {
 }
 
 func (w *WebRTCReceiver) GetRedReceiver() TrackReceiver {
-	if w.isRED || w.closed.Load() {
+	// RED is not negotiated for multichannel opus, its blocks would carry the stereo opus payload type
+	if w.isRED || w.closed.Load() || strings.EqualFold(w.codec.MimeType, MimeTypeMultiOpus) {
 		return w
 	}
 
@@ -747,3 +853,12 @@ func (w *WebRTCReceiver) GetTemporalLayerFpsForSpatial(layer int32) []float32 {
 func (w *WebRTCReceiver) GetReferenceLayerRTPTimestamp(ts uint32, layer int32, referenceLayer int32) (uint32, error) {
 	return w.streamTrackerManager.GetReferenceLayerRTPTimestamp(ts, layer, referenceLayer)
 }
+
+// ---------------------------------------------------------------
+
+// newLayerImpairment offsets the seed by layer so that layers do not lose the same packets
+func newLayerImpairment(config *impairment.Config, layer int32) *impairment.Impairment {
+	layerConfig := *config
+	layerConfig.Seed += int64(layer)
+	return impairment.New(layerConfig)
+}
@@ -108,6 +108,7 @@ const (
 	streamAllocatorSignalResume
 	streamAllocatorSignalSetAllowPause
 	streamAllocatorSignalSetChannelCapacity
+	streamAllocatorSignalSetChannelCapacityCeiling
 	streamAllocatorSignalNACK
 	streamAllocatorSignalRTCPReceiverReport
 )
@@ -134,6 +135,8 @@ func (s streamAllocatorSignal) String() string {
 		return "SET_ALLOW_PAUSE"
 	case streamAllocatorSignalSetChannelCapacity:
 		return "SET_CHANNEL_CAPACITY"
+	case streamAllocatorSignalSetChannelCapacityCeiling:
+		return "SET_CHANNEL_CAPACITY_CEILING"
 	case streamAllocatorSignalNACK:
 		return "NACK"
 	case streamAllocatorSignalRTCPReceiverReport:
@@ -174,6 +177,8 @@ type StreamAllocator struct {
 	lastReceivedEstimate      int64
 	committedChannelCapacity  int64
 	overriddenChannelCapacity int64
+	// caps the estimate, unlike an override which replaces it
+	channelCapacityCeiling int64
 
 	probeInterval         time.Duration
 	lastProbeStartTime    time.Time
@@ -317,6 +322,15 @@ func (s *StreamAllocator) SetChannelCapacity(channelCapacity int64) {
 	})
 }
 
+// SetChannelCapacityCeiling caps the estimated channel capacity used for allocation, 0 removes the cap.
+// An overridden channel capacity takes precedence.
+func (s *StreamAllocator) SetChannelCapacityCeiling(ceiling int64) {
+	s.postEvent(Event{
+		Signal: streamAllocatorSignalSetChannelCapacityCeiling,
+		Data:   ceiling,
+	})
+}
+
 func (s *StreamAllocator) resetState() {
 	s.channelObserver = s.newChannelObserverNonProbe()
 	s.resetProbe()
@@ -602,6 +616,8 @@ func (s *StreamAllocator) handleEvent(event *Event) {
 		s.handleSignalSetAllowPause(event)
 	case streamAllocatorSignalSetChannelCapacity:
 		s.handleSignalSetChannelCapacity(event)
+	case streamAllocatorSignalSetChannelCapacityCeiling:
+		s.handleSignalSetChannelCapacityCeiling(event)
 	case streamAllocatorSignalNACK:
 		s.handleSignalNACK(event)
 	case streamAllocatorSignalRTCPReceiverReport:
@@ -736,6 +752,16 @@ func (s *StreamAllocator) handleSignalSetChannelCapacity(event *Event) {
 	}
 }
 
+func (s *StreamAllocator) handleSignalSetChannelCapacityCeiling(event *Event) {
+	ceiling := event.Data.(int64)
+	if ceiling == s.channelCapacityCeiling {
+		return
+	}
+	s.params.Logger.Infow("updating channel capacity ceiling", "old(bps)", s.channelCapacityCeiling, "new(bps)", ceiling)
+	s.channelCapacityCeiling = ceiling
+	s.allocateAllTracks()
+}
+
 func (s *StreamAllocator) handleSignalNACK(event *Event) {
 	nackInfos := event.Data.([]sfu.NackInfo)
 
@@ -1025,6 +1051,9 @@ func (s *StreamAllocator) maybeBoostDeficientTracks() {
 			"override", committedChannelCapacity,
 		)
 	}
+	if s.channelCapacityCeiling > 0 && committedChannelCapacity > s.channelCapacityCeiling {
+		committedChannelCapacity = s.channelCapacityCeiling
+	}
 	availableChannelCapacity := committedChannelCapacity - s.getExpectedBandwidthUsage()
 	if availableChannelCapacity <= 0 {
 		return
@@ -1082,6 +1111,14 @@ func (s *StreamAllocator) allocateAllTracks() {
 			"override", availableChannelCapacity,
 		)
 	}
+	if s.channelCapacityCeiling > 0 && availableChannelCapacity > s.channelCapacityCeiling {
+		availableChannelCapacity = s.channelCapacityCeiling
+		s.params.Logger.Debugw(
+			"stream allocator: capping channel capacity",
+			"actual", s.committedChannelCapacity,
+			"ceiling", availableChannelCapacity,
+		)
+	}
 	if s.overriddenChannelCapacity > 0 {
 		availableChannelCapacity = s.overriddenChannelCapacity
 		s.params.Logger.Debugw(
@@ -1131,19 +1168,14 @@ func (s *StreamAllocator) allocateAllTracks() {
 			track.ProvisionalAllocatePrepare()
 		}
 
-		for spatial := int32(0); spatial <= buffer.DefaultMaxLayerSpatial; spatial++ {
-			for temporal := int32(0); temporal <= buffer.DefaultMaxLayerTemporal; temporal++ {
-				layer := buffer.VideoLayer{
-					Spatial:  spatial,
-					Temporal: temporal,
-				}
-
-				for _, track := range sorted {
-					usedChannelCapacity := track.ProvisionalAllocate(availableChannelCapacity, layer, s.allowPause, FlagAllowOvershootWhileDeficient)
-					availableChannelCapacity -= usedChannelCapacity
-					if availableChannelCapacity < 0 {
-						availableChannelCapacity = 0
-					}
+		// each round offers every track its next layer, the order of layers depends on layer preference of the track
+		numRounds := int32((buffer.DefaultMaxLayerSpatial + 1) * (buffer.DefaultMaxLayerTemporal + 1))
+		for round := int32(0); round < numRounds; round++ {
+			for _, track := range sorted {
+				usedChannelCapacity := track.ProvisionalAllocate(availableChannelCapacity, track.AllocationLayer(round), s.allowPause, FlagAllowOvershootWhileDeficient)
+				availableChannelCapacity -= usedChannelCapacity
+				if availableChannelCapacity < 0 {
+					availableChannelCapacity = 0
 				}
 			}
 		}
@@ -1304,6 +1336,10 @@ func (s *StreamAllocator) maybeProbe() {
 		// do not probe if channel capacity is overridden
 		return
 	}
+	if s.channelCapacityCeiling > 0 && s.committedChannelCapacity >= s.channelCapacityCeiling {
+		// do not probe beyond the ceiling
+		return
+	}
 
 	switch s.params.Config.ProbeMode {
 	case config.CongestionControlProbeModeMedia:
@@ -0,0 +1,143 @@
+package streamallocator
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// testTrackReceiver is a simulcast VP8 receiver reporting fixed bitrates, calls not needed for allocation panic
+type testTrackReceiver struct {
+	sfu.TrackReceiver
+
+	trackID  livekit.TrackID
+	bitrates sfu.Bitrates
+}
+
+func (r *testTrackReceiver) TrackID() livekit.TrackID {
+	return r.trackID
+}
+
+func (r *testTrackReceiver) StreamID() string {
+	return string(r.trackID)
+}
+
+func (r *testTrackReceiver) Codec() webrtc.RTPCodecParameters {
+	return testVP8Codec
+}
+
+func (r *testTrackReceiver) HeaderExtensions() []webrtc.RTPHeaderExtensionParameter {
+	return nil
+}
+
+func (r *testTrackReceiver) GetLayeredBitrate() ([]int32, sfu.Bitrates) {
+	return []int32{0, 1, 2}, r.bitrates
+}
+
+func (r *testTrackReceiver) SendPLI(_layer int32, _force bool) {
+}
+
+var testVP8Codec = webrtc.RTPCodecParameters{
+	RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+	PayloadType:        96,
+}
+
+// bitrates of layers are cumulative, i. e. they include the lower temporal layers
+var (
+	cameraBitrates = sfu.Bitrates{
+		{100, 150, 200, 0},
+		{400, 600, 800, 0},
+		{1000, 1500, 2000, 0},
+	}
+	screenShareBitrates = sfu.Bitrates{
+		{50, 75, 100, 0},
+		{200, 300, 400, 0},
+		{800, 1200, 1600, 0},
+	}
+)
+
+func newTestStreamAllocator() *StreamAllocator {
+	return NewStreamAllocator(StreamAllocatorParams{
+		Config: config.CongestionControlConfig{Enabled: true},
+		Logger: logger.GetLogger(),
+	})
+}
+
+func addTestTrack(t *testing.T, s *StreamAllocator, trackID livekit.TrackID, source livekit.TrackSource, bitrates sfu.Bitrates) *sfu.DownTrack {
+	dt, err := sfu.NewDownTrack(
+		[]webrtc.RTPCodecParameters{testVP8Codec},
+		&testTrackReceiver{trackID: trackID, bitrates: bitrates},
+		buffer.NewFactoryOfBufferFactory(500).CreateBufferFactory(),
+		"subscriber",
+		500,
+		false,
+		config.ConnectionQualityConfig{},
+		logger.GetLogger(),
+	)
+	require.NoError(t, err)
+	_, err = dt.BindWriter([]webrtc.RTPCodecParameters{testVP8Codec}, uint32(len(s.getTracks())+1), nil)
+	require.NoError(t, err)
+
+	dt.SetMaxSpatialLayer(buffer.DefaultMaxLayerSpatial)
+	dt.SetMaxTemporalLayer(buffer.DefaultMaxLayerTemporal)
+	dt.UpTrackMaxPublishedLayerChange(buffer.DefaultMaxLayerSpatial)
+	dt.UpTrackMaxTemporalLayerSeenChange(2)
+
+	s.AddTrack(dt, AddTrackParams{
+		Source:      source,
+		IsSimulcast: true,
+		PublisherID: "publisher",
+	})
+	return dt
+}
+
+func TestStreamAllocatorLayerPreference(t *testing.T) {
+	t.Run("screen share keeps resolution, camera keeps frame rate", func(t *testing.T) {
+		s := newTestStreamAllocator()
+		camera := addTestTrack(t, s, "camera", livekit.TrackSource_CAMERA, cameraBitrates)
+		screenShare := addTestTrack(t, s, "screen", livekit.TrackSource_SCREEN_SHARE, screenShareBitrates)
+
+		s.committedChannelCapacity = 1150
+		s.allocateAllTracks()
+
+		// top spatial layer at base frame rate, offering layers spatial major would have stopped it at (1, 2)
+		require.Equal(t, buffer.VideoLayer{Spatial: 2, Temporal: 0}, screenShare.TargetLayer())
+		// all temporal layers of the lowest spatial layer, the next spatial layer does not fit
+		require.Equal(t, buffer.VideoLayer{Spatial: 0, Temporal: 2}, camera.TargetLayer())
+	})
+
+	t.Run("camera only follows spatial major order", func(t *testing.T) {
+		// layers are offered in the same order as the spatial then temporal loops used before layer preferences
+		s := newTestStreamAllocator()
+		addTestTrack(t, s, "camera", livekit.TrackSource_CAMERA, cameraBitrates)
+		camera := s.videoTracks["camera"]
+		round := int32(0)
+		for spatial := int32(0); spatial <= buffer.DefaultMaxLayerSpatial; spatial++ {
+			for temporal := int32(0); temporal <= buffer.DefaultMaxLayerTemporal; temporal++ {
+				require.Equal(t, buffer.VideoLayer{Spatial: spatial, Temporal: temporal}, camera.AllocationLayer(round))
+				round++
+			}
+		}
+
+		for _, tc := range []struct {
+			capacity int64
+			expected buffer.VideoLayer
+		}{
+			{capacity: 150, expected: buffer.VideoLayer{Spatial: 0, Temporal: 1}},
+			{capacity: 500, expected: buffer.VideoLayer{Spatial: 1, Temporal: 0}},
+			{capacity: 1600, expected: buffer.VideoLayer{Spatial: 2, Temporal: 1}},
+		} {
+			s.committedChannelCapacity = tc.capacity
+			s.allocateAllTracks()
+			require.Equal(t, tc.expected, camera.DownTrack().TargetLayer(), "capacity: %d", tc.capacity)
+		}
+	})
+}
@@ -65,6 +65,7 @@ func NewTrack(
 	}
 	t.SetPriority(0)
 	t.SetMaxLayer(downTrack.MaxLayer())
+	downTrack.SetLayerPreference(t.LayerPreference())
 
 	return t
 }
@@ -109,6 +110,31 @@ func (t *Track) Priority() uint8 {
 	return t.priority
 }
 
+// LayerPreference returns what to keep when bandwidth is short, text and detail of
+// screen shares need resolution, motion in camera feeds needs frame rate
+func (t *Track) LayerPreference() sfu.VideoLayerPreference {
+	if t.source == livekit.TrackSource_SCREEN_SHARE {
+		return sfu.VideoLayerPreferenceResolution
+	}
+	return sfu.VideoLayerPreferenceFrameRate
+}
+
+// AllocationLayer returns the layer to offer the track in a round of allocation. Rounds go through all layers
+// from lowest to highest, spatial layers first for resolution preference and temporal layers first otherwise.
+func (t *Track) AllocationLayer(round int32) buffer.VideoLayer {
+	if t.LayerPreference() == sfu.VideoLayerPreferenceResolution {
+		return buffer.VideoLayer{
+			Spatial:  round % (buffer.DefaultMaxLayerSpatial + 1),
+			Temporal: round / (buffer.DefaultMaxLayerSpatial + 1),
+		}
+	}
+
+	return buffer.VideoLayer{
+		Spatial:  round / (buffer.DefaultMaxLayerTemporal + 1),
+		Temporal: round % (buffer.DefaultMaxLayerTemporal + 1),
+	}
+}
+
 func (t *Track) DownTrack() *sfu.DownTrack {
 	return t.downTrack
 }
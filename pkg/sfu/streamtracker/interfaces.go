@@ -37,6 +37,6 @@ type StreamTrackerImpl interface {
 
 	GetCheckInterval() time.Duration
 
-	Observe(hasMarker bool, ts uint32) StreamStatusChange
+	Observe(hasMarker bool, ts uint32, pktSize int) StreamStatusChange
 	CheckStatus() StreamStatusChange
 }
@@ -183,7 +183,7 @@ func (s *StreamTracker) Observe(
 		return
 	}
 
-	statusChange := s.params.StreamTrackerImpl.Observe(hasMarker, ts)
+	statusChange := s.params.StreamTrackerImpl.Observe(hasMarker, ts, pktSize)
 	if statusChange == StreamStatusChangeActive {
 		s.setStatusLocked(StreamStatusActive)
 		s.lastBitrateReport = time.Now()
@@ -0,0 +1,126 @@
+package streamtracker
+
+import (
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/logger"
+)
+
+// used when a per-layer config overrides some fields and leaves cycle duration out
+const defaultBitrateCycleDuration = 500 * time.Millisecond
+
+type StreamTrackerBitrateParams struct {
+	Config config.StreamTrackerBitrateConfig
+	// bitrate the publisher advertises for the layer in bps, 0 if not known
+	ExpectedBitrate int64
+	Logger          logger.Logger
+}
+
+// StreamTrackerBitrate declares a layer stopped when the bitrate averaged over a window of cycles falls below
+// a fraction of the expected bitrate of the layer, and active when it is back at or above it.
+// Averaging over a window keeps sparse streams, like screen shares of static content, active.
+type StreamTrackerBitrate struct {
+	params StreamTrackerBitrateParams
+
+	minBitrate    float64
+	cycleDuration time.Duration
+
+	initialized bool
+
+	bytesSinceLast int64
+
+	cycleBytes  []int64
+	cycleIndex  int
+	cyclesTaken int
+	windowBytes int64
+}
+
+func NewStreamTrackerBitrate(params StreamTrackerBitrateParams) StreamTrackerImpl {
+	expectedBitrate := params.ExpectedBitrate
+	if expectedBitrate <= 0 {
+		expectedBitrate = params.Config.ExpectedBitrate
+	}
+
+	cycleDuration := params.Config.CycleDuration
+	if cycleDuration <= 0 {
+		cycleDuration = defaultBitrateCycleDuration
+	}
+
+	windowCycles := params.Config.WindowCycles
+	if windowCycles == 0 {
+		windowCycles = 1
+	}
+
+	s := &StreamTrackerBitrate{
+		params:        params,
+		minBitrate:    float64(expectedBitrate) * params.Config.MinRatio,
+		cycleDuration: cycleDuration,
+		cycleBytes:    make([]int64, windowCycles),
+	}
+	s.params.Logger.Debugw("bitrate tracker", "expectedBitrate", expectedBitrate, "minBitrate", s.minBitrate, "cycleDuration", cycleDuration)
+	return s
+}
+
+func (s *StreamTrackerBitrate) Start() {
+}
+
+func (s *StreamTrackerBitrate) Stop() {
+}
+
+func (s *StreamTrackerBitrate) Reset() {
+	s.initialized = false
+
+	s.bytesSinceLast = 0
+
+	for i := range s.cycleBytes {
+		s.cycleBytes[i] = 0
+	}
+	s.cycleIndex = 0
+	s.cyclesTaken = 0
+	s.windowBytes = 0
+}
+
+func (s *StreamTrackerBitrate) GetCheckInterval() time.Duration {
+	return s.cycleDuration
+}
+
+func (s *StreamTrackerBitrate) Observe(_hasMarker bool, _ts uint32, pktSize int) StreamStatusChange {
+	s.bytesSinceLast += int64(pktSize)
+
+	if !s.initialized {
+		// first packet
+		s.initialized = true
+		return StreamStatusChangeActive
+	}
+
+	return StreamStatusChangeNone
+}
+
+func (s *StreamTrackerBitrate) CheckStatus() StreamStatusChange {
+	if !s.initialized {
+		// should not be getting called when not initialized, but be safe
+		return StreamStatusChangeNone
+	}
+
+	s.windowBytes += s.bytesSinceLast - s.cycleBytes[s.cycleIndex]
+	s.cycleBytes[s.cycleIndex] = s.bytesSinceLast
+	s.cycleIndex = (s.cycleIndex + 1) % len(s.cycleBytes)
+	s.bytesSinceLast = 0
+
+	if s.cyclesTaken < len(s.cycleBytes) {
+		s.cyclesTaken++
+		if s.cyclesTaken < len(s.cycleBytes) {
+			// not enough time since start to tell a sparse stream from a stopped one
+			return StreamStatusChangeNone
+		}
+	}
+
+	windowDuration := time.Duration(len(s.cycleBytes)) * s.cycleDuration
+	bitrate := float64(s.windowBytes*8) / windowDuration.Seconds()
+	if s.windowBytes == 0 || bitrate < s.minBitrate {
+		return StreamStatusChangeStopped
+	}
+
+	return StreamStatusChangeActive
+}
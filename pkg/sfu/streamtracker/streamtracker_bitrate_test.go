@@ -0,0 +1,130 @@
+package streamtracker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/logger"
+)
+
+func newStreamTrackerBitrate(expectedBitrate int64) StreamTrackerImpl {
+	return NewStreamTrackerBitrate(StreamTrackerBitrateParams{
+		Config: config.StreamTrackerBitrateConfig{
+			ExpectedBitrate: 100_000,
+			MinRatio:        0.1,
+			CycleDuration:   time.Second,
+			WindowCycles:    4,
+		},
+		ExpectedBitrate: expectedBitrate,
+		Logger:          logger.GetLogger(),
+	})
+}
+
+func TestStreamTrackerBitrate(t *testing.T) {
+	t.Run("flips to active on first observe", func(t *testing.T) {
+		s := newStreamTrackerBitrate(0)
+		require.Equal(t, StreamStatusChangeNone, s.CheckStatus())
+		require.Equal(t, StreamStatusChangeActive, s.Observe(false, 0, 100))
+		require.Equal(t, StreamStatusChangeNone, s.Observe(false, 0, 100))
+	})
+
+	t.Run("sparse stream stays active", func(t *testing.T) {
+		s := newStreamTrackerBitrate(0)
+		require.Equal(t, StreamStatusChangeActive, s.Observe(false, 0, 1000))
+
+		// a burst of 5000 bytes every 4 cycles is 10 kbps, at the minimum of 10% of 100 kbps
+		for burst := 0; burst < 3; burst++ {
+			s.Observe(false, 0, 4000)
+			for cycle := 0; cycle < 4; cycle++ {
+				status := s.CheckStatus()
+				if burst == 0 && cycle < 3 {
+					// window not filled yet
+					require.Equal(t, StreamStatusChangeNone, status)
+				} else {
+					require.Equal(t, StreamStatusChangeActive, status)
+				}
+			}
+			s.Observe(false, 0, 1000)
+		}
+	})
+
+	t.Run("stops when sustained bitrate falls", func(t *testing.T) {
+		s := newStreamTrackerBitrate(0)
+		require.Equal(t, StreamStatusChangeActive, s.Observe(false, 0, 10_000))
+		for cycle := 0; cycle < 3; cycle++ {
+			require.Equal(t, StreamStatusChangeNone, s.CheckStatus())
+			s.Observe(false, 0, 10_000)
+		}
+		require.Equal(t, StreamStatusChangeActive, s.CheckStatus())
+
+		// a trickle at 4 kbps, stopped once the window is down to the trickle
+		for cycle := 0; cycle < 3; cycle++ {
+			s.Observe(false, 0, 500)
+			require.Equal(t, StreamStatusChangeActive, s.CheckStatus())
+		}
+		s.Observe(false, 0, 500)
+		require.Equal(t, StreamStatusChangeStopped, s.CheckStatus())
+
+		// back above minimum
+		s.Observe(false, 0, 10_000)
+		require.Equal(t, StreamStatusChangeActive, s.CheckStatus())
+	})
+
+	t.Run("advertised bitrate overrides config", func(t *testing.T) {
+		// minimum is 10% of 1 Mbps
+		s := newStreamTrackerBitrate(1_000_000)
+		require.Equal(t, StreamStatusChangeActive, s.Observe(false, 0, 10_000))
+		for cycle := 0; cycle < 3; cycle++ {
+			s.Observe(false, 0, 10_000)
+			require.Equal(t, StreamStatusChangeNone, s.CheckStatus())
+		}
+		// 40 kbps
+		require.Equal(t, StreamStatusChangeStopped, s.CheckStatus())
+	})
+
+	t.Run("reset waits for window", func(t *testing.T) {
+		s := newStreamTrackerBitrate(0)
+		s.Observe(false, 0, 100_000)
+		for cycle := 0; cycle < 4; cycle++ {
+			s.CheckStatus()
+		}
+
+		s.Reset()
+		require.Equal(t, StreamStatusChangeNone, s.CheckStatus())
+		require.Equal(t, StreamStatusChangeActive, s.Observe(false, 0, 100))
+		for cycle := 0; cycle < 3; cycle++ {
+			require.Equal(t, StreamStatusChangeNone, s.CheckStatus())
+		}
+		require.Equal(t, StreamStatusChangeStopped, s.CheckStatus())
+	})
+}
+
+func TestStreamTrackerBitratePartialLayerConfig(t *testing.T) {
+	// a layer entry replaces the default entry as a whole, fields left out are zero
+	conf, err := config.NewConfig(`
+video:
+  stream_tracker:
+    video:
+      bitrate_tracker:
+        1:
+          min_ratio: 0.2
+`, true, nil, nil)
+	require.NoError(t, err)
+	layerConfig := conf.Video.StreamTracker.Video.BitrateTracker[1]
+	require.Equal(t, time.Duration(0), layerConfig.CycleDuration)
+
+	stb := NewStreamTrackerBitrate(StreamTrackerBitrateParams{
+		Config:          layerConfig,
+		ExpectedBitrate: 500_000,
+		Logger:          logger.GetLogger(),
+	})
+	require.Equal(t, defaultBitrateCycleDuration, stb.GetCheckInterval())
+
+	// check worker ticks at cycle duration, window of one cycle
+	require.Equal(t, StreamStatusChangeActive, stb.Observe(false, 0, 10_000))
+	require.Equal(t, StreamStatusChangeActive, stb.CheckStatus())
+	require.Equal(t, StreamStatusChangeStopped, stb.CheckStatus())
+}
@@ -73,7 +73,7 @@ func (s *StreamTrackerFrame) GetCheckInterval() time.Duration {
 	return checkInterval
 }
 
-func (s *StreamTrackerFrame) Observe(hasMarker bool, ts uint32) StreamStatusChange {
+func (s *StreamTrackerFrame) Observe(hasMarker bool, ts uint32, _pktSize int) StreamStatusChange {
 	if hasMarker {
 		if !s.tsInitialized {
 			s.tsInitialized = true
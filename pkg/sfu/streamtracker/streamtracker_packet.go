@@ -45,7 +45,7 @@ func (s *StreamTrackerPacket) GetCheckInterval() time.Duration {
 	return s.params.Config.CycleDuration
 }
 
-func (s *StreamTrackerPacket) Observe(_hasMarker bool, _ts uint32) StreamStatusChange {
+func (s *StreamTrackerPacket) Observe(_hasMarker bool, _ts uint32, _pktSize int) StreamStatusChange {
 	if !s.initialized {
 		// first packet
 		s.initialized = true
@@ -128,6 +128,31 @@ func (s *StreamTrackerManager) createStreamTrackerFrame(layer int32) streamtrack
 	return streamtracker.NewStreamTrackerFrame(params)
 }
 
+func (s *StreamTrackerManager) createStreamTrackerBitrate(layer int32) streamtracker.StreamTrackerImpl {
+	bitrateTrackerConfig, ok := s.trackerConfig.BitrateTracker[layer]
+	if !ok {
+		return nil
+	}
+
+	params := streamtracker.StreamTrackerBitrateParams{
+		Config:          bitrateTrackerConfig,
+		ExpectedBitrate: s.getAdvertisedBitrate(layer),
+		Logger:          s.logger.WithValues("layer", layer),
+	}
+	return streamtracker.NewStreamTrackerBitrate(params)
+}
+
+// getAdvertisedBitrate returns the bitrate the publisher advertises for a spatial layer, 0 if not known
+func (s *StreamTrackerManager) getAdvertisedBitrate(layer int32) int64 {
+	for _, videoLayer := range s.trackInfo.Layers {
+		if buffer.VideoQualityToSpatialLayer(videoLayer.Quality, s.trackInfo) == layer {
+			return int64(videoLayer.Bitrate)
+		}
+	}
+
+	return 0
+}
+
 func (s *StreamTrackerManager) AddTracker(layer int32) *streamtracker.StreamTracker {
 	bitrateInterval, ok := s.trackerConfig.BitrateReportInterval[layer]
 	if !ok {
@@ -140,6 +165,8 @@ func (s *StreamTrackerManager) AddTracker(layer int32) *streamtracker.StreamTrac
 		trackerImpl = s.createStreamTrackerPacket(layer)
 	case config.StreamTrackerTypeFrame:
 		trackerImpl = s.createStreamTrackerFrame(layer)
+	case config.StreamTrackerTypeBitrate:
+		trackerImpl = s.createStreamTrackerBitrate(layer)
 	}
 	if trackerImpl == nil {
 		return nil
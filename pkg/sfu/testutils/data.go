@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
 	"github.com/pion/webrtc/v3"
 
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
@@ -72,11 +73,36 @@ func GetTestExtPacketVP8(params *TestExtPacketParams, vp8 *buffer.VP8) (*buffer.
 
 // --------------------------------------
 
+// GetTestExtPacketVP9 returns a packet whose payload starts with the given VP9 payload descriptor
+func GetTestExtPacketVP9(params *TestExtPacketParams, vp9Header []byte) (*buffer.ExtPacket, error) {
+	ep, err := GetTestExtPacket(params)
+	if err != nil {
+		return nil, err
+	}
+
+	ep.Packet.Payload = append(append([]byte{}, vp9Header...), ep.Packet.Payload...)
+	var vp9 codecs.VP9Packet
+	if _, err = vp9.Unmarshal(ep.Packet.Payload); err != nil {
+		return nil, err
+	}
+
+	ep.KeyFrame = params.IsKeyFrame
+	ep.Payload = vp9
+	return ep, nil
+}
+
+// --------------------------------------
+
 var TestVP8Codec = webrtc.RTPCodecCapability{
 	MimeType:  "video/vp8",
 	ClockRate: 90000,
 }
 
+var TestH265Codec = webrtc.RTPCodecCapability{
+	MimeType:  "video/h265",
+	ClockRate: 90000,
+}
+
 var TestOpusCodec = webrtc.RTPCodecCapability{
 	MimeType:  "audio/opus",
 	ClockRate: 48000,
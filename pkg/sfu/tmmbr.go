@@ -0,0 +1,196 @@
+package sfu
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/pion/rtcp"
+)
+
+// RFC 5104 section 4.2, Temporary Maximum Media Stream Bit Rate Request/Notification
+const (
+	FormatTMMBR uint8 = 3
+	FormatTMMBN uint8 = 4
+
+	tmmbHeaderLength = 12
+	tmmbItemLength   = 8
+
+	tmmbMaxMantissa = (1 << 17) - 1
+	tmmbMaxOverhead = (1 << 9) - 1
+)
+
+var (
+	ErrTMMBInvalidPacket = errors.New("invalid TMMBR/TMMBN packet")
+)
+
+// TMMBItem is a bitrate bound of a media stream
+type TMMBItem struct {
+	// in TMMBR, SSRC of the media stream the bound applies to.
+	// in TMMBN, SSRC of the owner of the bound, i. e. the requester.
+	SSRC uint32
+	// bits per second
+	Bitrate uint64
+	// per packet overhead in bytes
+	Overhead uint16
+}
+
+func (t TMMBItem) String() string {
+	return fmt.Sprintf("TMMBItem{ssrc: %d, bitrate: %d, overhead: %d}", t.SSRC, t.Bitrate, t.Overhead)
+}
+
+// TMMBR requests media senders to limit bitrate of streams
+type TMMBR struct {
+	SenderSSRC uint32
+	Items      []TMMBItem
+}
+
+func (t *TMMBR) DestinationSSRC() []uint32 {
+	ssrcs := make([]uint32, 0, len(t.Items))
+	for _, item := range t.Items {
+		ssrcs = append(ssrcs, item.SSRC)
+	}
+	return ssrcs
+}
+
+func (t *TMMBR) Marshal() ([]byte, error) {
+	return marshalTMMB(FormatTMMBR, t.SenderSSRC, t.Items)
+}
+
+func (t *TMMBR) Unmarshal(rawPacket []byte) error {
+	senderSSRC, items, err := unmarshalTMMB(FormatTMMBR, rawPacket)
+	if err != nil {
+		return err
+	}
+
+	t.SenderSSRC = senderSSRC
+	t.Items = items
+	return nil
+}
+
+// TMMBN is sent by a media sender to acknowledge TMMBR with the bounds it applies
+type TMMBN struct {
+	SenderSSRC uint32
+	Items      []TMMBItem
+}
+
+func (t *TMMBN) DestinationSSRC() []uint32 {
+	return []uint32{t.SenderSSRC}
+}
+
+func (t *TMMBN) Marshal() ([]byte, error) {
+	return marshalTMMB(FormatTMMBN, t.SenderSSRC, t.Items)
+}
+
+func (t *TMMBN) Unmarshal(rawPacket []byte) error {
+	senderSSRC, items, err := unmarshalTMMB(FormatTMMBN, rawPacket)
+	if err != nil {
+		return err
+	}
+
+	t.SenderSSRC = senderSSRC
+	t.Items = items
+	return nil
+}
+
+// IsTMMBN returns true if an RTCP packet not known to the RTCP parser is a TMMBN
+func IsTMMBN(pkt *rtcp.RawPacket) bool {
+	header := pkt.Header()
+	return header.Type == rtcp.TypeTransportSpecificFeedback && header.Count == FormatTMMBN
+}
+
+// -----------------------------------------------------------
+
+func marshalTMMB(format uint8, senderSSRC uint32, items []TMMBItem) ([]byte, error) {
+	/*
+	    0                   1                   2                   3
+	    0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	   |V=2|P| FMT=3/4 |   PT=205      |             length            |
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	   |                  SSRC of packet sender                        |
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	   |                  SSRC of media source (unused) = 0            |
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	   |                              SSRC                             |
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	   | MxTBR Exp |  MxTBR Mantissa                 |Measured Overhead|
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	*/
+	size := tmmbHeaderLength + tmmbItemLength*len(items)
+	header, err := rtcp.Header{
+		Count:  format,
+		Type:   rtcp.TypeTransportSpecificFeedback,
+		Length: uint16(size/4 - 1),
+	}.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, size)
+	copy(buf, header)
+	binary.BigEndian.PutUint32(buf[4:], senderSSRC)
+
+	offset := tmmbHeaderLength
+	for _, item := range items {
+		exp, mantissa := tmmbEncodeBitrate(item.Bitrate)
+		overhead := uint32(item.Overhead)
+		if overhead > tmmbMaxOverhead {
+			overhead = tmmbMaxOverhead
+		}
+
+		binary.BigEndian.PutUint32(buf[offset:], item.SSRC)
+		binary.BigEndian.PutUint32(buf[offset+4:], exp<<26|mantissa<<9|overhead)
+		offset += tmmbItemLength
+	}
+
+	return buf, nil
+}
+
+func unmarshalTMMB(format uint8, rawPacket []byte) (uint32, []TMMBItem, error) {
+	var header rtcp.Header
+	if err := header.Unmarshal(rawPacket); err != nil {
+		return 0, nil, err
+	}
+
+	size := (int(header.Length) + 1) * 4
+	if header.Type != rtcp.TypeTransportSpecificFeedback ||
+		header.Count != format ||
+		size < tmmbHeaderLength ||
+		size > len(rawPacket) ||
+		(size-tmmbHeaderLength)%tmmbItemLength != 0 {
+		return 0, nil, ErrTMMBInvalidPacket
+	}
+
+	senderSSRC := binary.BigEndian.Uint32(rawPacket[4:])
+
+	var items []TMMBItem
+	for offset := tmmbHeaderLength; offset < size; offset += tmmbItemLength {
+		word := binary.BigEndian.Uint32(rawPacket[offset+4:])
+		items = append(items, TMMBItem{
+			SSRC:     binary.BigEndian.Uint32(rawPacket[offset:]),
+			Bitrate:  uint64(word>>9&tmmbMaxMantissa) << (word >> 26),
+			Overhead: uint16(word & tmmbMaxOverhead),
+		})
+	}
+
+	return senderSSRC, items, nil
+}
+
+// TMMBBitrate returns the bitrate as carried in a TMMBR/TMMBN
+func TMMBBitrate(bitrate uint64) uint64 {
+	exp, mantissa := tmmbEncodeBitrate(bitrate)
+	return uint64(mantissa) << exp
+}
+
+// tmmbEncodeBitrate returns the smallest exponent that fits the bitrate in the mantissa,
+// rounding down as a bound should not be exceeded
+func tmmbEncodeBitrate(bitrate uint64) (uint32, uint32) {
+	// a 64 bit bitrate always fits with exponent less than 64
+	exp := uint32(0)
+	for bitrate > tmmbMaxMantissa {
+		bitrate >>= 1
+		exp++
+	}
+	return exp, uint32(bitrate)
+}
@@ -0,0 +1,64 @@
+package sfu
+
+import (
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTMMB(t *testing.T) {
+	tmmbr := &TMMBR{
+		SenderSSRC: 0x1234,
+		Items: []TMMBItem{
+			{SSRC: 0xabcd, Bitrate: 300_000, Overhead: 40},
+			{SSRC: 0xbcde, Bitrate: 0},
+		},
+	}
+	buf, err := tmmbr.Marshal()
+	require.NoError(t, err)
+	require.Len(t, buf, 28)
+
+	var decodedTMMBR TMMBR
+	require.NoError(t, decodedTMMBR.Unmarshal(buf))
+	require.Equal(t, *tmmbr, decodedTMMBR)
+	require.Equal(t, []uint32{0xabcd, 0xbcde}, decodedTMMBR.DestinationSSRC())
+
+	// not a TMMBN
+	var tmmbn TMMBN
+	require.ErrorIs(t, tmmbn.Unmarshal(buf), ErrTMMBInvalidPacket)
+
+	tmmbn = TMMBN{
+		SenderSSRC: 0xabcd,
+		Items:      []TMMBItem{{SSRC: 0x1234, Bitrate: 300_000, Overhead: 40}},
+	}
+	buf, err = tmmbn.Marshal()
+	require.NoError(t, err)
+
+	// parsed as unknown by the RTCP parser
+	pkts, err := rtcp.Unmarshal(buf)
+	require.NoError(t, err)
+	require.Len(t, pkts, 1)
+	raw, ok := pkts[0].(*rtcp.RawPacket)
+	require.True(t, ok)
+	require.True(t, IsTMMBN(raw))
+
+	var decodedTMMBN TMMBN
+	require.NoError(t, decodedTMMBN.Unmarshal(*raw))
+	require.Equal(t, tmmbn, decodedTMMBN)
+
+	// truncated
+	require.Error(t, decodedTMMBN.Unmarshal(buf[:len(buf)-4]))
+}
+
+func TestTMMBEncodeBitrate(t *testing.T) {
+	exp, mantissa := tmmbEncodeBitrate(100_000)
+	require.Equal(t, uint32(0), exp)
+	require.Equal(t, uint32(100_000), mantissa)
+
+	// rounded down to fit mantissa
+	exp, mantissa = tmmbEncodeBitrate(2_500_001)
+	require.Equal(t, uint32(5), exp)
+	require.Equal(t, uint32(2_500_001>>5), mantissa)
+	require.LessOrEqual(t, uint64(mantissa)<<exp, uint64(2_500_001))
+}
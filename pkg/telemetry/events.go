@@ -14,6 +14,10 @@ import (
 )
 
 func (t *telemetryService) NotifyEvent(ctx context.Context, event *livekit.WebhookEvent) {
+	t.NotifyEventWithExtensions(ctx, event, nil)
+}
+
+func (t *telemetryService) NotifyEventWithExtensions(ctx context.Context, event *livekit.WebhookEvent, extensions WebhookExtensions) {
 	if t.notifier == nil {
 		return
 	}
@@ -21,7 +25,13 @@ func (t *telemetryService) NotifyEvent(ctx context.Context, event *livekit.Webho
 	event.CreatedAt = time.Now().Unix()
 	event.Id = utils.NewGuid("EV_")
 
-	if err := t.notifier.QueueNotify(ctx, event); err != nil {
+	var err error
+	if en, ok := t.notifier.(ExtendedQueuedNotifier); ok && len(extensions) != 0 {
+		err = en.QueueNotifyWithExtensions(ctx, event, extensions)
+	} else {
+		err = t.notifier.QueueNotify(ctx, event)
+	}
+	if err != nil {
 		logger.Warnw("failed to notify webhook", err, "event", event.Event)
 	}
 }
@@ -41,12 +51,12 @@ func (t *telemetryService) RoomStarted(ctx context.Context, room *livekit.Room)
 	})
 }
 
-func (t *telemetryService) RoomEnded(ctx context.Context, room *livekit.Room) {
+func (t *telemetryService) RoomEnded(ctx context.Context, room *livekit.Room, extensions WebhookExtensions) {
 	t.enqueue(func() {
-		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+		t.NotifyEventWithExtensions(ctx, &livekit.WebhookEvent{
 			Event: webhook.EventRoomFinished,
 			Room:  room,
-		})
+		}, extensions)
 
 		t.SendEvent(ctx, &livekit.AnalyticsEvent{
 			Type:      livekit.AnalyticsEventType_ROOM_ENDED,
@@ -116,6 +126,9 @@ func (t *telemetryService) ParticipantActive(
 			prometheus.AddParticipant()
 		}
 		worker.SetConnected()
+		if clientMeta != nil {
+			worker.SetICEConnectionType(clientMeta.ConnectionType)
+		}
 
 		ev := newParticipantEvent(livekit.AnalyticsEventType_PARTICIPANT_ACTIVE, room, participant)
 		ev.ClientMeta = clientMeta
@@ -131,6 +144,10 @@ func (t *telemetryService) ParticipantResumed(
 	reason livekit.ReconnectReason,
 ) {
 	t.enqueue(func() {
+		if worker, ok := t.getWorker(livekit.ParticipantID(participant.Sid)); ok {
+			worker.OnReconnected()
+		}
+
 		ev := newParticipantEvent(livekit.AnalyticsEventType_PARTICIPANT_RESUMED, room, participant)
 		ev.ClientMeta = &livekit.AnalyticsClientMeta{
 			Node:            string(nodeID),
@@ -140,18 +157,37 @@ func (t *telemetryService) ParticipantResumed(
 	})
 }
 
+func (t *telemetryService) ParticipantReconnected(_ context.Context, _ *livekit.Room, participant *livekit.ParticipantInfo) {
+	t.enqueue(func() {
+		if worker, ok := t.getWorker(livekit.ParticipantID(participant.Sid)); ok {
+			worker.OnReconnected()
+		}
+	})
+}
+
+func (t *telemetryService) ParticipantICEReconnected(_ context.Context, participantID livekit.ParticipantID, connectionType string) {
+	t.enqueue(func() {
+		if worker, ok := t.getWorker(participantID); ok {
+			worker.SetICEConnectionType(connectionType)
+		}
+	})
+}
+
 func (t *telemetryService) ParticipantLeft(ctx context.Context,
 	room *livekit.Room,
 	participant *livekit.ParticipantInfo,
 	shouldSendEvent bool,
+	extensions WebhookExtensions,
 ) {
 	t.enqueue(func() {
 		isConnected := false
 		hasWorker := false
+		var sessionQuality *SessionQuality
 		if worker, ok := t.getWorker(livekit.ParticipantID(participant.Sid)); ok {
 			hasWorker = true
 			isConnected = worker.IsConnected()
 			worker.Close()
+			sessionQuality = worker.SessionQuality()
 		}
 
 		if hasWorker {
@@ -160,17 +196,67 @@ func (t *telemetryService) ParticipantLeft(ctx context.Context,
 		}
 
 		if isConnected && shouldSendEvent {
-			t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			if sessionQuality != nil {
+				withSessionQuality := make(WebhookExtensions, len(extensions)+1)
+				for name, extension := range extensions {
+					withSessionQuality[name] = extension
+				}
+				withSessionQuality[WebhookExtensionSessionQuality] = sessionQuality
+				extensions = withSessionQuality
+			}
+
+			t.NotifyEventWithExtensions(ctx, &livekit.WebhookEvent{
 				Event:       webhook.EventParticipantLeft,
 				Room:        room,
 				Participant: participant,
-			})
+			}, extensions)
 
 			t.SendEvent(ctx, newParticipantEvent(livekit.AnalyticsEventType_PARTICIPANT_LEFT, room, participant))
 		}
 	})
 }
 
+func (t *telemetryService) ParticipantLobbyJoined(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo) {
+	t.notifyLobbyEvent(ctx, EventParticipantLobbyJoined, room, participant)
+}
+
+func (t *telemetryService) ParticipantLobbyApproved(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo) {
+	t.notifyLobbyEvent(ctx, EventParticipantLobbyApproved, room, participant)
+}
+
+func (t *telemetryService) ParticipantLobbyDenied(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo) {
+	t.notifyLobbyEvent(ctx, EventParticipantLobbyDenied, room, participant)
+}
+
+func (t *telemetryService) ParticipantQualityAlert(
+	ctx context.Context,
+	room *livekit.Room,
+	participant *livekit.ParticipantInfo,
+	alert *QualityAlert,
+) {
+	t.enqueue(func() {
+		prometheus.RecordQualityAlert(alert.Rule, alert.Metric)
+
+		t.NotifyEventWithExtensions(ctx, &livekit.WebhookEvent{
+			Event:       EventParticipantQualityAlert,
+			Room:        room,
+			Participant: participant,
+		}, WebhookExtensions{
+			WebhookExtensionQualityAlert: alert,
+		})
+	})
+}
+
+func (t *telemetryService) notifyLobbyEvent(ctx context.Context, event string, room *livekit.Room, participant *livekit.ParticipantInfo) {
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event:       event,
+			Room:        room,
+			Participant: participant,
+		})
+	})
+}
+
 func (t *telemetryService) TrackPublishRequested(
 	ctx context.Context,
 	participantID livekit.ParticipantID,
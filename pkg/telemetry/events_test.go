@@ -70,7 +70,7 @@ func Test_OnParticipantLeft_EventIsSent(t *testing.T) {
 
 	// do
 	fixture.sut.ParticipantActive(context.Background(), room, participantInfo, &livekit.AnalyticsClientMeta{})
-	fixture.sut.ParticipantLeft(context.Background(), room, participantInfo, true)
+	fixture.sut.ParticipantLeft(context.Background(), room, participantInfo, true, nil)
 	time.Sleep(time.Millisecond * 500)
 
 	// test
@@ -0,0 +1,73 @@
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	canaryJoinTime     prometheus.Histogram
+	canaryMediaLatency prometheus.Histogram
+	canaryProbes       *prometheus.CounterVec
+	canaryUp           prometheus.Gauge
+)
+
+func initCanaryStats(nodeID string, nodeType livekit.NodeType, env string) {
+	canaryJoinTime = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "canary",
+		Name:        "join_time_ms",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Buckets:     []float64{50, 100, 200, 300, 500, 750, 1000, 2000, 5000},
+	})
+	canaryMediaLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "canary",
+		Name:        "media_latency_ms",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Buckets:     []float64{1, 2, 5, 10, 20, 50, 100, 200, 500},
+	})
+	canaryProbes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "canary",
+		Name:        "probes",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"status"})
+	canaryUp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "canary",
+		Name:        "up",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	})
+
+	prometheus.MustRegister(canaryJoinTime)
+	prometheus.MustRegister(canaryMediaLatency)
+	prometheus.MustRegister(canaryProbes)
+	prometheus.MustRegister(canaryUp)
+}
+
+// RecordCanaryProbe records the outcome of a canary probe, join time and media latency are recorded when measured
+func RecordCanaryProbe(success bool, joinTime time.Duration, mediaLatency time.Duration) {
+	if joinTime > 0 {
+		canaryJoinTime.Observe(float64(joinTime.Milliseconds()))
+	}
+	if mediaLatency > 0 {
+		canaryMediaLatency.Observe(float64(mediaLatency.Microseconds()) / 1000)
+	}
+	if success {
+		canaryProbes.WithLabelValues("success").Inc()
+	} else {
+		canaryProbes.WithLabelValues("failure").Inc()
+	}
+}
+
+func SetCanaryUp(up bool) {
+	if up {
+		canaryUp.Set(1)
+	} else {
+		canaryUp.Set(0)
+	}
+}
@@ -158,6 +158,11 @@ func IncrementBytes(direction Direction, count uint64, retransmit bool) {
 	}
 }
 
+// BytesOut returns total bytes sent by the node
+func BytesOut() uint64 {
+	return bytesOut.Load()
+}
+
 func IncrementRTCP(direction Direction, nack, pli, fir uint32) {
 	if nack > 0 {
 		promNackTotal.WithLabelValues(string(direction)).Add(float64(nack))
@@ -10,6 +10,7 @@ var (
 	qualityRating prometheus.Histogram
 	qualityScore  prometheus.Histogram
 	qualityDrop   *prometheus.CounterVec
+	qualityAlert  *prometheus.CounterVec
 )
 
 func initQualityStats(nodeID string, nodeType livekit.NodeType, env string) {
@@ -34,9 +35,17 @@ func initQualityStats(nodeID string, nodeType livekit.NodeType, env string) {
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 	}, []string{"direction"})
 
+	qualityAlert = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "quality",
+		Name:        "alert",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"rule", "metric"})
+
 	prometheus.MustRegister(qualityRating)
 	prometheus.MustRegister(qualityScore)
 	prometheus.MustRegister(qualityDrop)
+	prometheus.MustRegister(qualityAlert)
 }
 
 func RecordQuality(rating livekit.ConnectionQuality, score float32, numUpDrops int, numDownDrops int) {
@@ -45,3 +54,7 @@ func RecordQuality(rating livekit.ConnectionQuality, score float32, numUpDrops i
 	qualityDrop.WithLabelValues("up").Add(float64(numUpDrops))
 	qualityDrop.WithLabelValues("down").Add(float64(numDownDrops))
 }
+
+func RecordQualityAlert(rule string, metric string) {
+	qualityAlert.WithLabelValues(rule, metric).Inc()
+}
@@ -0,0 +1,15 @@
+package telemetry
+
+// QualityAlert is raised when a participant's connection matches a quality alert rule for the rule's duration
+type QualityAlert struct {
+	Rule   string `json:"rule"`
+	Metric string `json:"metric"`
+	// participant the alert is about
+	ParticipantSid string `json:"participantSid"`
+	Identity       string `json:"identity"`
+	// connection quality for quality rules, loss percentage for loss rules
+	Quality        string  `json:"quality,omitempty"`
+	LossPercentage float32 `json:"lossPercentage,omitempty"`
+	// how long the rule has matched
+	DurationMs int64 `json:"durationMs"`
+}
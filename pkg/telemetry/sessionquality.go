@@ -0,0 +1,189 @@
+package telemetry
+
+import (
+	"strings"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// SessionQuality summarizes the media quality a participant experienced over its session,
+// across tracks published and subscribed
+type SessionQuality struct {
+	// mean opinion score of stats intervals with a score
+	AvgMOS float32 `json:"avgMos"`
+	MinMOS float32 `json:"minMos"`
+
+	Packets        uint64  `json:"packets"`
+	PacketsLost    uint64  `json:"packetsLost"`
+	LossPercentage float32 `json:"lossPercentage"`
+
+	AvgJitterUs uint32 `json:"avgJitterUs"`
+	MaxJitterUs uint32 `json:"maxJitterUs"`
+	AvgRttMs    uint32 `json:"avgRttMs"`
+	MaxRttMs    uint32 `json:"maxRttMs"`
+
+	// time video was sent/received at each spatial layer, summed over tracks
+	VideoLayerTimeMs map[int32]int64 `json:"videoLayerTimeMs"`
+	// number of times video stalled, frames falling to less than half while losing packets or requesting key frames
+	Freezes uint32 `json:"freezes"`
+
+	// number of times the session was resumed, plus one when it replaced a previous session of the same identity
+	// still in the room, as clients do when they fully reconnect. Full reconnects after the previous session has
+	// left the room are not counted.
+	Reconnects uint32 `json:"reconnects"`
+	// candidate type of the last ICE connection
+	ICEConnectionType string `json:"iceConnectionType,omitempty"`
+}
+
+type sessionQualityTrackKey struct {
+	trackID   livekit.TrackID
+	direction livekit.StreamType
+	mime      string
+}
+
+type sessionQualityTrackState struct {
+	lastStatAt time.Time
+	lastFrames uint32
+}
+
+type sessionQualityAggregator struct {
+	scoreSum   float64
+	numScores  int
+	minScore   float32
+	jitterSum  uint64
+	numJitters int
+	maxJitter  uint32
+	rttSum     uint64
+	numRtts    int
+	maxRtt     uint32
+
+	packets          uint64
+	packetsLost      uint64
+	videoLayerTimeMs map[int32]int64
+	freezes          uint32
+
+	reconnects        uint32
+	iceConnectionType string
+
+	tracks map[sessionQualityTrackKey]*sessionQualityTrackState
+}
+
+func newSessionQualityAggregator() *sessionQualityAggregator {
+	return &sessionQualityAggregator{
+		videoLayerTimeMs: make(map[int32]int64),
+		tracks:           make(map[sessionQualityTrackKey]*sessionQualityTrackState),
+	}
+}
+
+func (s *sessionQualityAggregator) addStat(trackID livekit.TrackID, direction livekit.StreamType, stat *livekit.AnalyticsStat, at time.Time) {
+	// data and signal stats have no media type
+	if stat.Mime == "" || !isValid(stat) {
+		return
+	}
+
+	if stat.Score > 0 {
+		if s.numScores == 0 || stat.Score < s.minScore {
+			s.minScore = stat.Score
+		}
+		s.scoreSum += float64(stat.Score)
+		s.numScores++
+	}
+
+	frames := uint32(0)
+	packetsLost := uint32(0)
+	plis := uint32(0)
+	maxLayer := int32(-1)
+	for _, stream := range stat.Streams {
+		s.packets += uint64(stream.PrimaryPackets) + uint64(stream.PacketsLost)
+		s.packetsLost += uint64(stream.PacketsLost)
+
+		if stream.PrimaryPackets != 0 {
+			s.jitterSum += uint64(stream.Jitter)
+			s.numJitters++
+			if stream.Jitter > s.maxJitter {
+				s.maxJitter = stream.Jitter
+			}
+		}
+		if stream.Rtt != 0 {
+			s.rttSum += uint64(stream.Rtt)
+			s.numRtts++
+			if stream.Rtt > s.maxRtt {
+				s.maxRtt = stream.Rtt
+			}
+		}
+
+		frames += stream.Frames
+		packetsLost += stream.PacketsLost
+		plis += stream.Plis + stream.Firs
+		if stream.Frames != 0 && maxLayer < 0 {
+			// streams without layers are single layer
+			maxLayer = 0
+		}
+		for _, layer := range stream.VideoLayers {
+			if layer.Layer > maxLayer {
+				maxLayer = layer.Layer
+			}
+		}
+	}
+
+	if !strings.HasPrefix(strings.ToLower(stat.Mime), "video/") {
+		return
+	}
+
+	key := sessionQualityTrackKey{trackID: trackID, direction: direction, mime: stat.Mime}
+	track := s.tracks[key]
+	if track == nil {
+		// interval of the first stat is unknown
+		s.tracks[key] = &sessionQualityTrackState{lastStatAt: at, lastFrames: frames}
+		return
+	}
+
+	if maxLayer >= 0 {
+		s.videoLayerTimeMs[maxLayer] += at.Sub(track.lastStatAt).Milliseconds()
+	}
+	// a muted or paused stream stops without loss or key frame requests
+	if track.lastFrames != 0 && frames*2 < track.lastFrames && (packetsLost != 0 || plis != 0) {
+		s.freezes++
+	}
+	track.lastStatAt = at
+	track.lastFrames = frames
+}
+
+func (s *sessionQualityAggregator) addReconnect() {
+	s.reconnects++
+}
+
+func (s *sessionQualityAggregator) setICEConnectionType(iceConnectionType string) {
+	s.iceConnectionType = iceConnectionType
+}
+
+func (s *sessionQualityAggregator) toSessionQuality() *SessionQuality {
+	sq := &SessionQuality{
+		MinMOS:            s.minScore,
+		Packets:           s.packets,
+		PacketsLost:       s.packetsLost,
+		MaxJitterUs:       s.maxJitter,
+		MaxRttMs:          s.maxRtt,
+		VideoLayerTimeMs:  make(map[int32]int64, len(s.videoLayerTimeMs)),
+		Freezes:           s.freezes,
+		Reconnects:        s.reconnects,
+		ICEConnectionType: s.iceConnectionType,
+	}
+	if s.numScores != 0 {
+		sq.AvgMOS = float32(s.scoreSum / float64(s.numScores))
+	}
+	if s.packets != 0 {
+		sq.LossPercentage = float32(s.packetsLost) * 100 / float32(s.packets)
+	}
+	if s.numJitters != 0 {
+		sq.AvgJitterUs = uint32(s.jitterSum / uint64(s.numJitters))
+	}
+	if s.numRtts != 0 {
+		sq.AvgRttMs = uint32(s.rttSum / uint64(s.numRtts))
+	}
+	for layer, ms := range s.videoLayerTimeMs {
+		sq.VideoLayerTimeMs[layer] = ms
+	}
+	return sq
+}
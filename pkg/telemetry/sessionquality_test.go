@@ -0,0 +1,124 @@
+package telemetry_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
+)
+
+type extensionsNotifier struct {
+	lock       sync.Mutex
+	events     []*livekit.WebhookEvent
+	extensions []telemetry.WebhookExtensions
+}
+
+func (n *extensionsNotifier) QueueNotify(ctx context.Context, event *livekit.WebhookEvent) error {
+	return n.QueueNotifyWithExtensions(ctx, event, nil)
+}
+
+func (n *extensionsNotifier) QueueNotifyWithExtensions(_ context.Context, event *livekit.WebhookEvent, extensions telemetry.WebhookExtensions) error {
+	n.lock.Lock()
+	n.events = append(n.events, event)
+	n.extensions = append(n.extensions, extensions)
+	n.lock.Unlock()
+	return nil
+}
+
+func (n *extensionsNotifier) Stop(_ bool) {}
+
+func (n *extensionsNotifier) getExtensions(event string) telemetry.WebhookExtensions {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for i, ev := range n.events {
+		if ev.Event == event {
+			return n.extensions[i]
+		}
+	}
+	return nil
+}
+
+func TestSessionQualityOnParticipantLeft(t *testing.T) {
+	notifier := &extensionsNotifier{}
+	sut := telemetry.NewTelemetryService(notifier, &telemetryfakes.FakeAnalyticsService{})
+
+	room := &livekit.Room{Sid: "RoomSid", Name: "RoomName"}
+	partSID := livekit.ParticipantID("part1")
+	participantInfo := &livekit.ParticipantInfo{Sid: string(partSID)}
+	sut.ParticipantJoined(context.Background(), room, participantInfo, nil, nil, true)
+	sut.ParticipantActive(context.Background(), room, participantInfo, &livekit.AnalyticsClientMeta{ConnectionType: "turn"})
+
+	key := telemetry.StatsKeyForTrack(livekit.StreamType_DOWNSTREAM, partSID, "TR_video", livekit.TrackSource_CAMERA, livekit.TrackType_VIDEO)
+	stats := []*livekit.AnalyticsStat{
+		{
+			Score: 4.5,
+			Mime:  "video/VP8",
+			Streams: []*livekit.AnalyticsStream{{
+				PrimaryPackets: 100,
+				Frames:         30,
+				Rtt:            40,
+				Jitter:         2000,
+				VideoLayers:    []*livekit.AnalyticsVideoLayer{{Layer: 2, Packets: 100, Bytes: 10000, Frames: 30}},
+			}},
+		},
+		{
+			// stalled with loss
+			Score: 2.5,
+			Mime:  "video/VP8",
+			Streams: []*livekit.AnalyticsStream{{
+				PrimaryPackets: 80,
+				PacketsLost:    20,
+				Frames:         5,
+				Rtt:            60,
+				Jitter:         4000,
+				Plis:           1,
+				VideoLayers:    []*livekit.AnalyticsVideoLayer{{Layer: 1, Packets: 80, Bytes: 5000, Frames: 5}},
+			}},
+		},
+	}
+	for _, stat := range stats {
+		sut.TrackStats(key, stat)
+		time.Sleep(10 * time.Millisecond)
+	}
+	sut.ParticipantResumed(context.Background(), room, participantInfo, "node", livekit.ReconnectReason_RR_SIGNAL_DISCONNECTED)
+	// full reconnects are counted too, and the connection type follows ICE reconnects
+	sut.ParticipantReconnected(context.Background(), room, participantInfo)
+	sut.ParticipantICEReconnected(context.Background(), partSID, "udp")
+	sut.ParticipantLeft(context.Background(), room, participantInfo, true, telemetry.WebhookExtensions{
+		telemetry.WebhookExtensionParticipation: "participation",
+	})
+
+	var extensions telemetry.WebhookExtensions
+	require.Eventually(t, func() bool {
+		extensions = notifier.getExtensions(webhook.EventParticipantLeft)
+		return extensions != nil
+	}, time.Second, 10*time.Millisecond)
+
+	require.Equal(t, "participation", extensions[telemetry.WebhookExtensionParticipation])
+	sq, ok := extensions[telemetry.WebhookExtensionSessionQuality].(*telemetry.SessionQuality)
+	require.True(t, ok)
+	require.InDelta(t, 3.5, sq.AvgMOS, 0.001)
+	require.Equal(t, float32(2.5), sq.MinMOS)
+	require.Equal(t, uint64(200), sq.Packets)
+	require.Equal(t, uint64(20), sq.PacketsLost)
+	require.InDelta(t, 10.0, sq.LossPercentage, 0.001)
+	require.Equal(t, uint32(3000), sq.AvgJitterUs)
+	require.Equal(t, uint32(4000), sq.MaxJitterUs)
+	require.Equal(t, uint32(50), sq.AvgRttMs)
+	require.Equal(t, uint32(60), sq.MaxRttMs)
+	// time is accounted from the second stat on
+	require.Contains(t, sq.VideoLayerTimeMs, int32(1))
+	require.NotContains(t, sq.VideoLayerTimeMs, int32(2))
+	require.Equal(t, uint32(1), sq.Freezes)
+	require.Equal(t, uint32(2), sq.Reconnects)
+	require.Equal(t, "udp", sq.ICEConnectionType)
+}
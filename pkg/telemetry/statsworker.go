@@ -27,6 +27,7 @@ type StatsWorker struct {
 	outgoingPerTrack map[livekit.TrackID][]*livekit.AnalyticsStat
 	incomingPerTrack map[livekit.TrackID][]*livekit.AnalyticsStat
 	closedAt         time.Time
+	sessionQuality   *sessionQualityAggregator
 }
 
 func newStatsWorker(
@@ -46,6 +47,7 @@ func newStatsWorker(
 		participantIdentity: identity,
 		outgoingPerTrack:    make(map[livekit.TrackID][]*livekit.AnalyticsStat),
 		incomingPerTrack:    make(map[livekit.TrackID][]*livekit.AnalyticsStat),
+		sessionQuality:      newSessionQualityAggregator(),
 	}
 	return s
 }
@@ -57,9 +59,30 @@ func (s *StatsWorker) OnTrackStat(trackID livekit.TrackID, direction livekit.Str
 	} else {
 		s.incomingPerTrack[trackID] = append(s.incomingPerTrack[trackID], stat)
 	}
+	s.sessionQuality.addStat(trackID, direction, stat, time.Now())
 	s.lock.Unlock()
 }
 
+func (s *StatsWorker) OnReconnected() {
+	s.lock.Lock()
+	s.sessionQuality.addReconnect()
+	s.lock.Unlock()
+}
+
+func (s *StatsWorker) SetICEConnectionType(iceConnectionType string) {
+	s.lock.Lock()
+	s.sessionQuality.setICEConnectionType(iceConnectionType)
+	s.lock.Unlock()
+}
+
+// SessionQuality returns the quality summary of the participant session so far
+func (s *StatsWorker) SessionQuality() *SessionQuality {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.sessionQuality.toSessionQuality()
+}
+
 func (s *StatsWorker) ParticipantID() livekit.ParticipantID {
 	return s.participantID
 }
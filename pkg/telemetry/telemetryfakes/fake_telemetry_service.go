@@ -38,6 +38,13 @@ type FakeTelemetryService struct {
 		arg1 context.Context
 		arg2 *livekit.WebhookEvent
 	}
+	NotifyEventWithExtensionsStub        func(context.Context, *livekit.WebhookEvent, telemetry.WebhookExtensions)
+	notifyEventWithExtensionsMutex       sync.RWMutex
+	notifyEventWithExtensionsArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.WebhookEvent
+		arg3 telemetry.WebhookExtensions
+	}
 	ParticipantActiveStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, *livekit.AnalyticsClientMeta)
 	participantActiveMutex       sync.RWMutex
 	participantActiveArgsForCall []struct {
@@ -46,6 +53,13 @@ type FakeTelemetryService struct {
 		arg3 *livekit.ParticipantInfo
 		arg4 *livekit.AnalyticsClientMeta
 	}
+	ParticipantICEReconnectedStub        func(context.Context, livekit.ParticipantID, string)
+	participantICEReconnectedMutex       sync.RWMutex
+	participantICEReconnectedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 string
+	}
 	ParticipantJoinedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, *livekit.ClientInfo, *livekit.AnalyticsClientMeta, bool)
 	participantJoinedMutex       sync.RWMutex
 	participantJoinedArgsForCall []struct {
@@ -56,13 +70,50 @@ type FakeTelemetryService struct {
 		arg5 *livekit.AnalyticsClientMeta
 		arg6 bool
 	}
-	ParticipantLeftStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, bool)
+	ParticipantLeftStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, bool, telemetry.WebhookExtensions)
 	participantLeftMutex       sync.RWMutex
 	participantLeftArgsForCall []struct {
 		arg1 context.Context
 		arg2 *livekit.Room
 		arg3 *livekit.ParticipantInfo
 		arg4 bool
+		arg5 telemetry.WebhookExtensions
+	}
+	ParticipantLobbyApprovedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo)
+	participantLobbyApprovedMutex       sync.RWMutex
+	participantLobbyApprovedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}
+	ParticipantLobbyDeniedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo)
+	participantLobbyDeniedMutex       sync.RWMutex
+	participantLobbyDeniedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}
+	ParticipantLobbyJoinedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo)
+	participantLobbyJoinedMutex       sync.RWMutex
+	participantLobbyJoinedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}
+	ParticipantQualityAlertStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, *telemetry.QualityAlert)
+	participantQualityAlertMutex       sync.RWMutex
+	participantQualityAlertArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 *telemetry.QualityAlert
+	}
+	ParticipantReconnectedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo)
+	participantReconnectedMutex       sync.RWMutex
+	participantReconnectedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
 	}
 	ParticipantResumedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, livekit.NodeID, livekit.ReconnectReason)
 	participantResumedMutex       sync.RWMutex
@@ -73,11 +124,12 @@ type FakeTelemetryService struct {
 		arg4 livekit.NodeID
 		arg5 livekit.ReconnectReason
 	}
-	RoomEndedStub        func(context.Context, *livekit.Room)
+	RoomEndedStub        func(context.Context, *livekit.Room, telemetry.WebhookExtensions)
 	roomEndedMutex       sync.RWMutex
 	roomEndedArgsForCall []struct {
 		arg1 context.Context
 		arg2 *livekit.Room
+		arg3 telemetry.WebhookExtensions
 	}
 	RoomStartedStub        func(context.Context, *livekit.Room)
 	roomStartedMutex       sync.RWMutex
@@ -370,6 +422,40 @@ func (fake *FakeTelemetryService) NotifyEventArgsForCall(i int) (context.Context
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeTelemetryService) NotifyEventWithExtensions(arg1 context.Context, arg2 *livekit.WebhookEvent, arg3 telemetry.WebhookExtensions) {
+	fake.notifyEventWithExtensionsMutex.Lock()
+	fake.notifyEventWithExtensionsArgsForCall = append(fake.notifyEventWithExtensionsArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.WebhookEvent
+		arg3 telemetry.WebhookExtensions
+	}{arg1, arg2, arg3})
+	stub := fake.NotifyEventWithExtensionsStub
+	fake.recordInvocation("NotifyEventWithExtensions", []interface{}{arg1, arg2, arg3})
+	fake.notifyEventWithExtensionsMutex.Unlock()
+	if stub != nil {
+		fake.NotifyEventWithExtensionsStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeTelemetryService) NotifyEventWithExtensionsCallCount() int {
+	fake.notifyEventWithExtensionsMutex.RLock()
+	defer fake.notifyEventWithExtensionsMutex.RUnlock()
+	return len(fake.notifyEventWithExtensionsArgsForCall)
+}
+
+func (fake *FakeTelemetryService) NotifyEventWithExtensionsCalls(stub func(context.Context, *livekit.WebhookEvent, telemetry.WebhookExtensions)) {
+	fake.notifyEventWithExtensionsMutex.Lock()
+	defer fake.notifyEventWithExtensionsMutex.Unlock()
+	fake.NotifyEventWithExtensionsStub = stub
+}
+
+func (fake *FakeTelemetryService) NotifyEventWithExtensionsArgsForCall(i int) (context.Context, *livekit.WebhookEvent, telemetry.WebhookExtensions) {
+	fake.notifyEventWithExtensionsMutex.RLock()
+	defer fake.notifyEventWithExtensionsMutex.RUnlock()
+	argsForCall := fake.notifyEventWithExtensionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
 func (fake *FakeTelemetryService) ParticipantActive(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 *livekit.AnalyticsClientMeta) {
 	fake.participantActiveMutex.Lock()
 	fake.participantActiveArgsForCall = append(fake.participantActiveArgsForCall, struct {
@@ -405,6 +491,40 @@ func (fake *FakeTelemetryService) ParticipantActiveArgsForCall(i int) (context.C
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
+func (fake *FakeTelemetryService) ParticipantICEReconnected(arg1 context.Context, arg2 livekit.ParticipantID, arg3 string) {
+	fake.participantICEReconnectedMutex.Lock()
+	fake.participantICEReconnectedArgsForCall = append(fake.participantICEReconnectedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.ParticipantICEReconnectedStub
+	fake.recordInvocation("ParticipantICEReconnected", []interface{}{arg1, arg2, arg3})
+	fake.participantICEReconnectedMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantICEReconnectedStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantICEReconnectedCallCount() int {
+	fake.participantICEReconnectedMutex.RLock()
+	defer fake.participantICEReconnectedMutex.RUnlock()
+	return len(fake.participantICEReconnectedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantICEReconnectedCalls(stub func(context.Context, livekit.ParticipantID, string)) {
+	fake.participantICEReconnectedMutex.Lock()
+	defer fake.participantICEReconnectedMutex.Unlock()
+	fake.ParticipantICEReconnectedStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantICEReconnectedArgsForCall(i int) (context.Context, livekit.ParticipantID, string) {
+	fake.participantICEReconnectedMutex.RLock()
+	defer fake.participantICEReconnectedMutex.RUnlock()
+	argsForCall := fake.participantICEReconnectedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
 func (fake *FakeTelemetryService) ParticipantJoined(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 *livekit.ClientInfo, arg5 *livekit.AnalyticsClientMeta, arg6 bool) {
 	fake.participantJoinedMutex.Lock()
 	fake.participantJoinedArgsForCall = append(fake.participantJoinedArgsForCall, struct {
@@ -424,6 +544,8 @@ func (fake *FakeTelemetryService) ParticipantJoined(arg1 context.Context, arg2 *
 }
 
 func (fake *FakeTelemetryService) ParticipantJoinedCallCount() int {
+	fake.participantICEReconnectedMutex.RLock()
+	defer fake.participantICEReconnectedMutex.RUnlock()
 	fake.participantJoinedMutex.RLock()
 	defer fake.participantJoinedMutex.RUnlock()
 	return len(fake.participantJoinedArgsForCall)
@@ -442,19 +564,20 @@ func (fake *FakeTelemetryService) ParticipantJoinedArgsForCall(i int) (context.C
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6
 }
 
-func (fake *FakeTelemetryService) ParticipantLeft(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 bool) {
+func (fake *FakeTelemetryService) ParticipantLeft(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 bool, arg5 telemetry.WebhookExtensions) {
 	fake.participantLeftMutex.Lock()
 	fake.participantLeftArgsForCall = append(fake.participantLeftArgsForCall, struct {
 		arg1 context.Context
 		arg2 *livekit.Room
 		arg3 *livekit.ParticipantInfo
 		arg4 bool
-	}{arg1, arg2, arg3, arg4})
+		arg5 telemetry.WebhookExtensions
+	}{arg1, arg2, arg3, arg4, arg5})
 	stub := fake.ParticipantLeftStub
-	fake.recordInvocation("ParticipantLeft", []interface{}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("ParticipantLeft", []interface{}{arg1, arg2, arg3, arg4, arg5})
 	fake.participantLeftMutex.Unlock()
 	if stub != nil {
-		fake.ParticipantLeftStub(arg1, arg2, arg3, arg4)
+		fake.ParticipantLeftStub(arg1, arg2, arg3, arg4, arg5)
 	}
 }
 
@@ -464,19 +587,190 @@ func (fake *FakeTelemetryService) ParticipantLeftCallCount() int {
 	return len(fake.participantLeftArgsForCall)
 }
 
-func (fake *FakeTelemetryService) ParticipantLeftCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo, bool)) {
+func (fake *FakeTelemetryService) ParticipantLeftCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo, bool, telemetry.WebhookExtensions)) {
 	fake.participantLeftMutex.Lock()
 	defer fake.participantLeftMutex.Unlock()
 	fake.ParticipantLeftStub = stub
 }
 
-func (fake *FakeTelemetryService) ParticipantLeftArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo, bool) {
+func (fake *FakeTelemetryService) ParticipantLeftArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo, bool, telemetry.WebhookExtensions) {
 	fake.participantLeftMutex.RLock()
 	defer fake.participantLeftMutex.RUnlock()
 	argsForCall := fake.participantLeftArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeTelemetryService) ParticipantLobbyApproved(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo) {
+	fake.participantLobbyApprovedMutex.Lock()
+	fake.participantLobbyApprovedArgsForCall = append(fake.participantLobbyApprovedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}{arg1, arg2, arg3})
+	stub := fake.ParticipantLobbyApprovedStub
+	fake.recordInvocation("ParticipantLobbyApproved", []interface{}{arg1, arg2, arg3})
+	fake.participantLobbyApprovedMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantLobbyApprovedStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantLobbyApprovedCallCount() int {
+	fake.participantLobbyApprovedMutex.RLock()
+	defer fake.participantLobbyApprovedMutex.RUnlock()
+	return len(fake.participantLobbyApprovedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantLobbyApprovedCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo)) {
+	fake.participantLobbyApprovedMutex.Lock()
+	defer fake.participantLobbyApprovedMutex.Unlock()
+	fake.ParticipantLobbyApprovedStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantLobbyApprovedArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo) {
+	fake.participantLobbyApprovedMutex.RLock()
+	defer fake.participantLobbyApprovedMutex.RUnlock()
+	argsForCall := fake.participantLobbyApprovedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeTelemetryService) ParticipantLobbyDenied(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo) {
+	fake.participantLobbyDeniedMutex.Lock()
+	fake.participantLobbyDeniedArgsForCall = append(fake.participantLobbyDeniedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}{arg1, arg2, arg3})
+	stub := fake.ParticipantLobbyDeniedStub
+	fake.recordInvocation("ParticipantLobbyDenied", []interface{}{arg1, arg2, arg3})
+	fake.participantLobbyDeniedMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantLobbyDeniedStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantLobbyDeniedCallCount() int {
+	fake.participantLobbyDeniedMutex.RLock()
+	defer fake.participantLobbyDeniedMutex.RUnlock()
+	return len(fake.participantLobbyDeniedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantLobbyDeniedCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo)) {
+	fake.participantLobbyDeniedMutex.Lock()
+	defer fake.participantLobbyDeniedMutex.Unlock()
+	fake.ParticipantLobbyDeniedStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantLobbyDeniedArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo) {
+	fake.participantLobbyDeniedMutex.RLock()
+	defer fake.participantLobbyDeniedMutex.RUnlock()
+	argsForCall := fake.participantLobbyDeniedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeTelemetryService) ParticipantLobbyJoined(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo) {
+	fake.participantLobbyJoinedMutex.Lock()
+	fake.participantLobbyJoinedArgsForCall = append(fake.participantLobbyJoinedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}{arg1, arg2, arg3})
+	stub := fake.ParticipantLobbyJoinedStub
+	fake.recordInvocation("ParticipantLobbyJoined", []interface{}{arg1, arg2, arg3})
+	fake.participantLobbyJoinedMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantLobbyJoinedStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantLobbyJoinedCallCount() int {
+	fake.participantLobbyJoinedMutex.RLock()
+	defer fake.participantLobbyJoinedMutex.RUnlock()
+	return len(fake.participantLobbyJoinedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantLobbyJoinedCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo)) {
+	fake.participantLobbyJoinedMutex.Lock()
+	defer fake.participantLobbyJoinedMutex.Unlock()
+	fake.ParticipantLobbyJoinedStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantLobbyJoinedArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo) {
+	fake.participantLobbyJoinedMutex.RLock()
+	defer fake.participantLobbyJoinedMutex.RUnlock()
+	argsForCall := fake.participantLobbyJoinedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeTelemetryService) ParticipantQualityAlert(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 *telemetry.QualityAlert) {
+	fake.participantQualityAlertMutex.Lock()
+	fake.participantQualityAlertArgsForCall = append(fake.participantQualityAlertArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 *telemetry.QualityAlert
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.ParticipantQualityAlertStub
+	fake.recordInvocation("ParticipantQualityAlert", []interface{}{arg1, arg2, arg3, arg4})
+	fake.participantQualityAlertMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantQualityAlertStub(arg1, arg2, arg3, arg4)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantQualityAlertCallCount() int {
+	fake.participantQualityAlertMutex.RLock()
+	defer fake.participantQualityAlertMutex.RUnlock()
+	return len(fake.participantQualityAlertArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantQualityAlertCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo, *telemetry.QualityAlert)) {
+	fake.participantQualityAlertMutex.Lock()
+	defer fake.participantQualityAlertMutex.Unlock()
+	fake.ParticipantQualityAlertStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantQualityAlertArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo, *telemetry.QualityAlert) {
+	fake.participantQualityAlertMutex.RLock()
+	defer fake.participantQualityAlertMutex.RUnlock()
+	argsForCall := fake.participantQualityAlertArgsForCall[i]
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
+func (fake *FakeTelemetryService) ParticipantReconnected(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo) {
+	fake.participantReconnectedMutex.Lock()
+	fake.participantReconnectedArgsForCall = append(fake.participantReconnectedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}{arg1, arg2, arg3})
+	stub := fake.ParticipantReconnectedStub
+	fake.recordInvocation("ParticipantReconnected", []interface{}{arg1, arg2, arg3})
+	fake.participantReconnectedMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantReconnectedStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantReconnectedCallCount() int {
+	fake.participantReconnectedMutex.RLock()
+	defer fake.participantReconnectedMutex.RUnlock()
+	return len(fake.participantReconnectedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantReconnectedCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo)) {
+	fake.participantReconnectedMutex.Lock()
+	defer fake.participantReconnectedMutex.Unlock()
+	fake.ParticipantReconnectedStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantReconnectedArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo) {
+	fake.participantReconnectedMutex.RLock()
+	defer fake.participantReconnectedMutex.RUnlock()
+	argsForCall := fake.participantReconnectedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
 func (fake *FakeTelemetryService) ParticipantResumed(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 livekit.NodeID, arg5 livekit.ReconnectReason) {
 	fake.participantResumedMutex.Lock()
 	fake.participantResumedArgsForCall = append(fake.participantResumedArgsForCall, struct {
@@ -495,6 +789,8 @@ func (fake *FakeTelemetryService) ParticipantResumed(arg1 context.Context, arg2
 }
 
 func (fake *FakeTelemetryService) ParticipantResumedCallCount() int {
+	fake.participantReconnectedMutex.RLock()
+	defer fake.participantReconnectedMutex.RUnlock()
 	fake.participantResumedMutex.RLock()
 	defer fake.participantResumedMutex.RUnlock()
 	return len(fake.participantResumedArgsForCall)
@@ -513,17 +809,18 @@ func (fake *FakeTelemetryService) ParticipantResumedArgsForCall(i int) (context.
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
-func (fake *FakeTelemetryService) RoomEnded(arg1 context.Context, arg2 *livekit.Room) {
+func (fake *FakeTelemetryService) RoomEnded(arg1 context.Context, arg2 *livekit.Room, arg3 telemetry.WebhookExtensions) {
 	fake.roomEndedMutex.Lock()
 	fake.roomEndedArgsForCall = append(fake.roomEndedArgsForCall, struct {
 		arg1 context.Context
 		arg2 *livekit.Room
-	}{arg1, arg2})
+		arg3 telemetry.WebhookExtensions
+	}{arg1, arg2, arg3})
 	stub := fake.RoomEndedStub
-	fake.recordInvocation("RoomEnded", []interface{}{arg1, arg2})
+	fake.recordInvocation("RoomEnded", []interface{}{arg1, arg2, arg3})
 	fake.roomEndedMutex.Unlock()
 	if stub != nil {
-		fake.RoomEndedStub(arg1, arg2)
+		fake.RoomEndedStub(arg1, arg2, arg3)
 	}
 }
 
@@ -533,17 +830,17 @@ func (fake *FakeTelemetryService) RoomEndedCallCount() int {
 	return len(fake.roomEndedArgsForCall)
 }
 
-func (fake *FakeTelemetryService) RoomEndedCalls(stub func(context.Context, *livekit.Room)) {
+func (fake *FakeTelemetryService) RoomEndedCalls(stub func(context.Context, *livekit.Room, telemetry.WebhookExtensions)) {
 	fake.roomEndedMutex.Lock()
 	defer fake.roomEndedMutex.Unlock()
 	fake.RoomEndedStub = stub
 }
 
-func (fake *FakeTelemetryService) RoomEndedArgsForCall(i int) (context.Context, *livekit.Room) {
+func (fake *FakeTelemetryService) RoomEndedArgsForCall(i int) (context.Context, *livekit.Room, telemetry.WebhookExtensions) {
 	fake.roomEndedMutex.RLock()
 	defer fake.roomEndedMutex.RUnlock()
 	argsForCall := fake.roomEndedArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeTelemetryService) RoomStarted(arg1 context.Context, arg2 *livekit.Room) {
@@ -1154,12 +1451,22 @@ func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	defer fake.flushStatsMutex.RUnlock()
 	fake.notifyEventMutex.RLock()
 	defer fake.notifyEventMutex.RUnlock()
+	fake.notifyEventWithExtensionsMutex.RLock()
+	defer fake.notifyEventWithExtensionsMutex.RUnlock()
 	fake.participantActiveMutex.RLock()
 	defer fake.participantActiveMutex.RUnlock()
 	fake.participantJoinedMutex.RLock()
 	defer fake.participantJoinedMutex.RUnlock()
 	fake.participantLeftMutex.RLock()
 	defer fake.participantLeftMutex.RUnlock()
+	fake.participantLobbyApprovedMutex.RLock()
+	defer fake.participantLobbyApprovedMutex.RUnlock()
+	fake.participantLobbyDeniedMutex.RLock()
+	defer fake.participantLobbyDeniedMutex.RUnlock()
+	fake.participantLobbyJoinedMutex.RLock()
+	defer fake.participantLobbyJoinedMutex.RUnlock()
+	fake.participantQualityAlertMutex.RLock()
+	defer fake.participantQualityAlertMutex.RUnlock()
 	fake.participantResumedMutex.RLock()
 	defer fake.participantResumedMutex.RUnlock()
 	fake.roomEndedMutex.RLock()
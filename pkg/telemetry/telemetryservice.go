@@ -18,15 +18,27 @@ type TelemetryService interface {
 
 	// events
 	RoomStarted(ctx context.Context, room *livekit.Room)
-	RoomEnded(ctx context.Context, room *livekit.Room)
+	RoomEnded(ctx context.Context, room *livekit.Room, extensions WebhookExtensions)
 	// ParticipantJoined - a participant establishes signal connection to a room
 	ParticipantJoined(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, clientInfo *livekit.ClientInfo, clientMeta *livekit.AnalyticsClientMeta, shouldSendEvent bool)
 	// ParticipantActive - a participant establishes media connection
 	ParticipantActive(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, clientMeta *livekit.AnalyticsClientMeta)
 	// ParticipantResumed - there has been an ICE restart or connection resume attempt
 	ParticipantResumed(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, nodeID livekit.NodeID, reason livekit.ReconnectReason)
+	// ParticipantReconnected - a participant joined with a new session, replacing its previous session still in the room
+	ParticipantReconnected(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
+	// ParticipantICEReconnected - ICE of an active participant connected again, possibly with another candidate type
+	ParticipantICEReconnected(ctx context.Context, participantID livekit.ParticipantID, connectionType string)
 	// ParticipantLeft - the participant leaves the room, only sent if ParticipantActive has been called before
-	ParticipantLeft(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, shouldSendEvent bool)
+	ParticipantLeft(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, shouldSendEvent bool, extensions WebhookExtensions)
+	// ParticipantLobbyJoined - a participant is waiting in the lobby to be approved
+	ParticipantLobbyJoined(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
+	// ParticipantLobbyApproved - a participant waiting in the lobby has been admitted to the room
+	ParticipantLobbyApproved(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
+	// ParticipantLobbyDenied - a participant waiting in the lobby has been turned away
+	ParticipantLobbyDenied(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
+	// ParticipantQualityAlert - a participant's connection matched a quality alert rule for long enough
+	ParticipantQualityAlert(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, alert *QualityAlert)
 	// TrackPublishRequested - a publication attempt has been received
 	TrackPublishRequested(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, track *livekit.TrackInfo)
 	// TrackPublished - a publication attempt has been successful
@@ -58,6 +70,7 @@ type TelemetryService interface {
 	// helpers
 	AnalyticsService
 	NotifyEvent(ctx context.Context, event *livekit.WebhookEvent)
+	NotifyEventWithExtensions(ctx context.Context, event *livekit.WebhookEvent, extensions WebhookExtensions)
 	FlushStats()
 }
 
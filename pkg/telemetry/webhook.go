@@ -0,0 +1,173 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/frostbyte73/core"
+	"github.com/hashicorp/go-retryablehttp"
+	"go.uber.org/atomic"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+const (
+	webhookQueueSize = 100
+
+	// extensions are named with a single lower case word
+
+	// talk time statistics, on participant_left and room_finished
+	WebhookExtensionParticipation = "participation"
+	// media quality summary of the session, on participant_left
+	WebhookExtensionSessionQuality = "quality"
+	// alert details, on participant_quality_alert
+	WebhookExtensionQualityAlert = "alert"
+)
+
+// webhook events in addition to those defined by the webhook package
+const (
+	EventParticipantLobbyJoined   = "participant_lobby_joined"
+	EventParticipantLobbyApproved = "participant_lobby_approved"
+	EventParticipantLobbyDenied   = "participant_lobby_denied"
+	EventParticipantQualityAlert  = "participant_quality_alert"
+)
+
+// WebhookExtensions are added to a webhook payload as top level fields, next to those of livekit.WebhookEvent.
+// Receivers that discard unknown fields, as webhook.ReceiveWebhookEvent does, are not affected by them.
+type WebhookExtensions map[string]interface{}
+
+type ExtendedQueuedNotifier interface {
+	webhook.QueuedNotifier
+	QueueNotifyWithExtensions(ctx context.Context, event *livekit.WebhookEvent, extensions WebhookExtensions) error
+}
+
+// WebhookNotifier sends events with their extensions, which webhook.URLNotifier cannot encode. Requests are built
+// by utils.NewSignedWebhookRequest, which the admission hook uses as well. All events of a URL go through a
+// single queue, so they are sent in order.
+type WebhookNotifier struct {
+	apiKey    string
+	apiSecret string
+	client    *retryablehttp.Client
+	queues    []*webhookQueue
+	// events of the room with this name are not sent
+	ignoredRoom string
+}
+
+type webhookQueue struct {
+	url     string
+	worker  core.QueueWorker
+	dropped atomic.Int32
+}
+
+func NewWebhookNotifier(apiKey, apiSecret string, urls []string) *WebhookNotifier {
+	n := &WebhookNotifier{
+		apiKey:    apiKey,
+		apiSecret: apiSecret,
+		client:    retryablehttp.NewClient(),
+	}
+	for _, url := range urls {
+		q := &webhookQueue{url: url}
+		q.worker = core.NewQueueWorker(core.QueueWorkerParams{
+			QueueSize:    webhookQueueSize,
+			DropWhenFull: true,
+			OnDropped:    func() { q.dropped.Inc() },
+		})
+		n.queues = append(n.queues, q)
+	}
+	return n
+}
+
+// IgnoreRoom stops events of a room reserved for internal use from being sent, it should be called before
+// events are queued.
+func (n *WebhookNotifier) IgnoreRoom(name string) {
+	n.ignoredRoom = name
+}
+
+func (n *WebhookNotifier) Stop(force bool) {
+	wg := sync.WaitGroup{}
+	for _, q := range n.queues {
+		wg.Add(1)
+		go func(q *webhookQueue) {
+			defer wg.Done()
+			if force {
+				q.worker.Kill()
+			} else {
+				q.worker.Drain()
+			}
+		}(q)
+	}
+	wg.Wait()
+}
+
+func (n *WebhookNotifier) QueueNotify(ctx context.Context, event *livekit.WebhookEvent) error {
+	return n.QueueNotifyWithExtensions(ctx, event, nil)
+}
+
+func (n *WebhookNotifier) QueueNotifyWithExtensions(_ context.Context, event *livekit.WebhookEvent, extensions WebhookExtensions) error {
+	if n.ignoredRoom != "" && event.GetRoom().GetName() == n.ignoredRoom {
+		return nil
+	}
+	for _, q := range n.queues {
+		q := q
+		q.worker.Submit(func() {
+			if err := n.send(q, event, extensions); err != nil {
+				logger.Warnw("failed to send webhook", err, "url", q.url, "event", event.Event)
+				q.dropped.Add(event.NumDropped + 1)
+			} else {
+				logger.Infow("sent webhook", "url", q.url, "event", event.Event)
+			}
+		})
+	}
+	return nil
+}
+
+func (n *WebhookNotifier) send(q *webhookQueue, event *livekit.WebhookEvent, extensions WebhookExtensions) error {
+	event.NumDropped = q.dropped.Swap(0)
+	encoded, err := marshalWebhookEvent(event, extensions)
+	if err != nil {
+		return err
+	}
+
+	req, err := utils.NewSignedWebhookRequest(context.Background(), q.url, n.apiKey, n.apiSecret, encoded)
+	if err != nil {
+		return err
+	}
+	r, err := retryablehttp.FromRequest(req)
+	if err != nil {
+		return err
+	}
+	res, err := n.client.Do(r)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	return nil
+}
+
+func marshalWebhookEvent(event *livekit.WebhookEvent, extensions WebhookExtensions) ([]byte, error) {
+	encoded, err := protojson.Marshal(event)
+	if err != nil || len(extensions) == 0 {
+		return encoded, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	for name, extension := range extensions {
+		if _, ok := fields[name]; ok {
+			// never shadow fields of the event itself
+			continue
+		}
+		if fields[name], err = json.Marshal(extension); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
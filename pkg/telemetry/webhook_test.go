@@ -0,0 +1,139 @@
+package telemetry_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+const (
+	apiKey    = "mykey"
+	apiSecret = "mysecret"
+)
+
+func TestWebhookNotifierExtensions(t *testing.T) {
+	payloads := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := webhook.Receive(r, auth.NewSimpleKeyProvider(apiKey, apiSecret))
+		require.NoError(t, err)
+		payloads <- data
+	}))
+	defer server.Close()
+
+	notifier := telemetry.NewWebhookNotifier(apiKey, apiSecret, []string{server.URL})
+	defer notifier.Stop(true)
+
+	err := notifier.QueueNotifyWithExtensions(context.Background(), &livekit.WebhookEvent{
+		Event: webhook.EventRoomFinished,
+		Room:  &livekit.Room{Name: "room"},
+	}, telemetry.WebhookExtensions{
+		"participation": map[string]int{"speakingTimeMs": 1000},
+		// fields of the event cannot be overridden
+		"event": "other",
+	})
+	require.NoError(t, err)
+
+	var data []byte
+	select {
+	case data = <-payloads:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for webhook")
+	}
+
+	fields := make(map[string]json.RawMessage)
+	require.NoError(t, json.Unmarshal(data, &fields))
+	require.JSONEq(t, `{"speakingTimeMs": 1000}`, string(fields["participation"]))
+	require.JSONEq(t, `"room_finished"`, string(fields["event"]))
+
+	// still parsed as a regular webhook event by receivers
+	event := &livekit.WebhookEvent{}
+	require.NoError(t, protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, event))
+	require.Equal(t, "room", event.Room.Name)
+}
+
+func TestWebhookNotifierOrder(t *testing.T) {
+	payloads := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := webhook.Receive(r, auth.NewSimpleKeyProvider(apiKey, apiSecret))
+		require.NoError(t, err)
+		payloads <- data
+	}))
+	defer server.Close()
+
+	notifier := telemetry.NewWebhookNotifier(apiKey, apiSecret, []string{server.URL})
+	defer notifier.Stop(true)
+
+	// events with and without extensions are sent in the order they are queued
+	events := []string{webhook.EventTrackUnpublished, webhook.EventParticipantLeft, webhook.EventRoomFinished}
+	for i, name := range events {
+		var extensions telemetry.WebhookExtensions
+		if i%2 == 1 {
+			extensions = telemetry.WebhookExtensions{"participation": map[string]int{"speakingTimeMs": 1000}}
+		}
+		require.NoError(t, notifier.QueueNotifyWithExtensions(context.Background(), &livekit.WebhookEvent{
+			Event: name,
+			Room:  &livekit.Room{Name: "room"},
+		}, extensions))
+	}
+
+	for _, name := range events {
+		select {
+		case data := <-payloads:
+			event := &livekit.WebhookEvent{}
+			require.NoError(t, protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, event))
+			require.Equal(t, name, event.Event)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "timed out waiting for webhook")
+		}
+	}
+}
+
+func TestWebhookNotifierIgnoredRooms(t *testing.T) {
+	payloads := make(chan []byte, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := webhook.Receive(r, auth.NewSimpleKeyProvider(apiKey, apiSecret))
+		require.NoError(t, err)
+		payloads <- data
+	}))
+	defer server.Close()
+
+	notifier := telemetry.NewWebhookNotifier(apiKey, apiSecret, []string{server.URL})
+	notifier.IgnoreRoom("_canary_node")
+	defer notifier.Stop(true)
+
+	for _, name := range []string{"_canary_node", "_canary_node_room"} {
+		require.NoError(t, notifier.QueueNotify(context.Background(), &livekit.WebhookEvent{
+			Event: webhook.EventRoomStarted,
+			Room:  &livekit.Room{Name: name},
+		}))
+	}
+
+	var data []byte
+	select {
+	case data = <-payloads:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for webhook")
+	}
+
+	event := &livekit.WebhookEvent{}
+	require.NoError(t, protojson.Unmarshal(data, event))
+	require.Equal(t, "_canary_node_room", event.Room.Name)
+
+	select {
+	case <-payloads:
+		require.Fail(t, "event of ignored room sent")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
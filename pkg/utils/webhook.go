@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+)
+
+// NewSignedWebhookRequest creates a POST of payload to url, signed the way the webhook package signs events
+// so that receivers verify it with webhook.Receive
+func NewSignedWebhookRequest(ctx context.Context, url, apiKey, apiSecret string, payload []byte) (*http.Request, error) {
+	sum := sha256.Sum256(payload)
+	token, err := auth.NewAccessToken(apiKey, apiSecret).
+		SetValidFor(5 * time.Minute).
+		SetSha256(base64.StdEncoding.EncodeToString(sum[:])).
+		ToJWT()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Authorization", token)
+	// custom mime type so that the signature is checked before parsing
+	r.Header.Set("Content-Type", "application/webhook+json")
+	return r, nil
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/logger"
+)
+
+func TestCanary(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	logger.Infow("----------------STARTING TEST----------------", "test", t.Name())
+	s := createSingleNodeServer(func(conf *config.Config) {
+		conf.Canary.Enabled = true
+		// only the probe at start runs on its own
+		conf.Canary.Interval = time.Hour
+		conf.Canary.MediaDuration = 500 * time.Millisecond
+		conf.Canary.Timeout = 5 * time.Second
+		conf.Canary.FailureThreshold = 2
+	})
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	canary := s.Canary()
+	require.NotNil(t, canary)
+	require.Eventually(t, func() bool {
+		return canary.LastResult() != nil
+	}, 10*time.Second, 50*time.Millisecond)
+
+	res := canary.LastResult()
+	require.NoError(t, res.Err)
+	require.NotZero(t, res.JoinTime)
+	require.NotZero(t, res.MediaLatency)
+	requireReady(t, true)
+
+	// not ready once failures reach the threshold
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Error(t, canary.Probe(cancelled).Err)
+	requireReady(t, true)
+	require.Error(t, canary.Probe(cancelled).Err)
+	require.False(t, canary.Ready())
+	requireReady(t, false)
+
+	// and ready again after a successful probe
+	require.NoError(t, canary.Probe(context.Background()).Err)
+	requireReady(t, true)
+}
+
+func requireReady(t *testing.T, ready bool) {
+	res, err := http.Get(fmt.Sprintf("http://localhost:%d/ready", defaultServerPort))
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	if ready {
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	} else {
+		require.Equal(t, http.StatusNotAcceptable, res.StatusCode)
+	}
+}
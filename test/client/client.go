@@ -14,6 +14,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v3"
 	"github.com/thoas/go-funk"
 	"go.uber.org/atomic"
@@ -55,11 +56,29 @@ type RTCClient struct {
 	pendingTrackWriters []*TrackWriter
 	OnConnected         func()
 	OnDataReceived      func(data []byte, sid string)
+	OnRTPReceived       func(track *webrtc.TrackRemote, pkt *rtp.Packet)
 	refreshToken        string
 
 	// map of livekit.ParticipantID and last packet
 	lastPackets   map[livekit.ParticipantID]*rtp.Packet
 	bytesReceived map[livekit.ParticipantID]uint64
+	receiveStats  map[*webrtc.TrackRemote]*TrackReceiveStats
+}
+
+// TrackReceiveStats are stats of a subscribed track
+type TrackReceiveStats struct {
+	ParticipantID livekit.ParticipantID
+	TrackID       livekit.TrackID
+	Kind          webrtc.RTPCodecType
+	FirstPacketAt time.Time
+	// audio packets carry a frame each, video frames end with a packet having the marker bit set
+	FirstFrameAt time.Time
+	Packets      uint64
+	PacketsLost  uint64
+	Bytes        uint64
+
+	firstSN   uint64
+	highestSN uint64
 }
 
 var (
@@ -117,6 +136,7 @@ func NewRTCClient(conn *websocket.Conn) (*RTCClient, error) {
 		me:                     &webrtc.MediaEngine{},
 		lastPackets:            make(map[livekit.ParticipantID]*rtp.Packet),
 		bytesReceived:          make(map[livekit.ParticipantID]uint64),
+		receiveStats:           make(map[*webrtc.TrackRemote]*TrackReceiveStats),
 	}
 	c.ctx, c.cancel = context.WithCancel(context.Background())
 
@@ -126,6 +146,8 @@ func NewRTCClient(conn *websocket.Conn) (*RTCClient, error) {
 		},
 	}
 	conf.SettingEngine.SetLite(false)
+	// mid and rid are needed by the server to tell simulcast layers apart
+	conf.Subscriber.RTPHeaderExtension.Video = []string{sdp.SDESMidURI, sdp.SDESRTPStreamIDURI}
 	conf.SettingEngine.SetAnsweringDTLSRole(webrtc.DTLSRoleClient)
 	codecs := []*livekit.Codec{
 		{
@@ -478,6 +500,10 @@ func (c *RTCClient) hasPrimaryEverConnected() bool {
 }
 
 func (c *RTCClient) AddTrack(track *webrtc.TrackLocalStaticSample, path string) (writer *TrackWriter, err error) {
+	return c.addTrack(track, path, 0)
+}
+
+func (c *RTCClient) addTrack(track *webrtc.TrackLocalStaticSample, path string, bitrate int) (writer *TrackWriter, err error) {
 	trackType := livekit.TrackType_AUDIO
 	if track.Kind() == webrtc.RTPCodecTypeVideo {
 		trackType = livekit.TrackType_VIDEO
@@ -487,25 +513,9 @@ func (c *RTCClient) AddTrack(track *webrtc.TrackLocalStaticSample, path string)
 		return
 	}
 
-	// wait till track published message is received
-	timeout := time.After(5 * time.Second)
-	var ti *livekit.TrackInfo
-	for {
-		select {
-		case <-timeout:
-			return nil, errors.New("could not publish track after timeout")
-		default:
-			c.lock.Lock()
-			ti = c.pendingPublishedTracks[track.ID()]
-			c.lock.Unlock()
-			if ti != nil {
-				break
-			}
-			time.Sleep(50 * time.Millisecond)
-		}
-		if ti != nil {
-			break
-		}
+	ti, err := c.waitForTrackPublished(track.ID())
+	if err != nil {
+		return
 	}
 
 	c.lock.Lock()
@@ -519,16 +529,113 @@ func (c *RTCClient) AddTrack(track *webrtc.TrackLocalStaticSample, path string)
 	c.localTracks[ti.Sid] = track
 	c.trackSenders[ti.Sid] = sender
 	c.publisher.Negotiate(false)
-	writer = NewTrackWriter(c.ctx, track, path)
+	if bitrate > 0 {
+		writer = NewSyntheticTrackWriter(c.ctx, track, bitrate)
+	} else {
+		writer = NewTrackWriter(c.ctx, track, path)
+	}
+
+	err = c.startWriterLocked(writer)
+	return
+}
+
+// AddSimulcastTrack publishes a VP8 track with synthetic media at the bitrate of each layer,
+// layers are given from the lowest to the highest quality
+func (c *RTCClient) AddSimulcastTrack(id string, label string, layers []SimulcastLayer) (writers []*TrackWriter, err error) {
+	if len(layers) == 0 {
+		return nil, errors.New("no simulcast layers")
+	}
+
+	codec := webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}
+	tracks := make([]*simulcastLayerTrack, 0, len(layers))
+	videoLayers := make([]*livekit.VideoLayer, 0, len(layers))
+	for i, layer := range layers {
+		track, err := newSimulcastLayerTrack(codec, id, label, layer.RID)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, track)
+		videoLayers = append(videoLayers, &livekit.VideoLayer{
+			Quality: livekit.VideoQuality(i),
+			Width:   layer.Width,
+			Height:  layer.Height,
+			Bitrate: uint32(layer.Bitrate),
+		})
+	}
+
+	top := layers[len(layers)-1]
+	if err = c.SendAddTrackRequest(&livekit.AddTrackRequest{
+		Cid:    id,
+		Name:   label,
+		Type:   livekit.TrackType_VIDEO,
+		Width:  top.Width,
+		Height: top.Height,
+		Layers: videoLayers,
+	}); err != nil {
+		return
+	}
+
+	ti, err := c.waitForTrackPublished(id)
+	if err != nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	sender, transceiver, err := c.publisher.AddTrack(tracks[0], types.AddTrackParams{})
+	if err != nil {
+		logger.Errorw("add track failed", err, "trackID", ti.Sid, "participant", c.localParticipant.Identity, "pID", c.localParticipant.Sid)
+		return
+	}
+	for _, track := range tracks[1:] {
+		if err = sender.AddEncoding(track); err != nil {
+			return
+		}
+	}
+	c.localTracks[ti.Sid] = tracks[0]
+	c.trackSenders[ti.Sid] = sender
+	c.publisher.Negotiate(false)
 
+	for i, track := range tracks {
+		track.setTransceiver(transceiver)
+
+		writer := NewSyntheticTrackWriter(c.ctx, track, layers[i].Bitrate)
+		if err = c.startWriterLocked(writer); err != nil {
+			return
+		}
+		writers = append(writers, writer)
+	}
+	return
+}
+
+func (c *RTCClient) startWriterLocked(writer *TrackWriter) error {
 	// write tracks only after connection established
 	if c.hasPrimaryEverConnected() {
-		err = writer.Start()
-	} else {
-		c.pendingTrackWriters = append(c.pendingTrackWriters, writer)
+		return writer.Start()
 	}
 
-	return
+	c.pendingTrackWriters = append(c.pendingTrackWriters, writer)
+	return nil
+}
+
+func (c *RTCClient) waitForTrackPublished(cid string) (*livekit.TrackInfo, error) {
+	// wait till track published message is received
+	timeout := time.After(5 * time.Second)
+	for {
+		c.lock.Lock()
+		ti := c.pendingPublishedTracks[cid]
+		c.lock.Unlock()
+		if ti != nil {
+			return ti, nil
+		}
+
+		select {
+		case <-timeout:
+			return nil, errors.New("could not publish track after timeout")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
 }
 
 func (c *RTCClient) AddStaticTrack(mime string, id string, label string) (writer *TrackWriter, err error) {
@@ -544,6 +651,16 @@ func (c *RTCClient) AddStaticTrackWithCodec(codec webrtc.RTPCodecCapability, id
 	return c.AddTrack(track, "")
 }
 
+// AddSyntheticTrack publishes a track with synthetic media at the given bitrate
+func (c *RTCClient) AddSyntheticTrack(codec webrtc.RTPCodecCapability, id string, label string, bitrate int) (writer *TrackWriter, err error) {
+	track, err := webrtc.NewTrackLocalStaticSample(codec, id, label)
+	if err != nil {
+		return
+	}
+
+	return c.addTrack(track, "", bitrate)
+}
+
 func (c *RTCClient) AddFileTrack(path string, id string, label string) (writer *TrackWriter, err error) {
 	// determine file mime
 	mime, ok := extMimeMapping[filepath.Ext(path)]
@@ -569,13 +686,17 @@ func (c *RTCClient) AddFileTrack(path string, id string, label string) (writer *
 
 // send AddTrack command to server to initiate server-side negotiation
 func (c *RTCClient) SendAddTrack(cid string, name string, trackType livekit.TrackType) error {
+	return c.SendAddTrackRequest(&livekit.AddTrackRequest{
+		Cid:  cid,
+		Name: name,
+		Type: trackType,
+	})
+}
+
+func (c *RTCClient) SendAddTrackRequest(req *livekit.AddTrackRequest) error {
 	return c.SendRequest(&livekit.SignalRequest{
 		Message: &livekit.SignalRequest_AddTrack{
-			AddTrack: &livekit.AddTrackRequest{
-				Cid:  cid,
-				Name: name,
-				Type: trackType,
-			},
+			AddTrack: req,
 		},
 	})
 }
@@ -675,8 +796,14 @@ func (c *RTCClient) processTrack(track *webrtc.TrackRemote) {
 	if trackId == "" {
 		trackId = livekit.TrackID(track.ID())
 	}
+	stats := &TrackReceiveStats{
+		ParticipantID: pId,
+		TrackID:       trackId,
+		Kind:          track.Kind(),
+	}
 	c.lock.Lock()
 	c.subscribedTracks[pId] = append(c.subscribedTracks[pId], track)
+	c.receiveStats[track] = stats
 	c.lock.Unlock()
 
 	logger.Infow("client added track", "participant", c.localParticipant.Identity,
@@ -706,7 +833,11 @@ func (c *RTCClient) processTrack(track *webrtc.TrackRemote) {
 		c.lock.Lock()
 		c.lastPackets[pId] = pkt
 		c.bytesReceived[pId] += uint64(pkt.MarshalSize())
+		stats.update(pkt)
 		c.lock.Unlock()
+		if c.OnRTPReceived != nil {
+			c.OnRTPReceived(track, pkt)
+		}
 		numBytes += pkt.MarshalSize()
 		if time.Since(lastUpdate) > 30*time.Second {
 			logger.Infow("consumed from participant",
@@ -727,6 +858,18 @@ func (c *RTCClient) BytesReceived() uint64 {
 	return total
 }
 
+// ReceiveStats returns stats of tracks subscribed to since the client joined, including unsubscribed tracks
+func (c *RTCClient) ReceiveStats() []TrackReceiveStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	stats := make([]TrackReceiveStats, 0, len(c.receiveStats))
+	for _, s := range c.receiveStats {
+		stats = append(stats, *s)
+	}
+	return stats
+}
+
 func (c *RTCClient) SendNacks(count int) {
 	var packets []rtcp.Packet
 	c.lock.Lock()
@@ -744,3 +887,26 @@ func (c *RTCClient) SendNacks(count int) {
 
 	_ = c.subscriber.WriteRTCP(packets)
 }
+
+func (s *TrackReceiveStats) update(pkt *rtp.Packet) {
+	sn := uint64(pkt.SequenceNumber)
+	if s.Packets == 0 {
+		s.FirstPacketAt = time.Now()
+		s.firstSN = sn
+		s.highestSN = sn
+	} else if diff := pkt.SequenceNumber - uint16(s.highestSN); diff != 0 && diff < 1<<15 {
+		s.highestSN += uint64(diff)
+	}
+
+	if s.FirstFrameAt.IsZero() && (s.Kind == webrtc.RTPCodecTypeAudio || pkt.Marker) {
+		s.FirstFrameAt = time.Now()
+	}
+
+	s.Packets++
+	s.Bytes += uint64(pkt.MarshalSize())
+	if expected := s.highestSN - s.firstSN + 1; expected > s.Packets {
+		s.PacketsLost = expected - s.Packets
+	} else {
+		s.PacketsLost = 0
+	}
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+)
+
+const (
+	simulcastMTU = 1200
+)
+
+// SimulcastLayer describes a layer of a simulcast track published by the client
+type SimulcastLayer struct {
+	RID     string
+	Width   uint32
+	Height  uint32
+	Bitrate int
+}
+
+// simulcastLayerTrack sends one layer of a simulcast track.
+// Senders with more than one encoding do not signal SSRCs that the receiver can use,
+// so packets are tagged with the mid and rid header extensions for the receiver to identify the layer, as browsers do.
+type simulcastLayerTrack struct {
+	*webrtc.TrackLocalStaticRTP
+
+	packetizer rtp.Packetizer
+	clockRate  uint32
+
+	lock        sync.RWMutex
+	transceiver *webrtc.RTPTransceiver
+	midExtID    uint8
+	ridExtID    uint8
+}
+
+func newSimulcastLayerTrack(codec webrtc.RTPCodecCapability, id string, label string, rid string) (*simulcastLayerTrack, error) {
+	track, err := webrtc.NewTrackLocalStaticRTP(codec, id, label, webrtc.WithRTPStreamID(rid))
+	if err != nil {
+		return nil, err
+	}
+
+	clockRate := codec.ClockRate
+	if clockRate == 0 {
+		clockRate = 90000
+	}
+	return &simulcastLayerTrack{
+		TrackLocalStaticRTP: track,
+		// payload type and SSRC are set by the binding
+		packetizer: rtp.NewPacketizer(simulcastMTU, 0, 0, &codecs.VP8Payloader{EnablePictureID: true}, rtp.NewRandomSequencer(), clockRate),
+		clockRate:  clockRate,
+	}, nil
+}
+
+func (t *simulcastLayerTrack) Bind(ctx webrtc.TrackLocalContext) (webrtc.RTPCodecParameters, error) {
+	codec, err := t.TrackLocalStaticRTP.Bind(ctx)
+	if err != nil {
+		return codec, err
+	}
+
+	t.lock.Lock()
+	for _, ext := range ctx.HeaderExtensions() {
+		switch ext.URI {
+		case sdp.SDESMidURI:
+			t.midExtID = uint8(ext.ID)
+		case sdp.SDESRTPStreamIDURI:
+			t.ridExtID = uint8(ext.ID)
+		}
+	}
+	t.lock.Unlock()
+	return codec, nil
+}
+
+func (t *simulcastLayerTrack) setTransceiver(transceiver *webrtc.RTPTransceiver) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.transceiver = transceiver
+}
+
+func (t *simulcastLayerTrack) WriteSample(sample media.Sample) error {
+	t.lock.RLock()
+	midExtID, ridExtID := t.midExtID, t.ridExtID
+	var mid string
+	if t.transceiver != nil {
+		mid = t.transceiver.Mid()
+	}
+	t.lock.RUnlock()
+
+	samples := uint32(sample.Duration.Seconds() * float64(t.clockRate))
+	for _, pkt := range t.packetizer.Packetize(sample.Data, samples) {
+		if midExtID != 0 && mid != "" {
+			if err := pkt.Header.SetExtension(midExtID, []byte(mid)); err != nil {
+				return err
+			}
+		}
+		if ridExtID != 0 {
+			if err := pkt.Header.SetExtension(ridExtID, []byte(t.RID())); err != nil {
+				return err
+			}
+		}
+		if err := t.WriteRTP(pkt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package client
 
 import (
 	"context"
+	"encoding/binary"
 	"io"
+	"math/rand"
 	"os"
 	"strings"
 	"time"
@@ -16,14 +18,40 @@ import (
 	"github.com/livekit/protocol/logger"
 )
 
+const (
+	syntheticVideoFrameRate   = 30
+	syntheticKeyFrameInterval = 2 * time.Second
+	syntheticAudioFrame       = 20 * time.Millisecond
+
+	// synthetic audio frames start with the time they were written at, in nanoseconds since the epoch
+	syntheticSendTimeSize = 8
+)
+
+// SyntheticSendTime returns the time a synthetic audio frame was written at, from the RTP payload of the frame
+func SyntheticSendTime(payload []byte) (time.Time, bool) {
+	if len(payload) < syntheticSendTimeSize {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(payload))), true
+}
+
+// SampleTrack is a local track that media samples can be written to
+type SampleTrack interface {
+	ID() string
+	Codec() webrtc.RTPCodecCapability
+	WriteSample(sample media.Sample) error
+}
+
 // Writes a file to an RTP track.
 // makes it easier to debug and create RTP streams
 type TrackWriter struct {
 	ctx      context.Context
 	cancel   context.CancelFunc
-	track    *webrtc.TrackLocalStaticSample
+	track    SampleTrack
 	filePath string
 	mime     string
+	// bits per second of synthetic media written when there is no file
+	bitrate int
 
 	ogg       *oggreader.OggReader
 	ivfheader *ivfreader.IVFFileHeader
@@ -31,7 +59,7 @@ type TrackWriter struct {
 	h264      *h264reader.H264Reader
 }
 
-func NewTrackWriter(ctx context.Context, track *webrtc.TrackLocalStaticSample, filePath string) *TrackWriter {
+func NewTrackWriter(ctx context.Context, track SampleTrack, filePath string) *TrackWriter {
 	ctx, cancel := context.WithCancel(ctx)
 	return &TrackWriter{
 		ctx:      ctx,
@@ -42,9 +70,22 @@ func NewTrackWriter(ctx context.Context, track *webrtc.TrackLocalStaticSample, f
 	}
 }
 
+// NewSyntheticTrackWriter writes frames of random bytes at the given bitrate,
+// VP8 frames are flagged as key frames at regular intervals so that receivers can switch layers,
+// audio frames carry their send time for receivers to measure latency, see SyntheticSendTime
+func NewSyntheticTrackWriter(ctx context.Context, track SampleTrack, bitrate int) *TrackWriter {
+	w := NewTrackWriter(ctx, track, "")
+	w.bitrate = bitrate
+	return w
+}
+
 func (w *TrackWriter) Start() error {
 	if w.filePath == "" {
-		go w.writeNull()
+		if w.bitrate > 0 {
+			go w.writeSynthetic()
+		} else {
+			go w.writeNull()
+		}
 		return nil
 	}
 
@@ -101,6 +142,51 @@ func (w *TrackWriter) writeNull() {
 	}
 }
 
+func (w *TrackWriter) writeSynthetic() {
+	defer w.onWriteComplete()
+
+	isVideo := strings.HasPrefix(strings.ToLower(w.mime), "video/")
+	frameDuration := syntheticAudioFrame
+	if isVideo {
+		frameDuration = time.Second / syntheticVideoFrameRate
+	}
+	frameSize := int(float64(w.bitrate) / 8 * frameDuration.Seconds())
+	if frameSize < 1 {
+		frameSize = 1
+	}
+
+	ticker := time.NewTicker(frameDuration)
+	defer ticker.Stop()
+
+	var lastKeyFrame time.Time
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		frame := make([]byte, frameSize)
+		_, _ = rand.Read(frame)
+		if isVideo {
+			// lowest bit of the VP8 frame tag is 0 for key frames
+			if time.Since(lastKeyFrame) >= syntheticKeyFrameInterval {
+				frame[0] &^= 0x01
+				lastKeyFrame = time.Now()
+			} else {
+				frame[0] |= 0x01
+			}
+		} else if len(frame) >= syntheticSendTimeSize {
+			binary.BigEndian.PutUint64(frame, uint64(time.Now().UnixNano()))
+		}
+
+		if err := w.track.WriteSample(media.Sample{Data: frame, Duration: frameDuration}); err != nil {
+			logger.Errorw("could not write sample", err)
+			return
+		}
+	}
+}
+
 func (w *TrackWriter) writeOgg() {
 	// Keep track of last granule, the difference is the amount of samples in the buffer
 	var lastGranule uint64
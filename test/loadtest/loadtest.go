@@ -0,0 +1,322 @@
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/test/client"
+)
+
+const (
+	cpuSampleInterval = time.Second
+)
+
+var (
+	ErrNoParticipants = errors.New("load test needs at least one publisher or subscriber")
+	ErrNoCredentials  = errors.New("api key and secret are required")
+)
+
+var DefaultVideoLayers = []client.SimulcastLayer{
+	{RID: "q", Width: 320, Height: 180, Bitrate: 150_000},
+	{RID: "h", Width: 640, Height: 360, Bitrate: 500_000},
+	{RID: "f", Width: 1280, Height: 720, Bitrate: 1_500_000},
+}
+
+const DefaultAudioBitrate = 32_000
+
+type Params struct {
+	// websocket URL of the server, e. g. ws://localhost:7880
+	URL       string
+	APIKey    string
+	APISecret string
+	Room      string
+
+	Publishers  int
+	Subscribers int
+
+	// each publisher publishes a VP8 simulcast track with these layers, no video when empty
+	VideoLayers []client.SimulcastLayer
+	// each publisher publishes an Opus track at this bitrate, no audio when 0
+	AudioBitrate int
+
+	// how long media flows once all participants have joined
+	Duration time.Duration
+	// delay between participants joining
+	JoinInterval time.Duration
+
+	// samples CPU load between 0 and 1, CPU is not reported when nil
+	CPUSampler func() (float32, error)
+}
+
+type participant struct {
+	identity  string
+	rtcClient *client.RTCClient
+	writers   []*client.TrackWriter
+	joinStart time.Time
+	joinTime  time.Duration
+	err       error
+}
+
+// LoadTest joins publishers and subscribers to a room using the test client,
+// publishers join first so that first frame times of subscribers do not include time to publish
+type LoadTest struct {
+	params Params
+}
+
+func NewLoadTest(params Params) *LoadTest {
+	return &LoadTest{
+		params: params,
+	}
+}
+
+func (t *LoadTest) Run(ctx context.Context) (*Report, error) {
+	if t.params.Publishers <= 0 && t.params.Subscribers <= 0 {
+		return nil, ErrNoParticipants
+	}
+	if t.params.APIKey == "" || t.params.APISecret == "" {
+		return nil, ErrNoCredentials
+	}
+
+	logger.Infow("starting load test",
+		"room", t.params.Room,
+		"publishers", t.params.Publishers,
+		"subscribers", t.params.Subscribers,
+		"duration", t.params.Duration,
+	)
+
+	publishers := t.join(ctx, "publisher", t.params.Publishers, false)
+	defer stopParticipants(publishers)
+
+	subscribers := t.join(ctx, "subscriber", t.params.Subscribers, true)
+	defer stopParticipants(subscribers)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// measure bitrate once everyone has joined
+	windowStart := time.Now()
+	bytesAtStart := receivedBytes(subscribers)
+
+	var cpuLoads []float64
+	if t.params.CPUSampler != nil {
+		// first sample sets the baseline
+		_, _ = t.params.CPUSampler()
+	}
+	ticker := time.NewTicker(cpuSampleInterval)
+	defer ticker.Stop()
+	timer := time.NewTimer(t.params.Duration)
+	defer timer.Stop()
+
+wait:
+	for {
+		select {
+		case <-ctx.Done():
+			break wait
+		case <-timer.C:
+			break wait
+		case <-ticker.C:
+			if t.params.CPUSampler == nil {
+				continue
+			}
+			if load, err := t.params.CPUSampler(); err == nil {
+				cpuLoads = append(cpuLoads, float64(load)*100)
+			} else {
+				logger.Warnw("could not sample cpu", err)
+			}
+		}
+	}
+
+	return t.buildReport(publishers, subscribers, windowStart, bytesAtStart, cpuLoads), nil
+}
+
+func (t *LoadTest) join(ctx context.Context, kind string, count int, isSubscriber bool) []*participant {
+	participants := make([]*participant, 0, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count && ctx.Err() == nil; i++ {
+		if i > 0 && t.params.JoinInterval > 0 {
+			select {
+			case <-ctx.Done():
+				continue
+			case <-time.After(t.params.JoinInterval):
+			}
+		}
+
+		p := &participant{identity: fmt.Sprintf("%s_%d", kind, i)}
+		participants = append(participants, p)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.err = t.joinParticipant(p, isSubscriber)
+			if p.err != nil {
+				logger.Warnw("participant failed", p.err, "participant", p.identity)
+			}
+		}()
+	}
+	wg.Wait()
+	return participants
+}
+
+func (t *LoadTest) joinParticipant(p *participant, isSubscriber bool) error {
+	grant := &auth.VideoGrant{
+		RoomJoin: true,
+		Room:     t.params.Room,
+	}
+	grant.SetCanPublish(!isSubscriber)
+	grant.SetCanSubscribe(isSubscriber)
+	token, err := auth.NewAccessToken(t.params.APIKey, t.params.APISecret).
+		AddGrant(grant).
+		SetIdentity(p.identity).
+		ToJWT()
+	if err != nil {
+		return err
+	}
+
+	p.joinStart = time.Now()
+	conn, err := client.NewWebSocketConn(t.params.URL, token, &client.Options{AutoSubscribe: isSubscriber})
+	if err != nil {
+		return err
+	}
+	p.rtcClient, err = client.NewRTCClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	go func() {
+		_ = p.rtcClient.Run()
+	}()
+	if err = p.rtcClient.WaitUntilConnected(); err != nil {
+		return err
+	}
+	p.joinTime = time.Since(p.joinStart)
+
+	if isSubscriber {
+		return nil
+	}
+
+	if len(t.params.VideoLayers) > 0 {
+		writers, err := p.rtcClient.AddSimulcastTrack("video_"+p.identity, "video", t.params.VideoLayers)
+		p.writers = append(p.writers, writers...)
+		if err != nil {
+			return err
+		}
+	}
+	if t.params.AudioBitrate > 0 {
+		writer, err := p.rtcClient.AddSyntheticTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2}, "audio_"+p.identity, "audio", t.params.AudioBitrate)
+		if err != nil {
+			return err
+		}
+		p.writers = append(p.writers, writer)
+	}
+	return nil
+}
+
+func (t *LoadTest) buildReport(
+	publishers []*participant,
+	subscribers []*participant,
+	windowStart time.Time,
+	bytesAtStart map[*participant]uint64,
+	cpuLoads []float64,
+) *Report {
+	window := time.Since(windowStart)
+	report := &Report{
+		Room:            t.params.Room,
+		Publishers:      len(publishers),
+		Subscribers:     len(subscribers),
+		DurationSeconds: window.Seconds(),
+	}
+
+	var joinTimes []float64
+	publishing := 0
+	for _, p := range publishers {
+		if p.joinTime > 0 {
+			joinTimes = append(joinTimes, float64(p.joinTime.Milliseconds()))
+		} else {
+			report.JoinFailures++
+		}
+		if p.err != nil && p.joinTime > 0 {
+			report.PublishFailures++
+		}
+		if p.err == nil {
+			publishing++
+		}
+	}
+
+	tracksPerPublisher := 0
+	if len(t.params.VideoLayers) > 0 {
+		tracksPerPublisher++
+	}
+	if t.params.AudioBitrate > 0 {
+		tracksPerPublisher++
+	}
+
+	var firstFrameTimes, bitrates []float64
+	for _, s := range subscribers {
+		if s.joinTime == 0 {
+			report.JoinFailures++
+			continue
+		}
+		joinTimes = append(joinTimes, float64(s.joinTime.Milliseconds()))
+
+		report.TracksExpected += publishing * tracksPerPublisher
+		var bytes uint64
+		for _, stats := range s.rtcClient.ReceiveStats() {
+			bytes += stats.Bytes
+			if stats.Packets == 0 {
+				continue
+			}
+
+			report.TracksReceived++
+			if !stats.FirstFrameAt.IsZero() {
+				firstFrameTimes = append(firstFrameTimes, float64(stats.FirstFrameAt.Sub(s.joinStart).Milliseconds()))
+			}
+			report.PacketsReceived += stats.Packets
+			report.PacketsLost += stats.PacketsLost
+		}
+		bitrates = append(bitrates, float64(bytes-bytesAtStart[s])*8/1000/window.Seconds())
+	}
+
+	report.JoinLatencyMs = newDistribution(joinTimes)
+	report.FirstFrameMs = newDistribution(firstFrameTimes)
+	report.SubscriberBitrateKbps = newDistribution(bitrates)
+	if expected := report.PacketsReceived + report.PacketsLost; expected > 0 {
+		report.PacketLossPercentage = float64(report.PacketsLost) * 100 / float64(expected)
+	}
+	if t.params.CPUSampler != nil {
+		cpu := newDistribution(cpuLoads)
+		report.CPUPercent = &cpu
+	}
+	return report
+}
+
+func receivedBytes(participants []*participant) map[*participant]uint64 {
+	bytes := make(map[*participant]uint64, len(participants))
+	for _, p := range participants {
+		if p.rtcClient == nil {
+			continue
+		}
+		for _, stats := range p.rtcClient.ReceiveStats() {
+			bytes[p] += stats.Bytes
+		}
+	}
+	return bytes
+}
+
+func stopParticipants(participants []*participant) {
+	for _, p := range participants {
+		for _, w := range p.writers {
+			w.Stop()
+		}
+		if p.rtcClient != nil {
+			p.rtcClient.Stop()
+		}
+	}
+}
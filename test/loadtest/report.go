@@ -0,0 +1,75 @@
+package loadtest
+
+import (
+	"math"
+	"sort"
+)
+
+type Distribution struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P95   float64 `json:"p95"`
+	Max   float64 `json:"max"`
+}
+
+func newDistribution(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	return Distribution{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Mean:  sum / float64(len(sorted)),
+		P50:   percentile(sorted, 50),
+		P95:   percentile(sorted, 95),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// nearest rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+type Report struct {
+	Room            string  `json:"room"`
+	Publishers      int     `json:"publishers"`
+	Subscribers     int     `json:"subscribers"`
+	DurationSeconds float64 `json:"durationSeconds"`
+
+	// participants that could not connect, and publishers that connected but could not publish
+	JoinFailures    int `json:"joinFailures"`
+	PublishFailures int `json:"publishFailures"`
+
+	// from connecting the signal connection to the primary peer connection being established
+	JoinLatencyMs Distribution `json:"joinLatencyMs"`
+	// from a subscriber connecting to the first complete frame of each subscribed track,
+	// video is forwarded from a key frame so that frame can be decoded
+	FirstFrameMs Distribution `json:"firstFrameMs"`
+	// bitrate received by each subscriber over the test duration
+	SubscriberBitrateKbps Distribution `json:"subscriberBitrateKbps"`
+
+	// subscribed tracks expected and with media received, over all subscribers
+	TracksExpected int `json:"tracksExpected"`
+	TracksReceived int `json:"tracksReceived"`
+
+	PacketsReceived      uint64  `json:"packetsReceived"`
+	PacketsLost          uint64  `json:"packetsLost"`
+	PacketLossPercentage float64 `json:"packetLossPercentage"`
+
+	CPUPercent *Distribution `json:"cpuPercent,omitempty"`
+}
@@ -0,0 +1,46 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/test/loadtest"
+)
+
+func TestLoadTest(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	_, finish := setupSingleNodeTest("TestLoadTest")
+	defer finish()
+
+	lt := loadtest.NewLoadTest(loadtest.Params{
+		URL:          fmt.Sprintf("ws://localhost:%d", defaultServerPort),
+		APIKey:       testApiKey,
+		APISecret:    testApiSecret,
+		Room:         testRoom,
+		Publishers:   1,
+		Subscribers:  2,
+		VideoLayers:  loadtest.DefaultVideoLayers,
+		AudioBitrate: loadtest.DefaultAudioBitrate,
+		Duration:     3 * time.Second,
+	})
+	report, err := lt.Run(context.Background())
+	require.NoError(t, err)
+
+	require.Zero(t, report.JoinFailures)
+	require.Zero(t, report.PublishFailures)
+	require.Equal(t, 3, report.JoinLatencyMs.Count)
+	require.Equal(t, 4, report.TracksExpected)
+	require.Equal(t, 4, report.TracksReceived)
+	require.Equal(t, 4, report.FirstFrameMs.Count)
+	require.Equal(t, 2, report.SubscriberBitrateKbps.Count)
+	require.Greater(t, report.SubscriberBitrateKbps.Min, 0.0)
+	require.NotZero(t, report.PacketsReceived)
+}